@@ -2,26 +2,35 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
+	"strings"
 
+	"github.com/PPAKruNN/golearn/domain/entity"
 	"github.com/PPAKruNN/golearn/domain/service"
 	"github.com/PPAKruNN/golearn/domain/service/dto"
 	"github.com/rs/zerolog/log"
 )
 
 type AccountServer struct {
-	AccountService  service.AccountService
-	TransferService service.TransferService
-	AuthService     service.AuthService
+	AccountService   service.AccountService
+	TransferService  service.TransferService
+	AuthService      service.AuthService
+	LoginGuard       service.LoginGuardService
+	TwoFactorService service.TwoFactorService
+
+	// Optional. When set, account creation and logins are recorded in the
+	// audit log.
+	Audit *service.AuditService
 }
 
-func NewAccountServer(transferService service.TransferService, accountService service.AccountService, authService service.AuthService) *AccountServer {
+func NewAccountServer(transferService service.TransferService, accountService service.AccountService, authService service.AuthService, loginGuard service.LoginGuardService, twoFactorService service.TwoFactorService) *AccountServer {
 
 	return &AccountServer{
-		AccountService:  accountService,
-		TransferService: transferService,
-		AuthService:     authService,
+		AccountService:   accountService,
+		TransferService:  transferService,
+		AuthService:      authService,
+		LoginGuard:       loginGuard,
+		TwoFactorService: twoFactorService,
 	}
 }
 
@@ -47,6 +56,9 @@ func (s *AccountServer) accountHandler(w http.ResponseWriter, r *http.Request) {
 	case http.MethodGet:
 		s.ReadAccounts(w, r)
 		break
+	case http.MethodPatch:
+		s.UpdateAccount(w, r)
+		break
 
 	case "":
 		s.ReadAccounts(w, r)
@@ -63,8 +75,36 @@ func (s *AccountServer) ReadAccounts(w http.ResponseWriter, r *http.Request) {
 
 	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint ReadAccounts!")
 
-	accounts := s.AccountService.ReadAccounts()
+	session, err := authorizeSession(s.AuthService, r.Header.Get("Authorization"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(err).
+			Msg("Failed authorizing request!")
+		return
+	}
+
+	accounts, statusCode, err := s.AccountService.ReadAccounts(session.AccountID)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Int("AccountID", session.AccountID).
+			Err(err).
+			Msg("Failed reading accounts!")
+		return
+	}
 
+	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(accounts)
 
 	// INFO: I think it is not worth to put accounts array in the log.
@@ -75,13 +115,87 @@ func (s *AccountServer) ReadAccounts(w http.ResponseWriter, r *http.Request) {
 		Msg("")
 }
 
+// UpdateAccount changes the profile of the authenticated holder own account.
+func (s *AccountServer) UpdateAccount(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint UpdateAccount!")
+
+	session, err := authorizeSession(s.AuthService, r.Header.Get("Authorization"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(err).
+			Msg("Failed authorizing request!")
+		return
+	}
+
+	var input dto.UpdateAccountInputDTO
+	err = json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/accounts/"), "/")
+
+	output, statusCode, err := s.AccountService.UpdateProfile(session.AccountID, id, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Int("AccountID", session.AccountID).
+			Err(err).
+			Msg("Failed updating account!")
+		return
+	}
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Interface("Response", output).
+		Msg("")
+}
+
 func (s *AccountServer) ReadAccountBalance(w http.ResponseWriter, r *http.Request) {
 
 	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint ReadAccountBalance!")
 
-	// Getting ID
-	var id int
-	fmt.Sscanf(r.URL.Path, "/accounts/%d/balance", &id)
+	session, err := authorizeSession(s.AuthService, r.Header.Get("Authorization"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(err).
+			Msg("Failed authorizing request!")
+		return
+	}
+
+	// Getting the public ID
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/accounts/"), "/balance")
 
 	// Getting ID
 	// var id int
@@ -107,7 +221,7 @@ func (s *AccountServer) ReadAccountBalance(w http.ResponseWriter, r *http.Reques
 		ID: id,
 	}
 
-	balance := s.AccountService.ReadAccountBalance(input)
+	balance := s.AccountService.ReadAccountBalance(session.AccountID, input)
 
 	if balance.Balance == -1 {
 		w.WriteHeader(http.StatusNotFound)
@@ -150,7 +264,7 @@ func (s *AccountServer) CreateAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = s.AccountService.CreateAccount(accountDTO)
+	account, err := s.AccountService.CreateAccount(accountDTO)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		log.Error().
@@ -163,6 +277,12 @@ func (s *AccountServer) CreateAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The audit log cannot be erased, it never keeps personal data in clear.
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditAccountCreated, entity.AUDIT_ACTOR_ANONYMOUS, entity.AuditAccount(account.ID), nil, map[string]interface{}{
+		"cpf":     entity.MaskCPF(account.CPF),
+		"balance": account.Balance,
+	}))
+
 	w.WriteHeader(http.StatusCreated)
 	log.Info().
 		Str("Method", r.Method).
@@ -192,21 +312,83 @@ func (s *AccountServer) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ip := clientIP(r)
+
+	if wait := s.LoginGuard.Attempt(accountDTO.CPF, ip); wait > 0 {
+		writeTooManyRequests(w, wait)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusTooManyRequests).
+			Dur("Retry After", wait).
+			Msg("Login locked after too many failures!")
+		return
+	}
+
 	id, err := s.AccountService.Authenticate(accountDTO.CPF, accountDTO.Secret)
 	if err != nil {
-		w.WriteHeader(http.StatusNotFound)
+		// The attempt was already counted as a failure.
+		recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditLoginFailed, entity.AUDIT_ACTOR_ANONYMOUS, "cpf:"+entity.MaskCPF(accountDTO.CPF), nil, nil))
+
+		// Same answer whether the CPF exists or not, so it cannot be used to
+		// find out which CPFs are customers.
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(service.ErrInvalidCredentials.Error())
 
 		log.Info().
 			Str("Method", r.Method).
 			Str("Path", r.URL.String()).
-			Int("Status Code", http.StatusNotFound).
+			Int("Status Code", http.StatusUnauthorized).
 			Err(err).
-			Interface("Account", accountDTO).
-			Msg("Account not found!")
+			Msg("Login failed!")
+		return
+	}
+
+	s.LoginGuard.RegisterSuccess(accountDTO.CPF, ip)
+
+	if s.TwoFactorService.IsEnabled(id) {
+		challenge, err := s.TwoFactorService.StartChallenge(id, r.UserAgent(), ip)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			log.Error().
+				Str("Method", r.Method).
+				Str("Path", r.URL.String()).
+				Int("Status Code", http.StatusInternalServerError).
+				Err(err).
+				Msg("Could not start login challenge!")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(dto.LoginOutputDTO{MFARequired: true, Challenge: challenge})
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusOK).
+			Msg("Login waiting for two-factor code")
+		return
+	}
+
+	token, err := s.AuthService.CreateSession(id, r.UserAgent(), ip)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		log.Error().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusInternalServerError).
+			Err(err).
+			Msg("Could not create session!")
 		return
 	}
 
-	token := s.AuthService.CreateToken(id)
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditLoginSucceeded, entity.AuditAccount(id), entity.AuditAccount(id), nil, nil))
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditTokenIssued, entity.AuditAccount(id), entity.AuditAccount(id), nil, map[string]interface{}{
+		"user_agent": r.UserAgent(),
+	}))
 
 	output := dto.LoginOutputDTO{
 		Token: token,
@@ -74,7 +74,15 @@ func createHTTPAccountServer() (TransferService *service.TransferService, Accoun
 
 	TransferService, AccountService, AuthService = createRepoAndServices()
 
-	server = NewAccountServer(*TransferService, *AccountService, *AuthService)
+	loginAttemptRepo := database.NewLoginAttemptRepository()
+	loginAttemptRepo.Reset()
+	loginGuard := service.NewLoginGuardService(loginAttemptRepo)
+
+	twoFactorRepo := database.NewTwoFactorRepository()
+	twoFactorRepo.Reset()
+	twoFactorService := service.NewTwoFactorService(twoFactorRepo, AccountService.Repo)
+
+	server = NewAccountServer(*TransferService, *AccountService, *AuthService, *loginGuard, *twoFactorService)
 
 	return
 }
@@ -135,72 +143,125 @@ func createHttpRequestAndResponse(method, URL string, body io.Reader) (*http.Req
 
 func TestGETAccounts(t *testing.T) {
 
-	_, AccountService, _, server := createHTTPAccountServer()
+	_, AccountService, AuthService, server := createHTTPAccountServer()
 
-	t.Run("Should return empty array when no account is created", func(t *testing.T) {
+	t.Run("Should NOT list accounts without authorization", func(t *testing.T) {
 
 		t.Cleanup(func() {
 			clearDatabase(server)
 		})
 
+		createMockAccount(AccountService)
+
 		request, response := createHttpRequestAndResponse(http.MethodGet, "/accounts", nil)
 		server.ReadAccounts(response, request)
 
-		assertStatusCode(t, response, http.StatusOK)
-		assertAccountsFromResponse(t, response, []dto.ReadAccountOutputDTO{})
+		assertStatusCode(t, response, http.StatusUnauthorized)
 	})
 
-	t.Run("Should return accounts when a there is any registered account", func(t *testing.T) {
+	t.Run("Should return only the caller own account", func(t *testing.T) {
 
 		t.Cleanup(func() {
 			clearDatabase(server)
 		})
 
 		mockAccount := createMockAccount(AccountService)
+		otherAccount := createMockAccount(AccountService)
 		account := dto.ReadAccountOutputDTO{
-			ID:      mockAccount.ID,
-			Name:    mockAccount.Name,
+			ID:   mockAccount.PublicID,
+			Name: mockAccount.Name,
+			// The caller own account, so the CPF comes in clear.
 			CPF:     mockAccount.CPF,
 			Balance: mockAccount.Balance,
 		}
 
+		token, _ := AuthService.CreateSession(mockAccount.ID, "phone", "10.0.0.1")
+
 		request, response := createHttpRequestAndResponse(http.MethodGet, "/accounts", nil)
+		request.Header.Add("Authorization", "Bearer "+token)
 		server.ReadAccounts(response, request)
 
 		assertStatusCode(t, response, http.StatusOK)
-		assertAccountsFromResponse(t, response, []dto.ReadAccountOutputDTO{account})
+
+		var got []dto.ReadAccountOutputDTO
+		json.NewDecoder(response.Body).Decode(&got)
+
+		if len(got) != 1 || got[0].ID != account.ID || got[0].CPF != account.CPF {
+			t.Errorf("Expected only the caller account %+v, got: %+v", account, got)
+		}
+
+		for _, acc := range got {
+			if acc.ID == otherAccount.PublicID {
+				t.Errorf("Another account should NOT be listed: %+v", acc)
+			}
+		}
 	})
 }
 
 func TestGetBalance(t *testing.T) {
 
-	_, AccountService, _, server := createHTTPAccountServer()
+	_, AccountService, AuthService, server := createHTTPAccountServer()
 
-	t.Run("Should return account list", func(t *testing.T) {
+	t.Run("Should return the caller own balance", func(t *testing.T) {
 
 		t.Cleanup(func() {
 			clearDatabase(server)
 		})
 
 		mockAccount := createMockAccount(AccountService)
+		token, _ := AuthService.CreateSession(mockAccount.ID, "phone", "10.0.0.1")
 
-		fmt.Print(mockAccount.ID)
-
-		request, response := createHttpRequestAndResponse(http.MethodGet, fmt.Sprintf("/accounts/%d/balance", mockAccount.ID), nil)
+		request, response := createHttpRequestAndResponse(http.MethodGet, fmt.Sprintf("/accounts/%s/balance", mockAccount.PublicID), nil)
+		request.Header.Add("Authorization", "Bearer "+token)
 		server.ReadAccountBalance(response, request)
 
 		assertStatusCode(t, response, http.StatusOK)
 		assertAccountBalance(t, response, mockAccount.Balance)
 	})
 
+	t.Run("Should NOT return the balance without authorization", func(t *testing.T) {
+
+		t.Cleanup(func() {
+			clearDatabase(server)
+		})
+
+		mockAccount := createMockAccount(AccountService)
+
+		request, response := createHttpRequestAndResponse(http.MethodGet, fmt.Sprintf("/accounts/%s/balance", mockAccount.PublicID), nil)
+		server.ReadAccountBalance(response, request)
+
+		assertStatusCode(t, response, http.StatusUnauthorized)
+	})
+
+	t.Run("Should return 404 for another account balance", func(t *testing.T) {
+
+		t.Cleanup(func() {
+			clearDatabase(server)
+		})
+
+		mockAccount := createMockAccount(AccountService)
+		otherAccount := createMockAccount(AccountService)
+		token, _ := AuthService.CreateSession(mockAccount.ID, "phone", "10.0.0.1")
+
+		request, response := createHttpRequestAndResponse(http.MethodGet, fmt.Sprintf("/accounts/%s/balance", otherAccount.PublicID), nil)
+		request.Header.Add("Authorization", "Bearer "+token)
+		server.ReadAccountBalance(response, request)
+
+		assertStatusCode(t, response, http.StatusNotFound)
+		assertBody(t, response, "")
+	})
+
 	t.Run("Should return 404 if account is not found!", func(t *testing.T) {
 
 		t.Cleanup(func() {
 			clearDatabase(server)
 		})
 
-		// FIXME: Should use a number that doesnt exist. Not this hardcoded magic number!
-		request, response := createHttpRequestAndResponse(http.MethodGet, fmt.Sprintf("/accounts/%d/balance", 31039813098), nil)
+		mockAccount := createMockAccount(AccountService)
+		token, _ := AuthService.CreateSession(mockAccount.ID, "phone", "10.0.0.1")
+
+		request, response := createHttpRequestAndResponse(http.MethodGet, fmt.Sprintf("/accounts/%s/balance", entity.NewPublicID(entity.ACCOUNT_ID_PREFIX)), nil)
+		request.Header.Add("Authorization", "Bearer "+token)
 		server.ReadAccountBalance(response, request)
 
 		assertStatusCode(t, response, http.StatusNotFound)
@@ -231,6 +292,11 @@ func assertAccountBalance(t *testing.T, response *httptest.ResponseRecorder, exp
 		return
 	}
 
+	// Nothing is held on the mock accounts.
+	if got.Ledger != expectedBalance || got.Available != expectedBalance {
+		t.Errorf("Ledger and available balances are different from expected! Got: %+v, expected: %d", got, expectedBalance)
+	}
+
 }
 
 // FIX: Refactor this function to become more DRY code.
@@ -324,6 +390,58 @@ func TestLogin(t *testing.T) {
 		}
 
 	})
+
+	t.Run("Should answer the same for unknown CPF and wrong secret", func(t *testing.T) {
+
+		t.Cleanup(func() {
+			AccountService.Repo.Reset()
+			server.LoginGuard.Repo.Reset()
+		})
+
+		mockedAccount := createMockAccount(AccountService)
+
+		inputs := []dto.LoginInputDTO{
+			{CPF: mockedAccount.CPF, Secret: "wrongSecret"},
+			{CPF: "00000000000", Secret: MOCKED_SECRET},
+		}
+
+		bodies := []string{}
+		for _, inputDTO := range inputs {
+			input, _ := json.Marshal(inputDTO)
+
+			request, response := createHttpRequestAndResponse(http.MethodPost, "/login", bytes.NewBuffer(input))
+			server.Login(response, request)
+
+			assertStatusCode(t, response, http.StatusUnauthorized)
+			bodies = append(bodies, response.Body.String())
+		}
+
+		if bodies[0] != bodies[1] {
+			t.Errorf("Login failures should be indistinguishable. Got: %s and %s", bodies[0], bodies[1])
+		}
+	})
+
+	t.Run("Should lock the CPF after too many failures", func(t *testing.T) {
+
+		t.Cleanup(func() {
+			AccountService.Repo.Reset()
+			server.LoginGuard.Repo.Reset()
+		})
+
+		mockedAccount := createMockAccount(AccountService)
+		input, _ := json.Marshal(dto.LoginInputDTO{CPF: mockedAccount.CPF, Secret: "wrongSecret"})
+
+		for i := 0; i < entity.LOGIN_MAX_FAILURES; i++ {
+			request, response := createHttpRequestAndResponse(http.MethodPost, "/login", bytes.NewBuffer(input))
+			server.Login(response, request)
+		}
+
+		input, _ = json.Marshal(dto.LoginInputDTO{CPF: mockedAccount.CPF, Secret: MOCKED_SECRET})
+		request, response := createHttpRequestAndResponse(http.MethodPost, "/login", bytes.NewBuffer(input))
+		server.Login(response, request)
+
+		assertStatusCode(t, response, http.StatusTooManyRequests)
+	})
 }
 
 func clearDatabase(server *AccountServer) {
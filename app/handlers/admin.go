@@ -0,0 +1,668 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+	"github.com/rs/zerolog/log"
+)
+
+type AdminServer struct {
+	AdminService service.AdminService
+
+	// Optional. When set, operator logins and admin actions are recorded in
+	// the audit log.
+	Audit *service.AuditService
+
+	// Optional. When set, operator logins are locked after repeated failures
+	// as customer logins are.
+	LoginGuard *service.LoginGuardService
+}
+
+func NewAdminServer(adminService service.AdminService) *AdminServer {
+	return &AdminServer{
+		AdminService: adminService,
+	}
+}
+
+func (s *AdminServer) ServeHTTP() *http.ServeMux {
+
+	router := http.NewServeMux()
+	router.Handle("/admin/login", http.HandlerFunc(s.Login))
+	router.Handle("/admin/logout", http.HandlerFunc(s.Logout))
+	router.Handle("/admin/operators", http.HandlerFunc(s.CreateOperator))
+	router.Handle("/admin/operators/{id}/tokens", http.HandlerFunc(s.RevokeOperatorTokens))
+	router.Handle("/admin/accounts", http.HandlerFunc(s.SearchAccounts))
+	router.Handle("/admin/accounts/{id}/adjustments", http.HandlerFunc(s.AdjustBalance))
+	router.Handle("/admin/accounts/{id}/status", http.HandlerFunc(s.ChangeAccountStatus))
+	router.Handle("/admin/transfers", http.HandlerFunc(s.SearchTransfers))
+	router.Handle("/admin/actions", http.HandlerFunc(s.ReadActions))
+
+	return router
+}
+
+// authorizeOperator resolves the operator behind the bearer token and checks it
+// holds the permission. On failure the response is already written.
+func (s *AdminServer) authorizeOperator(w http.ResponseWriter, r *http.Request, permission entity.Permission) (entity.Operator, bool) {
+
+	var token string
+	_, scanErr := fmt.Sscanf(r.Header.Get("Authorization"), "Bearer %s", &token)
+	if scanErr != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode("Invalid bearer token format!")
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(scanErr).
+			Msg("Failed authorizing operator!")
+		return entity.Operator{}, false
+	}
+
+	operator, statusCode, err := s.AdminService.Authorize(token, permission)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Str("Permission", string(permission)).
+			Err(err).
+			Msg("Failed authorizing operator!")
+		return entity.Operator{}, false
+	}
+
+	return operator, true
+}
+
+// accountPathID writes the response itself when the path does not hold an
+// account ID.
+func accountPathID(w http.ResponseWriter, r *http.Request) (string, bool) {
+
+	id := r.PathValue("id")
+	if !entity.IsPublicID(entity.ACCOUNT_ID_PREFIX, id) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Invalid account ID: " + id)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusBadRequest).
+			Msg("Failed parsing account ID!")
+		return "", false
+	}
+
+	return id, true
+}
+
+func (s *AdminServer) Login(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint AdminLogin!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var input dto.AdminLoginInputDTO
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	ip := clientIP(r)
+
+	if s.LoginGuard != nil {
+		if wait := s.LoginGuard.AttemptOperator(input.Email, ip); wait > 0 {
+			writeTooManyRequests(w, wait)
+
+			log.Info().
+				Str("Method", r.Method).
+				Str("Path", r.URL.String()).
+				Int("Status Code", http.StatusTooManyRequests).
+				Dur("Retry After", wait).
+				Msg("Operator login locked after too many failures!")
+			return
+		}
+	}
+
+	token, operatorId, err := s.AdminService.Login(input)
+	if err != nil {
+		// Unknown emails are not audited, they may be anyone's address.
+		subject := entity.AUDIT_SUBJECT_UNKNOWN_OPERATOR
+		if operatorId != 0 {
+			subject = entity.AuditOperator(operatorId)
+		}
+
+		recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditLoginFailed, entity.AUDIT_ACTOR_ANONYMOUS, subject, nil, nil))
+
+		w.WriteHeader(http.StatusUnauthorized)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(err).
+			Msg("Operator login failed!")
+		return
+	}
+
+	if s.LoginGuard != nil {
+		s.LoginGuard.RegisterOperatorSuccess(input.Email, ip)
+	}
+
+	operator := entity.AuditOperator(operatorId)
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditLoginSucceeded, operator, operator, nil, nil))
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditTokenIssued, operator, operator, nil, nil))
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dto.LoginOutputDTO{Token: token})
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", http.StatusOK).
+		Msg("")
+}
+
+func (s *AdminServer) Logout(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint AdminLogout!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var token string
+	_, scanErr := fmt.Sscanf(r.Header.Get("Authorization"), "Bearer %s", &token)
+	if scanErr != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode("Invalid bearer token format!")
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(scanErr).
+			Msg("Failed authorizing operator!")
+		return
+	}
+
+	operatorId, statusCode, err := s.AdminService.Logout(token)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Could not logout operator!")
+		return
+	}
+
+	operator := entity.AuditOperator(operatorId)
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditTokenRevoked, operator, operator, nil, nil))
+
+	w.WriteHeader(statusCode)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Int("OperatorID", operatorId).
+		Msg("")
+}
+
+// RevokeOperatorTokens logs an operator out of every device, the one acting
+// included when it revokes its own tokens.
+func (s *AdminServer) RevokeOperatorTokens(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint AdminRevokeOperatorTokens!")
+
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	operator, ok := s.authorizeOperator(w, r, entity.PermissionManageOperators)
+	if !ok {
+		return
+	}
+
+	targetId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || targetId <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Invalid operator ID: " + r.PathValue("id"))
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusBadRequest).
+			Msg("Failed parsing operator ID!")
+		return
+	}
+
+	var input dto.RevokeOperatorTokensInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	output, statusCode, err := s.AdminService.RevokeTokens(operator, targetId, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Int("OperatorID", operator.ID).
+			Err(err).
+			Msg("Failed revoking operator tokens!")
+		return
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditAdminAction, entity.AuditOperator(operator.ID), entity.AuditOperator(targetId), nil, map[string]interface{}{
+		"action":  entity.AdminActionRevokeTokens,
+		"revoked": output.Revoked,
+		"reason":  input.Reason,
+	}))
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Int("OperatorID", operator.ID).
+		Msg("")
+}
+
+func (s *AdminServer) CreateOperator(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint CreateOperator!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	operator, ok := s.authorizeOperator(w, r, entity.PermissionManageOperators)
+	if !ok {
+		return
+	}
+
+	var input dto.CreateOperatorInputDTO
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	output, statusCode, err := s.AdminService.CreateOperator(operator, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Int("OperatorID", operator.ID).
+			Err(err).
+			Msg("Failed creating operator!")
+		return
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditAdminAction, entity.AuditOperator(operator.ID), entity.AuditOperator(output.ID), nil, map[string]interface{}{
+		"action":      entity.AdminActionCreateOperator,
+		"role":        output.Role,
+		"permissions": output.Permissions,
+	}))
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Int("OperatorID", operator.ID).
+		Msg("")
+}
+
+func (s *AdminServer) SearchAccounts(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint AdminSearchAccounts!")
+
+	operator, ok := s.authorizeOperator(w, r, entity.PermissionReadAccounts)
+	if !ok {
+		return
+	}
+
+	input := dto.SearchAccountsInputDTO{Query: r.URL.Query().Get("q")}
+
+	accounts, statusCode, err := s.AdminService.SearchAccounts(operator, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Int("OperatorID", operator.ID).
+			Err(err).
+			Msg("Failed searching accounts!")
+		return
+	}
+
+	// The query may be a CPF, only the fact that a search happened is kept.
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditAdminAction, entity.AuditOperator(operator.ID), "accounts", nil, map[string]interface{}{
+		"action":  entity.AdminActionSearchAccounts,
+		"results": len(accounts),
+	}))
+
+	json.NewEncoder(w).Encode(accounts)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", http.StatusOK).
+		Int("OperatorID", operator.ID).
+		Msg("")
+}
+
+func (s *AdminServer) SearchTransfers(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint AdminSearchTransfers!")
+
+	operator, ok := s.authorizeOperator(w, r, entity.PermissionReadTransfers)
+	if !ok {
+		return
+	}
+
+	input, err := parseTransferSearch(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing query!")
+		return
+	}
+
+	transfers, statusCode, err := s.AdminService.SearchTransfers(operator, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Int("OperatorID", operator.ID).
+			Err(err).
+			Msg("Failed searching transfers!")
+		return
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditAdminAction, entity.AuditOperator(operator.ID), "transfers", nil, map[string]interface{}{
+		"action":     entity.AdminActionSearchTransfers,
+		"account_id": input.AccountID,
+		"results":    len(transfers),
+	}))
+
+	json.NewEncoder(w).Encode(transfers)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", http.StatusOK).
+		Int("OperatorID", operator.ID).
+		Msg("")
+}
+
+func (s *AdminServer) AdjustBalance(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint AdminAdjustBalance!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	operator, ok := s.authorizeOperator(w, r, entity.PermissionAdjustBalance)
+	if !ok {
+		return
+	}
+
+	var input dto.CreateAdjustmentInputDTO
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	accountId, ok := accountPathID(w, r)
+	if !ok {
+		return
+	}
+	input.AccountID = accountId
+
+	output, statusCode, err := s.AdminService.AdjustBalance(operator, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Int("OperatorID", operator.ID).
+			Err(err).
+			Msg("Failed adjusting balance!")
+		return
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditAdminAction, entity.AuditOperator(operator.ID), entity.AuditAccount(output.AuditAccountID),
+		map[string]interface{}{"balance": output.BalanceBefore},
+		map[string]interface{}{"balance": output.BalanceAfter, "action": entity.AdminActionAdjustBalance, "reason": input.Reason},
+	))
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Int("OperatorID", operator.ID).
+		Interface("Response", output).
+		Msg("")
+}
+
+func (s *AdminServer) ChangeAccountStatus(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint AdminChangeAccountStatus!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	operator, ok := s.authorizeOperator(w, r, entity.PermissionManageAccountStatus)
+	if !ok {
+		return
+	}
+
+	var input dto.ChangeAccountStatusInputDTO
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	accountId, ok := accountPathID(w, r)
+	if !ok {
+		return
+	}
+	input.AccountID = accountId
+
+	output, statusCode, err := s.AdminService.ChangeAccountStatus(operator, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Int("OperatorID", operator.ID).
+			Err(err).
+			Msg("Failed changing account status!")
+		return
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditAdminAction, entity.AuditOperator(operator.ID), entity.AuditAccount(output.AuditAccountID),
+		map[string]interface{}{"status": output.StatusBefore},
+		map[string]interface{}{"status": output.StatusAfter, "action": entity.AdminActionChangeStatus, "reason": input.Reason},
+	))
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Int("OperatorID", operator.ID).
+		Interface("Response", output).
+		Msg("")
+}
+
+func (s *AdminServer) ReadActions(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint AdminReadActions!")
+
+	operator, ok := s.authorizeOperator(w, r, entity.PermissionReadActions)
+	if !ok {
+		return
+	}
+
+	actions, statusCode, err := s.AdminService.ReadActions()
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Int("OperatorID", operator.ID).
+			Err(err).
+			Msg("Failed reading admin actions!")
+		return
+	}
+
+	json.NewEncoder(w).Encode(actions)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", http.StatusOK).
+		Int("OperatorID", operator.ID).
+		Msg("")
+}
+
+func parseTransferSearch(r *http.Request) (dto.SearchTransfersInputDTO, error) {
+
+	query := r.URL.Query()
+	input := dto.SearchTransfersInputDTO{}
+
+	input.AccountID = query.Get("account_id")
+
+	ints := map[string]*int{
+		"min_amount": &input.MinAmount,
+		"max_amount": &input.MaxAmount,
+	}
+	for key, dest := range ints {
+		if raw := query.Get(key); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil {
+				return input, fmt.Errorf("Invalid %s: %s", key, raw)
+			}
+			*dest = parsed
+		}
+	}
+
+	times := map[string]*time.Time{
+		"from": &input.From,
+		"to":   &input.To,
+	}
+	for key, dest := range times {
+		if raw := query.Get(key); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return input, fmt.Errorf("Invalid %s, expected RFC3339: %s", key, raw)
+			}
+			*dest = parsed
+		}
+	}
+
+	return input, nil
+}
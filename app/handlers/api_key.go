@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	API_KEY_ID_HEADER        = "X-Api-Key-Id"
+	API_KEY_TIMESTAMP_HEADER = "X-Api-Timestamp"
+	API_KEY_NONCE_HEADER     = "X-Api-Nonce"
+	API_KEY_SIGNATURE_HEADER = "X-Api-Signature"
+)
+
+type apiKeyContextKey struct{}
+
+type APIKeyServer struct {
+	APIKeyService service.APIKeyService
+	AuthService   service.AuthService
+}
+
+func NewAPIKeyServer(apiKeyService service.APIKeyService, authService service.AuthService) *APIKeyServer {
+	return &APIKeyServer{
+		APIKeyService: apiKeyService,
+		AuthService:   authService,
+	}
+}
+
+func (s *APIKeyServer) ServeHTTP() *http.ServeMux {
+
+	router := http.NewServeMux()
+	router.Handle("/api-keys", http.HandlerFunc(s.apiKeysHandler))
+	router.Handle("/api-keys/{id}", http.HandlerFunc(s.RevokeAPIKey))
+	router.Handle("/api-keys/{id}/rotate", http.HandlerFunc(s.RotateAPIKey))
+
+	return router
+}
+
+// Middleware verifies signed requests. Requests without the key ID header go
+// through untouched, so bearer tokens keep working on the same routes.
+func (s *APIKeyServer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		keyId := r.Header.Get(API_KEY_ID_HEADER)
+		if keyId == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		key, err := s.APIKeyService.Authenticate(dto.SignedRequestDTO{
+			KeyID:     keyId,
+			Signature: r.Header.Get(API_KEY_SIGNATURE_HEADER),
+			Timestamp: r.Header.Get(API_KEY_TIMESTAMP_HEADER),
+			Nonce:     r.Header.Get(API_KEY_NONCE_HEADER),
+			Method:    r.Method,
+			Path:      r.URL.RequestURI(),
+			Body:      body,
+		})
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(err.Error())
+
+			log.Info().
+				Str("Method", r.Method).
+				Str("Path", r.URL.String()).
+				Int("Status Code", http.StatusUnauthorized).
+				Str("KeyID", keyId).
+				Err(err).
+				Msg("Failed verifying signed request!")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, key)))
+	})
+}
+
+// authorizeRequest returns the account acting on the request, either through
+// an API key already verified by the middleware or through a bearer session.
+// Sessions hold every scope.
+func authorizeRequest(authService service.AuthService, r *http.Request, scope entity.Scope) (int, error) {
+
+	if key, ok := r.Context().Value(apiKeyContextKey{}).(entity.APIKey); ok {
+		if !key.HasScope(scope) {
+			return 0, fmt.Errorf("API key does not have the %s scope!", scope)
+		}
+
+		return key.AccountID, nil
+	}
+
+	session, err := authorizeSession(authService, r.Header.Get("Authorization"))
+	if err != nil {
+		return 0, err
+	}
+
+	return session.AccountID, nil
+}
+
+func (s *APIKeyServer) apiKeysHandler(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+	case http.MethodPost:
+		s.CreateAPIKey(w, r)
+	case http.MethodGet, "":
+		s.ReadAPIKeys(w, r)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Cannot " + r.Method + " " + r.URL.String())
+	}
+}
+
+// authorizeOwner only accepts bearer sessions, an API key cannot manage keys.
+func (s *APIKeyServer) authorizeOwner(w http.ResponseWriter, r *http.Request) (int, bool) {
+
+	session, err := authorizeSession(s.AuthService, r.Header.Get("Authorization"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(err).
+			Msg("Failed authorizing request!")
+		return 0, false
+	}
+
+	return session.AccountID, true
+}
+
+func (s *APIKeyServer) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint CreateAPIKey!")
+
+	accountId, ok := s.authorizeOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var input dto.CreateAPIKeyInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	output, statusCode, err := s.APIKeyService.Create(accountId, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed creating API key!")
+		return
+	}
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Str("KeyID", output.ID).
+		Msg("")
+}
+
+func (s *APIKeyServer) ReadAPIKeys(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint ReadAPIKeys!")
+
+	accountId, ok := s.authorizeOwner(w, r)
+	if !ok {
+		return
+	}
+
+	keys, statusCode, err := s.APIKeyService.List(accountId)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed reading API keys!")
+		return
+	}
+
+	json.NewEncoder(w).Encode(keys)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", http.StatusOK).
+		Msg("")
+}
+
+func (s *APIKeyServer) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint RotateAPIKey!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeOwner(w, r)
+	if !ok {
+		return
+	}
+
+	keyId := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api-keys/"), "/rotate")
+
+	output, statusCode, err := s.APIKeyService.Rotate(accountId, keyId)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed rotating API key!")
+		return
+	}
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Str("KeyID", keyId).
+		Msg("")
+}
+
+func (s *APIKeyServer) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint RevokeAPIKey!")
+
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeOwner(w, r)
+	if !ok {
+		return
+	}
+
+	keyId := strings.TrimPrefix(r.URL.Path, "/api-keys/")
+
+	statusCode, err := s.APIKeyService.Revoke(accountId, keyId)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed revoking API key!")
+		return
+	}
+
+	w.WriteHeader(statusCode)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Str("KeyID", keyId).
+		Msg("")
+}
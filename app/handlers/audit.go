@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/google/uuid"
+)
+
+const (
+	REQUEST_ID_HEADER = "X-Request-Id"
+)
+
+type requestIDContextKey struct{}
+
+// RequestID tags every request with an ID, echoed back in the response and
+// kept in the audit events it produces. An ID sent by the client is reused so
+// it can be followed across services.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		id := r.Header.Get(REQUEST_ID_HEADER)
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(REQUEST_ID_HEADER, id)
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDContextKey{}, id)))
+	})
+}
+
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// recordAudit fills the request details of the event and appends it. Servers
+// without an audit service configured record nothing.
+func recordAudit(audit *service.AuditService, r *http.Request, event *entity.AuditEvent) {
+
+	if audit == nil {
+		return
+	}
+
+	event.IP = clientIP(r)
+	event.RequestID = requestID(r)
+
+	audit.Record(*event)
+}
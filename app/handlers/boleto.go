@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+	"github.com/rs/zerolog/log"
+)
+
+type BoletoServer struct {
+	BoletoService service.BoletoService
+	AuthService   service.AuthService
+
+	// Optional. When set, boletos issued, cancelled and paid are recorded in
+	// the audit log.
+	Audit *service.AuditService
+}
+
+func NewBoletoServer(boletoService service.BoletoService, authService service.AuthService) *BoletoServer {
+	return &BoletoServer{
+		BoletoService: boletoService,
+		AuthService:   authService,
+	}
+}
+
+func (s *BoletoServer) ServeHTTP() *http.ServeMux {
+
+	router := http.NewServeMux()
+	router.Handle("/boletos", http.HandlerFunc(s.boletosHandler))
+	router.Handle("/boletos/payments", http.HandlerFunc(s.PayBoleto))
+	router.Handle("/boletos/{id}", http.HandlerFunc(s.boletoHandler))
+
+	return router
+}
+
+func (s *BoletoServer) boletosHandler(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+	case http.MethodGet, "":
+		s.ReadBoletos(w, r)
+	case http.MethodPost:
+		s.IssueBoleto(w, r)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Cannot " + r.Method + " " + r.URL.String())
+	}
+}
+
+func (s *BoletoServer) boletoHandler(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+	case http.MethodGet, "":
+		s.ReadBoleto(w, r)
+	case http.MethodDelete:
+		s.CancelBoleto(w, r)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Cannot " + r.Method + " " + r.URL.String())
+	}
+}
+
+// authorizeAccount writes the response itself when the request is not
+// authenticated.
+func (s *BoletoServer) authorizeAccount(w http.ResponseWriter, r *http.Request, scope entity.Scope) (int, bool) {
+
+	accountId, err := authorizeRequest(s.AuthService, r, scope)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(err).
+			Msg("Failed authorizing request!")
+		return 0, false
+	}
+
+	return accountId, true
+}
+
+func (s *BoletoServer) IssueBoleto(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint IssueBoleto!")
+
+	accountId, ok := s.authorizeAccount(w, r, entity.ScopeTransfersWrite)
+	if !ok {
+		return
+	}
+
+	var input dto.CreateBoletoInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	output, statusCode, err := s.BoletoService.Issue(accountId, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed issuing boleto!")
+		return
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditBoletoIssued, entity.AuditAccount(accountId), entity.AuditAccount(accountId), nil, map[string]interface{}{
+		"boleto_id": output.ID,
+		"amount":    output.Amount,
+		"due_date":  output.DueDate,
+	}))
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Str("BoletoID", output.ID).
+		Msg("")
+}
+
+func (s *BoletoServer) ReadBoletos(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint ReadBoletos!")
+
+	accountId, ok := s.authorizeAccount(w, r, entity.ScopeTransfersRead)
+	if !ok {
+		return
+	}
+
+	output, statusCode, err := s.BoletoService.ReadBoletos(accountId)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed reading boletos!")
+		return
+	}
+
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Msg("")
+}
+
+func (s *BoletoServer) ReadBoleto(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint ReadBoleto!")
+
+	accountId, ok := s.authorizeAccount(w, r, entity.ScopeTransfersRead)
+	if !ok {
+		return
+	}
+
+	output, statusCode, err := s.BoletoService.ReadBoleto(accountId, strings.TrimPrefix(r.URL.Path, "/boletos/"))
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed reading boleto!")
+		return
+	}
+
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Msg("")
+}
+
+func (s *BoletoServer) CancelBoleto(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint CancelBoleto!")
+
+	accountId, ok := s.authorizeAccount(w, r, entity.ScopeTransfersWrite)
+	if !ok {
+		return
+	}
+
+	boletoId := strings.TrimPrefix(r.URL.Path, "/boletos/")
+
+	statusCode, err := s.BoletoService.Cancel(accountId, boletoId)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed cancelling boleto!")
+		return
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditBoletoCancelled, entity.AuditAccount(accountId), entity.AuditAccount(accountId), map[string]interface{}{
+		"boleto_id": boletoId,
+	}, nil))
+
+	w.WriteHeader(statusCode)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Msg("")
+}
+
+func (s *BoletoServer) PayBoleto(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint PayBoleto!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeAccount(w, r, entity.ScopeTransfersWrite)
+	if !ok {
+		return
+	}
+
+	var input dto.PayBoletoInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	output, statusCode, err := s.BoletoService.Pay(accountId, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed paying boleto!")
+		return
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditBoletoPaid, entity.AuditAccount(accountId), entity.AuditAccount(accountId), nil, map[string]interface{}{
+		"boleto_id": output.BoletoID,
+		"amount":    output.AmountPaid,
+	}))
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Str("BoletoID", output.BoletoID).
+		Msg("")
+}
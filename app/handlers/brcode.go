@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+	"github.com/rs/zerolog/log"
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	QR_CODE_SIZE = 256
+)
+
+type BRCodeServer struct {
+	BRCodeService service.BRCodeService
+	AuthService   service.AuthService
+
+	// Optional. When set, payments are recorded in the audit log.
+	Audit *service.AuditService
+}
+
+func NewBRCodeServer(brCodeService service.BRCodeService, authService service.AuthService) *BRCodeServer {
+	return &BRCodeServer{
+		BRCodeService: brCodeService,
+		AuthService:   authService,
+	}
+}
+
+func (s *BRCodeServer) ServeHTTP() *http.ServeMux {
+
+	router := http.NewServeMux()
+	router.Handle("/pix/qrcodes", http.HandlerFunc(s.CreateStaticBRCode))
+	router.Handle("/pix/qrcodes/image", http.HandlerFunc(s.RenderBRCode))
+	router.Handle("/pix/charges", http.HandlerFunc(s.CreateCharge))
+	router.Handle("/pix/charges/{id}", http.HandlerFunc(s.ReadCharge))
+	router.Handle("/pix/payments", http.HandlerFunc(s.PayBRCode))
+
+	return router
+}
+
+// authorizeAccount writes the response itself when the request is not
+// authenticated.
+func (s *BRCodeServer) authorizeAccount(w http.ResponseWriter, r *http.Request, scope entity.Scope) (int, bool) {
+
+	accountId, err := authorizeRequest(s.AuthService, r, scope)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.Path).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(err).
+			Msg("Failed authorizing request!")
+		return 0, false
+	}
+
+	return accountId, true
+}
+
+func (s *BRCodeServer) CreateStaticBRCode(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint CreateStaticBRCode!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeAccount(w, r, entity.ScopeTransfersWrite)
+	if !ok {
+		return
+	}
+
+	var input dto.CreateStaticBRCodeInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	output, statusCode, err := s.BRCodeService.CreateStatic(accountId, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed creating static BR Code!")
+		return
+	}
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Msg("")
+}
+
+func (s *BRCodeServer) CreateCharge(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint CreatePixCharge!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeAccount(w, r, entity.ScopeTransfersWrite)
+	if !ok {
+		return
+	}
+
+	var input dto.CreatePixChargeInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	output, statusCode, err := s.BRCodeService.CreateCharge(accountId, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed creating Pix charge!")
+		return
+	}
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Str("ChargeID", output.ID).
+		Msg("")
+}
+
+func (s *BRCodeServer) ReadCharge(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint ReadPixCharge!")
+
+	if r.Method != http.MethodGet && r.Method != "" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeAccount(w, r, entity.ScopeTransfersRead)
+	if !ok {
+		return
+	}
+
+	output, statusCode, err := s.BRCodeService.ReadCharge(accountId, strings.TrimPrefix(r.URL.Path, "/pix/charges/"))
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed reading Pix charge!")
+		return
+	}
+
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Msg("")
+}
+
+// RenderBRCode draws any valid BR Code, given as ?payload=, as a PNG QR code.
+func (s *BRCodeServer) RenderBRCode(w http.ResponseWriter, r *http.Request) {
+
+	// Only the path is logged, the payload holds the Pix key.
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.Path).Msg("Called endpoint RenderBRCode!")
+
+	if r.Method != http.MethodGet && r.Method != "" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	payload := r.URL.Query().Get("payload")
+
+	if _, err := entity.ParseBRCode(payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.Path).
+			Int("Status Code", http.StatusBadRequest).
+			Err(err).
+			Msg("Failed parsing BR Code!")
+		return
+	}
+
+	image, err := qrcode.Encode(payload, qrcode.Medium, QR_CODE_SIZE)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		log.Error().
+			Str("Method", r.Method).
+			Str("Path", r.URL.Path).
+			Int("Status Code", http.StatusInternalServerError).
+			Err(err).
+			Msg("Could not render QR code!")
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(image)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.Path).
+		Int("Status Code", http.StatusOK).
+		Msg("")
+}
+
+func (s *BRCodeServer) PayBRCode(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint PayBRCode!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeAccount(w, r, entity.ScopeTransfersWrite)
+	if !ok {
+		return
+	}
+
+	var input dto.PayBRCodeInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	transfer, statusCode, err := s.BRCodeService.Pay(accountId, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed paying BR Code!")
+		return
+	}
+
+	// The payload itself holds the receiver key, it is not audited.
+	code, _ := entity.ParseBRCode(input.Payload)
+	if code.Amount == 0 {
+		code.Amount = input.Amount
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditTransferCreated, entity.AuditAccount(accountId), entity.AuditTransfer(transfer.ID), nil, map[string]interface{}{
+		"amount":       code.Amount,
+		"brcode":       true,
+		"dynamic":      code.Dynamic,
+		"pix_key_type": entity.DetectPixKeyType(code.PixKey),
+		"txid":         code.TxID,
+	}))
+
+	w.WriteHeader(statusCode)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Msg("")
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+	"github.com/rs/zerolog/log"
+)
+
+type EscrowServer struct {
+	EscrowService service.EscrowService
+	AuthService   service.AuthService
+
+	// Optional. When set, escrows are recorded in the audit log.
+	Audit *service.AuditService
+}
+
+func NewEscrowServer(escrowService service.EscrowService, authService service.AuthService) *EscrowServer {
+	return &EscrowServer{
+		EscrowService: escrowService,
+		AuthService:   authService,
+	}
+}
+
+func (s *EscrowServer) ServeHTTP() *http.ServeMux {
+
+	router := http.NewServeMux()
+	router.Handle("/escrows", http.HandlerFunc(s.escrowsHandler))
+	router.Handle("/escrows/{id}", http.HandlerFunc(s.ReadEscrow))
+	router.Handle("/escrows/{id}/release", http.HandlerFunc(s.ReleaseEscrow))
+	router.Handle("/escrows/{id}/refund", http.HandlerFunc(s.RefundEscrow))
+
+	return router
+}
+
+func (s *EscrowServer) escrowsHandler(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+	case http.MethodGet, "":
+		s.ReadEscrows(w, r)
+	case http.MethodPost:
+		s.CreateEscrow(w, r)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Cannot " + r.Method + " " + r.URL.String())
+	}
+}
+
+// authorizeAccount writes the response itself when the request is not
+// authenticated.
+func (s *EscrowServer) authorizeAccount(w http.ResponseWriter, r *http.Request, scope entity.Scope) (int, bool) {
+
+	accountId, err := authorizeRequest(s.AuthService, r, scope)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(err).
+			Msg("Failed authorizing request!")
+		return 0, false
+	}
+
+	return accountId, true
+}
+
+func (s *EscrowServer) CreateEscrow(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint CreateEscrow!")
+
+	accountId, ok := s.authorizeAccount(w, r, entity.ScopeTransfersWrite)
+	if !ok {
+		return
+	}
+
+	var input dto.CreateEscrowInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	output, statusCode, err := s.EscrowService.Create(accountId, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed creating escrow!")
+		return
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditEscrowFunded, entity.AuditAccount(accountId), entity.AuditEscrow(output.ID), nil, map[string]interface{}{
+		"status":     output.Status,
+		"balance":    output.Balance,
+		"payee_id":   output.PayeeID,
+		"release_at": output.ReleaseAt,
+	}))
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Str("EscrowID", output.ID).
+		Msg("")
+}
+
+func (s *EscrowServer) ReadEscrows(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint ReadEscrows!")
+
+	accountId, ok := s.authorizeAccount(w, r, entity.ScopeTransfersRead)
+	if !ok {
+		return
+	}
+
+	output, statusCode, err := s.EscrowService.ReadEscrows(accountId)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed reading escrows!")
+		return
+	}
+
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Int("Escrows", len(output)).
+		Msg("")
+}
+
+func (s *EscrowServer) ReadEscrow(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint ReadEscrow!")
+
+	if r.Method != http.MethodGet && r.Method != "" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeAccount(w, r, entity.ScopeTransfersRead)
+	if !ok {
+		return
+	}
+
+	output, statusCode, err := s.EscrowService.Read(accountId, strings.TrimPrefix(r.URL.Path, "/escrows/"))
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed reading escrow!")
+		return
+	}
+
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Str("Status", output.Status).
+		Msg("")
+}
+
+func (s *EscrowServer) ReleaseEscrow(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint ReleaseEscrow!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeAccount(w, r, entity.ScopeTransfersWrite)
+	if !ok {
+		return
+	}
+
+	escrowId := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/escrows/"), "/release")
+
+	output, statusCode, err := s.EscrowService.Release(accountId, escrowId)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed releasing escrow!")
+		return
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditEscrowReleased, entity.AuditAccount(accountId), entity.AuditEscrow(output.ID),
+		map[string]interface{}{"status": entity.EscrowFunded, "balance": output.Amount},
+		map[string]interface{}{"status": output.Status, "balance": output.Balance},
+	))
+
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Str("EscrowID", output.ID).
+		Msg("")
+}
+
+func (s *EscrowServer) RefundEscrow(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint RefundEscrow!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeAccount(w, r, entity.ScopeTransfersWrite)
+	if !ok {
+		return
+	}
+
+	escrowId := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/escrows/"), "/refund")
+
+	output, statusCode, err := s.EscrowService.Refund(accountId, escrowId)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed refunding escrow!")
+		return
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditEscrowRefunded, entity.AuditAccount(accountId), entity.AuditEscrow(output.ID),
+		map[string]interface{}{"status": entity.EscrowFunded, "balance": output.Amount},
+		map[string]interface{}{"status": output.Status, "balance": output.Balance},
+	))
+
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Str("EscrowID", output.ID).
+		Msg("")
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+	"github.com/PPAKruNN/golearn/infra/repository/database"
+)
+
+func createEscrowServices() (EscrowService *service.EscrowService, AccountService *service.AccountService) {
+
+	TransferService, AccountService, _ := createRepoAndServices()
+
+	escrowRepo := database.NewEscrowRepository()
+	escrowRepo.Reset()
+
+	EscrowService = service.NewEscrowService(escrowRepo, TransferService)
+
+	return
+}
+
+// createDueEscrow funds an escrow of the payer and waits for it to be due.
+func createDueEscrow(t *testing.T, EscrowService *service.EscrowService, payer, payee entity.Account, amount int) dto.ReadEscrowOutputDTO {
+
+	escrow, _, err := EscrowService.Create(payer.ID, dto.CreateEscrowInputDTO{AccountDestinationID: payee.PublicID, Amount: amount, ReleaseIn: 1})
+	if err != nil {
+		t.Fatalf("Escrow should be funded. Err: %v", err)
+	}
+
+	time.Sleep(time.Until(escrow.ReleaseAt))
+
+	return escrow
+}
+
+func TestEscrowReleaseGivenUp(t *testing.T) {
+
+	EscrowService, AccountService := createEscrowServices()
+
+	t.Run("Should refund the payer once the release failed too many times", func(t *testing.T) {
+
+		payer := createMockAccount(AccountService)
+		payee := createMockAccount(AccountService)
+
+		escrow := createDueEscrow(t, EscrowService, payer, payee, 40)
+		AccountService.Repo.UpdateStatus(payee.ID, entity.AccountFrozen)
+
+		for i := 1; i < entity.ESCROW_MAX_RELEASE_FAILURES; i++ {
+			if released, err := EscrowService.ReleaseDue(); err != nil || released != 0 {
+				t.Fatalf("Escrow to a frozen payee should NOT be released. Released: %d, Err: %v", released, err)
+			}
+		}
+
+		// Still tried until the last failure.
+		assertBalance(t, AccountService, payer, MOCKED_BALANCE-40)
+
+		EscrowService.ReleaseDue()
+
+		read, _, err := EscrowService.Read(payer.ID, escrow.ID)
+		if err != nil || read.Status != string(entity.EscrowRefunded) || read.Balance != 0 {
+			t.Errorf("Escrow should be refunded. Got: %+v, Err: %v", read, err)
+		}
+
+		assertBalance(t, AccountService, payer, MOCKED_BALANCE)
+		assertBalance(t, AccountService, payee, MOCKED_BALANCE)
+	})
+
+	t.Run("Should fail the escrow when the payer cannot be refunded either", func(t *testing.T) {
+
+		payer := createMockAccount(AccountService)
+		payee := createMockAccount(AccountService)
+
+		escrow := createDueEscrow(t, EscrowService, payer, payee, 40)
+		AccountService.Repo.UpdateStatus(payee.ID, entity.AccountFrozen)
+		AccountService.Repo.UpdateStatus(payer.ID, entity.AccountFrozen)
+
+		for i := 0; i < entity.ESCROW_MAX_RELEASE_FAILURES; i++ {
+			EscrowService.ReleaseDue()
+		}
+
+		read, _, err := EscrowService.Read(payer.ID, escrow.ID)
+		if err != nil || read.Status != string(entity.EscrowFailed) || read.Balance != 40 {
+			t.Errorf("Escrow should be failed, still holding the money. Got: %+v, Err: %v", read, err)
+		}
+
+		assertBalance(t, AccountService, payer, MOCKED_BALANCE-40)
+		assertBalance(t, AccountService, payee, MOCKED_BALANCE)
+	})
+}
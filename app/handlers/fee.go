@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/rs/zerolog/log"
+)
+
+type FeeServer struct {
+	FeeService  service.FeeService
+	AuthService service.AuthService
+}
+
+func NewFeeServer(feeService service.FeeService, authService service.AuthService) *FeeServer {
+	return &FeeServer{
+		FeeService:  feeService,
+		AuthService: authService,
+	}
+}
+
+func (s *FeeServer) ServeHTTP() *http.ServeMux {
+
+	router := http.NewServeMux()
+	router.Handle("/fees", http.HandlerFunc(s.ReadFeeSchedules))
+	router.Handle("/fees/quote", http.HandlerFunc(s.QuoteFee))
+
+	return router
+}
+
+// ReadFeeSchedules is public, the fees are shown before opening an account.
+func (s *FeeServer) ReadFeeSchedules(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint ReadFeeSchedules!")
+
+	if r.Method != http.MethodGet && r.Method != "" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(s.FeeService.ReadSchedules())
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", http.StatusOK).
+		Msg("")
+}
+
+// QuoteFee prices a transfer of the account, given as ?kind=&amount=, before
+// it is confirmed.
+func (s *FeeServer) QuoteFee(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint QuoteFee!")
+
+	if r.Method != http.MethodGet && r.Method != "" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, err := authorizeRequest(s.AuthService, r, entity.ScopeTransfersRead)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(err).
+			Msg("Failed authorizing request!")
+		return
+	}
+
+	kind := r.URL.Query().Get("kind")
+	if kind == "" {
+		kind = string(entity.TransferKindTransfer)
+	}
+
+	amount, err := strconv.Atoi(r.URL.Query().Get("amount"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Invalid amount: " + r.URL.Query().Get("amount"))
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusBadRequest).
+			Err(err).
+			Msg("Failed parsing amount!")
+		return
+	}
+
+	output, statusCode, err := s.FeeService.Quote(accountId, kind, amount)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed quoting fee!")
+		return
+	}
+
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Int("Fee", output.Fee).
+		Msg("")
+}
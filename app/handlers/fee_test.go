@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+)
+
+func TestFreeTransferAllowance(t *testing.T) {
+
+	TransferService, AccountService, _ := createRepoAndServices()
+
+	revenue := createMockAccount(AccountService)
+
+	FeeService, err := service.NewFeeService([]entity.FeeSchedule{{Kind: entity.TransferKindTransfer, Flat: 5, FreePerMonth: 2}}, revenue.ID, TransferService.TransferRepo)
+	if err != nil {
+		t.Fatalf("Fee schedule should be valid. Err: %v", err)
+	}
+	TransferService.Fees = FeeService
+
+	t.Run("Should charge only the transfers past the free ones of the month", func(t *testing.T) {
+
+		origin := createMockAccount(AccountService)
+		destination := createMockAccount(AccountService)
+
+		transfer := dto.CreateTrasnferInputDTO{AccountDestinationID: destination.PublicID, Amount: 10}
+
+		for i := 1; i <= 2; i++ {
+			if _, _, err := TransferService.CreateTransfer(origin.ID, transfer); err != nil {
+				t.Fatalf("Transfer %d should be made. Err: %v", i, err)
+			}
+
+			assertBalance(t, AccountService, origin, MOCKED_BALANCE-10*i)
+			assertBalance(t, AccountService, revenue, MOCKED_BALANCE)
+		}
+
+		quote, _, err := FeeService.Quote(origin.ID, string(entity.TransferKindTransfer), 10)
+		if err != nil || quote.Fee != 5 || quote.FreeRemaining != 0 {
+			t.Errorf("Transfer past the free ones should be quoted the fee. Got: %+v, Err: %v", quote, err)
+		}
+
+		if _, _, err := TransferService.CreateTransfer(origin.ID, transfer); err != nil {
+			t.Fatalf("Transfer past the free ones should be made. Err: %v", err)
+		}
+
+		assertBalance(t, AccountService, origin, MOCKED_BALANCE-30-5)
+		assertBalance(t, AccountService, destination, MOCKED_BALANCE+30)
+		assertBalance(t, AccountService, revenue, MOCKED_BALANCE+5)
+	})
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+	"github.com/rs/zerolog/log"
+)
+
+type HoldServer struct {
+	HoldService service.HoldService
+	AuthService service.AuthService
+
+	// Optional. When set, holds are recorded in the audit log.
+	Audit *service.AuditService
+}
+
+func NewHoldServer(holdService service.HoldService, authService service.AuthService) *HoldServer {
+	return &HoldServer{
+		HoldService: holdService,
+		AuthService: authService,
+	}
+}
+
+func (s *HoldServer) ServeHTTP() *http.ServeMux {
+
+	router := http.NewServeMux()
+	router.Handle("/holds", http.HandlerFunc(s.AuthorizeHold))
+	router.Handle("/holds/{id}", http.HandlerFunc(s.ReadHold))
+	router.Handle("/holds/{id}/capture", http.HandlerFunc(s.CaptureHold))
+	router.Handle("/holds/{id}/void", http.HandlerFunc(s.VoidHold))
+
+	return router
+}
+
+// authorizeAccount writes the response itself when the request is not
+// authenticated.
+func (s *HoldServer) authorizeAccount(w http.ResponseWriter, r *http.Request, scope entity.Scope) (int, bool) {
+
+	accountId, err := authorizeRequest(s.AuthService, r, scope)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(err).
+			Msg("Failed authorizing request!")
+		return 0, false
+	}
+
+	return accountId, true
+}
+
+func (s *HoldServer) AuthorizeHold(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint AuthorizeHold!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeAccount(w, r, entity.ScopeTransfersWrite)
+	if !ok {
+		return
+	}
+
+	var input dto.CreateHoldInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	output, statusCode, err := s.HoldService.Authorize(accountId, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed authorizing hold!")
+		return
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditHoldAuthorized, entity.AuditAccount(accountId), entity.AuditAccount(accountId), nil, map[string]interface{}{
+		"hold_id":                output.ID,
+		"account_destination_id": output.AccountDestinationID,
+		"amount":                 output.Amount,
+		"expires_at":             output.ExpiresAt,
+	}))
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Str("HoldID", output.ID).
+		Msg("")
+}
+
+func (s *HoldServer) ReadHold(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint ReadHold!")
+
+	if r.Method != http.MethodGet && r.Method != "" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeAccount(w, r, entity.ScopeTransfersRead)
+	if !ok {
+		return
+	}
+
+	output, statusCode, err := s.HoldService.Read(accountId, strings.TrimPrefix(r.URL.Path, "/holds/"))
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed reading hold!")
+		return
+	}
+
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Str("Status", output.Status).
+		Msg("")
+}
+
+func (s *HoldServer) CaptureHold(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint CaptureHold!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeAccount(w, r, entity.ScopeTransfersWrite)
+	if !ok {
+		return
+	}
+
+	// The body is optional, without it the whole hold is captured.
+	var input dto.CaptureHoldInputDTO
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+
+			log.Info().
+				Str("Method", r.Method).
+				Str("Path", r.URL.String()).
+				Int("Status Code", http.StatusUnprocessableEntity).
+				Err(err).
+				Msg("Failed processing body!")
+			return
+		}
+	}
+
+	holdId := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/holds/"), "/capture")
+
+	output, statusCode, err := s.HoldService.Capture(accountId, holdId, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed capturing hold!")
+		return
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditHoldCaptured, entity.AuditAccount(accountId), entity.AuditAccount(accountId),
+		map[string]interface{}{"status": entity.HoldAuthorized, "amount": output.Amount},
+		map[string]interface{}{"status": output.Status, "captured_amount": output.CapturedAmount, "transfer_id": output.TransferID},
+	))
+
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Str("HoldID", output.ID).
+		Msg("")
+}
+
+func (s *HoldServer) VoidHold(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint VoidHold!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeAccount(w, r, entity.ScopeTransfersWrite)
+	if !ok {
+		return
+	}
+
+	holdId := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/holds/"), "/void")
+
+	output, statusCode, err := s.HoldService.Void(accountId, holdId)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed voiding hold!")
+		return
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditHoldVoided, entity.AuditAccount(accountId), entity.AuditAccount(accountId),
+		map[string]interface{}{"status": entity.HoldAuthorized, "amount": output.Amount},
+		map[string]interface{}{"status": output.Status},
+	))
+
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Str("HoldID", output.ID).
+		Msg("")
+}
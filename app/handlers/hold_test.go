@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+	"github.com/PPAKruNN/golearn/infra/repository/database"
+)
+
+func createHoldServices() (HoldService *service.HoldService, TransferService *service.TransferService, AccountService *service.AccountService) {
+
+	TransferService, AccountService, _ = createRepoAndServices()
+
+	holdRepo := database.NewHoldRepository()
+	holdRepo.Reset()
+
+	HoldService = service.NewHoldService(holdRepo, TransferService)
+
+	return
+}
+
+func TestConcurrentHold(t *testing.T) {
+
+	HoldService, TransferService, AccountService := createHoldServices()
+
+	t.Run("Should NOT let a hold and a transfer made at once spend the same funds", func(t *testing.T) {
+
+		for i := 0; i < 20; i++ {
+			origin := createMockAccount(AccountService)
+			destination := createMockAccount(AccountService)
+
+			var wg sync.WaitGroup
+			var holdErr, transferErr error
+
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				_, _, holdErr = HoldService.Authorize(origin.ID, dto.CreateHoldInputDTO{AccountDestinationID: destination.PublicID, Amount: MOCKED_BALANCE})
+			}()
+			go func() {
+				defer wg.Done()
+				_, _, transferErr = TransferService.CreateTransfer(origin.ID, dto.CreateTrasnferInputDTO{AccountDestinationID: destination.PublicID, Amount: MOCKED_BALANCE})
+			}()
+			wg.Wait()
+
+			if holdErr == nil && transferErr == nil {
+				t.Fatalf("Hold and transfer should NOT both spend the balance!")
+			}
+
+			balance := AccountService.ReadAccountBalance(origin.ID, dto.ReadAccountBalanceInputDTO{ID: origin.PublicID})
+
+			expected := MOCKED_BALANCE
+			if transferErr == nil {
+				expected = 0
+			}
+
+			if balance.Balance != expected || balance.Available != 0 {
+				t.Fatalf("Wrong origin balance. Got: %+v, expected balance: %d and nothing available", balance, expected)
+			}
+		}
+	})
+}
+
+func TestHoldDailyLimit(t *testing.T) {
+
+	HoldService, TransferService, AccountService := createHoldServices()
+
+	TransferService.DailyLimit = 60
+	defer func() { TransferService.DailyLimit = 0 }()
+
+	t.Run("Should NOT authorize a hold above the daily limit", func(t *testing.T) {
+
+		origin := createMockAccount(AccountService)
+		destination := createMockAccount(AccountService)
+
+		_, statusCode, err := HoldService.Authorize(origin.ID, dto.CreateHoldInputDTO{AccountDestinationID: destination.PublicID, Amount: 70})
+		if err == nil || statusCode != http.StatusBadRequest {
+			t.Fatalf("Hold above the daily limit should NOT be authorized. Status: %d, Err: %v", statusCode, err)
+		}
+
+		balance := AccountService.ReadAccountBalance(origin.ID, dto.ReadAccountBalanceInputDTO{ID: origin.PublicID})
+		if balance.Balance != MOCKED_BALANCE || balance.Available != MOCKED_BALANCE {
+			t.Errorf("Nothing should be held. Got: %+v", balance)
+		}
+	})
+
+	t.Run("Should NOT capture a hold once what was sent since goes over the limit", func(t *testing.T) {
+
+		origin := createMockAccount(AccountService)
+		destination := createMockAccount(AccountService)
+		other := createMockAccount(AccountService)
+
+		hold, _, err := HoldService.Authorize(origin.ID, dto.CreateHoldInputDTO{AccountDestinationID: destination.PublicID, Amount: 50})
+		if err != nil {
+			t.Fatalf("Hold within the daily limit should be authorized. Err: %v", err)
+		}
+
+		if _, _, err := TransferService.CreateTransfer(origin.ID, dto.CreateTrasnferInputDTO{AccountDestinationID: other.PublicID, Amount: 20}); err != nil {
+			t.Fatalf("Transfer within the daily limit should be made. Err: %v", err)
+		}
+
+		_, statusCode, err := HoldService.Capture(destination.ID, hold.ID, dto.CaptureHoldInputDTO{Amount: 50})
+		if err == nil {
+			t.Fatalf("Capture above the daily limit should fail. Status: %d", statusCode)
+		}
+
+		read, _, err := HoldService.Read(origin.ID, hold.ID)
+		if err != nil || read.Status != string(entity.HoldAuthorized) {
+			t.Errorf("Hold should still be authorized. Got: %+v, Err: %v", read, err)
+		}
+
+		balance := AccountService.ReadAccountBalance(origin.ID, dto.ReadAccountBalanceInputDTO{ID: origin.PublicID})
+		if balance.Balance != MOCKED_BALANCE-20 || balance.Available != MOCKED_BALANCE-20-50 {
+			t.Errorf("Only the transfer should be debited, the hold still held. Got: %+v", balance)
+		}
+
+		assertBalance(t, AccountService, destination, MOCKED_BALANCE)
+		assertBalance(t, AccountService, other, MOCKED_BALANCE+20)
+	})
+}
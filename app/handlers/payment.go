@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+	"github.com/rs/zerolog/log"
+)
+
+type PaymentServer struct {
+	BillPaymentService  service.BillPaymentService
+	SplitPaymentService service.SplitPaymentService
+	AuthService         service.AuthService
+
+	// Optional. When set, payments are recorded in the audit log.
+	Audit *service.AuditService
+}
+
+func NewPaymentServer(billPaymentService service.BillPaymentService, splitPaymentService service.SplitPaymentService, authService service.AuthService) *PaymentServer {
+	return &PaymentServer{
+		BillPaymentService:  billPaymentService,
+		SplitPaymentService: splitPaymentService,
+		AuthService:         authService,
+	}
+}
+
+func (s *PaymentServer) ServeHTTP() *http.ServeMux {
+
+	router := http.NewServeMux()
+	router.Handle("/payments/boleto", http.HandlerFunc(s.PayBoleto))
+	router.Handle("/payments/split", http.HandlerFunc(s.PaySplit))
+
+	return router
+}
+
+func (s *PaymentServer) PayBoleto(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint PayBill!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, err := authorizeRequest(s.AuthService, r, entity.ScopeTransfersWrite)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(err).
+			Msg("Failed authorizing request!")
+		return
+	}
+
+	var input dto.PayBillInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	output, statusCode, err := s.BillPaymentService.PayBoleto(accountId, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed paying bill!")
+		return
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditBoletoPaid, entity.AuditAccount(accountId), entity.AuditAccount(accountId), nil, map[string]interface{}{
+		"barcode":   output.Barcode,
+		"bank_code": output.BankCode,
+		"amount":    output.AmountPaid,
+	}))
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Msg("")
+}
+
+// PaySplit debits the amount once and credits each receiver its share by the
+// split rules.
+func (s *PaymentServer) PaySplit(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint PaySplit!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, err := authorizeRequest(s.AuthService, r, entity.ScopeTransfersWrite)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(err).
+			Msg("Failed authorizing request!")
+		return
+	}
+
+	var input dto.CreateSplitPaymentInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	output, statusCode, err := s.SplitPaymentService.Pay(accountId, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed paying split!")
+		return
+	}
+
+	credits := []map[string]interface{}{}
+	for _, split := range output.Splits {
+		credits = append(credits, map[string]interface{}{
+			"transfer_id":            split.ID,
+			"account_destination_id": split.AccountDestinationID,
+			"amount":                 split.Amount,
+		})
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditTransferCreated, entity.AuditAccount(accountId), entity.AuditAccount(accountId), nil, map[string]interface{}{
+		"transfer_id": output.ID,
+		"amount":      output.Amount,
+		"splits":      credits,
+	}))
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Str("TransferID", output.ID).
+		Msg("")
+}
@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+	"github.com/rs/zerolog/log"
+)
+
+type PixKeyServer struct {
+	PixKeyService service.PixKeyService
+	AuthService   service.AuthService
+
+	// Optional. When set, keys registered and deleted are recorded in the
+	// audit log.
+	Audit *service.AuditService
+
+	// Optional. Limits lookups per account, so they cannot be used to go
+	// through the customer base.
+	LookupLimiter *RateLimiter
+}
+
+func NewPixKeyServer(pixKeyService service.PixKeyService, authService service.AuthService) *PixKeyServer {
+	return &PixKeyServer{
+		PixKeyService: pixKeyService,
+		AuthService:   authService,
+	}
+}
+
+func (s *PixKeyServer) ServeHTTP() *http.ServeMux {
+
+	router := http.NewServeMux()
+	router.Handle("/pix-keys", http.HandlerFunc(s.pixKeysHandler))
+	router.Handle("/pix-keys/lookup", http.HandlerFunc(s.LookupPixKey))
+	router.Handle("/pix-keys/{id}", http.HandlerFunc(s.DeletePixKey))
+	router.Handle("/pix-keys/{id}/verify", http.HandlerFunc(s.VerifyPixKey))
+	router.Handle("/pix-keys/{id}/code", http.HandlerFunc(s.ResendPixKeyCode))
+
+	return router
+}
+
+func (s *PixKeyServer) pixKeysHandler(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+	case http.MethodGet, "":
+		s.ReadPixKeys(w, r)
+	case http.MethodPost:
+		s.RegisterPixKey(w, r)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Cannot " + r.Method + " " + r.URL.String())
+	}
+}
+
+// authorizeHolder writes the response itself when the request is not
+// authenticated.
+func (s *PixKeyServer) authorizeHolder(w http.ResponseWriter, r *http.Request) (int, bool) {
+
+	session, err := authorizeSession(s.AuthService, r.Header.Get("Authorization"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(err).
+			Msg("Failed authorizing request!")
+		return 0, false
+	}
+
+	return session.AccountID, true
+}
+
+func (s *PixKeyServer) RegisterPixKey(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint RegisterPixKey!")
+
+	accountId, ok := s.authorizeHolder(w, r)
+	if !ok {
+		return
+	}
+
+	var input dto.CreatePixKeyInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	output, statusCode, err := s.PixKeyService.Register(accountId, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Interface("Input", input).
+			Err(err).
+			Msg("Failed registering Pix key!")
+		return
+	}
+
+	// The key value is personal data, only its ID and type are audited.
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditPixKeyRegistered, entity.AuditAccount(accountId), entity.AuditAccount(accountId), nil, map[string]interface{}{
+		"key_id": output.ID,
+		"type":   output.Type,
+	}))
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Str("KeyID", output.ID).
+		Msg("")
+}
+
+func (s *PixKeyServer) ReadPixKeys(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint ReadPixKeys!")
+
+	accountId, ok := s.authorizeHolder(w, r)
+	if !ok {
+		return
+	}
+
+	output, statusCode, err := s.PixKeyService.ReadKeys(accountId)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Error().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Could not read Pix keys!")
+		return
+	}
+
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Msg("")
+}
+
+func (s *PixKeyServer) VerifyPixKey(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint VerifyPixKey!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeHolder(w, r)
+	if !ok {
+		return
+	}
+
+	var input dto.VerifyPixKeyInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	keyId := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/pix-keys/"), "/verify")
+
+	output, statusCode, err := s.PixKeyService.Verify(accountId, keyId, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed verifying Pix key!")
+		return
+	}
+
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Str("KeyID", output.ID).
+		Msg("")
+}
+
+func (s *PixKeyServer) ResendPixKeyCode(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint ResendPixKeyCode!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeHolder(w, r)
+	if !ok {
+		return
+	}
+
+	keyId := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/pix-keys/"), "/code")
+
+	statusCode, err := s.PixKeyService.ResendCode(accountId, keyId)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed resending Pix key code!")
+		return
+	}
+
+	w.WriteHeader(statusCode)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Msg("")
+}
+
+func (s *PixKeyServer) DeletePixKey(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint DeletePixKey!")
+
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeHolder(w, r)
+	if !ok {
+		return
+	}
+
+	keyId := strings.TrimPrefix(r.URL.Path, "/pix-keys/")
+
+	statusCode, err := s.PixKeyService.Delete(accountId, keyId)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed deleting Pix key!")
+		return
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditPixKeyDeleted, entity.AuditAccount(accountId), entity.AuditAccount(accountId), map[string]interface{}{
+		"key_id": keyId,
+	}, nil))
+
+	w.WriteHeader(statusCode)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Msg("")
+}
+
+// LookupPixKey finds the account behind a key, given as ?key=. Only logged in
+// holders can look keys up.
+func (s *PixKeyServer) LookupPixKey(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.Path).Msg("Called endpoint LookupPixKey!")
+
+	if r.Method != http.MethodGet && r.Method != "" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeHolder(w, r)
+	if !ok {
+		return
+	}
+
+	if !allowAccount(s.LookupLimiter, w, r, accountId) {
+		return
+	}
+
+	output, statusCode, err := s.PixKeyService.Lookup(r.URL.Query().Get("key"))
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.Path).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed looking Pix key up!")
+		return
+	}
+
+	json.NewEncoder(w).Encode(output)
+
+	// The path only, the query holds the key.
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.Path).
+		Int("Status Code", statusCode).
+		Msg("")
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+	"github.com/rs/zerolog/log"
+)
+
+type PrivacyServer struct {
+	PrivacyService service.PrivacyService
+	AuthService    service.AuthService
+	Audit          *service.AuditService
+}
+
+func NewPrivacyServer(privacyService service.PrivacyService, authService service.AuthService, audit *service.AuditService) *PrivacyServer {
+	return &PrivacyServer{
+		PrivacyService: privacyService,
+		AuthService:    authService,
+		Audit:          audit,
+	}
+}
+
+func (s *PrivacyServer) ServeHTTP() *http.ServeMux {
+
+	router := http.NewServeMux()
+	router.Handle("/accounts/me/export", http.HandlerFunc(s.Export))
+	router.Handle("/accounts/me/erasure", http.HandlerFunc(s.Erase))
+
+	return router
+}
+
+func (s *PrivacyServer) authorizeHolder(w http.ResponseWriter, r *http.Request) (int, bool) {
+
+	session, err := authorizeSession(s.AuthService, r.Header.Get("Authorization"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(err).
+			Msg("Failed authorizing request!")
+		return 0, false
+	}
+
+	return session.AccountID, true
+}
+
+// Export downloads the personal data of the account holder, as a ZIP of JSON
+// files by default or as a single JSON with ?format=json.
+func (s *PrivacyServer) Export(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint ExportData!")
+
+	if r.Method != http.MethodGet && r.Method != "" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeHolder(w, r)
+	if !ok {
+		return
+	}
+
+	output, statusCode, err := s.PrivacyService.Export(accountId)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed exporting personal data!")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditDataExported, entity.AuditAccount(accountId), entity.AuditAccount(accountId), nil, map[string]interface{}{
+		"format": format,
+	}))
+
+	if format == "json" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="account-%s.json"`, output.Account.ID))
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(output)
+	} else {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="account-%s.zip"`, output.Account.ID))
+		w.WriteHeader(http.StatusOK)
+
+		if err := writeExportZip(w, output); err != nil {
+			log.Error().
+				Str("Method", r.Method).
+				Str("Path", r.URL.String()).
+				Err(err).
+				Msg("Failed writing export archive!")
+			return
+		}
+	}
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", http.StatusOK).
+		Msg("")
+}
+
+func writeExportZip(w http.ResponseWriter, output dto.DataExportDTO) error {
+
+	archive := zip.NewWriter(w)
+
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"account.json", output.Account},
+		{"transfers.json", output.Transfers},
+		{"sessions.json", output.Sessions},
+		{"audit_events.json", output.AuditEvents},
+		{"pix_keys.json", output.PixKeys},
+	}
+
+	for _, file := range files {
+		writer, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.content); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+func (s *PrivacyServer) Erase(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint EraseAccount!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeHolder(w, r)
+	if !ok {
+		return
+	}
+
+	var input dto.EraseAccountInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	statusCode, err := s.PrivacyService.Erase(accountId, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed erasing account!")
+		return
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditAccountErased, entity.AuditAccount(accountId), entity.AuditAccount(accountId), nil, map[string]interface{}{
+		"fields": []string{"name", "cpf", "secret"},
+	}))
+
+	w.WriteHeader(statusCode)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Msg("")
+}
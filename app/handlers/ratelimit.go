@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	MAX_RATE_LIMIT_BUCKETS = 10000
+)
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// RateLimiter is a token bucket per client IP. Every client starts with
+// `burst` tokens, each request takes one and tokens refill at `perMinute`.
+type RateLimiter struct {
+	perMinute float64
+	burst     float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewRateLimiter(perMinute, burst int) *RateLimiter {
+	return &RateLimiter{
+		perMinute: float64(perMinute),
+		burst:     float64(burst),
+		buckets:   map[string]*bucket{},
+	}
+}
+
+// Allow takes a token from the key bucket. When the bucket is empty it returns
+// how long until the next token is available.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	if len(l.buckets) > MAX_RATE_LIMIT_BUCKETS {
+		l.prune(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, updatedAt: now}
+		l.buckets[key] = b
+	}
+
+	refill := now.Sub(b.updatedAt).Minutes() * l.perMinute
+	b.tokens = math.Min(l.burst, b.tokens+refill)
+	b.updatedAt = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.perMinute * float64(time.Minute))
+		return false, wait
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+// prune drops buckets that had time to refill completely, they are the same as
+// a new bucket.
+func (l *RateLimiter) prune(now time.Time) {
+	full := time.Duration(l.burst / l.perMinute * float64(time.Minute))
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updatedAt) > full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		allowed, wait := l.Allow(clientIP(r))
+		if !allowed {
+			writeTooManyRequests(w, wait)
+
+			log.Info().
+				Str("Method", r.Method).
+				Str("Path", r.URL.String()).
+				Int("Status Code", http.StatusTooManyRequests).
+				Msg("Rate limit exceeded!")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowAccount limits by account instead of IP, for endpoints that could be
+// used to scrape data. A nil limiter allows everything. On failure the
+// response is already written.
+func allowAccount(l *RateLimiter, w http.ResponseWriter, r *http.Request, accountId int) bool {
+
+	if l == nil {
+		return true
+	}
+
+	allowed, wait := l.Allow(fmt.Sprintf("account:%d", accountId))
+	if !allowed {
+		writeTooManyRequests(w, wait)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.Path).
+			Int("Status Code", http.StatusTooManyRequests).
+			Int("AccountID", accountId).
+			Msg("Account rate limit exceeded!")
+		return false
+	}
+
+	return true
+}
+
+func writeTooManyRequests(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", fmt.Sprintf("%d", int(math.Ceil(wait.Seconds()))))
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode("Too many requests, try again later!")
+}
+
+func clientIP(r *http.Request) string {
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/rs/zerolog/log"
+)
+
+type SessionServer struct {
+	AuthService service.AuthService
+}
+
+func NewSessionServer(authService service.AuthService) *SessionServer {
+	return &SessionServer{
+		AuthService: authService,
+	}
+}
+
+func (s *SessionServer) ServeHTTP() *http.ServeMux {
+
+	router := http.NewServeMux()
+	router.Handle("/logout", http.HandlerFunc(s.Logout))
+	router.Handle("/sessions", http.HandlerFunc(s.sessionsHandler))
+	router.Handle("/sessions/{id}", http.HandlerFunc(s.RevokeSession))
+
+	return router
+}
+
+// authorizeSession resolves the session behind a "Bearer <token>" header.
+func authorizeSession(authService service.AuthService, authorization string) (entity.Session, error) {
+
+	var token string
+	_, scanErr := fmt.Sscanf(authorization, "Bearer %s", &token)
+	if scanErr != nil {
+		return entity.Session{}, fmt.Errorf("Invalid bearer token format!")
+	}
+
+	session, err := authService.DecodeSession(token)
+	if err != nil {
+		return entity.Session{}, fmt.Errorf("Invalid token provided!")
+	}
+
+	return session, nil
+}
+
+func (s *SessionServer) sessionsHandler(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+	case http.MethodGet, "":
+		s.ReadSessions(w, r)
+	case http.MethodDelete:
+		s.RevokeAllSessions(w, r)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Cannot " + r.Method + " " + r.URL.String())
+	}
+}
+
+func (s *SessionServer) writeUnauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(err.Error())
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", http.StatusUnauthorized).
+		Err(err).
+		Msg("Failed authorizing request!")
+}
+
+func (s *SessionServer) Logout(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint Logout!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	session, err := authorizeSession(s.AuthService, r.Header.Get("Authorization"))
+	if err != nil {
+		s.writeUnauthorized(w, r, err)
+		return
+	}
+
+	if err := s.AuthService.Logout(session); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		log.Error().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusInternalServerError).
+			Err(err).
+			Msg("Could not logout!")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", http.StatusNoContent).
+		Msg("")
+}
+
+func (s *SessionServer) ReadSessions(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint ReadSessions!")
+
+	session, err := authorizeSession(s.AuthService, r.Header.Get("Authorization"))
+	if err != nil {
+		s.writeUnauthorized(w, r, err)
+		return
+	}
+
+	sessions, err := s.AuthService.ReadSessions(session)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		log.Error().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusInternalServerError).
+			Err(err).
+			Msg("Could not read sessions!")
+		return
+	}
+
+	json.NewEncoder(w).Encode(sessions)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", http.StatusOK).
+		Msg("")
+}
+
+func (s *SessionServer) RevokeSession(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint RevokeSession!")
+
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	session, err := authorizeSession(s.AuthService, r.Header.Get("Authorization"))
+	if err != nil {
+		s.writeUnauthorized(w, r, err)
+		return
+	}
+
+	var sessionId string
+	fmt.Sscanf(r.URL.Path, "/sessions/%s", &sessionId)
+
+	if err := s.AuthService.RevokeSession(session.AccountID, sessionId); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusNotFound).
+			Err(err).
+			Msg("Could not revoke session!")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", http.StatusNoContent).
+		Msg("")
+}
+
+// RevokeAllSessions logs the account out everywhere, including this device.
+func (s *SessionServer) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint RevokeAllSessions!")
+
+	session, err := authorizeSession(s.AuthService, r.Header.Get("Authorization"))
+	if err != nil {
+		s.writeUnauthorized(w, r, err)
+		return
+	}
+
+	if err := s.AuthService.RevokeAllSessions(session.AccountID); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		log.Error().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusInternalServerError).
+			Err(err).
+			Msg("Could not revoke sessions!")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", http.StatusNoContent).
+		Msg("")
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+)
+
+func createHTTPSessionServer() (AccountService *service.AccountService, AuthService *service.AuthService, server *SessionServer) {
+
+	_, AccountService, AuthService = createRepoAndServices()
+	server = NewSessionServer(*AuthService)
+
+	return
+}
+
+func TestSessions(t *testing.T) {
+
+	AccountService, AuthService, server := createHTTPSessionServer()
+
+	t.Run("Should keep one session per device", func(t *testing.T) {
+
+		t.Cleanup(func() {
+			AuthService.Repo.Reset()
+			AccountService.Repo.Reset()
+		})
+
+		acc := createMockAccount(AccountService)
+		phone, _ := AuthService.CreateSession(acc.ID, "phone", "10.0.0.1")
+		AuthService.CreateSession(acc.ID, "laptop", "10.0.0.2")
+
+		request, response := createHttpRequestAndResponse(http.MethodGet, "/sessions", nil)
+		request.Header.Add("Authorization", "Bearer "+phone)
+		server.ReadSessions(response, request)
+
+		assertStatusCode(t, response, http.StatusOK)
+
+		var sessions []dto.ReadSessionOutputDTO
+		json.NewDecoder(response.Body).Decode(&sessions)
+
+		if len(sessions) != 2 {
+			t.Errorf("Expected 2 sessions, got %d", len(sessions))
+		}
+	})
+
+	t.Run("Should NOT accept a token after logout", func(t *testing.T) {
+
+		t.Cleanup(func() {
+			AuthService.Repo.Reset()
+			AccountService.Repo.Reset()
+		})
+
+		acc := createMockAccount(AccountService)
+		token, _ := AuthService.CreateSession(acc.ID, "phone", "10.0.0.1")
+
+		request, response := createHttpRequestAndResponse(http.MethodPost, "/logout", nil)
+		request.Header.Add("Authorization", "Bearer "+token)
+		server.Logout(response, request)
+
+		assertStatusCode(t, response, http.StatusNoContent)
+
+		if _, err := AuthService.DecodeToken(token); err == nil {
+			t.Errorf("Token should be invalid after logout")
+		}
+	})
+
+	t.Run("Should revoke another device session", func(t *testing.T) {
+
+		t.Cleanup(func() {
+			AuthService.Repo.Reset()
+			AccountService.Repo.Reset()
+		})
+
+		acc := createMockAccount(AccountService)
+		phone, _ := AuthService.CreateSession(acc.ID, "phone", "10.0.0.1")
+		laptop, _ := AuthService.CreateSession(acc.ID, "laptop", "10.0.0.2")
+
+		laptopSession, _ := AuthService.DecodeSession(laptop)
+
+		request, response := createHttpRequestAndResponse(http.MethodDelete, fmt.Sprintf("/sessions/%s", laptopSession.ID), nil)
+		request.Header.Add("Authorization", "Bearer "+phone)
+		server.RevokeSession(response, request)
+
+		assertStatusCode(t, response, http.StatusNoContent)
+
+		if _, err := AuthService.DecodeToken(laptop); err == nil {
+			t.Errorf("Laptop token should be invalid after being revoked")
+		}
+
+		if _, err := AuthService.DecodeToken(phone); err != nil {
+			t.Errorf("Phone token should still be valid")
+		}
+	})
+}
@@ -2,9 +2,9 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 
+	"github.com/PPAKruNN/golearn/domain/entity"
 	"github.com/PPAKruNN/golearn/domain/service"
 	"github.com/PPAKruNN/golearn/domain/service/dto"
 
@@ -14,6 +14,13 @@ import (
 type TransferServer struct {
 	TransferService service.TransferService
 	AuthService     service.AuthService
+
+	// Optional. When set, transfers are recorded in the audit log.
+	Audit *service.AuditService
+
+	// Optional. Limits payee confirmations and transfer previews per account,
+	// so they cannot be used to go through the customer base.
+	PayeeLimiter *RateLimiter
 }
 
 func NewTransferServer(transferService service.TransferService, authService service.AuthService) *TransferServer {
@@ -27,6 +34,8 @@ func (s *TransferServer) ServeHTTP() *http.ServeMux {
 
 	router := http.NewServeMux()
 	router.Handle("/transfers/", http.HandlerFunc(s.transferHandler))
+	router.Handle("/transfers/payee", http.HandlerFunc(s.ConfirmPayee))
+	router.Handle("/transfers/preview", http.HandlerFunc(s.PreviewTransfer))
 
 	return router
 
@@ -37,8 +46,6 @@ func (s *TransferServer) transferHandler(w http.ResponseWriter, r *http.Request)
 
 	method := r.Method
 
-	fmt.Print(method)
-
 	switch method {
 	case http.MethodPost:
 		s.CreateTransfer(w, r)
@@ -57,20 +64,8 @@ func (s *TransferServer) transferHandler(w http.ResponseWriter, r *http.Request)
 
 }
 
-func (s *TransferServer) authorizeAccount(authorization string) (int, error) {
-
-	var token string
-	_, scanErr := fmt.Sscanf(authorization, "Bearer %s", &token)
-	if scanErr != nil {
-		return 0, fmt.Errorf("Invalid bearer token format!")
-	}
-
-	accountId, tokenErr := s.AuthService.DecodeToken(token)
-	if tokenErr != nil {
-		return 0, fmt.Errorf("Invalid token provided!")
-	}
-
-	return accountId, nil
+func (s *TransferServer) authorizeAccount(r *http.Request, scope entity.Scope) (int, error) {
+	return authorizeRequest(s.AuthService, r, scope)
 }
 
 func (s *TransferServer) ReadTransfers(w http.ResponseWriter, r *http.Request) {
@@ -78,8 +73,7 @@ func (s *TransferServer) ReadTransfers(w http.ResponseWriter, r *http.Request) {
 	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint ReadTransfers!")
 
 	// Authorization
-	authorization := r.Header.Get("Authorization")
-	accountId, err := s.authorizeAccount(authorization)
+	accountId, err := s.authorizeAccount(r, entity.ScopeTransfersRead)
 	if err != nil {
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(err.Error())
@@ -109,8 +103,7 @@ func (s *TransferServer) CreateTransfer(w http.ResponseWriter, r *http.Request)
 	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint CreateTransfer!")
 
 	// Authorization
-	authorization := r.Header.Get("Authorization")
-	accountId, authErr := s.authorizeAccount(authorization)
+	accountId, authErr := s.authorizeAccount(r, entity.ScopeTransfersWrite)
 	if authErr != nil {
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(authErr.Error())
@@ -139,20 +132,8 @@ func (s *TransferServer) CreateTransfer(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// FIX: DTO should not ask for OriginID on json.
-	// Temporary solution: Force origin to be accountId.
-	if input.AccountOriginID != accountId {
-		log.Warn().
-			Str("Method", r.Method).
-			Str("Path", r.URL.String()).
-			Interface("UserSentInput", input).
-			Int("NewCorrectedOriginID", accountId).
-			Msg("Manually correcting an transfer originID to a accountID obtained using account authentication!")
-
-		input.AccountOriginID = accountId
-	}
-
-	statusCode, transferErr := s.TransferService.CreateTransfer(input)
+	// The origin is always the authenticated account.
+	transfer, statusCode, transferErr := s.TransferService.CreateTransfer(accountId, input)
 	if transferErr != nil {
 		w.WriteHeader(statusCode)
 		json.NewEncoder(w).Encode(transferErr.Error())
@@ -165,6 +146,25 @@ func (s *TransferServer) CreateTransfer(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	after := map[string]interface{}{
+		"account_destination_id": input.AccountDestinationID,
+		"amount":                 input.Amount,
+	}
+
+	// Only the key type, email and phone keys are personal data.
+	if input.PixKey != "" {
+		after["pix_key_type"] = entity.DetectPixKeyType(input.PixKey)
+	}
+	if input.AccountDestinationCPF != "" {
+		after["account_destination_cpf"] = entity.MaskCPF(input.AccountDestinationCPF)
+	}
+	if input.AccountDestinationNumber != "" {
+		after["account_destination_agency"] = input.AccountDestinationAgency
+		after["account_destination_number"] = input.AccountDestinationNumber
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditTransferCreated, entity.AuditAccount(accountId), entity.AuditTransfer(transfer.ID), nil, after))
+
 	w.WriteHeader(http.StatusCreated)
 
 	log.Info().
@@ -174,3 +174,135 @@ func (s *TransferServer) CreateTransfer(w http.ResponseWriter, r *http.Request)
 		Int("Status Code", http.StatusCreated).
 		Msg("")
 }
+
+// ConfirmPayee shows the masked holder of a destination, given with the same
+// fields of a transfer as query parameters, before the transfer is sent.
+func (s *TransferServer) ConfirmPayee(w http.ResponseWriter, r *http.Request) {
+
+	// Only the path is logged, the query may hold a Pix key or a CPF.
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.Path).Msg("Called endpoint ConfirmPayee!")
+
+	if r.Method != http.MethodGet && r.Method != "" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, err := s.authorizeAccount(r, entity.ScopeTransfersWrite)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.Path).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(err).
+			Msg("Failed authorizing request!")
+		return
+	}
+
+	if !allowAccount(s.PayeeLimiter, w, r, accountId) {
+		return
+	}
+
+	input := dto.ConfirmPayeeInputDTO{
+		AccountDestinationID:     r.URL.Query().Get("account_destination_id"),
+		PixKey:                   r.URL.Query().Get("pix_key"),
+		AccountDestinationCPF:    r.URL.Query().Get("account_destination_cpf"),
+		AccountDestinationAgency: r.URL.Query().Get("account_destination_agency"),
+		AccountDestinationNumber: r.URL.Query().Get("account_destination_number"),
+	}
+
+	output, statusCode, err := s.TransferService.ConfirmPayee(input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.Path).
+			Int("Status Code", statusCode).
+			Int("AccountID", accountId).
+			Err(err).
+			Msg("Failed confirming payee!")
+		return
+	}
+
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.Path).
+		Int("Status Code", statusCode).
+		Int("AccountID", accountId).
+		Msg("")
+}
+
+// PreviewTransfer takes the body of a transfer and answers with what sending
+// it would do, or the error sending it would get.
+func (s *TransferServer) PreviewTransfer(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint PreviewTransfer!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, err := s.authorizeAccount(r, entity.ScopeTransfersWrite)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(err).
+			Msg("Failed authorizing request!")
+		return
+	}
+
+	if !allowAccount(s.PayeeLimiter, w, r, accountId) {
+		return
+	}
+
+	var input dto.CreateTrasnferInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	output, statusCode, err := s.TransferService.PreviewTransfer(accountId, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Int("AccountID", accountId).
+			Err(err).
+			Msg("Failed previewing transfer!")
+		return
+	}
+
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Int("AccountID", accountId).
+		Int("Fee", output.Fee).
+		Msg("")
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// Largest JSON body or CSV upload accepted for a batch.
+	MAX_BATCH_UPLOAD_SIZE = 10 << 20
+)
+
+type TransferBatchServer struct {
+	TransferBatchService service.TransferBatchService
+	AuthService          service.AuthService
+
+	// Optional. When set, batches are recorded in the audit log.
+	Audit *service.AuditService
+}
+
+func NewTransferBatchServer(transferBatchService service.TransferBatchService, authService service.AuthService) *TransferBatchServer {
+	return &TransferBatchServer{
+		TransferBatchService: transferBatchService,
+		AuthService:          authService,
+	}
+}
+
+func (s *TransferBatchServer) ServeHTTP() *http.ServeMux {
+
+	router := http.NewServeMux()
+	router.Handle("/transfers/batches", http.HandlerFunc(s.CreateTransferBatch))
+	router.Handle("/transfers/batches/{id}", http.HandlerFunc(s.ReadTransferBatch))
+
+	return router
+}
+
+// authorizeAccount writes the response itself when the request is not
+// authenticated.
+func (s *TransferBatchServer) authorizeAccount(w http.ResponseWriter, r *http.Request, scope entity.Scope) (int, bool) {
+
+	accountId, err := authorizeRequest(s.AuthService, r, scope)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(err).
+			Msg("Failed authorizing request!")
+		return 0, false
+	}
+
+	return accountId, true
+}
+
+// CreateTransferBatch takes either a JSON body or a multipart form with the
+// mode, pin and totp_code fields and the transfers as a CSV file.
+func (s *TransferBatchServer) CreateTransferBatch(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint CreateTransferBatch!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeAccount(w, r, entity.ScopeTransfersWrite)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MAX_BATCH_UPLOAD_SIZE)
+
+	input, err := decodeTransferBatch(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	output, statusCode, err := s.TransferBatchService.Create(accountId, input)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Int("Transfers", len(input.Transfers)).
+			Err(err).
+			Msg("Failed creating transfer batch!")
+		return
+	}
+
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditTransferBatchCreated, entity.AuditAccount(accountId), entity.AuditAccount(accountId), nil, map[string]interface{}{
+		"batch_id":  output.ID,
+		"mode":      output.Mode,
+		"transfers": len(output.Items),
+		"total":     output.Total,
+	}))
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Str("BatchID", output.ID).
+		Msg("")
+}
+
+func (s *TransferBatchServer) ReadTransferBatch(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint ReadTransferBatch!")
+
+	if r.Method != http.MethodGet && r.Method != "" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	accountId, ok := s.authorizeAccount(w, r, entity.ScopeTransfersRead)
+	if !ok {
+		return
+	}
+
+	output, statusCode, err := s.TransferBatchService.Read(accountId, strings.TrimPrefix(r.URL.Path, "/transfers/batches/"))
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed reading transfer batch!")
+		return
+	}
+
+	json.NewEncoder(w).Encode(output)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Str("Status", output.Status).
+		Msg("")
+}
+
+func decodeTransferBatch(r *http.Request) (dto.CreateTransferBatchInputDTO, error) {
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		var input dto.CreateTransferBatchInputDTO
+		err := json.NewDecoder(r.Body).Decode(&input)
+		return input, err
+	}
+
+	if err := r.ParseMultipartForm(MAX_BATCH_UPLOAD_SIZE); err != nil {
+		return dto.CreateTransferBatchInputDTO{}, err
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return dto.CreateTransferBatchInputDTO{}, fmt.Errorf("The transfers must be uploaded as the file field! Err: %v", err)
+	}
+	defer file.Close()
+
+	transfers, err := decodeTransfersCSV(file)
+	if err != nil {
+		return dto.CreateTransferBatchInputDTO{}, err
+	}
+
+	return dto.CreateTransferBatchInputDTO{
+		Mode:      r.FormValue("mode"),
+		Pin:       r.FormValue("pin"),
+		TOTPCode:  r.FormValue("totp_code"),
+		Transfers: transfers,
+	}, nil
+}
+
+// decodeTransfersCSV reads one transfer per line. The header names the
+// columns with the JSON fields of a transfer, amount and one destination.
+func decodeTransfersCSV(reader io.Reader) ([]dto.CreateTrasnferInputDTO, error) {
+
+	lines, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("CSV must have a header and at least one transfer!")
+	}
+
+	header := lines[0]
+	if !containsColumn(header, "amount") {
+		return nil, fmt.Errorf("CSV header must have an amount column!")
+	}
+
+	transfers := []dto.CreateTrasnferInputDTO{}
+	for i, line := range lines[1:] {
+		var transfer dto.CreateTrasnferInputDTO
+
+		for column, value := range line {
+			value = strings.TrimSpace(value)
+
+			switch strings.TrimSpace(header[column]) {
+			case "account_destination_id":
+				transfer.AccountDestinationID = value
+			case "pix_key":
+				transfer.PixKey = value
+			case "account_destination_cpf":
+				transfer.AccountDestinationCPF = value
+			case "account_destination_agency":
+				transfer.AccountDestinationAgency = value
+			case "account_destination_number":
+				transfer.AccountDestinationNumber = value
+			case "amount":
+				if transfer.Amount, err = strconv.Atoi(value); err != nil {
+					return nil, fmt.Errorf("CSV line %d: amount must be an integer in cents!", i+2)
+				}
+			default:
+				return nil, fmt.Errorf("CSV has an unknown column %q!", header[column])
+			}
+		}
+
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, nil
+}
+
+func containsColumn(header []string, name string) bool {
+
+	for _, column := range header {
+		if strings.TrimSpace(column) == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+	"github.com/PPAKruNN/golearn/infra/repository/database"
+)
+
+func createTransferBatchServices() (TransferBatchService *service.TransferBatchService, TransferService *service.TransferService, AccountService *service.AccountService) {
+
+	TransferService, AccountService, _ = createRepoAndServices()
+
+	batchRepo := database.NewTransferBatchRepository()
+	batchRepo.Reset()
+
+	TransferBatchService = service.NewTransferBatchService(batchRepo, TransferService)
+
+	return
+}
+
+// waitTransferBatch reads the batch until it is processed in the background.
+func waitTransferBatch(t *testing.T, TransferBatchService *service.TransferBatchService, accountId int, id string) dto.ReadTransferBatchOutputDTO {
+
+	for i := 0; i < 100; i++ {
+		batch, _, err := TransferBatchService.Read(accountId, id)
+		if err != nil {
+			t.Fatalf("Could not read batch! Err: %v", err)
+		}
+
+		if batch.FinishedAt != nil {
+			return batch
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	t.Fatalf("Batch %s was not processed in time!", id)
+	return dto.ReadTransferBatchOutputDTO{}
+}
+
+func assertBalance(t *testing.T, AccountService *service.AccountService, account entity.Account, expected int) {
+
+	balance := AccountService.ReadAccountBalance(account.ID, dto.ReadAccountBalanceInputDTO{ID: account.PublicID})
+	if balance.Balance != expected {
+		t.Errorf("Wrong balance of account %s. Got: %d, expected: %d", account.PublicID, balance.Balance, expected)
+	}
+}
+
+// inDoubtTransferRepository makes the transfers, then fails as if the commit
+// was never acknowledged.
+type inDoubtTransferRepository struct {
+	service.TransferRepository
+}
+
+func (r inDoubtTransferRepository) CreateTransfers(transfers []entity.Transfer) ([]entity.Transfer, error) {
+
+	if _, err := r.TransferRepository.CreateTransfers(transfers); err != nil {
+		return nil, err
+	}
+
+	return nil, entity.ErrTransferInDoubt
+}
+
+func TestDecodeTransfersCSV(t *testing.T) {
+
+	t.Run("Should read every kind of destination", func(t *testing.T) {
+		csv := "account_destination_id,pix_key,account_destination_number,amount\n" +
+			"acc_1,,,100\n" +
+			",ze@example.com,,200\n" +
+			",,00000042-6,300\n"
+
+		transfers, err := decodeTransfersCSV(strings.NewReader(csv))
+		if err != nil {
+			t.Fatalf("CSV should be valid, got: %v", err)
+		}
+
+		if len(transfers) != 3 ||
+			transfers[0].AccountDestinationID != "acc_1" || transfers[0].Amount != 100 ||
+			transfers[1].PixKey != "ze@example.com" || transfers[1].Amount != 200 ||
+			transfers[2].AccountDestinationNumber != "00000042-6" || transfers[2].Amount != 300 {
+			t.Errorf("Wrong transfers read. Got: %+v", transfers)
+		}
+	})
+
+	t.Run("Should NOT accept unknown columns nor invalid amounts", func(t *testing.T) {
+		if _, err := decodeTransfersCSV(strings.NewReader("account_destination_id,amount,note\nacc_1,100,rent\n")); err == nil {
+			t.Errorf("Unknown column should NOT be accepted")
+		}
+
+		if _, err := decodeTransfersCSV(strings.NewReader("account_destination_id,amount\nacc_1,10.50\n")); err == nil {
+			t.Errorf("Amount not in cents should NOT be accepted")
+		}
+	})
+}
+
+func TestAtomicTransferBatch(t *testing.T) {
+
+	TransferBatchService, TransferService, AccountService := createTransferBatchServices()
+
+	t.Run("Should make none of the transfers when one of them fails", func(t *testing.T) {
+
+		origin := createMockAccount(AccountService)
+		dest1 := createMockAccount(AccountService)
+		dest2 := createMockAccount(AccountService)
+
+		// Funds cover the batch, the second transfer goes over the limit
+		// only once the first one is made.
+		TransferService.DailyLimit = 60
+		defer func() { TransferService.DailyLimit = 0 }()
+
+		created, statusCode, err := TransferBatchService.Create(origin.ID, dto.CreateTransferBatchInputDTO{
+			Mode: string(entity.BatchAtomic),
+			Transfers: []dto.CreateTrasnferInputDTO{
+				{AccountDestinationID: dest1.PublicID, Amount: 50},
+				{AccountDestinationID: dest2.PublicID, Amount: 50},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Batch should be accepted. Status: %d, Err: %v", statusCode, err)
+		}
+
+		batch := waitTransferBatch(t, TransferBatchService, origin.ID, created.ID)
+
+		if batch.Status != string(entity.BatchFailed) {
+			t.Errorf("Batch should have failed. Got: %s", batch.Status)
+		}
+
+		for _, item := range batch.Items {
+			if item.Status != string(entity.BatchItemFailed) || item.TransferID != "" {
+				t.Errorf("Every item should have failed. Got: %+v", item)
+			}
+		}
+
+		assertBalance(t, AccountService, origin, MOCKED_BALANCE)
+		assertBalance(t, AccountService, dest1, MOCKED_BALANCE)
+		assertBalance(t, AccountService, dest2, MOCKED_BALANCE)
+	})
+
+	t.Run("Should mark the items in doubt when the transfers may have been made", func(t *testing.T) {
+
+		origin := createMockAccount(AccountService)
+		dest1 := createMockAccount(AccountService)
+		dest2 := createMockAccount(AccountService)
+
+		repo := TransferService.TransferRepo
+		TransferService.TransferRepo = inDoubtTransferRepository{repo}
+		defer func() { TransferService.TransferRepo = repo }()
+
+		created, statusCode, err := TransferBatchService.Create(origin.ID, dto.CreateTransferBatchInputDTO{
+			Mode: string(entity.BatchAtomic),
+			Transfers: []dto.CreateTrasnferInputDTO{
+				{AccountDestinationID: dest1.PublicID, Amount: 30},
+				{AccountDestinationID: dest2.PublicID, Amount: 20},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Batch should be accepted. Status: %d, Err: %v", statusCode, err)
+		}
+
+		batch := waitTransferBatch(t, TransferBatchService, origin.ID, created.ID)
+
+		for _, item := range batch.Items {
+			if item.Status != string(entity.BatchItemInDoubt) {
+				t.Errorf("Every item should be in doubt, not failed. Got: %+v", item)
+			}
+		}
+
+		// The transfers were made, so they must not be sent again.
+		assertBalance(t, AccountService, origin, MOCKED_BALANCE-50)
+		assertBalance(t, AccountService, dest1, MOCKED_BALANCE+30)
+		assertBalance(t, AccountService, dest2, MOCKED_BALANCE+20)
+	})
+}
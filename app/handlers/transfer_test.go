@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/PPAKruNN/golearn/domain/entity"
 	"github.com/PPAKruNN/golearn/domain/service"
 	"github.com/PPAKruNN/golearn/domain/service/dto"
 )
@@ -45,12 +47,11 @@ func TestGETTransfers(t *testing.T) {
 
 		// Register a transfer
 		newTransfer := dto.CreateTrasnferInputDTO{
-			AccountOriginID:      acc1.ID,
-			AccountDestinationID: acc2.ID,
+			AccountDestinationID: acc2.PublicID,
 			Amount:               10,
 		}
 
-		_, err := TransferService.CreateTransfer(newTransfer)
+		_, _, err := TransferService.CreateTransfer(acc1.ID, newTransfer)
 		if err != nil {
 			t.Errorf("Error while creating mock transfer! Err: %+v", err)
 			return
@@ -67,7 +68,7 @@ func TestGETTransfers(t *testing.T) {
 		currTransfer := output[0]
 
 		if currTransfer.AccountDestinationID != newTransfer.AccountDestinationID ||
-			currTransfer.AccountOriginID != newTransfer.AccountOriginID ||
+			currTransfer.AccountOriginID != acc1.PublicID ||
 			currTransfer.Amount != newTransfer.Amount {
 			t.Errorf("Persisted transfer is different from sent transfer! \nPersisted: %+v \nSent: %+v", currTransfer, newTransfer)
 		}
@@ -86,8 +87,7 @@ func TestPOSTTransfer(t *testing.T) {
 	t.Run("Should return Unauthorized if no token is provided!", func(t *testing.T) {
 
 		body, err := json.Marshal(dto.CreateTrasnferInputDTO{
-			AccountOriginID:      0,
-			AccountDestinationID: 0,
+			AccountDestinationID: acc2.PublicID,
 			Amount:               10,
 		})
 		if err != nil {
@@ -105,8 +105,7 @@ func TestPOSTTransfer(t *testing.T) {
 		t.Skip()
 
 		newTransfer := dto.CreateTrasnferInputDTO{
-			AccountOriginID:      acc1.ID,
-			AccountDestinationID: acc2.ID,
+			AccountDestinationID: acc2.PublicID,
 			Amount:               10,
 		}
 
@@ -127,14 +126,14 @@ func TestPOSTTransfer(t *testing.T) {
 
 		server.CreateTransfer(response, request)
 
-		newerBalanceOrigin := AccountService.ReadAccountBalance(dto.ReadAccountBalanceInputDTO{ID: acc1.ID})
-		newerBalanceDest := AccountService.ReadAccountBalance(dto.ReadAccountBalanceInputDTO{ID: acc2.ID})
+		newerBalanceOrigin := AccountService.ReadAccountBalance(acc1.ID, dto.ReadAccountBalanceInputDTO{ID: acc1.PublicID})
+		newerBalanceDest := AccountService.ReadAccountBalance(acc2.ID, dto.ReadAccountBalanceInputDTO{ID: acc2.PublicID})
 
 		tranfers := TransferService.ReadTransfersByAccount(acc1.ID)
 		currTransfer := tranfers[0]
 
 		if currTransfer.AccountDestinationID != newTransfer.AccountDestinationID ||
-			currTransfer.AccountOriginID != newTransfer.AccountOriginID ||
+			currTransfer.AccountOriginID != acc1.PublicID ||
 			currTransfer.Amount != newTransfer.Amount {
 			t.Errorf("Persisted transfer is different from sent transfer! \nPersisted: %+v \nSent: %+v", currTransfer, newTransfer)
 		}
@@ -151,8 +150,7 @@ func TestPOSTTransfer(t *testing.T) {
 	t.Run("Should NOT be able to create a transfer when account hava insufficient funds", func(t *testing.T) {
 
 		newTransfer := dto.CreateTrasnferInputDTO{
-			AccountOriginID:      acc1.ID,
-			AccountDestinationID: acc2.ID,
+			AccountDestinationID: acc2.PublicID,
 			Amount:               mockedAccount.Balance + 100,
 		}
 
@@ -172,7 +170,7 @@ func TestPOSTTransfer(t *testing.T) {
 
 		assertStatusCode(t, response, http.StatusBadRequest)
 
-		acc := AccountService.ReadAccountBalance(dto.ReadAccountBalanceInputDTO{ID: acc1.ID})
+		acc := AccountService.ReadAccountBalance(acc1.ID, dto.ReadAccountBalanceInputDTO{ID: acc1.PublicID})
 		if acc.Balance < 0 {
 			t.Errorf("Transaction removed money from account! It was expected to not do it.")
 		}
@@ -181,8 +179,7 @@ func TestPOSTTransfer(t *testing.T) {
 	t.Run("Should NOT be able to create a transfer to itself", func(t *testing.T) {
 
 		newTransfer := dto.CreateTrasnferInputDTO{
-			AccountOriginID:      acc1.ID,
-			AccountDestinationID: acc1.ID,
+			AccountDestinationID: acc1.PublicID,
 			Amount:               10,
 		}
 
@@ -203,4 +200,148 @@ func TestPOSTTransfer(t *testing.T) {
 		assertStatusCode(t, response, http.StatusBadRequest)
 	})
 
+	t.Run("Should be able to create a transfer by agency and account number", func(t *testing.T) {
+
+		newTransfer := dto.CreateTrasnferInputDTO{
+			AccountDestinationAgency: acc2.Agency,
+			AccountDestinationNumber: acc2.Number,
+			Amount:                   10,
+		}
+
+		body, err := json.Marshal(newTransfer)
+		if err != nil {
+			t.Error("Error while creating body for CreateTrasnferInputDTO")
+		}
+
+		request, response := createHttpRequestAndResponse(http.MethodPost, "/transfers", bytes.NewBuffer(body))
+
+		// Register a token for account
+		token := AuthService.CreateToken(acc1.ID)
+		bearer := "Bearer " + token
+		request.Header.Add("Authorization", bearer)
+
+		server.CreateTransfer(response, request)
+
+		assertStatusCode(t, response, http.StatusCreated)
+
+		tranfers := TransferService.ReadTransfersByAccount(acc2.ID)
+		if len(tranfers) == 0 || tranfers[len(tranfers)-1].AccountDestinationID != acc2.PublicID {
+			t.Errorf("Transfer should have reached the account with the given number! Got: %+v", tranfers)
+		}
+	})
+
+	t.Run("Should NOT accept more than one destination", func(t *testing.T) {
+
+		newTransfer := dto.CreateTrasnferInputDTO{
+			AccountDestinationID:  acc2.PublicID,
+			AccountDestinationCPF: acc2.CPF,
+			Amount:                10,
+		}
+
+		body, err := json.Marshal(newTransfer)
+		if err != nil {
+			t.Error("Error while creating body for CreateTrasnferInputDTO")
+		}
+
+		request, response := createHttpRequestAndResponse(http.MethodPost, "/transfers", bytes.NewBuffer(body))
+
+		// Register a token for account
+		token := AuthService.CreateToken(acc1.ID)
+		bearer := "Bearer " + token
+		request.Header.Add("Authorization", bearer)
+
+		server.CreateTransfer(response, request)
+
+		assertStatusCode(t, response, http.StatusBadRequest)
+	})
+
+}
+
+func TestPOSTTransferPreview(t *testing.T) {
+	TransferService, AccountService, AuthService, server := createHTTPTransferServer()
+
+	acc1 := createMockAccount(AccountService)
+	acc2 := createMockAccount(AccountService)
+
+	t.Run("Should preview a transfer without moving money", func(t *testing.T) {
+
+		body, err := json.Marshal(dto.CreateTrasnferInputDTO{
+			AccountDestinationID: acc2.PublicID,
+			Amount:               10,
+		})
+		if err != nil {
+			t.Error("Error while creating body for CreateTrasnferInputDTO")
+		}
+
+		request, response := createHttpRequestAndResponse(http.MethodPost, "/transfers/preview", bytes.NewBuffer(body))
+		request.Header.Add("Authorization", "Bearer "+AuthService.CreateToken(acc1.ID))
+
+		server.PreviewTransfer(response, request)
+
+		assertStatusCode(t, response, http.StatusOK)
+
+		var output dto.PreviewTransferOutputDTO
+		json.NewDecoder(response.Body).Decode(&output)
+
+		if output.Amount != 10 || output.Total != 10 || output.BalanceAfter != acc1.Balance-10 || output.Name != entity.MaskName(acc2.Name) {
+			t.Errorf("Preview is different from the transfer sent! Got: %+v", output)
+		}
+
+		balance := AccountService.ReadAccountBalance(acc1.ID, dto.ReadAccountBalanceInputDTO{ID: acc1.PublicID})
+		if balance.Balance != acc1.Balance || len(TransferService.ReadTransfersByAccount(acc1.ID)) != 0 {
+			t.Errorf("Preview should NOT move money!")
+		}
+	})
+
+	t.Run("Should fail as the transfer would when funds are insufficient", func(t *testing.T) {
+
+		body, err := json.Marshal(dto.CreateTrasnferInputDTO{
+			AccountDestinationID: acc2.PublicID,
+			Amount:               acc1.Balance + 100,
+		})
+		if err != nil {
+			t.Error("Error while creating body for CreateTrasnferInputDTO")
+		}
+
+		request, response := createHttpRequestAndResponse(http.MethodPost, "/transfers/preview", bytes.NewBuffer(body))
+		request.Header.Add("Authorization", "Bearer "+AuthService.CreateToken(acc1.ID))
+
+		server.PreviewTransfer(response, request)
+
+		assertStatusCode(t, response, http.StatusBadRequest)
+	})
+	t.Run("Should tell what is left of the daily limit and fail above it", func(t *testing.T) {
+
+		limited := *TransferService
+		limited.DailyLimit = 50
+		limitedServer := NewTransferServer(limited, *AuthService)
+
+		preview := func(amount int) *httptest.ResponseRecorder {
+			body, err := json.Marshal(dto.CreateTrasnferInputDTO{
+				AccountDestinationID: acc2.PublicID,
+				Amount:               amount,
+			})
+			if err != nil {
+				t.Error("Error while creating body for CreateTrasnferInputDTO")
+			}
+
+			request, response := createHttpRequestAndResponse(http.MethodPost, "/transfers/preview", bytes.NewBuffer(body))
+			request.Header.Add("Authorization", "Bearer "+AuthService.CreateToken(acc1.ID))
+
+			limitedServer.PreviewTransfer(response, request)
+			return response
+		}
+
+		response := preview(10)
+		assertStatusCode(t, response, http.StatusOK)
+
+		var output dto.PreviewTransferOutputDTO
+		json.NewDecoder(response.Body).Decode(&output)
+
+		if output.RemainingDailyLimit == nil || *output.RemainingDailyLimit != 40 {
+			t.Errorf("Preview should tell 40 are left of the daily limit! Got: %+v", output.RemainingDailyLimit)
+		}
+
+		assertStatusCode(t, preview(60), http.StatusBadRequest)
+	})
 }
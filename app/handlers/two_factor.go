@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+	"github.com/rs/zerolog/log"
+)
+
+type TwoFactorServer struct {
+	TwoFactorService service.TwoFactorService
+	AuthService      service.AuthService
+
+	// Optional. When set, logins are recorded in the audit log.
+	Audit *service.AuditService
+}
+
+func NewTwoFactorServer(twoFactorService service.TwoFactorService, authService service.AuthService) *TwoFactorServer {
+	return &TwoFactorServer{
+		TwoFactorService: twoFactorService,
+		AuthService:      authService,
+	}
+}
+
+func (s *TwoFactorServer) ServeHTTP() *http.ServeMux {
+
+	router := http.NewServeMux()
+	router.Handle("/accounts/me/2fa", http.HandlerFunc(s.twoFactorHandler))
+	router.Handle("/accounts/me/2fa/confirm", http.HandlerFunc(s.Confirm))
+	router.Handle("/login/2fa", http.HandlerFunc(s.Login))
+
+	return router
+}
+
+func (s *TwoFactorServer) twoFactorHandler(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+	case http.MethodPost:
+		s.Enroll(w, r)
+	case http.MethodDelete:
+		s.Disable(w, r)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Cannot " + r.Method + " " + r.URL.String())
+	}
+}
+
+func (s *TwoFactorServer) Enroll(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint EnrollTwoFactor!")
+
+	session, err := authorizeSession(s.AuthService, r.Header.Get("Authorization"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(err).
+			Msg("Failed authorizing request!")
+		return
+	}
+
+	output, statusCode, err := s.TwoFactorService.Enroll(session.AccountID)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed enrolling two-factor!")
+		return
+	}
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(output)
+
+	// Secret and recovery codes are not logged on purpose.
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Msg("")
+}
+
+func (s *TwoFactorServer) Confirm(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint ConfirmTwoFactor!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	session, err := authorizeSession(s.AuthService, r.Header.Get("Authorization"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(err).
+			Msg("Failed authorizing request!")
+		return
+	}
+
+	var input dto.TwoFactorCodeInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	statusCode, err := s.TwoFactorService.Confirm(session.AccountID, input.Code)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed confirming two-factor!")
+		return
+	}
+
+	w.WriteHeader(statusCode)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Msg("")
+}
+
+func (s *TwoFactorServer) Disable(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint DisableTwoFactor!")
+
+	session, err := authorizeSession(s.AuthService, r.Header.Get("Authorization"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnauthorized).
+			Err(err).
+			Msg("Failed authorizing request!")
+		return
+	}
+
+	var input dto.TwoFactorCodeInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	statusCode, err := s.TwoFactorService.Disable(session.AccountID, input.Code)
+	if err != nil {
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed disabling two-factor!")
+		return
+	}
+
+	w.WriteHeader(statusCode)
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", statusCode).
+		Msg("")
+}
+
+// Login is the second step of the login of accounts with two-factor enabled.
+func (s *TwoFactorServer) Login(w http.ResponseWriter, r *http.Request) {
+
+	log.Info().Str("Method", r.Method).Str("Path", r.URL.String()).Msg("Called endpoint LoginTwoFactor!")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var input dto.LoginTwoFactorInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusUnprocessableEntity).
+			Err(err).
+			Msg("Failed processing body!")
+		return
+	}
+
+	challenge, statusCode, err := s.TwoFactorService.CompleteChallenge(input)
+	if err != nil {
+		recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditLoginFailed, entity.AUDIT_ACTOR_ANONYMOUS, "challenge", nil, map[string]interface{}{
+			"step": "two_factor",
+		}))
+
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(err.Error())
+
+		log.Info().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", statusCode).
+			Err(err).
+			Msg("Failed completing login challenge!")
+		return
+	}
+
+	token, err := s.AuthService.CreateSession(challenge.AccountID, challenge.UserAgent, challenge.IP)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		log.Error().
+			Str("Method", r.Method).
+			Str("Path", r.URL.String()).
+			Int("Status Code", http.StatusInternalServerError).
+			Err(err).
+			Msg("Could not create session!")
+		return
+	}
+
+	actor := entity.AuditAccount(challenge.AccountID)
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditLoginSucceeded, actor, actor, nil, map[string]interface{}{
+		"step": "two_factor",
+	}))
+	recordAudit(s.Audit, r, entity.NewAuditEvent(entity.AuditTokenIssued, actor, actor, nil, map[string]interface{}{
+		"user_agent": challenge.UserAgent,
+	}))
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dto.LoginOutputDTO{Token: token})
+
+	log.Info().
+		Str("Method", r.Method).
+		Str("Path", r.URL.String()).
+		Int("Status Code", http.StatusOK).
+		Msg("")
+}
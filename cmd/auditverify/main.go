@@ -0,0 +1,22 @@
+// Command auditverify walks the audit log hash chain and exits with an error
+// when any entry was modified, removed or reordered. Compare the printed head
+// hash with the one of a previous run to also catch entries cut from the end.
+package main
+
+import (
+	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/PPAKruNN/golearn/infra/repository/database"
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+
+	auditService := service.NewAuditService(database.NewAuditEventRepository())
+
+	count, head, err := auditService.Verify()
+	if err != nil {
+		log.Fatal().Err(err).Int("Entries", count).Msg("Audit log was tampered with!")
+	}
+
+	log.Info().Int("Entries", count).Str("Head", head).Msg("Audit log is intact!")
+}
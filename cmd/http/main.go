@@ -4,20 +4,57 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/PPAKruNN/golearn/app/handlers"
+	"github.com/PPAKruNN/golearn/domain/entity"
 	"github.com/PPAKruNN/golearn/domain/service"
+	"github.com/PPAKruNN/golearn/infra/clearing"
+	"github.com/PPAKruNN/golearn/infra/fees"
+	"github.com/PPAKruNN/golearn/infra/notification"
+	"github.com/PPAKruNN/golearn/infra/redact"
 	"github.com/PPAKruNN/golearn/infra/repository/database"
 	"github.com/rs/zerolog"
 	logger "github.com/rs/zerolog/log"
 )
 
 const (
-	PORT = ":5000"
+	PORT             = ":5000"
+	ADMIN_EMAIL_ENV  = "ADMIN_EMAIL"
+	ADMIN_SECRET_ENV = "ADMIN_SECRET"
+
+	// Notifications are appended to this file. Without it the server only
+	// starts with NOTIFICATION_LOG=true, logging them, tokens included, which
+	// is only meant for development.
+	NOTIFICATION_FILE_ENV = "NOTIFICATION_FILE"
+	NOTIFICATION_LOG_ENV  = "NOTIFICATION_LOG"
+
+	// Transfers above this amount need a two-factor code when enabled.
+	STEP_UP_THRESHOLD_ENV     = "TRANSFER_STEP_UP_THRESHOLD"
+	DEFAULT_STEP_UP_THRESHOLD = 100000
+
+	// Most an account can send in transfers a day, unlimited when 0.
+	DAILY_LIMIT_ENV     = "TRANSFER_DAILY_LIMIT"
+	DEFAULT_DAILY_LIMIT = 0
+
+	// JSON fee schedules and the public ID of the account credited with the
+	// fees. Without a schedules file transfers are free.
+	FEE_SCHEDULES_FILE_ENV     = "FEE_SCHEDULES_FILE"
+	FEE_REVENUE_ACCOUNT_ID_ENV = "FEE_REVENUE_ACCOUNT_ID"
+
+	// How often holds past their expiry are marked expired.
+	HOLD_EXPIRY_INTERVAL = time.Minute
+	// How often escrows past their release date are released.
+	ESCROW_RELEASE_INTERVAL = time.Minute
 )
 
 func main() {
 
+	// Masks secrets and CPFs of everything logged with Interface().
+	redact.Install()
+
 	// Repository instances
 	transferRepo := database.NewTransferRepository()
 	fmt.Print("\nTransferrepo\n")
@@ -28,24 +65,265 @@ func main() {
 	authRepo := database.NewAuthRepository()
 	fmt.Print("\nAuthRepo\n")
 
+	operatorRepo := database.NewOperatorRepository()
+	adminActionRepo := database.NewAdminActionRepository()
+	loginAttemptRepo := database.NewLoginAttemptRepository()
+	twoFactorRepo := database.NewTwoFactorRepository()
+	pinRepo := database.NewPinRepository()
+	apiKeyRepo := database.NewAPIKeyRepository()
+	auditRepo := database.NewAuditEventRepository()
+	secretResetRepo := database.NewSecretResetRepository()
+	pixKeyRepo := database.NewPixKeyRepository()
+	pixChargeRepo := database.NewPixChargeRepository()
+	boletoRepo := database.NewBoletoRepository()
+	transferBatchRepo := database.NewTransferBatchRepository()
+	holdRepo := database.NewHoldRepository()
+	escrowRepo := database.NewEscrowRepository()
+
 	// Services instances
 	transferService := *service.NewTransferService(transferRepo, accountRepo)
 	authService := *service.NewAuthService(authRepo)
 	accountService := *service.NewAccountService(accountRepo, authRepo)
+	adminService := *service.NewAdminService(operatorRepo, adminActionRepo, accountRepo, transferRepo)
+	loginGuard := *service.NewLoginGuardService(loginAttemptRepo)
+	twoFactorService := *service.NewTwoFactorService(twoFactorRepo, accountRepo)
+	pinService := *service.NewPinService(pinRepo, accountRepo)
+	apiKeyService := *service.NewAPIKeyService(apiKeyRepo)
+	auditService := *service.NewAuditService(auditRepo)
+	privacyService := *service.NewPrivacyService(accountRepo, transferRepo, authRepo, apiKeyRepo, auditRepo)
+	notifier := loadNotifier()
+	secretService := *service.NewSecretService(accountRepo, authRepo, secretResetRepo, notifier)
+	pixKeyService := *service.NewPixKeyService(pixKeyRepo, accountRepo, notifier)
+
+	feeService := loadFeeService(transferRepo, accountRepo)
+
+	transferService.Pin = &pinService
+	transferService.Fees = feeService
+	transferService.PixKeyRepo = pixKeyRepo
+	privacyService.PixKeyRepo = pixKeyRepo
+	privacyService.TwoFactorRepo = twoFactorRepo
+	privacyService.PinRepo = pinRepo
+	privacyService.LoginGuard = &loginGuard
+
+	brCodeService := *service.NewBRCodeService(pixChargeRepo, pixKeyRepo, accountRepo, &transferService)
+	boletoService := *service.NewBoletoService(boletoRepo, accountRepo, &transferService)
+	billPaymentService := *service.NewBillPaymentService(&boletoService, &transferService, clearing.NewFakeClearing())
+	transferBatchService := *service.NewTransferBatchService(transferBatchRepo, &transferService)
+	splitPaymentService := *service.NewSplitPaymentService(&transferService)
+	holdService := *service.NewHoldService(holdRepo, &transferService)
+	escrowService := *service.NewEscrowService(escrowRepo, &transferService)
+	escrowService.Audit = &auditService
+
+	twoFactorService.Guard = &loginGuard
+	secretService.Guard = &loginGuard
+	pinService.Guard = &loginGuard
+	secretService.APIKeyRepo = apiKeyRepo
+	transferService.TwoFactor = &twoFactorService
+	transferService.StepUpThreshold = loadIntEnv(STEP_UP_THRESHOLD_ENV, DEFAULT_STEP_UP_THRESHOLD)
+	transferService.DailyLimit = loadIntEnv(DAILY_LIMIT_ENV, DEFAULT_DAILY_LIMIT)
+
+	// First admin operator, the others are created through the admin API.
+	if email, secret := os.Getenv(ADMIN_EMAIL_ENV), os.Getenv(ADMIN_SECRET_ENV); email != "" && secret != "" {
+		if err := adminService.Bootstrap(email, secret); err != nil {
+			logger.Error().Err(err).Msg("Could not bootstrap admin operator")
+		}
+	}
 
 	// Handlers instances
-	accountServer := handlers.NewAccountServer(transferService, accountService, authService)
+	accountServer := handlers.NewAccountServer(transferService, accountService, authService, loginGuard, twoFactorService)
 	transferServer := handlers.NewTransferServer(transferService, authService)
+	adminServer := handlers.NewAdminServer(adminService)
+	sessionServer := handlers.NewSessionServer(authService)
+	twoFactorServer := handlers.NewTwoFactorServer(twoFactorService, authService)
+	pinServer := handlers.NewPinServer(pinService, authService)
+	apiKeyServer := handlers.NewAPIKeyServer(apiKeyService, authService)
+	privacyServer := handlers.NewPrivacyServer(privacyService, authService, &auditService)
+	secretServer := handlers.NewSecretServer(secretService, authService)
+	pixKeyServer := handlers.NewPixKeyServer(pixKeyService, authService)
+	brCodeServer := handlers.NewBRCodeServer(brCodeService, authService)
+	boletoServer := handlers.NewBoletoServer(boletoService, authService)
+	paymentServer := handlers.NewPaymentServer(billPaymentService, splitPaymentService, authService)
+	transferBatchServer := handlers.NewTransferBatchServer(transferBatchService, authService)
+	holdServer := handlers.NewHoldServer(holdService, authService)
+	escrowServer := handlers.NewEscrowServer(escrowService, authService)
+	feeServer := handlers.NewFeeServer(*feeService, authService)
+
+	accountServer.Audit = &auditService
+	transferServer.Audit = &auditService
+	adminServer.Audit = &auditService
+	adminServer.LoginGuard = &loginGuard
+	twoFactorServer.Audit = &auditService
+	secretServer.Audit = &auditService
+	pixKeyServer.Audit = &auditService
+	brCodeServer.Audit = &auditService
+	boletoServer.Audit = &auditService
+	paymentServer.Audit = &auditService
+	transferBatchServer.Audit = &auditService
+	holdServer.Audit = &auditService
+	escrowServer.Audit = &auditService
+
+	// Rate limiters, requests per minute and burst per client IP.
+	loginLimiter := handlers.NewRateLimiter(10, 5)
+	adminLimiter := handlers.NewRateLimiter(60, 20)
+	apiLimiter := handlers.NewRateLimiter(120, 30)
+
+	// Per account, shared by the payee confirmation and the Pix key lookup,
+	// both show who is behind an account.
+	payeeLimiter := handlers.NewRateLimiter(20, 10)
+	transferServer.PayeeLimiter = payeeLimiter
+	pixKeyServer.LookupLimiter = payeeLimiter
 
 	// Router
 	router := http.NewServeMux()
-	router.Handle("/accounts/", accountServer.ServeHTTP())
-	router.Handle("/transfers/", transferServer.ServeHTTP())
-	router.Handle("/login", http.HandlerFunc(accountServer.Login))
+	router.Handle("/accounts/", apiLimiter.Middleware(accountServer.ServeHTTP()))
+	router.Handle("/transfers/", apiLimiter.Middleware(apiKeyServer.Middleware(transferServer.ServeHTTP())))
+	router.Handle("/transfers/batches", apiLimiter.Middleware(apiKeyServer.Middleware(transferBatchServer.ServeHTTP())))
+	router.Handle("/transfers/batches/", apiLimiter.Middleware(apiKeyServer.Middleware(transferBatchServer.ServeHTTP())))
+	router.Handle("/login", loginLimiter.Middleware(http.HandlerFunc(accountServer.Login)))
+	router.Handle("/login/2fa", loginLimiter.Middleware(twoFactorServer.ServeHTTP()))
+	router.Handle("/accounts/me/2fa", apiLimiter.Middleware(twoFactorServer.ServeHTTP()))
+	router.Handle("/accounts/me/2fa/", apiLimiter.Middleware(twoFactorServer.ServeHTTP()))
+	router.Handle("/accounts/me/pin", apiLimiter.Middleware(pinServer.ServeHTTP()))
+	router.Handle("/accounts/me/export", apiLimiter.Middleware(privacyServer.ServeHTTP()))
+	router.Handle("/accounts/me/erasure", apiLimiter.Middleware(privacyServer.ServeHTTP()))
+	router.Handle("/accounts/me/secret", apiLimiter.Middleware(secretServer.ServeHTTP()))
+	router.Handle("/accounts/secret-reset", loginLimiter.Middleware(secretServer.ServeHTTP()))
+	router.Handle("/accounts/secret-reset/confirm", loginLimiter.Middleware(secretServer.ServeHTTP()))
+	router.Handle("/pix-keys", apiLimiter.Middleware(pixKeyServer.ServeHTTP()))
+	router.Handle("/pix-keys/", apiLimiter.Middleware(pixKeyServer.ServeHTTP()))
+	router.Handle("/pix/", apiLimiter.Middleware(apiKeyServer.Middleware(brCodeServer.ServeHTTP())))
+	router.Handle("/boletos", apiLimiter.Middleware(apiKeyServer.Middleware(boletoServer.ServeHTTP())))
+	router.Handle("/boletos/", apiLimiter.Middleware(apiKeyServer.Middleware(boletoServer.ServeHTTP())))
+	router.Handle("/payments/", apiLimiter.Middleware(apiKeyServer.Middleware(paymentServer.ServeHTTP())))
+	router.Handle("/holds", apiLimiter.Middleware(apiKeyServer.Middleware(holdServer.ServeHTTP())))
+	router.Handle("/holds/", apiLimiter.Middleware(apiKeyServer.Middleware(holdServer.ServeHTTP())))
+	router.Handle("/escrows", apiLimiter.Middleware(apiKeyServer.Middleware(escrowServer.ServeHTTP())))
+	router.Handle("/escrows/", apiLimiter.Middleware(apiKeyServer.Middleware(escrowServer.ServeHTTP())))
+	router.Handle("/fees", apiLimiter.Middleware(apiKeyServer.Middleware(feeServer.ServeHTTP())))
+	router.Handle("/fees/", apiLimiter.Middleware(apiKeyServer.Middleware(feeServer.ServeHTTP())))
+	router.Handle("/api-keys", apiLimiter.Middleware(apiKeyServer.ServeHTTP()))
+	router.Handle("/api-keys/", apiLimiter.Middleware(apiKeyServer.ServeHTTP()))
+	router.Handle("/logout", apiLimiter.Middleware(sessionServer.ServeHTTP()))
+	router.Handle("/sessions", apiLimiter.Middleware(sessionServer.ServeHTTP()))
+	router.Handle("/sessions/", apiLimiter.Middleware(sessionServer.ServeHTTP()))
+	router.Handle("/admin/login", loginLimiter.Middleware(adminServer.ServeHTTP()))
+	router.Handle("/admin/", adminLimiter.Middleware(adminServer.ServeHTTP()))
+
+	// Before serving, no batch of this run can be taken for interrupted.
+	if recovered, err := transferBatchService.RecoverInterrupted(); err != nil {
+		logger.Error().Err(err).Msg("Could not recover interrupted transfer batches")
+	} else if recovered > 0 {
+		logger.Warn().Int("Batches", recovered).Msg("Recovered transfer batches interrupted by a restart")
+	}
+
+	go expireHolds(holdService)
+	go releaseEscrows(escrowService)
 
 	// Logging
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	logger.Info().Msg(fmt.Sprintf("Running server on port %s", PORT))
 
-	log.Fatal(http.ListenAndServe(PORT, router))
+	log.Fatal(http.ListenAndServe(PORT, handlers.RequestID(router)))
+}
+
+// expireHolds marks stale holds, they already stopped reserving funds when
+// they expired.
+func expireHolds(holdService service.HoldService) {
+
+	for range time.Tick(HOLD_EXPIRY_INTERVAL) {
+		expired, err := holdService.ExpireStale()
+		if err != nil {
+			logger.Warn().Err(err).Msg("Could not expire holds")
+			continue
+		}
+
+		if expired > 0 {
+			logger.Info().Int("Holds", expired).Msg("Expired stale holds")
+		}
+	}
+}
+
+// releaseEscrows pays the payees of escrows past their release date, those
+// failing are tried again on the next runs, then refunded.
+func releaseEscrows(escrowService service.EscrowService) {
+
+	for range time.Tick(ESCROW_RELEASE_INTERVAL) {
+		released, err := escrowService.ReleaseDue()
+		if err != nil {
+			logger.Warn().Err(err).Msg("Could not release escrows")
+			continue
+		}
+
+		if released > 0 {
+			logger.Info().Int("Escrows", released).Msg("Released due escrows")
+		}
+	}
+}
+
+// loadNotifier stops the server rather than logging reset tokens and
+// verification codes by default.
+func loadNotifier() service.Notifier {
+
+	if path := os.Getenv(NOTIFICATION_FILE_ENV); path != "" {
+		return notification.NewFileNotifier(path)
+	}
+
+	if os.Getenv(NOTIFICATION_LOG_ENV) != "true" {
+		logger.Fatal().Str("Env", NOTIFICATION_FILE_ENV).Msg("No notification file. Set NOTIFICATION_LOG=true to log notifications in development")
+	}
+
+	if os.Getenv("GO_ENV") == "prod" {
+		logger.Fatal().Str("Env", NOTIFICATION_LOG_ENV).Msg("Notifications cannot be logged in production")
+	}
+
+	logger.Warn().Str("Env", NOTIFICATION_LOG_ENV).Msg("Logging notifications, tokens included. Development only!")
+
+	return notification.NewLogNotifier()
+}
+
+// loadFeeService stops the server on a schedules file it cannot use, charging
+// wrong fees is worse than not starting.
+func loadFeeService(transferRepo service.TransferRepository, accountRepo service.AccountRepository) *service.FeeService {
+
+	schedules := []entity.FeeSchedule{}
+	revenueAccountId := 0
+
+	if path := os.Getenv(FEE_SCHEDULES_FILE_ENV); path != "" {
+		loaded, err := fees.LoadFile(path)
+		if err != nil {
+			logger.Fatal().Err(err).Str("Path", path).Msg("Could not load fee schedules")
+		}
+
+		revenueAccount, err := accountRepo.ReadByPublicID(os.Getenv(FEE_REVENUE_ACCOUNT_ID_ENV))
+		if err != nil {
+			logger.Fatal().Err(err).Str("Env", FEE_REVENUE_ACCOUNT_ID_ENV).Msg("Could not find the fee revenue account")
+		}
+
+		schedules, revenueAccountId = loaded, revenueAccount.ID
+	} else {
+		logger.Warn().Str("Env", FEE_SCHEDULES_FILE_ENV).Msg("No fee schedules, transfers are free")
+	}
+
+	feeService, err := service.NewFeeService(schedules, revenueAccountId, transferRepo)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Invalid fee schedules")
+	}
+
+	return feeService
+}
+
+func loadIntEnv(name string, fallback int) int {
+
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		logger.Warn().Err(err).Str("Env", name).Msg("Invalid integer env, using default")
+		return fallback
+	}
+
+	return value
 }
@@ -0,0 +1,59 @@
+// Command reencrypt moves the encrypted account fields and the two-factor
+// secrets to the active master key. Run it after rotating
+// ENCRYPTION_ACTIVE_KEY, and once after enabling encryption to seal the rows
+// still in plaintext. The old master key must stay configured until it
+// finishes.
+//
+// The database is always re-encrypted. The accounts file of the indisk backend
+// is re-encrypted too when INDISK_DATA_DIR points to its directory.
+package main
+
+import (
+	"os"
+
+	"github.com/PPAKruNN/golearn/infra/repository/database"
+	"github.com/PPAKruNN/golearn/infra/repository/indisk"
+	"github.com/rs/zerolog/log"
+)
+
+const INDISK_DATA_DIR_ENV = "INDISK_DATA_DIR"
+
+func main() {
+
+	accountRepo := database.NewAccountRepository()
+
+	updated, err := accountRepo.Reencrypt()
+	if err != nil {
+		log.Fatal().Err(err).Int("Updated", updated).Msg("Failed re-encrypting accounts!")
+	}
+
+	log.Info().Int("Updated", updated).Msg("Accounts re-encrypted!")
+
+	twoFactorRepo := database.NewTwoFactorRepository()
+
+	updated, err = twoFactorRepo.Reencrypt()
+	if err != nil {
+		log.Fatal().Err(err).Int("Updated", updated).Msg("Failed re-encrypting two-factor secrets!")
+	}
+
+	log.Info().Int("Updated", updated).Msg("Two-factor secrets re-encrypted!")
+
+	dir := os.Getenv(INDISK_DATA_DIR_ENV)
+	if dir == "" {
+		log.Info().Msg("INDISK_DATA_DIR not set, skipping the indisk accounts file.")
+		return
+	}
+
+	if _, err := os.Stat(dir); err != nil {
+		log.Fatal().Err(err).Str("Dir", dir).Msg("Could not find the indisk data directory!")
+	}
+
+	indiskRepo := indisk.NewAccountRepository(dir)
+
+	updated, err = indiskRepo.Reencrypt()
+	if err != nil {
+		log.Fatal().Err(err).Int("Updated", updated).Str("Dir", dir).Msg("Failed re-encrypting indisk accounts!")
+	}
+
+	log.Info().Int("Updated", updated).Str("Dir", dir).Msg("Indisk accounts re-encrypted!")
+}
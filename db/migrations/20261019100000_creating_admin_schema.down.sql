@@ -0,0 +1,6 @@
+ALTER TABLE "AdminAction" DROP CONSTRAINT IF EXISTS "AdminAction_fk0";
+ALTER TABLE "OperatorAuth" DROP CONSTRAINT IF EXISTS "OperatorAuth_fk0";
+
+DROP TABLE IF EXISTS "AdminAction" CASCADE;
+DROP TABLE IF EXISTS "OperatorAuth" CASCADE;
+DROP TABLE IF EXISTS "Operator" CASCADE;
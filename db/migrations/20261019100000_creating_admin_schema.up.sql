@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS "Operator" (
+	"id" bigint GENERATED ALWAYS AS IDENTITY NOT NULL UNIQUE,
+	"name" text NOT NULL,
+	"email" text NOT NULL UNIQUE,
+	"secret" text NOT NULL,
+	"role" text NOT NULL,
+	"permissions" text[] NOT NULL DEFAULT '{}',
+	"created_at" timestamp with time zone NOT NULL DEFAULT NOW(),
+	PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "OperatorAuth" (
+	"token" uuid NOT NULL,
+	"operator_id" bigint NOT NULL,
+	"created_at" timestamp with time zone NOT NULL DEFAULT NOW(),
+	PRIMARY KEY ("token")
+);
+
+CREATE TABLE IF NOT EXISTS "AdminAction" (
+	"id" bigint GENERATED ALWAYS AS IDENTITY NOT NULL UNIQUE,
+	"operator_id" bigint NOT NULL,
+	"action" text NOT NULL,
+	"target_id" bigint NOT NULL DEFAULT 0,
+	"reason" text NOT NULL DEFAULT '',
+	"details" jsonb NOT NULL DEFAULT '{}',
+	"created_at" timestamp with time zone NOT NULL DEFAULT NOW(),
+	PRIMARY KEY ("id")
+);
+
+ALTER TABLE "OperatorAuth" ADD CONSTRAINT "OperatorAuth_fk0" FOREIGN KEY ("operator_id") REFERENCES "Operator"("id") ON DELETE CASCADE;
+
+ALTER TABLE "AdminAction" ADD CONSTRAINT "AdminAction_fk0" FOREIGN KEY ("operator_id") REFERENCES "Operator"("id");
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS "LoginAttempt" (
+	"key" text NOT NULL,
+	"failures" integer NOT NULL DEFAULT 0,
+	"locked_until" timestamp with time zone NOT NULL DEFAULT 'epoch',
+	"last_failure_at" timestamp with time zone NOT NULL DEFAULT 'epoch',
+	PRIMARY KEY ("key")
+);
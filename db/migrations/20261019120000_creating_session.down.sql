@@ -0,0 +1,9 @@
+ALTER TABLE "Session" DROP CONSTRAINT IF EXISTS "Session_fk0";
+
+DROP TABLE IF EXISTS "Session" CASCADE;
+
+CREATE TABLE IF NOT EXISTS "Auth" (
+	"id" bigint NOT NULL UNIQUE,
+  "token" uuid NOT NULL,
+	PRIMARY KEY ("id")
+);
@@ -0,0 +1,16 @@
+DROP TABLE IF EXISTS "Auth" CASCADE;
+
+CREATE TABLE IF NOT EXISTS "Session" (
+	"id" uuid NOT NULL,
+	"token" uuid NOT NULL UNIQUE,
+	"account_id" bigint NOT NULL,
+	"user_agent" text NOT NULL DEFAULT '',
+	"ip" text NOT NULL DEFAULT '',
+	"created_at" timestamp with time zone NOT NULL DEFAULT NOW(),
+	"last_used_at" timestamp with time zone NOT NULL DEFAULT NOW(),
+	PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "Session_account_id_idx" ON "Session" ("account_id");
+
+ALTER TABLE "Session" ADD CONSTRAINT "Session_fk0" FOREIGN KEY ("account_id") REFERENCES "Account"("id") ON DELETE CASCADE;
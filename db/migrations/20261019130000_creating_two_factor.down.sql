@@ -0,0 +1,5 @@
+ALTER TABLE "LoginChallenge" DROP CONSTRAINT IF EXISTS "LoginChallenge_fk0";
+ALTER TABLE "TwoFactor" DROP CONSTRAINT IF EXISTS "TwoFactor_fk0";
+
+DROP TABLE IF EXISTS "LoginChallenge" CASCADE;
+DROP TABLE IF EXISTS "TwoFactor" CASCADE;
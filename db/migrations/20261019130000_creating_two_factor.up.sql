@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS "TwoFactor" (
+	"account_id" bigint NOT NULL,
+	"secret" text NOT NULL,
+	"enabled" boolean NOT NULL DEFAULT FALSE,
+	"recovery_codes" text[] NOT NULL DEFAULT '{}',
+	"last_used_step" bigint NOT NULL DEFAULT 0,
+	"created_at" timestamp with time zone NOT NULL DEFAULT NOW(),
+	PRIMARY KEY ("account_id")
+);
+
+CREATE TABLE IF NOT EXISTS "LoginChallenge" (
+	"id" uuid NOT NULL,
+	"account_id" bigint NOT NULL,
+	"user_agent" text NOT NULL DEFAULT '',
+	"ip" text NOT NULL DEFAULT '',
+	"attempts" integer NOT NULL DEFAULT 0,
+	"expires_at" timestamp with time zone NOT NULL,
+	PRIMARY KEY ("id")
+);
+
+ALTER TABLE "TwoFactor" ADD CONSTRAINT "TwoFactor_fk0" FOREIGN KEY ("account_id") REFERENCES "Account"("id") ON DELETE CASCADE;
+
+ALTER TABLE "LoginChallenge" ADD CONSTRAINT "LoginChallenge_fk0" FOREIGN KEY ("account_id") REFERENCES "Account"("id") ON DELETE CASCADE;
@@ -0,0 +1,3 @@
+ALTER TABLE "TransactionPin" DROP CONSTRAINT IF EXISTS "TransactionPin_fk0";
+
+DROP TABLE IF EXISTS "TransactionPin" CASCADE;
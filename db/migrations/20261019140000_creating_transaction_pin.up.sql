@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS "TransactionPin" (
+	"account_id" bigint NOT NULL,
+	"hash" text NOT NULL,
+	"failures" integer NOT NULL DEFAULT 0,
+	"locked_until" timestamp with time zone NOT NULL DEFAULT 'epoch',
+	"updated_at" timestamp with time zone NOT NULL DEFAULT NOW(),
+	PRIMARY KEY ("account_id")
+);
+
+ALTER TABLE "TransactionPin" ADD CONSTRAINT "TransactionPin_fk0" FOREIGN KEY ("account_id") REFERENCES "Account"("id") ON DELETE CASCADE;
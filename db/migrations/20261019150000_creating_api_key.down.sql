@@ -0,0 +1,3 @@
+ALTER TABLE "APIKey" DROP CONSTRAINT IF EXISTS "APIKey_fk0";
+
+DROP TABLE IF EXISTS "APIKey" CASCADE;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS "APIKey" (
+	"id" text NOT NULL,
+	"account_id" bigint NOT NULL,
+	"name" text NOT NULL DEFAULT '',
+	"secret" text NOT NULL,
+	"scopes" text[] NOT NULL DEFAULT '{}',
+	"created_at" timestamp with time zone NOT NULL DEFAULT NOW(),
+	"rotated_at" timestamp with time zone NOT NULL DEFAULT NOW(),
+	"revoked_at" timestamp with time zone,
+	"last_used_at" timestamp with time zone,
+	PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "APIKey_account_id_idx" ON "APIKey" ("account_id");
+
+ALTER TABLE "APIKey" ADD CONSTRAINT "APIKey_fk0" FOREIGN KEY ("account_id") REFERENCES "Account"("id") ON DELETE CASCADE;
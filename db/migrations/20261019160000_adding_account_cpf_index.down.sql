@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "Account_cpf_index_idx";
+
+ALTER TABLE "Account" DROP COLUMN IF EXISTS "cpf_index";
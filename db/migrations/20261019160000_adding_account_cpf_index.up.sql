@@ -0,0 +1,5 @@
+-- The cpf column now holds the encrypted CPF, looked up by its blind index.
+-- Existing rows get the index from the re-encryption command (cmd/reencrypt).
+ALTER TABLE "Account" ADD COLUMN IF NOT EXISTS "cpf_index" text;
+
+CREATE INDEX IF NOT EXISTS "Account_cpf_index_idx" ON "Account" ("cpf_index");
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS "AuditEvent_no_truncate" ON "AuditEvent";
+DROP TRIGGER IF EXISTS "AuditEvent_no_update_delete" ON "AuditEvent";
+DROP FUNCTION IF EXISTS "AuditEvent_append_only"();
+
+DROP TABLE IF EXISTS "AuditEvent" CASCADE;
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS "AuditEvent" (
+	"id" bigint GENERATED ALWAYS AS IDENTITY NOT NULL UNIQUE,
+	"type" text NOT NULL,
+	"actor" text NOT NULL,
+	"ip" text NOT NULL DEFAULT '',
+	"request_id" text NOT NULL DEFAULT '',
+	"subject" text NOT NULL DEFAULT '',
+	"before" jsonb,
+	"after" jsonb,
+	"created_at" timestamp with time zone NOT NULL,
+	"prev_hash" text NOT NULL,
+	"hash" text NOT NULL UNIQUE,
+	PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "AuditEvent_subject_idx" ON "AuditEvent" ("subject");
+
+-- The audit log is append-only, even for the application user. The hash chain
+-- still catches changes made by someone able to drop the triggers.
+CREATE OR REPLACE FUNCTION "AuditEvent_append_only"() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'AuditEvent is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "AuditEvent_no_update_delete"
+	BEFORE UPDATE OR DELETE ON "AuditEvent"
+	FOR EACH ROW EXECUTE FUNCTION "AuditEvent_append_only"();
+
+CREATE TRIGGER "AuditEvent_no_truncate"
+	BEFORE TRUNCATE ON "AuditEvent"
+	FOR EACH STATEMENT EXECUTE FUNCTION "AuditEvent_append_only"();
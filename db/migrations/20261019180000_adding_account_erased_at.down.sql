@@ -0,0 +1 @@
+ALTER TABLE "Account" DROP COLUMN IF EXISTS "erased_at";
@@ -0,0 +1,3 @@
+-- Set when the holder personal data is erased, the row is kept for the
+-- transfers referencing it.
+ALTER TABLE "Account" ADD COLUMN IF NOT EXISTS "erased_at" timestamp with time zone;
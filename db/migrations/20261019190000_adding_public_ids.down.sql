@@ -0,0 +1,5 @@
+ALTER TABLE "Transfer" DROP CONSTRAINT IF EXISTS "Transfer_public_id_key";
+ALTER TABLE "Transfer" DROP COLUMN IF EXISTS "public_id";
+
+ALTER TABLE "Account" DROP CONSTRAINT IF EXISTS "Account_public_id_key";
+ALTER TABLE "Account" DROP COLUMN IF EXISTS "public_id";
@@ -0,0 +1,11 @@
+-- Opaque IDs shown to clients, the sequential IDs stay internal. Rows created
+-- before get a random one in the same format.
+ALTER TABLE "Account" ADD COLUMN IF NOT EXISTS "public_id" text;
+UPDATE "Account" SET "public_id" = 'acc_' || replace(gen_random_uuid()::text, '-', '') WHERE "public_id" IS NULL;
+ALTER TABLE "Account" ALTER COLUMN "public_id" SET NOT NULL;
+ALTER TABLE "Account" ADD CONSTRAINT "Account_public_id_key" UNIQUE ("public_id");
+
+ALTER TABLE "Transfer" ADD COLUMN IF NOT EXISTS "public_id" text;
+UPDATE "Transfer" SET "public_id" = 'trf_' || replace(gen_random_uuid()::text, '-', '') WHERE "public_id" IS NULL;
+ALTER TABLE "Transfer" ALTER COLUMN "public_id" SET NOT NULL;
+ALTER TABLE "Transfer" ADD CONSTRAINT "Transfer_public_id_key" UNIQUE ("public_id");
@@ -0,0 +1,2 @@
+ALTER TABLE "Account" DROP CONSTRAINT IF EXISTS "Account_status_check";
+ALTER TABLE "Account" DROP COLUMN IF EXISTS "status";
@@ -0,0 +1,5 @@
+ALTER TABLE "Account" ADD COLUMN IF NOT EXISTS "status" text NOT NULL DEFAULT 'active';
+ALTER TABLE "Account" ADD CONSTRAINT "Account_status_check" CHECK ("status" IN ('active', 'frozen', 'closed'));
+
+-- Erased accounts had their balance withdrawn, they are closed.
+UPDATE "Account" SET "status" = 'closed' WHERE "erased_at" IS NOT NULL;
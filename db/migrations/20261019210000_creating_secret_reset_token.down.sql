@@ -0,0 +1,3 @@
+ALTER TABLE "SecretResetToken" DROP CONSTRAINT IF EXISTS "SecretResetToken_fk0";
+
+DROP TABLE IF EXISTS "SecretResetToken" CASCADE;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS "SecretResetToken" (
+	"hash" text NOT NULL,
+	"account_id" bigint NOT NULL,
+	"expires_at" timestamp with time zone NOT NULL,
+	"used_at" timestamp with time zone,
+	"created_at" timestamp with time zone NOT NULL DEFAULT NOW(),
+	PRIMARY KEY ("hash")
+);
+
+CREATE INDEX IF NOT EXISTS "SecretResetToken_account_id_idx" ON "SecretResetToken" ("account_id");
+
+ALTER TABLE "SecretResetToken" ADD CONSTRAINT "SecretResetToken_fk0" FOREIGN KEY ("account_id") REFERENCES "Account"("id") ON DELETE CASCADE;
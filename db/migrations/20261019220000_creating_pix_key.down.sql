@@ -0,0 +1,3 @@
+ALTER TABLE "PixKey" DROP CONSTRAINT IF EXISTS "PixKey_fk0";
+
+DROP TABLE IF EXISTS "PixKey" CASCADE;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS "PixKey" (
+	"id" text NOT NULL,
+	"account_id" bigint NOT NULL,
+	"type" text NOT NULL CHECK ("type" IN ('cpf', 'email', 'phone', 'evp')),
+	-- Encrypted like the account CPF, looked up by its blind index.
+	"value" text NOT NULL,
+	"value_index" text NOT NULL,
+	"code_hash" text,
+	"code_expires_at" timestamp with time zone NOT NULL DEFAULT 'epoch',
+	"code_failures" integer NOT NULL DEFAULT 0,
+	"verified_at" timestamp with time zone,
+	"created_at" timestamp with time zone NOT NULL DEFAULT NOW(),
+	PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "PixKey_account_id_idx" ON "PixKey" ("account_id");
+
+-- Many accounts may claim a key while verifying it, only one can own it.
+CREATE UNIQUE INDEX IF NOT EXISTS "PixKey_verified_value_idx" ON "PixKey" ("type", "value_index") WHERE "verified_at" IS NOT NULL;
+
+ALTER TABLE "PixKey" ADD CONSTRAINT "PixKey_fk0" FOREIGN KEY ("account_id") REFERENCES "Account"("id") ON DELETE CASCADE;
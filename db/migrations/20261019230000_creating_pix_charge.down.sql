@@ -0,0 +1,3 @@
+ALTER TABLE "PixCharge" DROP CONSTRAINT IF EXISTS "PixCharge_fk0";
+
+DROP TABLE IF EXISTS "PixCharge" CASCADE;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS "PixCharge" (
+	"id" text NOT NULL,
+	"account_id" bigint NOT NULL,
+	-- Encrypted, it is the holder CPF, email or phone.
+	"pix_key" text NOT NULL,
+	"amount" integer NOT NULL CHECK ("amount" > 0),
+	"txid" text NOT NULL,
+	"expires_at" timestamp with time zone NOT NULL,
+	"paid_at" timestamp with time zone,
+	"created_at" timestamp with time zone NOT NULL DEFAULT NOW(),
+	PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "PixCharge_txid_idx" ON "PixCharge" ("txid");
+
+ALTER TABLE "PixCharge" ADD CONSTRAINT "PixCharge_fk0" FOREIGN KEY ("account_id") REFERENCES "Account"("id") ON DELETE CASCADE;
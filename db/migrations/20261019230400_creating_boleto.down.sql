@@ -0,0 +1,3 @@
+ALTER TABLE "Boleto" DROP CONSTRAINT IF EXISTS "Boleto_fk0";
+
+DROP TABLE IF EXISTS "Boleto" CASCADE;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS "Boleto" (
+	"id" text NOT NULL,
+	"account_id" bigint NOT NULL,
+	"our_number" text NOT NULL,
+	"amount" bigint NOT NULL CHECK ("amount" > 0),
+	"due_date" date NOT NULL,
+	"payer_name" text NOT NULL,
+	-- Encrypted, it is the payer CPF or CNPJ.
+	"payer_document" text NOT NULL,
+	"fine_rate" integer NOT NULL DEFAULT 0 CHECK ("fine_rate" >= 0),
+	"interest_rate" integer NOT NULL DEFAULT 0 CHECK ("interest_rate" >= 0),
+	"status" text NOT NULL DEFAULT 'open' CHECK ("status" IN ('open', 'paid', 'cancelled')),
+	"paid_at" timestamp with time zone,
+	"paid_amount" bigint NOT NULL DEFAULT 0,
+	"created_at" timestamp with time zone NOT NULL DEFAULT NOW(),
+	PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "Boleto_our_number_idx" ON "Boleto" ("our_number");
+CREATE INDEX IF NOT EXISTS "Boleto_account_id_idx" ON "Boleto" ("account_id");
+
+ALTER TABLE "Boleto" ADD CONSTRAINT "Boleto_fk0" FOREIGN KEY ("account_id") REFERENCES "Account"("id") ON DELETE CASCADE;
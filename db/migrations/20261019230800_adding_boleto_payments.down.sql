@@ -0,0 +1,7 @@
+ALTER TABLE "Transfer" DROP CONSTRAINT IF EXISTS "Transfer_kind_fields_check";
+
+DELETE FROM "Transfer" WHERE "account_destination_id" IS NULL;
+
+ALTER TABLE "Transfer" DROP COLUMN IF EXISTS "barcode";
+ALTER TABLE "Transfer" DROP COLUMN IF EXISTS "kind";
+ALTER TABLE "Transfer" ALTER COLUMN "account_destination_id" SET NOT NULL;
@@ -0,0 +1,9 @@
+-- Boleto payments to other banks leave the bank, they have no destination.
+ALTER TABLE "Transfer" ALTER COLUMN "account_destination_id" DROP NOT NULL;
+ALTER TABLE "Transfer" ADD COLUMN IF NOT EXISTS "kind" text NOT NULL DEFAULT 'transfer' CHECK ("kind" IN ('transfer', 'boleto_payment'));
+ALTER TABLE "Transfer" ADD COLUMN IF NOT EXISTS "barcode" text;
+
+ALTER TABLE "Transfer" ADD CONSTRAINT "Transfer_kind_fields_check" CHECK (
+	("kind" = 'transfer' AND "account_destination_id" IS NOT NULL) OR
+	("kind" = 'boleto_payment' AND "barcode" IS NOT NULL)
+);
@@ -0,0 +1,6 @@
+ALTER TABLE "Account" DROP CONSTRAINT IF EXISTS "Account_agency_number_key";
+
+ALTER TABLE "Account" DROP COLUMN IF EXISTS "number";
+ALTER TABLE "Account" DROP COLUMN IF EXISTS "agency";
+
+DROP SEQUENCE IF EXISTS "Account_number_seq";
@@ -0,0 +1,21 @@
+CREATE SEQUENCE IF NOT EXISTS "Account_number_seq";
+
+ALTER TABLE "Account" ADD COLUMN IF NOT EXISTS "agency" text NOT NULL DEFAULT '0001';
+ALTER TABLE "Account" ADD COLUMN IF NOT EXISTS "number" text;
+
+-- Existing accounts are numbered in creation order. The check digit is the
+-- same mod 11 of entity.NewAccountNumber: weights 2 to 9 from the right, 10
+-- and 11 become 0.
+WITH numbered AS (
+	SELECT id, lpad(nextval('"Account_number_seq"')::text, 8, '0') AS digits
+	FROM (SELECT id FROM "Account" WHERE "number" IS NULL ORDER BY id) AS pending
+)
+UPDATE "Account" a SET "number" = n.digits || '-' || (
+	SELECT CASE WHEN 11 - w.total % 11 >= 10 THEN 0 ELSE 11 - w.total % 11 END
+	FROM (SELECT sum(substr(n.digits, 9 - i, 1)::int * (i + 1)) AS total FROM generate_series(1, 8) AS i) AS w
+)
+FROM numbered n
+WHERE a.id = n.id;
+
+ALTER TABLE "Account" ALTER COLUMN "number" SET NOT NULL;
+ALTER TABLE "Account" ADD CONSTRAINT "Account_agency_number_key" UNIQUE ("agency", "number");
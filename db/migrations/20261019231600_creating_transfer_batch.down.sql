@@ -0,0 +1,3 @@
+ALTER TABLE "TransferBatch" DROP CONSTRAINT IF EXISTS "TransferBatch_fk0";
+
+DROP TABLE IF EXISTS "TransferBatch" CASCADE;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS "TransferBatch" (
+	"id" text NOT NULL,
+	"account_id" bigint NOT NULL,
+	"mode" text NOT NULL CHECK ("mode" IN ('atomic', 'best_effort')),
+	"status" text NOT NULL CHECK ("status" IN ('pending', 'processing', 'completed', 'partially_completed', 'failed')),
+	-- Destinations already resolved, with the result of each transfer.
+	"items" jsonb NOT NULL,
+	"created_at" timestamp with time zone NOT NULL DEFAULT NOW(),
+	"finished_at" timestamp with time zone,
+	PRIMARY KEY ("id")
+);
+
+ALTER TABLE "TransferBatch" ADD CONSTRAINT "TransferBatch_fk0" FOREIGN KEY ("account_id") REFERENCES "Account"("id") ON DELETE CASCADE;
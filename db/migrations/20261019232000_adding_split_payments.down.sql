@@ -0,0 +1,15 @@
+ALTER TABLE "Transfer" DROP CONSTRAINT IF EXISTS "Transfer_parent_id_fkey";
+
+DELETE FROM "Transfer" WHERE "kind" = 'split_credit';
+DELETE FROM "Transfer" WHERE "kind" = 'split';
+
+ALTER TABLE "Transfer" DROP CONSTRAINT IF EXISTS "Transfer_kind_fields_check";
+ALTER TABLE "Transfer" ADD CONSTRAINT "Transfer_kind_fields_check" CHECK (
+	("kind" = 'transfer' AND "account_destination_id" IS NOT NULL) OR
+	("kind" = 'boleto_payment' AND "barcode" IS NOT NULL)
+);
+
+ALTER TABLE "Transfer" DROP CONSTRAINT IF EXISTS "Transfer_kind_check";
+ALTER TABLE "Transfer" ADD CONSTRAINT "Transfer_kind_check" CHECK ("kind" IN ('transfer', 'boleto_payment'));
+
+ALTER TABLE "Transfer" DROP COLUMN IF EXISTS "parent_id";
@@ -0,0 +1,14 @@
+ALTER TABLE "Transfer" ADD COLUMN IF NOT EXISTS "parent_id" integer;
+ALTER TABLE "Transfer" ADD CONSTRAINT "Transfer_parent_id_fkey" FOREIGN KEY ("parent_id") REFERENCES "Transfer" ("id");
+
+ALTER TABLE "Transfer" DROP CONSTRAINT IF EXISTS "Transfer_kind_check";
+ALTER TABLE "Transfer" ADD CONSTRAINT "Transfer_kind_check" CHECK ("kind" IN ('transfer', 'boleto_payment', 'split', 'split_credit'));
+
+-- Split payments have no destination, each of their credits has one.
+ALTER TABLE "Transfer" DROP CONSTRAINT IF EXISTS "Transfer_kind_fields_check";
+ALTER TABLE "Transfer" ADD CONSTRAINT "Transfer_kind_fields_check" CHECK (
+	("kind" = 'transfer' AND "account_destination_id" IS NOT NULL) OR
+	("kind" = 'boleto_payment' AND "barcode" IS NOT NULL) OR
+	("kind" = 'split' AND "account_destination_id" IS NULL) OR
+	("kind" = 'split_credit' AND "account_destination_id" IS NOT NULL AND "parent_id" IS NOT NULL)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE "Hold" DROP CONSTRAINT IF EXISTS "Hold_fk0";
+ALTER TABLE "Hold" DROP CONSTRAINT IF EXISTS "Hold_fk1";
+
+DROP TABLE IF EXISTS "Hold" CASCADE;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS "Hold" (
+	"id" text NOT NULL,
+	"account_id" bigint NOT NULL,
+	"destination_id" bigint NOT NULL,
+	"amount" bigint NOT NULL CHECK ("amount" > 0),
+	"captured_amount" bigint NOT NULL DEFAULT 0,
+	"status" text NOT NULL CHECK ("status" IN ('authorized', 'captured', 'voided', 'expired')),
+	"transfer_id" text,
+	"expires_at" timestamp with time zone NOT NULL,
+	"created_at" timestamp with time zone NOT NULL DEFAULT NOW(),
+	"finished_at" timestamp with time zone,
+	PRIMARY KEY ("id")
+);
+
+-- The available balance sums the authorized holds of the account.
+CREATE INDEX IF NOT EXISTS "Hold_account_authorized_idx" ON "Hold" ("account_id") WHERE "status" = 'authorized';
+
+ALTER TABLE "Hold" ADD CONSTRAINT "Hold_fk0" FOREIGN KEY ("account_id") REFERENCES "Account"("id") ON DELETE CASCADE;
+ALTER TABLE "Hold" ADD CONSTRAINT "Hold_fk1" FOREIGN KEY ("destination_id") REFERENCES "Account"("id") ON DELETE CASCADE;
@@ -0,0 +1,23 @@
+ALTER TABLE "Transfer" DROP CONSTRAINT IF EXISTS "Transfer_escrow_id_fkey";
+
+DELETE FROM "Transfer" WHERE "kind" IN ('escrow_deposit', 'escrow_release', 'escrow_refund');
+
+ALTER TABLE "Transfer" DROP CONSTRAINT IF EXISTS "Transfer_kind_fields_check";
+ALTER TABLE "Transfer" ADD CONSTRAINT "Transfer_kind_fields_check" CHECK (
+	("kind" = 'transfer' AND "account_destination_id" IS NOT NULL) OR
+	("kind" = 'boleto_payment' AND "barcode" IS NOT NULL) OR
+	("kind" = 'split' AND "account_destination_id" IS NULL) OR
+	("kind" = 'split_credit' AND "account_destination_id" IS NOT NULL AND "parent_id" IS NOT NULL)
+);
+
+ALTER TABLE "Transfer" DROP CONSTRAINT IF EXISTS "Transfer_kind_check";
+ALTER TABLE "Transfer" ADD CONSTRAINT "Transfer_kind_check" CHECK ("kind" IN ('transfer', 'boleto_payment', 'split', 'split_credit'));
+
+ALTER TABLE "Transfer" DROP COLUMN IF EXISTS "escrow_id";
+
+ALTER TABLE "EscrowEvent" DROP CONSTRAINT IF EXISTS "EscrowEvent_fk0";
+ALTER TABLE "Escrow" DROP CONSTRAINT IF EXISTS "Escrow_fk0";
+ALTER TABLE "Escrow" DROP CONSTRAINT IF EXISTS "Escrow_fk1";
+
+DROP TABLE IF EXISTS "EscrowEvent" CASCADE;
+DROP TABLE IF EXISTS "Escrow" CASCADE;
@@ -0,0 +1,48 @@
+CREATE TABLE IF NOT EXISTS "Escrow" (
+	"id" text NOT NULL,
+	"payer_id" bigint NOT NULL,
+	"payee_id" bigint NOT NULL,
+	"amount" bigint NOT NULL CHECK ("amount" > 0),
+	"description" text NOT NULL DEFAULT '',
+	"status" text NOT NULL CHECK ("status" IN ('funded', 'released', 'refunded')),
+	"release_at" timestamp with time zone NOT NULL,
+	"created_at" timestamp with time zone NOT NULL DEFAULT NOW(),
+	"settled_at" timestamp with time zone,
+	PRIMARY KEY ("id")
+);
+
+-- Escrows due are looked up to be released.
+CREATE INDEX IF NOT EXISTS "Escrow_funded_release_at_idx" ON "Escrow" ("release_at") WHERE "status" = 'funded';
+
+ALTER TABLE "Escrow" ADD CONSTRAINT "Escrow_fk0" FOREIGN KEY ("payer_id") REFERENCES "Account"("id") ON DELETE CASCADE;
+ALTER TABLE "Escrow" ADD CONSTRAINT "Escrow_fk1" FOREIGN KEY ("payee_id") REFERENCES "Account"("id") ON DELETE CASCADE;
+
+-- State transitions of each escrow, only appended to.
+CREATE TABLE IF NOT EXISTS "EscrowEvent" (
+	"id" serial NOT NULL,
+	"escrow_id" text NOT NULL,
+	"from_status" text,
+	"to_status" text NOT NULL,
+	"actor" text NOT NULL,
+	"created_at" timestamp with time zone NOT NULL DEFAULT NOW(),
+	PRIMARY KEY ("id")
+);
+
+ALTER TABLE "EscrowEvent" ADD CONSTRAINT "EscrowEvent_fk0" FOREIGN KEY ("escrow_id") REFERENCES "Escrow"("id") ON DELETE CASCADE;
+
+-- Money going into an escrow has no destination, coming out it has one.
+ALTER TABLE "Transfer" ADD COLUMN IF NOT EXISTS "escrow_id" text;
+ALTER TABLE "Transfer" ADD CONSTRAINT "Transfer_escrow_id_fkey" FOREIGN KEY ("escrow_id") REFERENCES "Escrow" ("id");
+
+ALTER TABLE "Transfer" DROP CONSTRAINT IF EXISTS "Transfer_kind_check";
+ALTER TABLE "Transfer" ADD CONSTRAINT "Transfer_kind_check" CHECK ("kind" IN ('transfer', 'boleto_payment', 'split', 'split_credit', 'escrow_deposit', 'escrow_release', 'escrow_refund'));
+
+ALTER TABLE "Transfer" DROP CONSTRAINT IF EXISTS "Transfer_kind_fields_check";
+ALTER TABLE "Transfer" ADD CONSTRAINT "Transfer_kind_fields_check" CHECK (
+	("kind" = 'transfer' AND "account_destination_id" IS NOT NULL) OR
+	("kind" = 'boleto_payment' AND "barcode" IS NOT NULL) OR
+	("kind" = 'split' AND "account_destination_id" IS NULL) OR
+	("kind" = 'split_credit' AND "account_destination_id" IS NOT NULL AND "parent_id" IS NOT NULL) OR
+	("kind" = 'escrow_deposit' AND "account_destination_id" IS NULL AND "escrow_id" IS NOT NULL) OR
+	("kind" IN ('escrow_release', 'escrow_refund') AND "account_destination_id" IS NOT NULL AND "escrow_id" IS NOT NULL)
+);
@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS "Transfer_origin_kind_created_at_idx";
+
+DELETE FROM "Transfer" WHERE "kind" = 'fee';
+
+ALTER TABLE "Transfer" DROP CONSTRAINT IF EXISTS "Transfer_kind_fields_check";
+ALTER TABLE "Transfer" ADD CONSTRAINT "Transfer_kind_fields_check" CHECK (
+	("kind" = 'transfer' AND "account_destination_id" IS NOT NULL) OR
+	("kind" = 'boleto_payment' AND "barcode" IS NOT NULL) OR
+	("kind" = 'split' AND "account_destination_id" IS NULL) OR
+	("kind" = 'split_credit' AND "account_destination_id" IS NOT NULL AND "parent_id" IS NOT NULL) OR
+	("kind" = 'escrow_deposit' AND "account_destination_id" IS NULL AND "escrow_id" IS NOT NULL) OR
+	("kind" IN ('escrow_release', 'escrow_refund') AND "account_destination_id" IS NOT NULL AND "escrow_id" IS NOT NULL)
+);
+
+ALTER TABLE "Transfer" DROP CONSTRAINT IF EXISTS "Transfer_kind_check";
+ALTER TABLE "Transfer" ADD CONSTRAINT "Transfer_kind_check" CHECK ("kind" IN ('transfer', 'boleto_payment', 'split', 'split_credit', 'escrow_deposit', 'escrow_release', 'escrow_refund'));
@@ -0,0 +1,17 @@
+ALTER TABLE "Transfer" DROP CONSTRAINT IF EXISTS "Transfer_kind_check";
+ALTER TABLE "Transfer" ADD CONSTRAINT "Transfer_kind_check" CHECK ("kind" IN ('transfer', 'boleto_payment', 'split', 'split_credit', 'escrow_deposit', 'escrow_release', 'escrow_refund', 'fee'));
+
+-- Fees go to the revenue account, pointing back to the transfer charged.
+ALTER TABLE "Transfer" DROP CONSTRAINT IF EXISTS "Transfer_kind_fields_check";
+ALTER TABLE "Transfer" ADD CONSTRAINT "Transfer_kind_fields_check" CHECK (
+	("kind" = 'transfer' AND "account_destination_id" IS NOT NULL) OR
+	("kind" = 'boleto_payment' AND "barcode" IS NOT NULL) OR
+	("kind" = 'split' AND "account_destination_id" IS NULL) OR
+	("kind" = 'split_credit' AND "account_destination_id" IS NOT NULL AND "parent_id" IS NOT NULL) OR
+	("kind" = 'escrow_deposit' AND "account_destination_id" IS NULL AND "escrow_id" IS NOT NULL) OR
+	("kind" IN ('escrow_release', 'escrow_refund') AND "account_destination_id" IS NOT NULL AND "escrow_id" IS NOT NULL) OR
+	("kind" = 'fee' AND "account_destination_id" IS NOT NULL AND "parent_id" IS NOT NULL)
+);
+
+-- Free transfers are counted per account, kind and month.
+CREATE INDEX IF NOT EXISTS "Transfer_origin_kind_created_at_idx" ON "Transfer" ("account_origin_id", "kind", "created_at");
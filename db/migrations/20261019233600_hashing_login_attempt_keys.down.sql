@@ -0,0 +1,2 @@
+-- Blind indexes cannot be turned back into CPFs, the attempts are dropped.
+DELETE FROM "LoginAttempt";
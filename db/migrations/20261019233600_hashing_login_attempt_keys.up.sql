@@ -0,0 +1,3 @@
+-- Keys are now a blind index of the CPF or IP. Attempts stored with the
+-- plaintext key are dropped, they only throttle logins for a while.
+DELETE FROM "LoginAttempt";
@@ -0,0 +1,7 @@
+-- Hashes cannot be turned back into tokens, every session is closed.
+DELETE FROM "Session";
+
+ALTER TABLE "Session" DROP COLUMN IF EXISTS "expires_at";
+
+ALTER TABLE "Session" RENAME COLUMN "token_hash" TO "token";
+ALTER TABLE "Session" ALTER COLUMN "token" TYPE uuid USING "token"::uuid;
@@ -0,0 +1,6 @@
+-- Only the SHA-256 of the token is kept, sessions already open stay valid.
+ALTER TABLE "Session" ALTER COLUMN "token" TYPE text USING encode(sha256(convert_to("token"::text, 'UTF8')), 'hex');
+ALTER TABLE "Session" RENAME COLUMN "token" TO "token_hash";
+
+ALTER TABLE "Session" ADD COLUMN IF NOT EXISTS "expires_at" timestamp with time zone NOT NULL DEFAULT NOW() + interval '7 days';
+ALTER TABLE "Session" ALTER COLUMN "expires_at" DROP DEFAULT;
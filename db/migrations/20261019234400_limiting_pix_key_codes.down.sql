@@ -0,0 +1 @@
+ALTER TABLE "PixKey" DROP COLUMN IF EXISTS "code_sends";
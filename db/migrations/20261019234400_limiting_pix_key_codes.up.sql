@@ -0,0 +1,2 @@
+-- Keys waiting for verification already had a code sent.
+ALTER TABLE "PixKey" ADD COLUMN "code_sends" integer NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+-- The dropped check only refused valid kinds and a fresh database never had
+-- it, there is nothing to restore.
@@ -0,0 +1,5 @@
+-- Databases that ran an edited boleto migration have the list of kinds under
+-- "Transfer_kind_values_check" as well, still refusing every kind added after
+-- boleto payments. The list is kept by "Transfer_kind_check" only, as on a
+-- fresh database.
+ALTER TABLE "Transfer" DROP CONSTRAINT IF EXISTS "Transfer_kind_values_check";
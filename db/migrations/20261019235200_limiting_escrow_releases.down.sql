@@ -0,0 +1,7 @@
+-- Failed escrows still hold the money, they go back to be released.
+UPDATE "Escrow" SET "status" = 'funded', "settled_at" = NULL WHERE "status" = 'failed';
+
+ALTER TABLE "Escrow" DROP CONSTRAINT IF EXISTS "Escrow_status_check";
+ALTER TABLE "Escrow" ADD CONSTRAINT "Escrow_status_check" CHECK ("status" IN ('funded', 'released', 'refunded'));
+
+ALTER TABLE "Escrow" DROP COLUMN IF EXISTS "release_failures";
@@ -0,0 +1,6 @@
+-- Releases failing too many times are refunded, or failed when the payer
+-- cannot be credited either.
+ALTER TABLE "Escrow" ADD COLUMN "release_failures" integer NOT NULL DEFAULT 0;
+
+ALTER TABLE "Escrow" DROP CONSTRAINT IF EXISTS "Escrow_status_check";
+ALTER TABLE "Escrow" ADD CONSTRAINT "Escrow_status_check" CHECK ("status" IN ('funded', 'released', 'refunded', 'failed'));
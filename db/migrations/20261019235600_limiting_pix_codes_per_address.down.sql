@@ -0,0 +1,5 @@
+-- Keys waiting for verification already had a code sent.
+ALTER TABLE "PixKey" ADD COLUMN IF NOT EXISTS "code_sends" integer NOT NULL DEFAULT 1;
+ALTER TABLE "PixKey" ADD COLUMN IF NOT EXISTS "code_failures" integer NOT NULL DEFAULT 0;
+
+DROP TABLE IF EXISTS "PixCodeLimit";
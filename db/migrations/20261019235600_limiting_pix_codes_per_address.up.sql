@@ -0,0 +1,13 @@
+-- Code limits were kept on the key row, deleting the key and registering it
+-- again reset them. They are now counted per address and per account.
+CREATE TABLE IF NOT EXISTS "PixCodeLimit" (
+	-- Blind index of the address or account the limit is for.
+	"key" text NOT NULL,
+	"sends" integer NOT NULL DEFAULT 0,
+	"failures" integer NOT NULL DEFAULT 0,
+	"window_start" timestamp with time zone NOT NULL DEFAULT 'epoch',
+	PRIMARY KEY ("key")
+);
+
+ALTER TABLE "PixKey" DROP COLUMN IF EXISTS "code_failures";
+ALTER TABLE "PixKey" DROP COLUMN IF EXISTS "code_sends";
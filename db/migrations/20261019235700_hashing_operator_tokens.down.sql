@@ -0,0 +1,5 @@
+-- Hashes cannot be turned back into tokens, every operator is logged out.
+DELETE FROM "OperatorAuth";
+
+ALTER TABLE "OperatorAuth" RENAME COLUMN "token_hash" TO "token";
+ALTER TABLE "OperatorAuth" ALTER COLUMN "token" TYPE uuid USING "token"::uuid;
@@ -0,0 +1,3 @@
+-- Only the SHA-256 of the token is kept, operators already logged in stay so.
+ALTER TABLE "OperatorAuth" ALTER COLUMN "token" TYPE text USING encode(sha256(convert_to("token"::text, 'UTF8')), 'hex');
+ALTER TABLE "OperatorAuth" RENAME COLUMN "token" TO "token_hash";
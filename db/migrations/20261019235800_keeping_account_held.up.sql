@@ -0,0 +1,8 @@
+-- Funds reserved by the authorized holds, kept in the account row so the
+-- balance and the holds are checked by the same row lock. Expired holds count
+-- until they are marked so.
+ALTER TABLE "Account" ADD COLUMN IF NOT EXISTS "held" bigint NOT NULL DEFAULT 0 CHECK ("held" >= 0);
+
+UPDATE "Account" SET "held" = h."total"
+FROM (SELECT "account_id", SUM("amount") AS "total" FROM "Hold" WHERE "status" = 'authorized' GROUP BY "account_id") h
+WHERE "Account"."id" = h."account_id";
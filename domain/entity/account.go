@@ -3,20 +3,44 @@ package entity
 import (
 	"fmt"
 	"hash"
+	"strings"
 	"time"
 )
 
 const (
 	JWT_SECRET_KEY = "JWT_SECRET_KEY"
+
+	// Name left on accounts erased at the request of the holder.
+	ANONYMIZED_NAME = "Anonymized"
+)
+
+type AccountStatus string
+
+const (
+	AccountActive AccountStatus = "active"
+	// Suspected of fraud, cannot send nor receive money until unfrozen.
+	AccountFrozen AccountStatus = "frozen"
+	// Final, only accounts without balance can be closed.
+	AccountClosed AccountStatus = "closed"
 )
 
 type Account struct {
-	ID        int
+	ID int
+	// Opaque ID shown to clients, the sequential ID never leaves the services.
+	PublicID string
+	// Agency and account number with check digit, as "0001" and "00000042-6".
+	Agency    string
+	Number    string
 	Name      string
-	CPF       string
-	Secret    hash.Hash
+	CPF       string    `redact:"cpf"`
+	Secret    hash.Hash `redact:"secret"`
 	Balance   int
+	Status    AccountStatus
 	CreatedAt time.Time
+
+	// Funds reserved by holds not captured, voided nor expired yet. They are
+	// still in the balance, the ledger one.
+	Held int
 }
 
 func NewAccount(id, balance int, name string, cpf string, secret hash.Hash, createdAt time.Time) *Account {
@@ -26,6 +50,7 @@ func NewAccount(id, balance int, name string, cpf string, secret hash.Hash, crea
 		Name:      name,
 		CPF:       cpf,
 		Secret:    secret,
+		Status:    AccountActive,
 		CreatedAt: createdAt,
 	}
 }
@@ -40,10 +65,23 @@ func (a Account) IsValid() (bool, error) {
 
 }
 
+// Available is what the account can still spend, the balance not held.
+func (a Account) Available() int {
+	return a.Balance - a.Held
+}
+
 func (a *Account) TransferTo(destination *Account, amount int) (Transfer, error) {
 
-	if a.Balance < amount {
-		return Transfer{}, fmt.Errorf("Cannot create transfer because insuficiend funds. Account Balance: %d, Transfer amount: %d", a.Balance, amount)
+	if err := a.CheckActive(); err != nil {
+		return Transfer{}, err
+	}
+
+	if err := destination.CheckActive(); err != nil {
+		return Transfer{}, err
+	}
+
+	if a.Available() < amount {
+		return Transfer{}, fmt.Errorf("Cannot create transfer because insuficiend funds. Available Balance: %d, Transfer amount: %d", a.Available(), amount)
 	}
 
 	// Temporary ID data just for understanding
@@ -60,3 +98,116 @@ func (a *Account) TransferTo(destination *Account, amount int) (Transfer, error)
 
 	return *transfer, nil
 }
+
+// PayBoleto debits a boleto paid to another bank, the money leaves through the
+// clearing instead of going to another account.
+func (a *Account) PayBoleto(amount int) error {
+
+	if err := a.CheckActive(); err != nil {
+		return err
+	}
+
+	if amount <= 0 {
+		return fmt.Errorf("Boleto payment amount cannot be less than 1. Amount: %d", amount)
+	}
+
+	if a.Available() < amount {
+		return fmt.Errorf("Cannot pay boleto because insuficiend funds. Available Balance: %d, Boleto amount: %d", a.Available(), amount)
+	}
+
+	a.Balance -= amount
+
+	return nil
+}
+
+// Adjust manually credits (positive amount) or debits (negative amount) the
+// account. Used by operators to fix balances outside of a transfer.
+func (a *Account) Adjust(amount int) error {
+
+	if amount == 0 {
+		return fmt.Errorf("Adjustment amount cannot be zero.")
+	}
+
+	if a.Status == AccountClosed {
+		return fmt.Errorf("Cannot adjust a closed account. Account ID: %d", a.ID)
+	}
+
+	if a.Balance+amount < 0 {
+		return fmt.Errorf("Cannot adjust account to a negative balance. Account Balance: %d, Adjustment amount: %d", a.Balance, amount)
+	}
+
+	a.Balance += amount
+
+	return nil
+}
+
+// Anonymize removes the personal data of the account holder. The account row
+// stays, transfers reference it and must be kept for the financial records.
+func (a *Account) Anonymize() error {
+
+	if a.Balance != 0 {
+		return fmt.Errorf("Cannot erase an account with balance, transfer it out first. Account Balance: %d", a.Balance)
+	}
+
+	a.Name = ANONYMIZED_NAME
+	a.CPF = ""
+	a.Status = AccountClosed
+
+	return nil
+}
+
+// CheckActive fails for frozen and closed accounts, which cannot move money.
+func (a Account) CheckActive() error {
+
+	switch a.Status {
+	case AccountFrozen:
+		return fmt.Errorf("Account %s is frozen.", a.PublicID)
+	case AccountClosed:
+		return fmt.Errorf("Account %s is closed.", a.PublicID)
+	}
+
+	return nil
+}
+
+// ChangeStatus moves the account between active and frozen, or closes it.
+// Closing needs a zero balance and cannot be undone.
+func (a *Account) ChangeStatus(status AccountStatus) error {
+
+	if a.Status == status {
+		return fmt.Errorf("Account is already %s.", status)
+	}
+
+	if a.Status == AccountClosed {
+		return fmt.Errorf("Account is closed, it cannot change status anymore.")
+	}
+
+	switch status {
+	case AccountActive, AccountFrozen:
+	case AccountClosed:
+		if a.Balance != 0 {
+			return fmt.Errorf("Cannot close an account with balance, transfer it out first. Account Balance: %d", a.Balance)
+		}
+	default:
+		return fmt.Errorf("Invalid account status: %s", status)
+	}
+
+	a.Status = status
+
+	return nil
+}
+
+// Rename updates the holder name, closed accounts keep their data as it was.
+func (a *Account) Rename(name string) error {
+
+	if a.Status == AccountClosed {
+		return fmt.Errorf("Cannot update a closed account.")
+	}
+
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("Account name cannot be empty.")
+	}
+
+	a.Name = strings.TrimSpace(name)
+
+	return nil
+}
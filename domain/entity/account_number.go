@@ -0,0 +1,78 @@
+package entity
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Every account is opened in the single, digital, agency.
+	ACCOUNT_AGENCY = "0001"
+
+	ACCOUNT_NUMBER_DIGITS = 8
+)
+
+// NewAccountNumber formats the sequence as the 8 digits account number and its
+// check digit, "00000042-6".
+func NewAccountNumber(sequence int) string {
+
+	digits := fmt.Sprintf("%0*d", ACCOUNT_NUMBER_DIGITS, sequence)
+	return digits + "-" + strconv.Itoa(accountNumberDV(digits))
+}
+
+// ParseAccountNumber normalizes a typed account number, with or without the
+// dash and leading zeros, checking its check digit.
+func ParseAccountNumber(number string) (string, error) {
+
+	digits := onlyDigits(number)
+	if len(digits) < 2 || len(digits) > ACCOUNT_NUMBER_DIGITS+1 {
+		return "", fmt.Errorf("Account number must have up to %d digits and the check digit!", ACCOUNT_NUMBER_DIGITS)
+	}
+
+	body := strings.Repeat("0", ACCOUNT_NUMBER_DIGITS+1-len(digits)) + digits[:len(digits)-1]
+	if strconv.Itoa(accountNumberDV(body)) != digits[len(digits)-1:] {
+		return "", fmt.Errorf("Account number has a wrong check digit!")
+	}
+
+	return body + "-" + digits[len(digits)-1:], nil
+}
+
+// ParseAgency pads a typed agency to its 4 digits.
+func ParseAgency(agency string) (string, error) {
+
+	if agency == "" {
+		return ACCOUNT_AGENCY, nil
+	}
+
+	digits := onlyDigits(agency)
+	if digits == "" || len(digits) > len(ACCOUNT_AGENCY) {
+		return "", fmt.Errorf("Agency must have up to %d digits!", len(ACCOUNT_AGENCY))
+	}
+
+	return strings.Repeat("0", len(ACCOUNT_AGENCY)-len(digits)) + digits, nil
+}
+
+// accountNumberDV is mod 11 with weights 2 to 9 from the right, results 10
+// and 11 become 0.
+func accountNumberDV(digits string) int {
+
+	sum := 0
+	weight := 2
+
+	for i := len(digits) - 1; i >= 0; i-- {
+		sum += int(digits[i]-'0') * weight
+
+		weight++
+		if weight > 9 {
+			weight = 2
+		}
+	}
+
+	dv := 11 - sum%11
+	if dv >= 10 {
+		return 0
+	}
+
+	return dv
+}
@@ -0,0 +1,38 @@
+package entity
+
+import "testing"
+
+func TestAccountNumber(t *testing.T) {
+
+	t.Run("Should generate a number with its check digit", func(t *testing.T) {
+		// 2*2 + 4*3 = 16, 11 - 16%11 = 6.
+		if got := NewAccountNumber(42); got != "00000042-6" {
+			t.Errorf("Wrong account number. Got: %s, expected: 00000042-6", got)
+		}
+	})
+
+	t.Run("Should accept the number typed in any form", func(t *testing.T) {
+		for _, typed := range []string{"00000042-6", "000000426", "42-6", "426"} {
+			number, err := ParseAccountNumber(typed)
+			if err != nil || number != "00000042-6" {
+				t.Errorf("Wrong number parsed from %s. Got: %s, err: %v", typed, number, err)
+			}
+		}
+	})
+
+	t.Run("Should NOT accept a wrong check digit", func(t *testing.T) {
+		if _, err := ParseAccountNumber("42-7"); err == nil {
+			t.Errorf("Wrong check digit should NOT be accepted")
+		}
+	})
+
+	t.Run("Should default and pad the agency", func(t *testing.T) {
+		if agency, _ := ParseAgency(""); agency != ACCOUNT_AGENCY {
+			t.Errorf("Empty agency should default to %s. Got: %s", ACCOUNT_AGENCY, agency)
+		}
+
+		if agency, _ := ParseAgency("1"); agency != "0001" {
+			t.Errorf("Agency should be padded. Got: %s", agency)
+		}
+	})
+}
@@ -46,3 +46,96 @@ func TestTransferTo(t *testing.T) {
 	// transfer, err := acc1.TransferTo(acc2, 100)
 
 }
+
+func TestAnonymize(t *testing.T) {
+
+	t.Run("Should remove the personal data of an empty account", func(t *testing.T) {
+		account := mockAccount(0)
+
+		if err := account.Anonymize(); err != nil {
+			t.Fatalf("Account should be anonymized. Err: %v", err)
+		}
+
+		if account.Name != ANONYMIZED_NAME || account.CPF != "" {
+			t.Errorf("Personal data should be removed, got name %q and cpf %q", account.Name, account.CPF)
+		}
+	})
+
+	t.Run("Should NOT anonymize an account with balance", func(t *testing.T) {
+		account := mockAccount(100)
+
+		if err := account.Anonymize(); err == nil {
+			t.Errorf("Account with balance should NOT be anonymized")
+		}
+
+		if account.Name != "Mock" {
+			t.Errorf("Account should be left untouched")
+		}
+	})
+}
+
+func TestChangeStatus(t *testing.T) {
+
+	t.Run("Should freeze and unfreeze an account", func(t *testing.T) {
+		account := mockAccount(100)
+
+		if err := account.ChangeStatus(AccountFrozen); err != nil || account.Status != AccountFrozen {
+			t.Fatalf("Account should be frozen. Err: %v", err)
+		}
+
+		if err := account.ChangeStatus(AccountActive); err != nil || account.Status != AccountActive {
+			t.Errorf("Account should be active again. Err: %v", err)
+		}
+	})
+
+	t.Run("Should NOT close an account with balance", func(t *testing.T) {
+		account := mockAccount(100)
+
+		if err := account.ChangeStatus(AccountClosed); err == nil {
+			t.Errorf("Account with balance should NOT be closed")
+		}
+	})
+
+	t.Run("Should NOT reopen a closed account", func(t *testing.T) {
+		account := mockAccount(0)
+
+		if err := account.ChangeStatus(AccountClosed); err != nil {
+			t.Fatalf("Empty account should be closed. Err: %v", err)
+		}
+
+		if err := account.ChangeStatus(AccountActive); err == nil {
+			t.Errorf("Closed account should NOT be reopened")
+		}
+	})
+
+	t.Run("Should NOT accept an unknown status", func(t *testing.T) {
+		account := mockAccount(0)
+
+		if err := account.ChangeStatus("suspended"); err == nil {
+			t.Errorf("Unknown status should NOT be accepted")
+		}
+	})
+}
+
+func TestTransferToFrozen(t *testing.T) {
+
+	t.Run("Should NOT send money from a frozen account", func(t *testing.T) {
+		origin, destination := mockAccount(100), mockAccount(0)
+		destination.ID = 2
+		origin.ChangeStatus(AccountFrozen)
+
+		if _, err := origin.TransferTo(destination, 10); err == nil || origin.Balance != 100 {
+			t.Errorf("Frozen account should NOT send money")
+		}
+	})
+
+	t.Run("Should NOT send money to a frozen account", func(t *testing.T) {
+		origin, destination := mockAccount(100), mockAccount(0)
+		destination.ID = 2
+		destination.ChangeStatus(AccountFrozen)
+
+		if _, err := origin.TransferTo(destination, 10); err == nil || destination.Balance != 0 {
+			t.Errorf("Frozen account should NOT receive money")
+		}
+	})
+}
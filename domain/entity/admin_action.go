@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"time"
+)
+
+const (
+	AdminActionCreateOperator  = "operator.create"
+	AdminActionSearchAccounts  = "accounts.search"
+	AdminActionSearchTransfers = "transfers.search"
+	AdminActionAdjustBalance   = "accounts.adjust"
+	AdminActionChangeStatus    = "accounts.status"
+	AdminActionRevokeTokens    = "operator.revoke_tokens"
+)
+
+// AdminAction records something an operator did through the admin API.
+type AdminAction struct {
+	ID         int
+	OperatorID int
+	Action     string
+	TargetID   int
+	Reason     string
+	Details    map[string]interface{}
+	CreatedAt  time.Time
+}
+
+func NewAdminAction(operatorID int, action string, targetID int, reason string, details map[string]interface{}) *AdminAction {
+	return &AdminAction{
+		OperatorID: operatorID,
+		Action:     action,
+		TargetID:   targetID,
+		Reason:     reason,
+		Details:    details,
+		CreatedAt:  time.Now(),
+	}
+}
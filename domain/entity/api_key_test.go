@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+
+	key, err := NewAPIKey(1, "Payroll", []Scope{ScopeTransfersWrite}, time.Now().UTC())
+	if err != nil {
+		t.Fatalf("Could not create API key. Err: %v", err)
+	}
+
+	body := []byte(`{"account_destination_id":2,"amount":10}`)
+	signature := SignRequest(key.Secret, "POST", "/transfers/", "1718000000", "n1", body)
+
+	t.Run("Should accept the signature of the same request", func(t *testing.T) {
+		if !key.VerifySignature(signature, "POST", "/transfers/", "1718000000", "n1", body) {
+			t.Errorf("Signature should be valid")
+		}
+	})
+
+	t.Run("Should NOT accept a signature of a tampered body", func(t *testing.T) {
+		tampered := []byte(`{"account_destination_id":3,"amount":10}`)
+
+		if key.VerifySignature(signature, "POST", "/transfers/", "1718000000", "n1", tampered) {
+			t.Errorf("Signature should NOT be valid for another body")
+		}
+	})
+
+	t.Run("Should NOT accept a signature after rotation", func(t *testing.T) {
+		rotated := *key
+		rotated.Rotate(time.Now().UTC())
+
+		if rotated.VerifySignature(signature, "POST", "/transfers/", "1718000000", "n1", body) {
+			t.Errorf("Signature of the old secret should NOT be valid")
+		}
+	})
+
+	t.Run("Should NOT create a key with unknown scope", func(t *testing.T) {
+		if _, err := NewAPIKey(1, "Bad", []Scope{"everything"}, time.Now().UTC()); err == nil {
+			t.Errorf("Key with unknown scope should NOT be created")
+		}
+	})
+}
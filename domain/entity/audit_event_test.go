@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func mockAuditChain() []AuditEvent {
+
+	events := []AuditEvent{}
+	prevHash := ""
+
+	for i, eventType := range []string{AuditAccountCreated, AuditLoginSucceeded, AuditTransferCreated} {
+		event := NewAuditEvent(eventType, AuditAccount(1), AuditAccount(1), nil, map[string]interface{}{"amount": 10})
+		event.ID = i + 1
+		event.Chain(prevHash)
+
+		events = append(events, *event)
+		prevHash = event.Hash
+	}
+
+	return events
+}
+
+func TestVerifyAuditChain(t *testing.T) {
+
+	t.Run("Should accept an untouched chain", func(t *testing.T) {
+		if err := VerifyAuditChain(mockAuditChain()); err != nil {
+			t.Errorf("Chain should be valid. Err: %v", err)
+		}
+	})
+
+	t.Run("Should accept a chain read back from JSON", func(t *testing.T) {
+		raw, _ := json.Marshal(mockAuditChain())
+
+		var events []AuditEvent
+		json.Unmarshal(raw, &events)
+
+		if err := VerifyAuditChain(events); err != nil {
+			t.Errorf("Chain should survive serialization. Err: %v", err)
+		}
+	})
+
+	t.Run("Should NOT accept a modified entry", func(t *testing.T) {
+		events := mockAuditChain()
+		events[1].After = map[string]interface{}{"amount": 1000}
+
+		if err := VerifyAuditChain(events); err == nil {
+			t.Errorf("Modified entry should be detected")
+		}
+	})
+
+	t.Run("Should NOT accept a removed entry", func(t *testing.T) {
+		events := mockAuditChain()
+		events = append(events[:1], events[2:]...)
+
+		if err := VerifyAuditChain(events); err == nil {
+			t.Errorf("Removed entry should be detected")
+		}
+	})
+
+	t.Run("Should NOT accept a re-sealed entry", func(t *testing.T) {
+		events := mockAuditChain()
+		events[0].Actor = AuditAccount(2)
+		events[0].Chain("")
+
+		if err := VerifyAuditChain(events); err == nil {
+			t.Errorf("Entries after a re-sealed one should no longer match")
+		}
+	})
+}
@@ -0,0 +1,393 @@
+package entity
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	BOLETO_ID_PREFIX = "bol"
+
+	// FEBRABAN code the boletos are issued under. Boletos with any other
+	// bank code belong to other institutions.
+	BANK_CODE       = "999"
+	BOLETO_CURRENCY = "9"
+
+	BOLETO_BARCODE_SIZE = 44
+	BOLETO_LINE_SIZE    = 47
+
+	// Largest amount the 10 digits of the barcode hold, in cents.
+	BOLETO_MAX_AMOUNT = 9999999999
+)
+
+type BoletoStatus string
+
+const (
+	BoletoOpen      BoletoStatus = "open"
+	BoletoPaid      BoletoStatus = "paid"
+	BoletoCancelled BoletoStatus = "cancelled"
+)
+
+// The due date factor counts days since the base date. It ran out at 9999 on
+// 2025-02-21 and restarted at 1000 the day after, so it repeats every 9000
+// days.
+var boletoFactorBase = time.Date(1997, 10, 7, 0, 0, 0, 0, time.UTC)
+
+const (
+	boletoFactorMin   = 1000
+	boletoFactorCycle = 9000
+)
+
+// Boleto is a payment slip issued by an account holder to collect from a
+// payer. Paying it credits the issuer account.
+type Boleto struct {
+	ID        string
+	AccountID int
+	// "Nosso número", the number identifying the boleto in the barcode.
+	OurNumber string
+	Amount    int
+	DueDate   time.Time
+
+	PayerName     string
+	PayerDocument string `redact:"cpf"`
+
+	// Charged once after the due date, in basis points of the amount.
+	FineRate int
+	// Charged per month late, pro rata per day, in basis points of the amount.
+	InterestRate int
+
+	Status     BoletoStatus
+	PaidAt     *time.Time
+	PaidAmount int
+	CreatedAt  time.Time
+}
+
+func NewBoleto(accountId, amount int, dueDate time.Time, payerName, payerDocument string, fineRate, interestRate int, createdAt time.Time) (*Boleto, error) {
+
+	if amount <= 0 || amount > BOLETO_MAX_AMOUNT {
+		return nil, fmt.Errorf("Boleto amount must be between 1 and %d!", BOLETO_MAX_AMOUNT)
+	}
+
+	dueDate = truncateToDay(dueDate)
+	if dueDate.Before(truncateToDay(createdAt)) {
+		return nil, fmt.Errorf("Boleto due date cannot be in the past!")
+	}
+
+	payerName = strings.TrimSpace(payerName)
+	if payerName == "" {
+		return nil, fmt.Errorf("Boleto payer name cannot be empty!")
+	}
+
+	payerDocument = onlyDigits(payerDocument)
+	if len(payerDocument) != 11 && len(payerDocument) != 14 {
+		return nil, fmt.Errorf("Boleto payer document must be a CPF or a CNPJ!")
+	}
+
+	if fineRate < 0 || interestRate < 0 {
+		return nil, fmt.Errorf("Boleto fine and interest cannot be negative!")
+	}
+
+	ourNumber, err := randomDigits(25)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Boleto{
+		ID:            NewPublicID(BOLETO_ID_PREFIX),
+		AccountID:     accountId,
+		OurNumber:     ourNumber,
+		Amount:        amount,
+		DueDate:       dueDate,
+		PayerName:     payerName,
+		PayerDocument: payerDocument,
+		FineRate:      fineRate,
+		InterestRate:  interestRate,
+		Status:        BoletoOpen,
+		CreatedAt:     createdAt,
+	}, nil
+}
+
+// Barcode is the 44 digits FEBRABAN barcode.
+func (b Boleto) Barcode() string {
+	return BuildBoletoBarcode(BANK_CODE, b.DueDate, b.Amount, b.OurNumber)
+}
+
+// DigitableLine is the 47 digits typed by payers, the "linha digitável".
+func (b Boleto) DigitableLine() string {
+	line, _ := BarcodeToDigitableLine(b.Barcode())
+	return line
+}
+
+// AmountDue adds the fine and the interest once the due date has passed.
+func (b Boleto) AmountDue(now time.Time) int {
+
+	daysLate := int(truncateToDay(now).Sub(b.DueDate).Hours() / 24)
+	if daysLate <= 0 {
+		return b.Amount
+	}
+
+	fine := b.Amount * b.FineRate / 10000
+	interest := b.Amount * b.InterestRate * daysLate / (10000 * 30)
+
+	return b.Amount + fine + interest
+}
+
+// Pay settles the boleto with what is due at the time.
+func (b *Boleto) Pay(now time.Time) (int, error) {
+
+	switch b.Status {
+	case BoletoPaid:
+		return 0, fmt.Errorf("Boleto was already paid!")
+	case BoletoCancelled:
+		return 0, fmt.Errorf("Boleto was cancelled!")
+	}
+
+	b.Status = BoletoPaid
+	b.PaidAt = &now
+	b.PaidAmount = b.AmountDue(now)
+
+	return b.PaidAmount, nil
+}
+
+func (b *Boleto) Cancel() error {
+
+	if b.Status != BoletoOpen {
+		return fmt.Errorf("Only open boletos can be cancelled! Status: %s", b.Status)
+	}
+
+	b.Status = BoletoCancelled
+	return nil
+}
+
+// BoletoRegistration is what the beneficiary bank registered for a boleto of
+// another bank, as answered by the clearing.
+type BoletoRegistration struct {
+	BeneficiaryName string
+	Amount          int
+	DueDate         *time.Time
+	FineRate        int
+	InterestRate    int
+	Paid            bool
+}
+
+// AmountDue follows the same fine and interest rules of the boletos issued
+// here.
+func (r BoletoRegistration) AmountDue(now time.Time) int {
+
+	if r.DueDate == nil {
+		return r.Amount
+	}
+
+	return Boleto{Amount: r.Amount, DueDate: *r.DueDate, FineRate: r.FineRate, InterestRate: r.InterestRate}.AmountDue(now)
+}
+
+// BoletoCode is what a barcode tells about a boleto of any bank.
+type BoletoCode struct {
+	Barcode  string
+	BankCode string
+	// Nil when the boleto has no due date, factor 0.
+	DueDate *time.Time
+	// Zero when the payer chooses the amount.
+	Amount    int
+	FreeField string
+}
+
+// ParseBoletoCode reads a barcode or a linha digitável, checking every check
+// digit and the due date factor. Due dates are read relative to now.
+func ParseBoletoCode(code string, now time.Time) (BoletoCode, error) {
+
+	barcode := onlyDigits(code)
+
+	switch len(barcode) {
+	case BOLETO_LINE_SIZE:
+		var err error
+		if barcode, err = DigitableLineToBarcode(barcode); err != nil {
+			return BoletoCode{}, err
+		}
+	case BOLETO_BARCODE_SIZE:
+		if strconv.Itoa(barcodeMod11(barcode[:4]+barcode[5:])) != barcode[4:5] {
+			return BoletoCode{}, fmt.Errorf("Boleto barcode has a wrong check digit!")
+		}
+	default:
+		return BoletoCode{}, fmt.Errorf("Boleto code must be a %d digits barcode or a %d digits linha digitável!", BOLETO_BARCODE_SIZE, BOLETO_LINE_SIZE)
+	}
+
+	if barcode[3:4] != BOLETO_CURRENCY {
+		return BoletoCode{}, fmt.Errorf("Only boletos in reais are accepted!")
+	}
+
+	parsed := BoletoCode{Barcode: barcode, BankCode: barcode[:3], FreeField: barcode[19:]}
+
+	factor, _ := strconv.Atoi(barcode[5:9])
+	if factor != 0 {
+		if factor < boletoFactorMin {
+			return BoletoCode{}, fmt.Errorf("Boleto due date factor %d is no longer valid!", factor)
+		}
+
+		dueDate := BoletoDueDate(factor, now)
+		parsed.DueDate = &dueDate
+	}
+
+	parsed.Amount, _ = strconv.Atoi(barcode[9:19])
+
+	return parsed, nil
+}
+
+// BuildBoletoBarcode lays the fields out as FEBRABAN specifies: bank,
+// currency, general check digit, due date factor, amount and the 25 digits
+// free field.
+func BuildBoletoBarcode(bankCode string, dueDate time.Time, amount int, freeField string) string {
+
+	withoutDV := fmt.Sprintf("%s%s%04d%010d%s", bankCode, BOLETO_CURRENCY, BoletoDueDateFactor(dueDate), amount, freeField)
+
+	return withoutDV[:4] + strconv.Itoa(barcodeMod11(withoutDV)) + withoutDV[4:]
+}
+
+// BoletoDueDateFactor is the number of days since the base date, restarting
+// at 1000 once it goes over 9999.
+func BoletoDueDateFactor(dueDate time.Time) int {
+
+	days := int(truncateToDay(dueDate).Sub(boletoFactorBase).Hours() / 24)
+	if days < boletoFactorMin {
+		return days
+	}
+
+	return (days-boletoFactorMin)%boletoFactorCycle + boletoFactorMin
+}
+
+// BoletoDueDate reads a factor back. As factors repeat, it picks the date
+// closest to the reference.
+func BoletoDueDate(factor int, reference time.Time) time.Time {
+
+	date := boletoFactorBase.AddDate(0, 0, factor)
+	reference = truncateToDay(reference)
+
+	for date.AddDate(0, 0, boletoFactorCycle/2).Before(reference) {
+		date = date.AddDate(0, 0, boletoFactorCycle)
+	}
+
+	return date
+}
+
+// BarcodeToDigitableLine splits the barcode in the five fields of the line,
+// the first three with their own mod 10 check digit.
+func BarcodeToDigitableLine(barcode string) (string, error) {
+
+	if len(barcode) != BOLETO_BARCODE_SIZE || onlyDigits(barcode) != barcode {
+		return "", fmt.Errorf("Boleto barcode must have %d digits!", BOLETO_BARCODE_SIZE)
+	}
+
+	free := barcode[19:]
+	field1 := barcode[0:4] + free[0:5]
+	field2 := free[5:15]
+	field3 := free[15:25]
+
+	return field1 + strconv.Itoa(mod10(field1)) +
+		field2 + strconv.Itoa(mod10(field2)) +
+		field3 + strconv.Itoa(mod10(field3)) +
+		barcode[4:5] + barcode[5:19], nil
+}
+
+// DigitableLineToBarcode validates every check digit of the line, typed with
+// or without the dots and spaces, and returns the barcode.
+func DigitableLineToBarcode(line string) (string, error) {
+
+	line = onlyDigits(line)
+	if len(line) != BOLETO_LINE_SIZE {
+		return "", fmt.Errorf("Linha digitável must have %d digits!", BOLETO_LINE_SIZE)
+	}
+
+	fields := []struct{ digits, dv string }{
+		{line[0:9], line[9:10]},
+		{line[10:20], line[20:21]},
+		{line[21:31], line[31:32]},
+	}
+
+	for i, field := range fields {
+		if strconv.Itoa(mod10(field.digits)) != field.dv {
+			return "", fmt.Errorf("Linha digitável has a wrong check digit on field %d!", i+1)
+		}
+	}
+
+	barcode := line[0:4] + line[32:33] + line[33:47] + line[4:9] + line[10:20] + line[21:31]
+
+	if strconv.Itoa(barcodeMod11(barcode[:4]+barcode[5:])) != barcode[4:5] {
+		return "", fmt.Errorf("Linha digitável has a wrong general check digit!")
+	}
+
+	return barcode, nil
+}
+
+// FormatDigitableLine groups the line the way it is printed on the boleto.
+func FormatDigitableLine(line string) string {
+
+	if len(line) != BOLETO_LINE_SIZE {
+		return line
+	}
+
+	return fmt.Sprintf("%s.%s %s.%s %s.%s %s %s", line[0:5], line[5:10], line[10:15], line[15:21], line[21:26], line[26:32], line[32:33], line[33:47])
+}
+
+// mod10 weights the digits 2, 1, 2... from the right, adding up the digits of
+// each product.
+func mod10(digits string) int {
+
+	sum := 0
+	weight := 2
+
+	for i := len(digits) - 1; i >= 0; i-- {
+		product := int(digits[i]-'0') * weight
+		sum += product/10 + product%10
+
+		weight = 3 - weight
+	}
+
+	return (10 - sum%10) % 10
+}
+
+// barcodeMod11 weights the digits 2 to 9 from the right. Results 0, 10 and 11
+// become 1, a barcode check digit is never 0.
+func barcodeMod11(digits string) int {
+
+	sum := 0
+	weight := 2
+
+	for i := len(digits) - 1; i >= 0; i-- {
+		sum += int(digits[i]-'0') * weight
+
+		weight++
+		if weight > 9 {
+			weight = 2
+		}
+	}
+
+	dv := 11 - sum%11
+	if dv == 0 || dv == 10 || dv == 11 {
+		return 1
+	}
+
+	return dv
+}
+
+func randomDigits(size int) (string, error) {
+
+	digits := make([]byte, size)
+	for i := range digits {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		digits[i] = byte('0' + n.Int64())
+	}
+
+	return string(digits), nil
+}
+
+func truncateToDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
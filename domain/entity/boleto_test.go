@@ -0,0 +1,155 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+// Banco do Brasil example, barcode and line of the same boleto.
+const (
+	MOCKED_BOLETO_BARCODE = "00193373700000001000500940144816060680935031"
+	MOCKED_BOLETO_LINE    = "00190.50095 40144.816069 06809.350314 3 37370000000100"
+)
+
+func TestBoletoDigitableLine(t *testing.T) {
+
+	t.Run("Should convert the barcode to the line and back", func(t *testing.T) {
+		line, err := BarcodeToDigitableLine(MOCKED_BOLETO_BARCODE)
+		if err != nil || line != onlyDigits(MOCKED_BOLETO_LINE) {
+			t.Errorf("Wrong line. Got: %s, err: %v", line, err)
+		}
+
+		if formatted := FormatDigitableLine(line); formatted != MOCKED_BOLETO_LINE {
+			t.Errorf("Wrong formatting. Got: %s", formatted)
+		}
+
+		barcode, err := DigitableLineToBarcode(MOCKED_BOLETO_LINE)
+		if err != nil || barcode != MOCKED_BOLETO_BARCODE {
+			t.Errorf("Wrong barcode. Got: %s, err: %v", barcode, err)
+		}
+	})
+
+	t.Run("Should NOT accept a mistyped line", func(t *testing.T) {
+		if _, err := DigitableLineToBarcode("00190.50095 40144.816069 06809.350314 3 37370000000101"); err == nil {
+			t.Errorf("Wrong amount should fail the general check digit")
+		}
+
+		if _, err := DigitableLineToBarcode("00190.50095 40144.816069 06809.350324 3 37370000000100"); err == nil {
+			t.Errorf("Wrong third field should fail its check digit")
+		}
+	})
+}
+
+func TestParseBoletoCode(t *testing.T) {
+
+	now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	barcode := BuildBoletoBarcode("341", now.AddDate(0, 0, 5), 15000, "1234567890123456789012345")
+	line, _ := BarcodeToDigitableLine(barcode)
+
+	t.Run("Should read the same fields from the barcode and the line", func(t *testing.T) {
+		for _, code := range []string{barcode, FormatDigitableLine(line)} {
+			parsed, err := ParseBoletoCode(code, now)
+			if err != nil {
+				t.Fatalf("Code should be valid, got: %v", err)
+			}
+
+			if parsed.Barcode != barcode || parsed.BankCode != "341" || parsed.Amount != 15000 || parsed.DueDate == nil || !parsed.DueDate.Equal(now.AddDate(0, 0, 5)) {
+				t.Errorf("Wrong fields parsed. Got: %+v", parsed)
+			}
+		}
+	})
+
+	t.Run("Should NOT accept a barcode with a wrong check digit", func(t *testing.T) {
+		tampered := barcode[:10] + "9" + barcode[11:]
+
+		if _, err := ParseBoletoCode(tampered, now); err == nil {
+			t.Errorf("Tampered barcode should NOT be accepted")
+		}
+	})
+}
+
+func TestBoletoDueDateFactor(t *testing.T) {
+
+	cases := []struct {
+		date   time.Time
+		factor int
+	}{
+		{time.Date(2000, 7, 3, 0, 0, 0, 0, time.UTC), 1000},
+		{time.Date(2025, 2, 21, 0, 0, 0, 0, time.UTC), 9999},
+		{time.Date(2025, 2, 22, 0, 0, 0, 0, time.UTC), 1000},
+	}
+
+	for _, c := range cases {
+		if got := BoletoDueDateFactor(c.date); got != c.factor {
+			t.Errorf("Wrong factor for %s. Got: %d, expected: %d", c.date.Format(time.DateOnly), got, c.factor)
+		}
+
+		if got := BoletoDueDate(c.factor, c.date.AddDate(0, 0, 30)); !got.Equal(c.date) {
+			t.Errorf("Wrong date for factor %d. Got: %s", c.factor, got.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestBoleto(t *testing.T) {
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Should NOT be created with invalid data", func(t *testing.T) {
+		if _, err := NewBoleto(1, 0, now, "Ze", "12345678909", 0, 0, now); err == nil {
+			t.Errorf("Zero amount should NOT be accepted")
+		}
+
+		if _, err := NewBoleto(1, 100, now.AddDate(0, 0, -1), "Ze", "12345678909", 0, 0, now); err == nil {
+			t.Errorf("Past due date should NOT be accepted")
+		}
+
+		if _, err := NewBoleto(1, 100, now, "Ze", "123", 0, 0, now); err == nil {
+			t.Errorf("Invalid payer document should NOT be accepted")
+		}
+	})
+
+	t.Run("Should generate a valid line", func(t *testing.T) {
+		boleto, err := NewBoleto(1, 12345, now.AddDate(0, 0, 10), "Ze", "123.456.789-09", 200, 100, now)
+		if err != nil {
+			t.Fatalf("Boleto should be created, got: %v", err)
+		}
+
+		barcode, err := DigitableLineToBarcode(boleto.DigitableLine())
+		if err != nil || barcode != boleto.Barcode() {
+			t.Errorf("Line should read back to the barcode. Got: %s, err: %v", barcode, err)
+		}
+
+		if barcode[:3] != BANK_CODE || barcode[9:19] != "0000012345" || barcode[19:] != boleto.OurNumber {
+			t.Errorf("Wrong barcode fields. Got: %s", barcode)
+		}
+	})
+
+	t.Run("Should charge fine and interest after the due date", func(t *testing.T) {
+		// 2% fine and 1% interest a month.
+		boleto, _ := NewBoleto(1, 30000, now, "Ze", "12345678909", 200, 100, now)
+
+		if got := boleto.AmountDue(now.Add(6 * time.Hour)); got != 30000 {
+			t.Errorf("Nothing should be added on the due date. Got: %d", got)
+		}
+
+		if got := boleto.AmountDue(now.AddDate(0, 0, 10)); got != 30000+600+100 {
+			t.Errorf("Wrong amount due. Got: %d, expected: %d", got, 30700)
+		}
+	})
+
+	t.Run("Should only be paid once", func(t *testing.T) {
+		boleto, _ := NewBoleto(1, 100, now, "Ze", "12345678909", 0, 0, now)
+
+		if _, err := boleto.Pay(now); err != nil {
+			t.Errorf("Open boleto should be paid, got: %v", err)
+		}
+
+		if _, err := boleto.Pay(now); err == nil {
+			t.Errorf("Paid boleto should NOT be paid again")
+		}
+
+		if err := boleto.Cancel(); err == nil {
+			t.Errorf("Paid boleto should NOT be cancelled")
+		}
+	})
+}
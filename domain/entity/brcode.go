@@ -0,0 +1,235 @@
+package entity
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BR Code is the EMV QR code payload used by Pix. The payload is a list of
+// ID (2 digits), length (2 digits) and value fields, some of them holding
+// fields themselves, ended by a CRC16 of everything before it.
+const (
+	BR_CODE_PAYLOAD_FORMAT = "01"
+	BR_CODE_GUI            = "br.gov.bcb.pix"
+	BR_CODE_CURRENCY_BRL   = "986"
+	BR_CODE_COUNTRY        = "BR"
+	BR_CODE_DEFAULT_CITY   = "BRASILIA"
+	// Transaction ID of static codes, which are not tied to a charge.
+	BR_CODE_NO_TXID = "***"
+
+	BR_CODE_STATIC  = "11"
+	BR_CODE_DYNAMIC = "12"
+
+	brCodeFieldFormat     = "00"
+	brCodeFieldInitiation = "01"
+	brCodeFieldAccount    = "26"
+	brCodeFieldMCC        = "52"
+	brCodeFieldCurrency   = "53"
+	brCodeFieldAmount     = "54"
+	brCodeFieldCountry    = "58"
+	brCodeFieldName       = "59"
+	brCodeFieldCity       = "60"
+	brCodeFieldAdditional = "62"
+	brCodeFieldCRC        = "63"
+
+	brCodeAccountGUI = "00"
+	brCodeAccountKey = "01"
+	brCodeTxID       = "05"
+
+	brCodeMaxName = 25
+	brCodeMaxCity = 15
+	brCodeMaxTxID = 25
+)
+
+type BRCode struct {
+	// Dynamic codes are single use, bound to a charge through the TxID.
+	Dynamic      bool
+	PixKey       string
+	Amount       int
+	MerchantName string
+	MerchantCity string
+	TxID         string
+}
+
+// Encode builds the payload with its checksum. Amounts are in cents, a zero
+// amount leaves it for the payer to fill.
+func (c BRCode) Encode() string {
+
+	initiation := BR_CODE_STATIC
+	if c.Dynamic {
+		initiation = BR_CODE_DYNAMIC
+	}
+
+	txid := c.TxID
+	if txid == "" {
+		txid = BR_CODE_NO_TXID
+	}
+
+	city := c.MerchantCity
+	if city == "" {
+		city = BR_CODE_DEFAULT_CITY
+	}
+
+	var payload strings.Builder
+	payload.WriteString(emvField(brCodeFieldFormat, BR_CODE_PAYLOAD_FORMAT))
+	payload.WriteString(emvField(brCodeFieldInitiation, initiation))
+	payload.WriteString(emvField(brCodeFieldAccount, emvField(brCodeAccountGUI, BR_CODE_GUI)+emvField(brCodeAccountKey, c.PixKey)))
+	payload.WriteString(emvField(brCodeFieldMCC, "0000"))
+	payload.WriteString(emvField(brCodeFieldCurrency, BR_CODE_CURRENCY_BRL))
+	if c.Amount > 0 {
+		payload.WriteString(emvField(brCodeFieldAmount, fmt.Sprintf("%d.%02d", c.Amount/100, c.Amount%100)))
+	}
+	payload.WriteString(emvField(brCodeFieldCountry, BR_CODE_COUNTRY))
+	payload.WriteString(emvField(brCodeFieldName, brCodeText(c.MerchantName, brCodeMaxName)))
+	payload.WriteString(emvField(brCodeFieldCity, brCodeText(city, brCodeMaxCity)))
+	payload.WriteString(emvField(brCodeFieldAdditional, emvField(brCodeTxID, txid)))
+	payload.WriteString(brCodeFieldCRC + "04")
+
+	return payload.String() + fmt.Sprintf("%04X", CRC16(payload.String()))
+}
+
+// ParseBRCode validates the checksum and the Pix fields of a payload.
+func ParseBRCode(payload string) (BRCode, error) {
+
+	payload = strings.TrimSpace(payload)
+
+	if len(payload) < 8 || payload[len(payload)-8:len(payload)-4] != brCodeFieldCRC+"04" {
+		return BRCode{}, fmt.Errorf("Invalid BR Code, checksum not found!")
+	}
+
+	crc, err := strconv.ParseUint(payload[len(payload)-4:], 16, 16)
+	if err != nil || uint16(crc) != CRC16(payload[:len(payload)-4]) {
+		return BRCode{}, fmt.Errorf("Invalid BR Code, wrong checksum!")
+	}
+
+	fields, err := parseEMV(payload[:len(payload)-8])
+	if err != nil {
+		return BRCode{}, err
+	}
+
+	if fields[brCodeFieldFormat] != BR_CODE_PAYLOAD_FORMAT {
+		return BRCode{}, fmt.Errorf("Invalid BR Code, unknown payload format!")
+	}
+
+	if fields[brCodeFieldCurrency] != BR_CODE_CURRENCY_BRL || fields[brCodeFieldCountry] != BR_CODE_COUNTRY {
+		return BRCode{}, fmt.Errorf("Only BR Codes in BRL are accepted!")
+	}
+
+	account, err := parseEMV(fields[brCodeFieldAccount])
+	if err != nil || !strings.EqualFold(account[brCodeAccountGUI], BR_CODE_GUI) || account[brCodeAccountKey] == "" {
+		return BRCode{}, fmt.Errorf("Invalid BR Code, it is not a Pix payload with a key!")
+	}
+
+	code := BRCode{
+		Dynamic:      fields[brCodeFieldInitiation] == BR_CODE_DYNAMIC,
+		PixKey:       account[brCodeAccountKey],
+		MerchantName: fields[brCodeFieldName],
+		MerchantCity: fields[brCodeFieldCity],
+	}
+
+	if raw, ok := fields[brCodeFieldAmount]; ok {
+		code.Amount, err = parseBRCodeAmount(raw)
+		if err != nil {
+			return BRCode{}, err
+		}
+	}
+
+	if additional, err := parseEMV(fields[brCodeFieldAdditional]); err == nil && additional[brCodeTxID] != BR_CODE_NO_TXID {
+		code.TxID = additional[brCodeTxID]
+	}
+
+	if code.Dynamic && code.TxID == "" {
+		return BRCode{}, fmt.Errorf("Invalid BR Code, dynamic codes need a transaction ID!")
+	}
+
+	return code, nil
+}
+
+// CRC16 is the CRC-16/CCITT-FALSE required by the EMV specification.
+func CRC16(data string) uint16 {
+
+	crc := uint16(0xFFFF)
+
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
+
+func emvField(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+func parseEMV(data string) (map[string]string, error) {
+
+	fields := map[string]string{}
+
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("Invalid BR Code, truncated field!")
+		}
+
+		size, err := strconv.Atoi(data[2:4])
+		if err != nil || len(data) < 4+size {
+			return nil, fmt.Errorf("Invalid BR Code, wrong field length!")
+		}
+
+		fields[data[:2]] = data[4 : 4+size]
+		data = data[4+size:]
+	}
+
+	return fields, nil
+}
+
+func parseBRCodeAmount(raw string) (int, error) {
+
+	reais, cents, _ := strings.Cut(raw, ".")
+	if len(cents) > 2 {
+		return 0, fmt.Errorf("Invalid BR Code amount: %s", raw)
+	}
+	cents += strings.Repeat("0", 2-len(cents))
+
+	amount, err := strconv.Atoi(reais + cents)
+	if err != nil || amount <= 0 {
+		return 0, fmt.Errorf("Invalid BR Code amount: %s", raw)
+	}
+
+	return amount, nil
+}
+
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "ê", "e", "è", "e", "í", "i", "ï", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ö", "o", "ú", "u", "ü", "u", "ç", "c",
+	"Á", "A", "À", "A", "Â", "A", "Ã", "A", "Ä", "A",
+	"É", "E", "Ê", "E", "È", "E", "Í", "I", "Ï", "I",
+	"Ó", "O", "Ô", "O", "Õ", "O", "Ö", "O", "Ú", "U", "Ü", "U", "Ç", "C",
+)
+
+// brCodeText keeps names ASCII and within the field size, as some readers
+// break on anything else.
+func brCodeText(text string, max int) string {
+
+	text = strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7E {
+			return -1
+		}
+		return r
+	}, accents.Replace(text))
+
+	if len(text) > max {
+		text = text[:max]
+	}
+
+	return text
+}
@@ -0,0 +1,86 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+// Static example from the Pix BR Code manual.
+const MOCKED_BR_CODE = "00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400005204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***63041D3D"
+
+func TestCRC16(t *testing.T) {
+
+	if got := CRC16("123456789"); got != 0x29B1 {
+		t.Errorf("Wrong CRC16. Got: %04X, expected: 29B1", got)
+	}
+}
+
+func TestParseBRCode(t *testing.T) {
+
+	t.Run("Should parse the manual example", func(t *testing.T) {
+		code, err := ParseBRCode(MOCKED_BR_CODE)
+		if err != nil {
+			t.Fatalf("Example should be valid, got: %v", err)
+		}
+
+		if code.Dynamic || code.PixKey != "123e4567-e12b-12d1-a456-426655440000" || code.Amount != 0 || code.TxID != "" || code.MerchantName != "Fulano de Tal" {
+			t.Errorf("Wrong fields parsed. Got: %+v", code)
+		}
+	})
+
+	t.Run("Should NOT accept a tampered payload", func(t *testing.T) {
+		tampered := MOCKED_BR_CODE[:60] + "9" + MOCKED_BR_CODE[61:]
+
+		if _, err := ParseBRCode(tampered); err == nil {
+			t.Errorf("Tampered payload should NOT be accepted")
+		}
+	})
+
+	t.Run("Should read back what was encoded", func(t *testing.T) {
+		encoded := BRCode{Dynamic: true, PixKey: "ze@example.com", Amount: 1050, MerchantName: "José da Conceição Guedes", TxID: "abc123"}.Encode()
+
+		code, err := ParseBRCode(encoded)
+		if err != nil {
+			t.Fatalf("Encoded payload should be valid, got: %v", err)
+		}
+
+		if !code.Dynamic || code.PixKey != "ze@example.com" || code.Amount != 1050 || code.TxID != "abc123" || code.MerchantName != "Jose da Conceicao Guedes" {
+			t.Errorf("Wrong fields read back. Got: %+v", code)
+		}
+	})
+}
+
+func TestPixCharge(t *testing.T) {
+
+	now := time.Now().UTC()
+	verifiedAt := now
+	key := PixKey{AccountID: 1, Type: PixKeyEmail, Value: "ze@example.com", VerifiedAt: &verifiedAt}
+
+	t.Run("Should NOT be created for unverified keys or without amount", func(t *testing.T) {
+		if _, err := NewPixCharge(PixKey{AccountID: 1, Value: "ze@example.com"}, 100, 0, now); err == nil {
+			t.Errorf("Unverified key should NOT be accepted")
+		}
+
+		if _, err := NewPixCharge(key, 0, 0, now); err == nil {
+			t.Errorf("Zero amount should NOT be accepted")
+		}
+	})
+
+	t.Run("Should only be paid by its own code before expiring", func(t *testing.T) {
+		charge, _ := NewPixCharge(key, 100, time.Minute, now)
+		code := charge.BRCode("Ze")
+
+		if err := charge.CheckPayable(code, now); err != nil {
+			t.Errorf("Charge should be payable, got: %v", err)
+		}
+
+		code.Amount = 1
+		if err := charge.CheckPayable(code, now); err == nil {
+			t.Errorf("Code with another amount should NOT pay the charge")
+		}
+
+		if err := charge.CheckPayable(charge.BRCode("Ze"), now.Add(time.Minute)); err == nil {
+			t.Errorf("Expired charge should NOT be payable")
+		}
+	})
+}
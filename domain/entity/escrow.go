@@ -0,0 +1,182 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	ESCROW_ID_PREFIX = "esc"
+
+	ESCROW_DEFAULT_RELEASE = 14 * 24 * time.Hour
+	ESCROW_MAX_RELEASE     = 180 * 24 * time.Hour
+	ESCROW_MAX_DESCRIPTION = 140
+
+	// Releases failing this many times, the payee frozen or closed, are
+	// refunded to the payer instead. Tried every minute, about an hour.
+	ESCROW_MAX_RELEASE_FAILURES = 60
+)
+
+type EscrowStatus string
+
+const (
+	// The payer was debited, the money is held by the bank.
+	EscrowFunded EscrowStatus = "funded"
+	// Paid to the payee, confirmed by the payer or past the deadline.
+	EscrowReleased EscrowStatus = "released"
+	// Given back to the payer, cancelled by the payee.
+	EscrowRefunded EscrowStatus = "refunded"
+	// Neither the payee nor the payer could be credited, the money is held by
+	// the bank until an operator settles it.
+	EscrowFailed EscrowStatus = "failed"
+)
+
+// Escrow keeps money of the payer, already out of its balance, until it is
+// released to the payee or refunded. Escrows not settled by the release date
+// are released to the payee.
+type Escrow struct {
+	ID            string
+	PayerID       int
+	PayerPublicID string
+	PayeeID       int
+	PayeePublicID string
+	Amount        int
+	Description   string
+	Status        EscrowStatus
+	ReleaseAt     time.Time
+	CreatedAt     time.Time
+	SettledAt     *time.Time
+	// Times the release when due failed.
+	ReleaseFailures int
+}
+
+func NewEscrow(payerId, payeeId, amount int, description string, releaseIn time.Duration, createdAt time.Time) (*Escrow, error) {
+
+	if amount <= 0 {
+		return nil, fmt.Errorf("Escrow amount must be greater than zero!")
+	}
+
+	if payerId == payeeId {
+		return nil, fmt.Errorf("Escrow cannot have the payer as payee!")
+	}
+
+	if len(description) > ESCROW_MAX_DESCRIPTION {
+		return nil, fmt.Errorf("Escrow description cannot be longer than %d characters!", ESCROW_MAX_DESCRIPTION)
+	}
+
+	if releaseIn == 0 {
+		releaseIn = ESCROW_DEFAULT_RELEASE
+	}
+
+	if releaseIn < 0 || releaseIn > ESCROW_MAX_RELEASE {
+		return nil, fmt.Errorf("Escrows must be released within %s!", ESCROW_MAX_RELEASE)
+	}
+
+	return &Escrow{
+		ID:          NewPublicID(ESCROW_ID_PREFIX),
+		PayerID:     payerId,
+		PayeeID:     payeeId,
+		Amount:      amount,
+		Description: description,
+		Status:      EscrowFunded,
+		ReleaseAt:   createdAt.Add(releaseIn),
+		CreatedAt:   createdAt,
+	}, nil
+}
+
+// Balance is what the escrow still holds.
+func (e Escrow) Balance() int {
+
+	if e.Status != EscrowFunded && e.Status != EscrowFailed {
+		return 0
+	}
+
+	return e.Amount
+}
+
+// IsDue tells whether the escrow must be released without confirmation.
+func (e Escrow) IsDue(now time.Time) bool {
+	return e.Status == EscrowFunded && !now.Before(e.ReleaseAt)
+}
+
+// Beneficiary is the account the money went, or goes, to when settled.
+func (e Escrow) Beneficiary() int {
+
+	if e.Status == EscrowRefunded {
+		return e.PayerID
+	}
+
+	return e.PayeeID
+}
+
+func (e *Escrow) Release(now time.Time) error {
+	return e.settle(EscrowReleased, now)
+}
+
+// Refund is refused once the escrow is due, it is released instead.
+func (e *Escrow) Refund(now time.Time) error {
+
+	if e.IsDue(now) {
+		return fmt.Errorf("Escrow is past its release date, it cannot be refunded!")
+	}
+
+	return e.settle(EscrowRefunded, now)
+}
+
+// FailRelease counts a release when due that failed. Tells whether the
+// escrow must be refunded, as releasing it is given up.
+func (e *Escrow) FailRelease() bool {
+
+	e.ReleaseFailures++
+
+	return e.ReleaseFailures >= ESCROW_MAX_RELEASE_FAILURES
+}
+
+// RefundUnreleased refunds a due escrow, only once its release was given up.
+func (e *Escrow) RefundUnreleased(now time.Time) error {
+
+	if e.ReleaseFailures < ESCROW_MAX_RELEASE_FAILURES {
+		return fmt.Errorf("Escrow release is still being tried, it cannot be refunded!")
+	}
+
+	return e.settle(EscrowRefunded, now)
+}
+
+// Fail stops an escrow that could not be released nor refunded.
+func (e *Escrow) Fail(now time.Time) error {
+	return e.settle(EscrowFailed, now)
+}
+
+func (e *Escrow) settle(status EscrowStatus, now time.Time) error {
+
+	if e.Status != EscrowFunded {
+		return fmt.Errorf("Escrow is %s, it cannot be settled again!", e.Status)
+	}
+
+	e.Status = status
+	e.SettledAt = &now
+
+	return nil
+}
+
+// EscrowEvent is a state transition of an escrow. From is empty for the
+// funding.
+type EscrowEvent struct {
+	ID       int
+	EscrowID string
+	From     EscrowStatus
+	To       EscrowStatus
+	// Who did it, as "account:<id>" or "system".
+	Actor     string
+	CreatedAt time.Time
+}
+
+func NewEscrowEvent(escrowId string, from, to EscrowStatus, actor string, createdAt time.Time) *EscrowEvent {
+	return &EscrowEvent{
+		EscrowID:  escrowId,
+		From:      from,
+		To:        to,
+		Actor:     actor,
+		CreatedAt: createdAt,
+	}
+}
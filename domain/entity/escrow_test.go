@@ -0,0 +1,92 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEscrow(t *testing.T) {
+
+	now := time.Now()
+
+	t.Run("Should NOT be created without amount, to the payer or for too long", func(t *testing.T) {
+		if _, err := NewEscrow(1, 2, 0, "", 0, now); err == nil {
+			t.Errorf("Escrow without amount should NOT be accepted")
+		}
+
+		if _, err := NewEscrow(1, 1, 10, "", 0, now); err == nil {
+			t.Errorf("Escrow to the payer should NOT be accepted")
+		}
+
+		if _, err := NewEscrow(1, 2, 10, "", ESCROW_MAX_RELEASE+time.Second, now); err == nil {
+			t.Errorf("Escrow released after the maximum should NOT be accepted")
+		}
+	})
+
+	t.Run("Should hold the amount until settled, only once", func(t *testing.T) {
+		escrow, _ := NewEscrow(1, 2, 100, "Order 42", 0, now)
+
+		if escrow.Balance() != 100 || escrow.Beneficiary() != 2 {
+			t.Errorf("Funded escrow should hold the amount for the payee. Got: %+v", escrow)
+		}
+
+		if err := escrow.Refund(now); err != nil || escrow.Balance() != 0 || escrow.Beneficiary() != 1 {
+			t.Fatalf("Escrow should be refunded to the payer, got: %v, %+v", err, escrow)
+		}
+
+		if err := escrow.Release(now); err == nil {
+			t.Errorf("Refunded escrow should NOT be released")
+		}
+	})
+
+	t.Run("Should be released, not refunded, once due", func(t *testing.T) {
+		escrow, _ := NewEscrow(1, 2, 100, "", time.Hour, now)
+		later := now.Add(time.Hour)
+
+		if escrow.IsDue(now) || !escrow.IsDue(later) {
+			t.Errorf("Escrow should be due only after its release date")
+		}
+
+		if err := escrow.Refund(later); err == nil {
+			t.Errorf("Due escrow should NOT be refunded")
+		}
+
+		if err := escrow.Release(later); err != nil || escrow.Status != EscrowReleased {
+			t.Errorf("Due escrow should be released, got: %v", err)
+		}
+	})
+	t.Run("Should be refunded once releasing it failed too many times", func(t *testing.T) {
+		escrow, _ := NewEscrow(1, 2, 100, "", time.Hour, now)
+		later := now.Add(time.Hour)
+
+		for i := 1; i < ESCROW_MAX_RELEASE_FAILURES; i++ {
+			if escrow.FailRelease() {
+				t.Fatalf("Escrow release should be given up only after %d failures, gave up after %d", ESCROW_MAX_RELEASE_FAILURES, i)
+			}
+		}
+
+		if err := escrow.RefundUnreleased(later); err == nil {
+			t.Errorf("Escrow should NOT be refunded while its release is still tried")
+		}
+
+		if !escrow.FailRelease() {
+			t.Fatalf("Escrow release should be given up after %d failures", ESCROW_MAX_RELEASE_FAILURES)
+		}
+
+		if err := escrow.RefundUnreleased(later); err != nil || escrow.Status != EscrowRefunded || escrow.Beneficiary() != 1 {
+			t.Errorf("Escrow should be refunded to the payer, got: %v, %+v", err, escrow)
+		}
+	})
+
+	t.Run("Should keep holding the amount when failed", func(t *testing.T) {
+		escrow, _ := NewEscrow(1, 2, 100, "", 0, now)
+
+		if err := escrow.Fail(now); err != nil || escrow.Balance() != 100 {
+			t.Errorf("Failed escrow should still hold the amount, got: %v, %+v", err, escrow)
+		}
+
+		if err := escrow.Release(now); err == nil {
+			t.Errorf("Failed escrow should NOT be released")
+		}
+	})
+}
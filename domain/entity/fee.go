@@ -0,0 +1,135 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// Fee rates are in basis points.
+	FEE_RATE_BASE = 10000
+)
+
+// FeeTier replaces the flat fee and rate of the schedule for amounts up to
+// UpTo. The last tier may have UpTo 0, taking any amount.
+type FeeTier struct {
+	UpTo int
+	Flat int
+	Rate int
+}
+
+// FeeSchedule is how much is charged on each transfer of a kind: a flat fee
+// plus a rate of the amount, or those of the tier of the amount, kept
+// between Min and Max. The first FreePerMonth transfers of each month are
+// free.
+type FeeSchedule struct {
+	Kind         TransferKind
+	Flat         int
+	Rate         int
+	Tiers        []FeeTier
+	Min          int
+	Max          int
+	FreePerMonth int
+}
+
+func (s FeeSchedule) Validate() error {
+
+	if s.Kind != TransferKindTransfer && s.Kind != TransferKindBoletoPayment {
+		return fmt.Errorf("Fees can only be charged on %s and %s!", TransferKindTransfer, TransferKindBoletoPayment)
+	}
+
+	if s.Flat < 0 || s.Rate < 0 || s.Min < 0 || s.Max < 0 || s.FreePerMonth < 0 {
+		return fmt.Errorf("Fee schedule of %s cannot have negative values!", s.Kind)
+	}
+
+	if s.Max != 0 && s.Max < s.Min {
+		return fmt.Errorf("Fee schedule of %s has a cap below its minimum!", s.Kind)
+	}
+
+	previous := 0
+	for i, tier := range s.Tiers {
+		if tier.Flat < 0 || tier.Rate < 0 || tier.UpTo < 0 {
+			return fmt.Errorf("Fee schedule of %s cannot have negative values!", s.Kind)
+		}
+
+		last := i == len(s.Tiers)-1
+		if (tier.UpTo == 0 && !last) || (tier.UpTo != 0 && tier.UpTo <= previous) {
+			return fmt.Errorf("Fee tiers of %s must go up, only the last one may take any amount!", s.Kind)
+		}
+
+		previous = tier.UpTo
+	}
+
+	return nil
+}
+
+// Fee is charged on a transfer of the amount, when the account already made
+// usedThisMonth transfers of the kind this month.
+func (s FeeSchedule) Fee(amount, usedThisMonth int) int {
+
+	if usedThisMonth < s.FreePerMonth {
+		return 0
+	}
+
+	flat, rate := s.Flat, s.Rate
+	for _, tier := range s.Tiers {
+		if tier.UpTo == 0 || amount <= tier.UpTo {
+			flat, rate = tier.Flat, tier.Rate
+			break
+		}
+	}
+
+	// Rounded half up to the cent.
+	fee := flat + (amount*rate+FEE_RATE_BASE/2)/FEE_RATE_BASE
+
+	if fee < s.Min {
+		fee = s.Min
+	}
+
+	if s.Max != 0 && fee > s.Max {
+		fee = s.Max
+	}
+
+	return fee
+}
+
+// FreeRemaining is how many free transfers are left this month.
+func (s FeeSchedule) FreeRemaining(usedThisMonth int) int {
+
+	if usedThisMonth >= s.FreePerMonth {
+		return 0
+	}
+
+	return s.FreePerMonth - usedThisMonth
+}
+
+// FeeCharge prices the fee of a transfer again as it is made, counting the
+// free transfers of the month within the same database transaction, so
+// concurrent transfers cannot all take the last free one.
+type FeeCharge struct {
+	Schedule         FeeSchedule
+	RevenueAccountID int
+	// Start of the month the free transfers are counted from.
+	Since time.Time
+}
+
+// NewFee is the fee of the transfer, to the revenue account, when the account
+// already made usedThisMonth transfers of the kind. Nil when free.
+func (c FeeCharge) NewFee(transfer Transfer, usedThisMonth int, now time.Time) *Transfer {
+
+	amount := c.Schedule.Fee(transfer.Amount, usedThisMonth)
+	if amount == 0 {
+		return nil
+	}
+
+	fee := NewTransfer(0, transfer.AccountOriginID, c.RevenueAccountID, amount, now)
+	fee.Kind = TransferKindFee
+
+	return fee
+}
+
+// StartOfMonth is when the free transfers of the month containing now start
+// being counted.
+func StartOfMonth(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+}
@@ -0,0 +1,84 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFeeSchedule(t *testing.T) {
+
+	t.Run("Should charge flat plus rate, between minimum and cap", func(t *testing.T) {
+		schedule := FeeSchedule{Kind: TransferKindTransfer, Flat: 10, Rate: 150, Min: 50, Max: 1000}
+
+		cases := map[int]int{
+			100:     50,   // 10 + 1.5, raised to the minimum
+			10000:   160,  // 10 + 150
+			10033:   160,  // 10 + 150.495, rounded down
+			10034:   161,  // 10 + 150.51, rounded up
+			1000000: 1000, // 10 + 15000, capped
+		}
+
+		for amount, expected := range cases {
+			if fee := schedule.Fee(amount, 0); fee != expected {
+				t.Errorf("Wrong fee for %d. Got: %d, expected: %d", amount, fee, expected)
+			}
+		}
+	})
+
+	t.Run("Should use the tier of the amount", func(t *testing.T) {
+		schedule := FeeSchedule{Kind: TransferKindTransfer, Tiers: []FeeTier{
+			{UpTo: 10000, Flat: 0},
+			{UpTo: 100000, Flat: 100},
+			{UpTo: 0, Rate: 20},
+		}}
+
+		cases := map[int]int{10000: 0, 10001: 100, 100000: 100, 1000000: 2000}
+		for amount, expected := range cases {
+			if fee := schedule.Fee(amount, 0); fee != expected {
+				t.Errorf("Wrong fee for %d. Got: %d, expected: %d", amount, fee, expected)
+			}
+		}
+	})
+
+	t.Run("Should NOT charge the free transfers of the month", func(t *testing.T) {
+		schedule := FeeSchedule{Kind: TransferKindTransfer, Flat: 100, FreePerMonth: 2}
+
+		if schedule.Fee(500, 1) != 0 || schedule.FreeRemaining(1) != 1 {
+			t.Errorf("Second transfer of the month should be free")
+		}
+
+		if schedule.Fee(500, 2) != 100 || schedule.FreeRemaining(5) != 0 {
+			t.Errorf("Third transfer of the month should be charged")
+		}
+	})
+
+	t.Run("Should make the fee to the revenue account only once the free transfers are used", func(t *testing.T) {
+		charge := FeeCharge{Schedule: FeeSchedule{Kind: TransferKindTransfer, Flat: 100, FreePerMonth: 1}, RevenueAccountID: 9}
+		transfer := NewTransfer(0, 1, 2, 500, time.Now())
+
+		if fee := charge.NewFee(*transfer, 0, time.Now()); fee != nil {
+			t.Errorf("First transfer of the month should be free, got: %+v", fee)
+		}
+
+		fee := charge.NewFee(*transfer, 1, time.Now())
+		if fee == nil || fee.Amount != 100 || fee.AccountOriginID != 1 || fee.AccountDestinationID != 9 || fee.Kind != TransferKindFee {
+			t.Errorf("Second transfer of the month should be charged to the revenue account, got: %+v", fee)
+		}
+	})
+
+	t.Run("Should NOT accept invalid schedules", func(t *testing.T) {
+		invalid := []FeeSchedule{
+			{Kind: TransferKindSplit},
+			{Kind: TransferKindTransfer, Flat: -1},
+			{Kind: TransferKindTransfer, Min: 100, Max: 50},
+			{Kind: TransferKindTransfer, Tiers: []FeeTier{{UpTo: 0}, {UpTo: 100}}},
+			{Kind: TransferKindTransfer, Tiers: []FeeTier{{UpTo: 100}, {UpTo: 50}}},
+		}
+
+		for _, schedule := range invalid {
+			if err := schedule.Validate(); err == nil {
+				t.Errorf("Schedule should NOT be accepted: %+v", schedule)
+			}
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	HOLD_ID_PREFIX = "hld"
+
+	HOLD_DEFAULT_TTL = 7 * 24 * time.Hour
+	HOLD_MAX_TTL     = 30 * 24 * time.Hour
+)
+
+type HoldStatus string
+
+const (
+	HoldAuthorized HoldStatus = "authorized"
+	HoldCaptured   HoldStatus = "captured"
+	HoldVoided     HoldStatus = "voided"
+	// Authorized holds not captured in time, their funds are available again.
+	HoldExpired HoldStatus = "expired"
+)
+
+// Hold reserves funds of the account for the destination, which later captures
+// the final amount, up to the one held, or voids it. Held funds are still in
+// the ledger balance, but not in the available one.
+type Hold struct {
+	ID                  string
+	AccountID           int
+	AccountPublicID     string
+	DestinationID       int
+	DestinationPublicID string
+	Amount              int
+	CapturedAmount      int
+	Status              HoldStatus
+	// Public ID of the transfer made by the capture.
+	TransferID string
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+	FinishedAt *time.Time
+}
+
+func NewHold(accountId, destinationId, amount int, ttl time.Duration, createdAt time.Time) (*Hold, error) {
+
+	if amount <= 0 {
+		return nil, fmt.Errorf("Hold amount must be greater than zero!")
+	}
+
+	if accountId == destinationId {
+		return nil, fmt.Errorf("Hold cannot have the account as destination!")
+	}
+
+	if ttl == 0 {
+		ttl = HOLD_DEFAULT_TTL
+	}
+
+	if ttl < 0 || ttl > HOLD_MAX_TTL {
+		return nil, fmt.Errorf("Holds must expire within %s!", HOLD_MAX_TTL)
+	}
+
+	return &Hold{
+		ID:            NewPublicID(HOLD_ID_PREFIX),
+		AccountID:     accountId,
+		DestinationID: destinationId,
+		Amount:        amount,
+		Status:        HoldAuthorized,
+		ExpiresAt:     createdAt.Add(ttl),
+		CreatedAt:     createdAt,
+	}, nil
+}
+
+// StatusAt shows authorized holds past their expiry as expired, even before
+// they are marked so.
+func (h Hold) StatusAt(now time.Time) HoldStatus {
+
+	if h.Status == HoldAuthorized && !now.Before(h.ExpiresAt) {
+		return HoldExpired
+	}
+
+	return h.Status
+}
+
+// Capture takes the amount, the whole hold when 0, releasing the rest.
+func (h *Hold) Capture(amount int, now time.Time) error {
+
+	if status := h.StatusAt(now); status != HoldAuthorized {
+		return fmt.Errorf("Hold is %s, it cannot be captured!", status)
+	}
+
+	if amount == 0 {
+		amount = h.Amount
+	}
+
+	if amount < 0 || amount > h.Amount {
+		return fmt.Errorf("Capture amount must be between 1 and the amount held. Held: %d, Capture: %d", h.Amount, amount)
+	}
+
+	h.Status = HoldCaptured
+	h.CapturedAmount = amount
+	h.FinishedAt = &now
+
+	return nil
+}
+
+func (h *Hold) Void(now time.Time) error {
+
+	if status := h.StatusAt(now); status != HoldAuthorized {
+		return fmt.Errorf("Hold is %s, it cannot be voided!", status)
+	}
+
+	h.Status = HoldVoided
+	h.FinishedAt = &now
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHold(t *testing.T) {
+
+	now := time.Now()
+
+	t.Run("Should NOT be created without amount, to itself or for too long", func(t *testing.T) {
+		if _, err := NewHold(1, 2, 0, 0, now); err == nil {
+			t.Errorf("Hold without amount should NOT be accepted")
+		}
+
+		if _, err := NewHold(1, 1, 10, 0, now); err == nil {
+			t.Errorf("Hold to the account itself should NOT be accepted")
+		}
+
+		if _, err := NewHold(1, 2, 10, HOLD_MAX_TTL+time.Second, now); err == nil {
+			t.Errorf("Hold longer than the maximum should NOT be accepted")
+		}
+	})
+
+	t.Run("Should capture partially, only once", func(t *testing.T) {
+		hold, _ := NewHold(1, 2, 100, 0, now)
+
+		if err := hold.Capture(101, now); err == nil {
+			t.Errorf("Capture above the amount held should NOT be accepted")
+		}
+
+		if err := hold.Capture(60, now); err != nil || hold.CapturedAmount != 60 || hold.Status != HoldCaptured {
+			t.Fatalf("Partial capture should be accepted, got: %v, %+v", err, hold)
+		}
+
+		if err := hold.Capture(10, now); err == nil {
+			t.Errorf("Captured hold should NOT be captured again")
+		}
+
+		if err := hold.Void(now); err == nil {
+			t.Errorf("Captured hold should NOT be voided")
+		}
+	})
+
+	t.Run("Should capture the whole amount by default", func(t *testing.T) {
+		hold, _ := NewHold(1, 2, 100, 0, now)
+
+		if err := hold.Capture(0, now); err != nil || hold.CapturedAmount != 100 {
+			t.Errorf("Full capture should be accepted, got: %v, %+v", err, hold)
+		}
+	})
+
+	t.Run("Should expire", func(t *testing.T) {
+		hold, _ := NewHold(1, 2, 100, time.Hour, now)
+		later := now.Add(time.Hour)
+
+		if hold.StatusAt(later) != HoldExpired {
+			t.Errorf("Hold should be expired. Got: %s", hold.StatusAt(later))
+		}
+
+		if err := hold.Capture(0, later); err == nil {
+			t.Errorf("Expired hold should NOT be captured")
+		}
+
+		if err := hold.Void(later); err == nil {
+			t.Errorf("Expired hold should NOT be voided")
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package entity
+
+import (
+	"time"
+)
+
+const (
+	// Failures tolerated before the key gets locked.
+	LOGIN_MAX_FAILURES = 5
+	// Lock duration after the first lockout, doubled on every further failure.
+	LOGIN_BASE_LOCKOUT = 30 * time.Second
+	LOGIN_MAX_LOCKOUT  = time.Hour
+	// Failures older than this are forgotten.
+	LOGIN_FAILURE_WINDOW = 24 * time.Hour
+)
+
+// LoginAttempt tracks failed logins for a key, which is either a CPF or a
+// client IP, so both credential stuffing and CPF guessing get throttled.
+// Repositories only store a blind index of the key.
+type LoginAttempt struct {
+	Key           string
+	Failures      int
+	LockedUntil   time.Time
+	LastFailureAt time.Time
+}
+
+func NewLoginAttempt(key string) *LoginAttempt {
+	return &LoginAttempt{Key: key}
+}
+
+func (l LoginAttempt) IsLocked(now time.Time) bool {
+	return now.Before(l.LockedUntil)
+}
+
+// RegisterFailure counts a failed login and locks the key with exponential
+// backoff once LOGIN_MAX_FAILURES is reached.
+func (l *LoginAttempt) RegisterFailure(now time.Time) {
+
+	if now.Sub(l.LastFailureAt) > LOGIN_FAILURE_WINDOW {
+		l.Failures = 0
+	}
+
+	l.Failures++
+	l.LastFailureAt = now
+
+	if l.Failures < LOGIN_MAX_FAILURES {
+		return
+	}
+
+	lockout := LOGIN_BASE_LOCKOUT
+	for i := LOGIN_MAX_FAILURES; i < l.Failures && lockout < LOGIN_MAX_LOCKOUT; i++ {
+		lockout *= 2
+	}
+	lockout = min(lockout, LOGIN_MAX_LOCKOUT)
+
+	l.LockedUntil = now.Add(lockout)
+}
+
+// Attempt counts an attempt before its credentials are checked, so parallel
+// attempts cannot all get past the limit. Attempts count as failures until
+// refunded. Locked keys refuse the attempt without counting it.
+func (l *LoginAttempt) Attempt(now time.Time) bool {
+
+	if l.IsLocked(now) {
+		return false
+	}
+
+	l.RegisterFailure(now)
+
+	return true
+}
+
+// Refund forgets an attempt that succeeded, unlocking the key when it was the
+// one that reached the limit.
+func (l *LoginAttempt) Refund() {
+
+	if l.Failures > 0 {
+		l.Failures--
+	}
+
+	if l.Failures < LOGIN_MAX_FAILURES {
+		l.LockedUntil = time.Time{}
+	}
+}
@@ -0,0 +1,90 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegisterFailure(t *testing.T) {
+
+	now := time.Now().UTC()
+
+	t.Run("Should NOT lock before reaching the maximum failures", func(t *testing.T) {
+		attempt := NewLoginAttempt("cpf:15799999970")
+
+		for i := 0; i < LOGIN_MAX_FAILURES-1; i++ {
+			attempt.RegisterFailure(now)
+		}
+
+		if attempt.IsLocked(now) {
+			t.Errorf("Attempt should NOT be locked after %d failures", attempt.Failures)
+		}
+	})
+
+	t.Run("Should double the lockout on every failure after the maximum", func(t *testing.T) {
+		attempt := NewLoginAttempt("cpf:15799999970")
+
+		for i := 0; i < LOGIN_MAX_FAILURES; i++ {
+			attempt.RegisterFailure(now)
+		}
+
+		if got := attempt.LockedUntil.Sub(now); got != LOGIN_BASE_LOCKOUT {
+			t.Errorf("Expected lockout of %v, got %v", LOGIN_BASE_LOCKOUT, got)
+		}
+
+		attempt.RegisterFailure(now)
+
+		if got := attempt.LockedUntil.Sub(now); got != 2*LOGIN_BASE_LOCKOUT {
+			t.Errorf("Expected lockout of %v, got %v", 2*LOGIN_BASE_LOCKOUT, got)
+		}
+	})
+
+	t.Run("Should never lock longer than the maximum lockout", func(t *testing.T) {
+		attempt := NewLoginAttempt("ip:127.0.0.1")
+
+		for i := 0; i < 100; i++ {
+			attempt.RegisterFailure(now)
+		}
+
+		if got := attempt.LockedUntil.Sub(now); got != LOGIN_MAX_LOCKOUT {
+			t.Errorf("Expected lockout of %v, got %v", LOGIN_MAX_LOCKOUT, got)
+		}
+	})
+}
+
+func TestAttempt(t *testing.T) {
+
+	now := time.Now().UTC()
+
+	t.Run("Should refuse attempts while locked, without counting them", func(t *testing.T) {
+		attempt := NewLoginAttempt("cpf:15799999970")
+
+		for i := 0; i < LOGIN_MAX_FAILURES; i++ {
+			if !attempt.Attempt(now) {
+				t.Fatalf("Attempt %d should be allowed", i+1)
+			}
+		}
+
+		if attempt.Attempt(now) {
+			t.Errorf("Attempt should NOT be allowed once locked")
+		}
+
+		if attempt.Failures != LOGIN_MAX_FAILURES {
+			t.Errorf("Refused attempt should NOT be counted. Got %d failures", attempt.Failures)
+		}
+	})
+
+	t.Run("Should unlock when the attempt reaching the limit is refunded", func(t *testing.T) {
+		attempt := NewLoginAttempt("ip:127.0.0.1")
+
+		for i := 0; i < LOGIN_MAX_FAILURES; i++ {
+			attempt.Attempt(now)
+		}
+
+		attempt.Refund()
+
+		if attempt.IsLocked(now) || attempt.Failures != LOGIN_MAX_FAILURES-1 {
+			t.Errorf("Refunded attempt should be forgotten. Got: %+v", attempt)
+		}
+	})
+}
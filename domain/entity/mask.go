@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MaskCPF keeps only the middle digits of a CPF: "12345678901" becomes
+// "***.456.789-**". Anything that is not 11 digits is fully masked.
+func MaskCPF(cpf string) string {
+
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, cpf)
+
+	if len(digits) != 11 {
+		return "***.***.***-**"
+	}
+
+	return "***." + digits[3:6] + "." + digits[6:9] + "-**"
+}
+
+// MaskName keeps the first name and the initial of the others: "Zé Guedes"
+// becomes "Zé G*****". Enough for a payer to recognize who they pay, not
+// enough to learn full names.
+func MaskName(name string) string {
+
+	words := strings.Fields(name)
+
+	for i, word := range words {
+		if i == 0 {
+			continue
+		}
+
+		runes := []rune(word)
+		words[i] = string(runes[0]) + strings.Repeat("*", len(runes)-1)
+	}
+
+	return strings.Join(words, " ")
+}
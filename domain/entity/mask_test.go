@@ -0,0 +1,36 @@
+package entity
+
+import "testing"
+
+func TestMaskCPF(t *testing.T) {
+
+	cases := map[string]string{
+		"12345678901":    "***.456.789-**",
+		"999.888.777-60": "***.888.777-**",
+		"123":            "***.***.***-**",
+		"":               "***.***.***-**",
+	}
+
+	for cpf, expected := range cases {
+		if got := MaskCPF(cpf); got != expected {
+			t.Errorf("Wrong mask for %q. Got: %s, expected: %s", cpf, got, expected)
+		}
+	}
+}
+
+func TestMaskName(t *testing.T) {
+
+	cases := map[string]string{
+		"Zé Guedes":          "Zé G*****",
+		"Maria da Conceição": "Maria d* C********",
+		"  João   Silva ":    "João S****",
+		"Zé":                 "Zé",
+		"":                   "",
+	}
+
+	for name, expected := range cases {
+		if got := MaskName(name); got != expected {
+			t.Errorf("Wrong mask for %q. Got: %s, expected: %s", name, got, expected)
+		}
+	}
+}
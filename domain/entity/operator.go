@@ -0,0 +1,125 @@
+package entity
+
+import (
+	"fmt"
+	"hash"
+	"slices"
+	"time"
+)
+
+// Operator tokens stop working this long after login.
+const OPERATOR_TOKEN_TTL = 8 * time.Hour
+
+// Operators reach every account, so their secrets must not be guessable.
+const OPERATOR_SECRET_MIN_LENGTH = 12
+
+type Role string
+
+const (
+	RoleViewer  Role = "viewer"
+	RoleSupport Role = "support"
+	RoleAdmin   Role = "admin"
+)
+
+type Permission string
+
+const (
+	PermissionReadAccounts    Permission = "accounts:read"
+	PermissionReadTransfers   Permission = "transfers:read"
+	PermissionAdjustBalance   Permission = "accounts:adjust"
+	PermissionReadActions     Permission = "actions:read"
+	PermissionManageOperators Permission = "operators:manage"
+	// Freezing, unfreezing and closing accounts.
+	PermissionManageAccountStatus Permission = "accounts:status"
+)
+
+// Permissions every operator with the role gets. Extra permissions can be
+// granted per operator on top of these.
+var rolePermissions = map[Role][]Permission{
+	RoleViewer: {
+		PermissionReadAccounts,
+		PermissionReadTransfers,
+	},
+	RoleSupport: {
+		PermissionReadAccounts,
+		PermissionReadTransfers,
+		PermissionAdjustBalance,
+	},
+	RoleAdmin: {
+		PermissionReadAccounts,
+		PermissionReadTransfers,
+		PermissionAdjustBalance,
+		PermissionReadActions,
+		PermissionManageOperators,
+		PermissionManageAccountStatus,
+	},
+}
+
+type Operator struct {
+	ID          int
+	Name        string
+	Email       string
+	Secret      hash.Hash `redact:"secret"`
+	Role        Role
+	Permissions []Permission
+	CreatedAt   time.Time
+}
+
+func NewOperator(id int, name, email string, secret hash.Hash, role Role, permissions []Permission, createdAt time.Time) *Operator {
+	return &Operator{
+		ID:          id,
+		Name:        name,
+		Email:       email,
+		Secret:      secret,
+		Role:        role,
+		Permissions: permissions,
+		CreatedAt:   createdAt,
+	}
+}
+
+func (o Operator) IsValid() (bool, error) {
+
+	if _, ok := rolePermissions[o.Role]; !ok {
+		return false, fmt.Errorf("Unknown operator role: %s", o.Role)
+	}
+
+	if o.Email == "" {
+		return false, fmt.Errorf("Operator must have an email!")
+	}
+
+	for _, permission := range o.Permissions {
+		if !isKnownPermission(permission) {
+			return false, fmt.Errorf("Unknown operator permission: %s", permission)
+		}
+	}
+
+	return true, nil
+}
+
+// ValidateOperatorSecret checks a plain secret before it is hashed, as the
+// operator only keeps the hash.
+func ValidateOperatorSecret(secret string) error {
+
+	if len(secret) < OPERATOR_SECRET_MIN_LENGTH {
+		return fmt.Errorf("Operator secret must have at least %d characters!", OPERATOR_SECRET_MIN_LENGTH)
+	}
+
+	return nil
+}
+
+func isKnownPermission(permission Permission) bool {
+
+	for _, permissions := range rolePermissions {
+		if slices.Contains(permissions, permission) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Can reports whether the operator holds the permission, either through its
+// role or through an extra grant.
+func (o Operator) Can(permission Permission) bool {
+	return slices.Contains(rolePermissions[o.Role], permission) || slices.Contains(o.Permissions, permission)
+}
@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"testing"
+	"time"
+)
+
+func mockOperator(role Role, permissions ...Permission) *Operator {
+	return NewOperator(-1, "Mock", "mock@golearn.dev", sha256.New(), role, permissions, time.Now().UTC())
+}
+
+func TestOperatorCan(t *testing.T) {
+
+	t.Run("Viewer should read but NOT adjust balances", func(t *testing.T) {
+		operator := mockOperator(RoleViewer)
+
+		if !operator.Can(PermissionReadAccounts) {
+			t.Errorf("Viewer should be able to read accounts")
+		}
+
+		if operator.Can(PermissionAdjustBalance) {
+			t.Errorf("Viewer should NOT be able to adjust balances")
+		}
+	})
+
+	t.Run("Extra grants should add to the role permissions", func(t *testing.T) {
+		operator := mockOperator(RoleViewer, PermissionReadActions)
+
+		if !operator.Can(PermissionReadActions) {
+			t.Errorf("Viewer with an extra grant should be able to read admin actions")
+		}
+	})
+
+	t.Run("Should NOT be valid with an unknown role", func(t *testing.T) {
+		operator := mockOperator(Role("root"))
+
+		if valid, err := operator.IsValid(); valid || err == nil {
+			t.Errorf("Operator with unknown role should NOT be valid")
+		}
+	})
+	t.Run("Should NOT be valid with an unknown permission", func(t *testing.T) {
+		operator := mockOperator(RoleViewer, Permission("accounts:delete"))
+
+		if valid, err := operator.IsValid(); valid || err == nil {
+			t.Errorf("Operator with unknown permission should NOT be valid")
+		}
+	})
+}
+
+func TestValidateOperatorSecret(t *testing.T) {
+
+	t.Run("Should reject an empty secret", func(t *testing.T) {
+		if err := ValidateOperatorSecret(""); err == nil {
+			t.Errorf("Empty secret should be rejected")
+		}
+	})
+
+	t.Run("Should reject a secret shorter than the minimum", func(t *testing.T) {
+		if err := ValidateOperatorSecret("short-pass"); err == nil {
+			t.Errorf("Short secret should be rejected")
+		}
+	})
+
+	t.Run("Should accept a secret of the minimum length", func(t *testing.T) {
+		if err := ValidateOperatorSecret("twelve-chars"); err != nil {
+			t.Errorf("Secret of the minimum length should be accepted, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package entity
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+const (
+	PIX_CHARGE_ID_PREFIX = "chg"
+
+	PIX_CHARGE_DEFAULT_TTL = 30 * time.Minute
+	PIX_CHARGE_MAX_TTL     = 24 * time.Hour
+)
+
+const txidAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// PixCharge is what a dynamic BR Code points to: a single use request for a
+// fixed amount, paid to one of the holder keys before it expires.
+type PixCharge struct {
+	ID        string
+	AccountID int
+	PixKey    string
+	Amount    int
+	TxID      string
+	ExpiresAt time.Time
+	PaidAt    *time.Time
+	CreatedAt time.Time
+}
+
+func NewPixCharge(key PixKey, amount int, ttl time.Duration, createdAt time.Time) (*PixCharge, error) {
+
+	if !key.IsVerified() {
+		return nil, fmt.Errorf("Charges need a verified Pix key!")
+	}
+
+	if amount <= 0 {
+		return nil, fmt.Errorf("Charge amount must be greater than zero!")
+	}
+
+	if ttl == 0 {
+		ttl = PIX_CHARGE_DEFAULT_TTL
+	}
+
+	if ttl < 0 || ttl > PIX_CHARGE_MAX_TTL {
+		return nil, fmt.Errorf("Charges must expire within %s!", PIX_CHARGE_MAX_TTL)
+	}
+
+	txid, err := newTxID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &PixCharge{
+		ID:        NewPublicID(PIX_CHARGE_ID_PREFIX),
+		AccountID: key.AccountID,
+		PixKey:    key.Value,
+		Amount:    amount,
+		TxID:      txid,
+		ExpiresAt: createdAt.Add(ttl),
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// BRCode is the dynamic code of the charge.
+func (c PixCharge) BRCode(merchantName string) BRCode {
+	return BRCode{
+		Dynamic:      true,
+		PixKey:       c.PixKey,
+		Amount:       c.Amount,
+		MerchantName: merchantName,
+		TxID:         c.TxID,
+	}
+}
+
+// CheckPayable tells whether the code read by the payer can pay the charge.
+func (c PixCharge) CheckPayable(code BRCode, now time.Time) error {
+
+	if c.PaidAt != nil {
+		return fmt.Errorf("Charge was already paid!")
+	}
+
+	if !now.Before(c.ExpiresAt) {
+		return fmt.Errorf("Charge expired!")
+	}
+
+	if code.PixKey != c.PixKey || code.Amount != c.Amount {
+		return fmt.Errorf("BR Code does not match the charge!")
+	}
+
+	return nil
+}
+
+// newTxID is as long as the BR Code transaction ID field allows, only with
+// the characters it accepts.
+func newTxID() (string, error) {
+
+	txid := make([]byte, brCodeMaxTxID)
+	for i := range txid {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(txidAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		txid[i] = txidAlphabet[n.Int64()]
+	}
+
+	return string(txid), nil
+}
@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// PixCodeLimit counts, over PIX_KEY_CODE_WINDOW, the verification codes sent to
+// an address or by an account, and the wrong codes tried for an address. It is
+// kept apart from the keys, so deleting a key and registering it again does
+// not reset it. Repositories only store a blind index of the key.
+type PixCodeLimit struct {
+	Key         string
+	Sends       int
+	Failures    int
+	WindowStart time.Time
+}
+
+func NewPixCodeLimit(key string) *PixCodeLimit {
+	return &PixCodeLimit{Key: key}
+}
+
+func PixCodeAccountKey(accountId int) string {
+	return fmt.Sprintf("account:%d", accountId)
+}
+
+func PixCodeAddressKey(keyType PixKeyType, value string) string {
+	return fmt.Sprintf("address:%s:%s", keyType, value)
+}
+
+// Send counts a code about to be sent, refusing it once maxSends codes were
+// sent in the window.
+func (l *PixCodeLimit) Send(now time.Time, maxSends int) bool {
+
+	l.roll(now)
+
+	if l.Sends >= maxSends {
+		return false
+	}
+
+	l.Sends++
+
+	return true
+}
+
+// Attempt counts a code before it is checked, so parallel guesses cannot all
+// get past the limit. Attempts count as failures until refunded.
+func (l *PixCodeLimit) Attempt(now time.Time) bool {
+
+	l.roll(now)
+
+	if l.Failures >= PIX_KEY_CODE_MAX_FAILURES {
+		return false
+	}
+
+	l.Failures++
+
+	return true
+}
+
+// Refund forgets an attempt whose code was right.
+func (l *PixCodeLimit) Refund() {
+
+	if l.Failures > 0 {
+		l.Failures--
+	}
+}
+
+// roll starts a new window once the current one is over.
+func (l *PixCodeLimit) roll(now time.Time) {
+
+	if now.Sub(l.WindowStart) < PIX_KEY_CODE_WINDOW {
+		return
+	}
+
+	l.Sends = 0
+	l.Failures = 0
+	l.WindowStart = now
+}
@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPixCodeLimit(t *testing.T) {
+
+	now := time.Now().UTC()
+
+	t.Run("Should stop sending after too many codes", func(t *testing.T) {
+		limit := NewPixCodeLimit(PixCodeAddressKey(PixKeyEmail, "ze@example.com"))
+
+		for i := 0; i < PIX_KEY_CODE_MAX_SENDS; i++ {
+			if !limit.Send(now, PIX_KEY_CODE_MAX_SENDS) {
+				t.Fatalf("Code %d should be sent", i+1)
+			}
+		}
+
+		if limit.Send(now, PIX_KEY_CODE_MAX_SENDS) {
+			t.Errorf("Code should NOT be sent past the limit")
+		}
+
+		if !limit.Send(now.Add(PIX_KEY_CODE_WINDOW), PIX_KEY_CODE_MAX_SENDS) {
+			t.Errorf("Code should be sent once the window is over")
+		}
+	})
+
+	t.Run("Should stop accepting attempts after too many failures", func(t *testing.T) {
+		limit := NewPixCodeLimit(PixCodeAddressKey(PixKeyPhone, "+5561912345678"))
+
+		for i := 0; i < PIX_KEY_CODE_MAX_FAILURES; i++ {
+			if !limit.Attempt(now) {
+				t.Fatalf("Attempt %d should be accepted", i+1)
+			}
+		}
+
+		if limit.Attempt(now) {
+			t.Errorf("Attempt should NOT be accepted past the limit")
+		}
+	})
+
+	t.Run("Should give refunded attempts back", func(t *testing.T) {
+		limit := NewPixCodeLimit(PixCodeAccountKey(1))
+
+		limit.Attempt(now)
+		limit.Refund()
+
+		if limit.Failures != 0 {
+			t.Errorf("Refunded attempt should NOT count, got %d failures", limit.Failures)
+		}
+	})
+}
@@ -0,0 +1,238 @@
+package entity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PixKeyType string
+
+const (
+	PixKeyCPF   PixKeyType = "cpf"
+	PixKeyEmail PixKeyType = "email"
+	PixKeyPhone PixKeyType = "phone"
+	// Random key, for holders who do not want to share personal data.
+	PixKeyEVP PixKeyType = "evp"
+)
+
+const (
+	PIX_KEY_ID_PREFIX = "pix"
+
+	// Same limit the central bank sets for individuals.
+	MAX_PIX_KEYS_PER_ACCOUNT = 5
+
+	PIX_KEY_CODE_TTL = 10 * time.Minute
+	// Window the code limits are counted over, see PixCodeLimit.
+	PIX_KEY_CODE_WINDOW = 24 * time.Hour
+	// Wrong codes tolerated for an address, whichever code they were for.
+	PIX_KEY_CODE_MAX_FAILURES = 5
+	// Codes sent to an address, the first one included.
+	PIX_KEY_CODE_MAX_SENDS = 5
+	// Codes sent by an account, whichever addresses they went to.
+	PIX_KEY_CODE_MAX_ACCOUNT_SENDS = 10
+)
+
+var (
+	phoneFormat = regexp.MustCompile(`^\+[1-9][0-9]{10,13}$`)
+	cpfFormat   = regexp.MustCompile(`^[0-9]{11}$`)
+)
+
+var ErrPixKeyCodeInvalid = fmt.Errorf("Verification code is invalid or expired!")
+
+var ErrPixKeyCodeExhausted = fmt.Errorf("Too many codes or wrong attempts for this Pix key! Try again later.")
+
+// PixKey is an alias holders share so others can send them money without
+// knowing the account ID. Keys only address transfers once verified.
+type PixKey struct {
+	ID        string
+	AccountID int
+	Type      PixKeyType
+	Value     string
+
+	// Hash of the code sent to the email or phone, proving the holder owns it.
+	CodeHash      []byte `redact:"secret"`
+	CodeExpiresAt time.Time
+
+	VerifiedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// NewPixKey validates the key for the account. CPF keys must be the account
+// own CPF and random keys are generated here, both are verified right away.
+// Email and phone keys come back with the code to deliver to them.
+func NewPixKey(account Account, keyType PixKeyType, value string, createdAt time.Time) (*PixKey, string, error) {
+
+	key := &PixKey{
+		ID:        NewPublicID(PIX_KEY_ID_PREFIX),
+		AccountID: account.ID,
+		Type:      keyType,
+		CreatedAt: createdAt,
+	}
+
+	switch keyType {
+	case PixKeyEVP:
+		key.Value = uuid.NewString()
+		key.VerifiedAt = &createdAt
+		return key, "", nil
+
+	case PixKeyCPF:
+		normalized, err := NormalizePixKey(keyType, value)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if normalized != onlyDigits(account.CPF) {
+			return nil, "", fmt.Errorf("CPF keys must be the account holder own CPF!")
+		}
+
+		key.Value = normalized
+		key.VerifiedAt = &createdAt
+		return key, "", nil
+
+	case PixKeyEmail, PixKeyPhone:
+		normalized, err := NormalizePixKey(keyType, value)
+		if err != nil {
+			return nil, "", err
+		}
+
+		key.Value = normalized
+
+		code, err := key.newCode(createdAt)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return key, code, nil
+	}
+
+	return nil, "", fmt.Errorf("Unknown Pix key type: %s", keyType)
+}
+
+// NormalizePixKey puts the value in the form keys are stored, so lookups match
+// regardless of how the key was typed.
+func NormalizePixKey(keyType PixKeyType, value string) (string, error) {
+
+	value = strings.TrimSpace(value)
+
+	switch keyType {
+	case PixKeyCPF:
+		digits := onlyDigits(value)
+		if !cpfFormat.MatchString(digits) {
+			return "", fmt.Errorf("CPF key must have 11 digits!")
+		}
+		return digits, nil
+
+	case PixKeyEmail:
+		address, err := mail.ParseAddress(value)
+		if err != nil || address.Address != value || len(value) > 77 {
+			return "", fmt.Errorf("Invalid email key!")
+		}
+		return strings.ToLower(value), nil
+
+	case PixKeyPhone:
+		phone := "+" + onlyDigits(value)
+		if !strings.HasPrefix(value, "+") || !phoneFormat.MatchString(phone) {
+			return "", fmt.Errorf("Phone key must be in the international format, like +5561912345678!")
+		}
+		return phone, nil
+
+	case PixKeyEVP:
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return "", fmt.Errorf("Invalid random key!")
+		}
+		return id.String(), nil
+	}
+
+	return "", fmt.Errorf("Unknown Pix key type: %s", keyType)
+}
+
+// DetectPixKeyType guesses the type of a key typed by a payer. Phones need the
+// leading "+", otherwise they could be taken for CPFs.
+func DetectPixKeyType(value string) PixKeyType {
+
+	value = strings.TrimSpace(value)
+
+	switch {
+	case strings.Contains(value, "@"):
+		return PixKeyEmail
+	case strings.HasPrefix(value, "+"):
+		return PixKeyPhone
+	case uuid.Validate(value) == nil:
+		return PixKeyEVP
+	default:
+		return PixKeyCPF
+	}
+}
+
+func (k PixKey) IsVerified() bool {
+	return k.VerifiedAt != nil
+}
+
+// Verify checks the code sent to the key. Wrong attempts are limited per
+// address with PixCodeLimit, whichever key and code they were for.
+func (k *PixKey) Verify(code string, now time.Time) error {
+
+	if k.IsVerified() {
+		return fmt.Errorf("Pix key is already verified!")
+	}
+
+	if k.CodeHash == nil || !now.Before(k.CodeExpiresAt) {
+		return ErrPixKeyCodeInvalid
+	}
+
+	sum := sha256.Sum256([]byte(code))
+	if subtle.ConstantTimeCompare(sum[:], k.CodeHash) != 1 {
+		return ErrPixKeyCodeInvalid
+	}
+
+	k.CodeHash = nil
+	k.VerifiedAt = &now
+
+	return nil
+}
+
+// ResendCode replaces the verification code. Codes sent are limited per
+// address and per account with PixCodeLimit.
+func (k *PixKey) ResendCode(now time.Time) (string, error) {
+
+	if k.IsVerified() {
+		return "", fmt.Errorf("Pix key is already verified!")
+	}
+
+	return k.newCode(now)
+}
+
+func (k *PixKey) newCode(now time.Time) (string, error) {
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+
+	code := fmt.Sprintf("%06d", n.Int64())
+	sum := sha256.Sum256([]byte(code))
+
+	k.CodeHash = sum[:]
+	k.CodeExpiresAt = now.Add(PIX_KEY_CODE_TTL)
+
+	return code, nil
+}
+
+func onlyDigits(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+}
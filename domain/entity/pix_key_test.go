@@ -0,0 +1,93 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewPixKey(t *testing.T) {
+
+	now := time.Now().UTC()
+	account := Account{ID: 1, CPF: "12345678901"}
+
+	t.Run("Should only accept the holder own CPF", func(t *testing.T) {
+		key, code, err := NewPixKey(account, PixKeyCPF, "123.456.789-01", now)
+		if err != nil {
+			t.Fatalf("Own CPF should be accepted, got: %v", err)
+		}
+
+		if key.Value != "12345678901" || !key.IsVerified() || code != "" {
+			t.Errorf("CPF key should be normalized and verified. Got: %+v", key)
+		}
+
+		if _, _, err := NewPixKey(account, PixKeyCPF, "10987654321", now); err == nil {
+			t.Errorf("Someone else CPF should NOT be accepted")
+		}
+	})
+
+	t.Run("Should generate random keys", func(t *testing.T) {
+		key, _, err := NewPixKey(account, PixKeyEVP, "", now)
+		if err != nil || DetectPixKeyType(key.Value) != PixKeyEVP || !key.IsVerified() {
+			t.Errorf("Random key should be a verified UUID. Got: %+v, %v", key, err)
+		}
+	})
+
+	t.Run("Should NOT accept malformed keys", func(t *testing.T) {
+		cases := map[PixKeyType]string{
+			PixKeyEmail: "not an email",
+			PixKeyPhone: "61912345678",
+			"iban":      "whatever",
+		}
+
+		for keyType, value := range cases {
+			if _, _, err := NewPixKey(account, keyType, value, now); err == nil {
+				t.Errorf("%s key %q should NOT be accepted", keyType, value)
+			}
+		}
+	})
+
+	t.Run("Should normalize email and phone keys", func(t *testing.T) {
+		email, _, _ := NewPixKey(account, PixKeyEmail, " Ze@Example.com ", now)
+		phone, _, _ := NewPixKey(account, PixKeyPhone, "+55 (61) 91234-5678", now)
+
+		if email.Value != "ze@example.com" || phone.Value != "+5561912345678" {
+			t.Errorf("Keys were not normalized. Got: %q, %q", email.Value, phone.Value)
+		}
+	})
+}
+
+func TestPixKeyVerify(t *testing.T) {
+
+	now := time.Now().UTC()
+	account := Account{ID: 1, CPF: "12345678901"}
+
+	t.Run("Should verify with the code sent", func(t *testing.T) {
+		key, code, _ := NewPixKey(account, PixKeyEmail, "ze@example.com", now)
+
+		if key.IsVerified() {
+			t.Fatalf("Email key should wait for the code")
+		}
+
+		if err := key.Verify(code, now); err != nil || !key.IsVerified() {
+			t.Errorf("Right code should verify the key, got: %v", err)
+		}
+	})
+
+	t.Run("Should accept the new code after a resend", func(t *testing.T) {
+		key, _, _ := NewPixKey(account, PixKeyPhone, "+5561912345678", now)
+
+		code, _ := key.ResendCode(now)
+
+		if err := key.Verify(code, now); err != nil {
+			t.Errorf("New code should be accepted, got: %v", err)
+		}
+	})
+
+	t.Run("Should NOT accept expired codes", func(t *testing.T) {
+		key, code, _ := NewPixKey(account, PixKeyEmail, "ze@example.com", now)
+
+		if err := key.Verify(code, now.Add(PIX_KEY_CODE_TTL)); err != ErrPixKeyCodeInvalid {
+			t.Errorf("Expired code should NOT be accepted, got: %v", err)
+		}
+	})
+}
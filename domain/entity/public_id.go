@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ACCOUNT_ID_PREFIX  = "acc"
+	TRANSFER_ID_PREFIX = "trf"
+)
+
+// NewPublicID returns the opaque ID shown to clients in place of the internal
+// sequential one, as "acc_<UUIDv7 hex>". UUIDv7 keeps them sortable by
+// creation without revealing how many rows exist.
+func NewPublicID(prefix string) string {
+	id := uuid.Must(uuid.NewV7())
+	return prefix + "_" + hex.EncodeToString(id[:])
+}
+
+// IsPublicID checks the format only, the ID may still not exist.
+func IsPublicID(prefix, id string) bool {
+
+	raw, found := strings.CutPrefix(id, prefix+"_")
+	if !found || len(raw) != 32 {
+		return false
+	}
+
+	_, err := hex.DecodeString(raw)
+	return err == nil
+}
@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"testing"
+)
+
+func TestPublicID(t *testing.T) {
+
+	t.Run("Should create distinct IDs with the prefix", func(t *testing.T) {
+		first := NewPublicID(ACCOUNT_ID_PREFIX)
+		second := NewPublicID(ACCOUNT_ID_PREFIX)
+
+		if first == second {
+			t.Errorf("Public IDs should be unique, got %s twice", first)
+		}
+
+		if !IsPublicID(ACCOUNT_ID_PREFIX, first) {
+			t.Errorf("%s should be a valid account public ID", first)
+		}
+	})
+
+	t.Run("Should NOT accept IDs of another kind or sequential IDs", func(t *testing.T) {
+		for _, id := range []string{NewPublicID(TRANSFER_ID_PREFIX), "42", "acc_42", ""} {
+			if IsPublicID(ACCOUNT_ID_PREFIX, id) {
+				t.Errorf("%q should NOT be a valid account public ID", id)
+			}
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package entity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+const (
+	SECRET_RESET_TOKEN_SIZE = 32
+	SECRET_RESET_TOKEN_TTL  = 15 * time.Minute
+)
+
+var ErrSecretResetTokenInvalid = fmt.Errorf("Secret reset token is invalid or expired!")
+
+// SecretResetToken lets a holder who forgot the secret set a new one. Only the
+// hash of the token is stored, the token itself goes to the holder through a
+// notifier.
+type SecretResetToken struct {
+	Hash      []byte `redact:"secret"`
+	AccountID int
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// NewSecretResetToken returns the token to be delivered along with what gets
+// stored of it.
+func NewSecretResetToken(accountID int, createdAt time.Time) (string, *SecretResetToken, error) {
+
+	raw := make([]byte, SECRET_RESET_TOKEN_SIZE)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+
+	token := hex.EncodeToString(raw)
+
+	return token, &SecretResetToken{
+		Hash:      HashSecretResetToken(token),
+		AccountID: accountID,
+		ExpiresAt: createdAt.Add(SECRET_RESET_TOKEN_TTL),
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// HashSecretResetToken is enough as a plain SHA-256, tokens are long random
+// strings and cannot be guessed like a secret.
+func HashSecretResetToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// Use marks the token as used. Used and expired tokens are rejected alike.
+func (t *SecretResetToken) Use(now time.Time) error {
+
+	if t.UsedAt != nil || !now.Before(t.ExpiresAt) {
+		return ErrSecretResetTokenInvalid
+	}
+
+	t.UsedAt = &now
+
+	return nil
+}
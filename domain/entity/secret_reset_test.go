@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestSecretResetToken(t *testing.T) {
+
+	now := time.Now().UTC()
+
+	t.Run("Should only store the token hash", func(t *testing.T) {
+		token, stored, err := NewSecretResetToken(1, now)
+		if err != nil {
+			t.Fatalf("Could not create token: %v", err)
+		}
+
+		if bytes.Equal(stored.Hash, []byte(token)) || !bytes.Equal(stored.Hash, HashSecretResetToken(token)) {
+			t.Errorf("Stored hash should be the token hash. Got: %x", stored.Hash)
+		}
+	})
+
+	t.Run("Should be used only once", func(t *testing.T) {
+		_, stored, _ := NewSecretResetToken(1, now)
+
+		if err := stored.Use(now); err != nil {
+			t.Errorf("Fresh token should be accepted, got: %v", err)
+		}
+
+		if err := stored.Use(now); err != ErrSecretResetTokenInvalid {
+			t.Errorf("Used token should NOT be accepted, got: %v", err)
+		}
+	})
+
+	t.Run("Should NOT be used after expiring", func(t *testing.T) {
+		_, stored, _ := NewSecretResetToken(1, now)
+
+		if err := stored.Use(now.Add(SECRET_RESET_TOKEN_TTL)); err != ErrSecretResetTokenInvalid {
+			t.Errorf("Expired token should NOT be accepted, got: %v", err)
+		}
+	})
+}
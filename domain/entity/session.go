@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Sessions end this long after login, however often they are used.
+const SESSION_TTL = 7 * 24 * time.Hour
+
+// Session is one logged in device of an account. The token is only known by
+// the device, only its hash is stored. The ID is what gets shown when listing
+// sessions.
+type Session struct {
+	ID         string
+	AccountID  int
+	TokenHash  string `redact:"secret"`
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+}
+
+func NewSession(id string, accountID int, token, userAgent, ip string, createdAt time.Time) *Session {
+	return &Session{
+		ID:         id,
+		AccountID:  accountID,
+		TokenHash:  HashSessionToken(token),
+		UserAgent:  userAgent,
+		IP:         ip,
+		CreatedAt:  createdAt,
+		LastUsedAt: createdAt,
+		ExpiresAt:  createdAt.Add(SESSION_TTL),
+	}
+}
+
+// HashSessionToken is a plain SHA-256, tokens are random UUIDs and cannot be
+// guessed like a secret.
+func HashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s Session) IsExpired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSession(t *testing.T) {
+
+	now := time.Now().UTC()
+	session := NewSession("id", 1, "token", "phone", "10.0.0.1", now)
+
+	if session.TokenHash == "token" || session.TokenHash != HashSessionToken("token") {
+		t.Errorf("Session should only keep the hash of the token. Got: %s", session.TokenHash)
+	}
+
+	if session.IsExpired(now.Add(SESSION_TTL - time.Second)) {
+		t.Errorf("Session should NOT expire before its TTL")
+	}
+
+	if !session.IsExpired(now.Add(SESSION_TTL)) {
+		t.Errorf("Session should expire after its TTL")
+	}
+}
@@ -0,0 +1,100 @@
+package entity
+
+import (
+	"fmt"
+	"sort"
+)
+
+type SplitRuleType string
+
+const (
+	// Fixed rules take their value, in cents, off the top.
+	SplitFixed SplitRuleType = "fixed"
+	// Percentage rules share what is left after the fixed ones, their value
+	// is in basis points.
+	SplitPercentage SplitRuleType = "percentage"
+
+	SPLIT_PERCENTAGE_BASE = 10000
+	MAX_SPLIT_RULES       = 20
+)
+
+type SplitRule struct {
+	Type  SplitRuleType
+	Value int
+}
+
+// SplitAmount returns the share of each rule, in the order of the rules. The
+// shares always add up to the amount: cents left over by the percentages go,
+// one each, to the rules with the largest fractions, the first rule winning
+// ties.
+func SplitAmount(amount int, rules []SplitRule) ([]int, error) {
+
+	if amount <= 0 {
+		return nil, fmt.Errorf("Split amount cannot be less than 1.")
+	}
+
+	if len(rules) == 0 || len(rules) > MAX_SPLIT_RULES {
+		return nil, fmt.Errorf("A split must have between 1 and %d rules!", MAX_SPLIT_RULES)
+	}
+
+	fixed := 0
+	percentage := 0
+	for i, rule := range rules {
+		if rule.Value <= 0 {
+			return nil, fmt.Errorf("Split rule %d: value cannot be less than 1.", i+1)
+		}
+
+		switch rule.Type {
+		case SplitFixed:
+			fixed += rule.Value
+		case SplitPercentage:
+			percentage += rule.Value
+		default:
+			return nil, fmt.Errorf("Split rule %d: type must be %s or %s!", i+1, SplitFixed, SplitPercentage)
+		}
+	}
+
+	if fixed > amount {
+		return nil, fmt.Errorf("Fixed split rules add up to more than the amount!")
+	}
+
+	if percentage == 0 && fixed != amount {
+		return nil, fmt.Errorf("Fixed split rules must add up to the amount when there are no percentage rules!")
+	}
+
+	if percentage != 0 && percentage != SPLIT_PERCENTAGE_BASE {
+		return nil, fmt.Errorf("Percentage split rules must add up to 100%%!")
+	}
+
+	remaining := amount - fixed
+	shares := make([]int, len(rules))
+	fractions := []int{}
+	allocated := 0
+
+	for i, rule := range rules {
+		if rule.Type == SplitFixed {
+			shares[i] = rule.Value
+			continue
+		}
+
+		shares[i] = remaining * rule.Value / SPLIT_PERCENTAGE_BASE
+		allocated += shares[i]
+		fractions = append(fractions, i)
+	}
+
+	sort.SliceStable(fractions, func(a, b int) bool {
+		return remaining*rules[fractions[a]].Value%SPLIT_PERCENTAGE_BASE > remaining*rules[fractions[b]].Value%SPLIT_PERCENTAGE_BASE
+	})
+
+	for i := 0; i < remaining-allocated; i++ {
+		shares[fractions[i]]++
+	}
+
+	for i, share := range shares {
+		if share == 0 {
+			return nil, fmt.Errorf("Split rule %d: share would be zero!", i+1)
+		}
+	}
+
+	return shares, nil
+}
@@ -0,0 +1,63 @@
+package entity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitAmount(t *testing.T) {
+
+	t.Run("Should take fixed values first and share the rest by percentage", func(t *testing.T) {
+		shares, err := SplitAmount(10000, []SplitRule{
+			{Type: SplitPercentage, Value: 9000},
+			{Type: SplitFixed, Value: 500},
+			{Type: SplitPercentage, Value: 1000},
+		})
+		if err != nil {
+			t.Fatalf("Split should be valid, got: %v", err)
+		}
+
+		if !reflect.DeepEqual(shares, []int{8550, 500, 950}) {
+			t.Errorf("Wrong shares. Got: %v", shares)
+		}
+	})
+
+	t.Run("Should give remainder cents to the largest fractions, first rule on ties", func(t *testing.T) {
+		shares, _ := SplitAmount(100, []SplitRule{
+			{Type: SplitPercentage, Value: 3333},
+			{Type: SplitPercentage, Value: 3333},
+			{Type: SplitPercentage, Value: 3334},
+		})
+
+		if !reflect.DeepEqual(shares, []int{33, 33, 34}) {
+			t.Errorf("Wrong shares. Got: %v", shares)
+		}
+
+		shares, _ = SplitAmount(10, []SplitRule{
+			{Type: SplitPercentage, Value: 5000},
+			{Type: SplitPercentage, Value: 2500},
+			{Type: SplitPercentage, Value: 2500},
+		})
+
+		if !reflect.DeepEqual(shares, []int{5, 3, 2}) {
+			t.Errorf("Wrong shares. Got: %v", shares)
+		}
+	})
+
+	t.Run("Should NOT accept rules not adding up to the amount", func(t *testing.T) {
+		invalid := [][]SplitRule{
+			{{Type: SplitFixed, Value: 50}},
+			{{Type: SplitFixed, Value: 150}},
+			{{Type: SplitPercentage, Value: 5000}},
+			{{Type: SplitFixed, Value: 100}, {Type: SplitPercentage, Value: 10000}},
+			{{Type: "whatever", Value: 100}},
+			{{Type: SplitPercentage, Value: 9999}, {Type: SplitPercentage, Value: 1}},
+		}
+
+		for _, rules := range invalid {
+			if _, err := SplitAmount(100, rules); err == nil {
+				t.Errorf("Rules should NOT be accepted: %+v", rules)
+			}
+		}
+	})
+}
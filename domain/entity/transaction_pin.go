@@ -0,0 +1,78 @@
+package entity
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	PIN_MAX_FAILURES = 3
+	// Lock duration after the first lockout, doubled on every further failure.
+	PIN_LOCKOUT     = 30 * time.Minute
+	PIN_MAX_LOCKOUT = 24 * time.Hour
+)
+
+var pinFormat = regexp.MustCompile(`^[0-9]{4,6}$`)
+
+var ErrPinLocked = fmt.Errorf("Transaction PIN is locked after too many wrong attempts!")
+
+// TransactionPin is the short code, distinct from the login secret, required
+// to move money out of the account.
+type TransactionPin struct {
+	AccountID   int
+	Hash        []byte `redact:"secret"`
+	Failures    int
+	LockedUntil time.Time
+	UpdatedAt   time.Time
+}
+
+func NewTransactionPin(accountID int, pin string, updatedAt time.Time) (*TransactionPin, error) {
+
+	if !pinFormat.MatchString(pin) {
+		return nil, fmt.Errorf("Transaction PIN must have 4 to 6 digits!")
+	}
+
+	// bcrypt, as a few digits PIN would be trivial to brute force from a fast hash.
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TransactionPin{
+		AccountID: accountID,
+		Hash:      hash,
+		UpdatedAt: updatedAt,
+	}, nil
+}
+
+// Verify checks the PIN, counting wrong attempts and locking the PIN once
+// PIN_MAX_FAILURES is reached. Failures are only forgotten after the right PIN,
+// so every wrong attempt after a lockout locks the PIN for longer.
+func (p *TransactionPin) Verify(pin string, now time.Time) error {
+
+	if now.Before(p.LockedUntil) {
+		return ErrPinLocked
+	}
+
+	if bcrypt.CompareHashAndPassword(p.Hash, []byte(pin)) != nil {
+		p.Failures++
+
+		if p.Failures >= PIN_MAX_FAILURES {
+			lockout := PIN_LOCKOUT
+			for i := PIN_MAX_FAILURES; i < p.Failures && lockout < PIN_MAX_LOCKOUT; i++ {
+				lockout *= 2
+			}
+
+			p.LockedUntil = now.Add(min(lockout, PIN_MAX_LOCKOUT))
+			return ErrPinLocked
+		}
+
+		return fmt.Errorf("Wrong transaction PIN! %d attempts left.", PIN_MAX_FAILURES-p.Failures)
+	}
+
+	p.Failures = 0
+	return nil
+}
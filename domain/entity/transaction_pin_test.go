@@ -0,0 +1,65 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransactionPin(t *testing.T) {
+
+	now := time.Now().UTC()
+
+	t.Run("Should NOT accept a PIN with letters or wrong length", func(t *testing.T) {
+		for _, pin := range []string{"123", "1234567", "12a4", ""} {
+			if _, err := NewTransactionPin(1, pin, now); err == nil {
+				t.Errorf("PIN %q should NOT be accepted", pin)
+			}
+		}
+	})
+
+	t.Run("Should lock after too many wrong attempts", func(t *testing.T) {
+		pin, _ := NewTransactionPin(1, "1234", now)
+
+		var err error
+		for i := 0; i < PIN_MAX_FAILURES; i++ {
+			err = pin.Verify("0000", now)
+		}
+
+		if err != ErrPinLocked {
+			t.Errorf("PIN should be locked, got: %v", err)
+		}
+
+		if err := pin.Verify("1234", now); err != ErrPinLocked {
+			t.Errorf("Right PIN should NOT be accepted while locked, got: %v", err)
+		}
+
+		if err := pin.Verify("1234", now.Add(PIN_LOCKOUT)); err != nil {
+			t.Errorf("Right PIN should be accepted after the lockout, got: %v", err)
+		}
+	})
+
+	t.Run("Should lock for longer on every wrong attempt after a lockout", func(t *testing.T) {
+		pin, _ := NewTransactionPin(1, "1234", now)
+
+		for i := 0; i < PIN_MAX_FAILURES; i++ {
+			pin.Verify("0000", now)
+		}
+
+		afterLockout := now.Add(PIN_LOCKOUT)
+		if err := pin.Verify("0000", afterLockout); err != ErrPinLocked {
+			t.Errorf("PIN should be locked again, got: %v", err)
+		}
+
+		if err := pin.Verify("1234", afterLockout.Add(PIN_LOCKOUT)); err != ErrPinLocked {
+			t.Errorf("Second lockout should last longer than the first, got: %v", err)
+		}
+
+		if err := pin.Verify("1234", afterLockout.Add(2*PIN_LOCKOUT)); err != nil {
+			t.Errorf("Right PIN should be accepted after the second lockout, got: %v", err)
+		}
+
+		if pin.Failures != 0 {
+			t.Errorf("Failures should be forgotten after the right PIN, got %d", pin.Failures)
+		}
+	})
+}
@@ -5,12 +5,63 @@ import (
 	"time"
 )
 
+type TransferKind string
+
+const (
+	TransferKindTransfer TransferKind = "transfer"
+	// Boleto payments have no destination account when the boleto is from
+	// another bank.
+	TransferKindBoletoPayment TransferKind = "boleto_payment"
+	// A split payment is a single debit, with no destination, credited to
+	// each receiver by a split credit pointing back to it.
+	TransferKindSplit       TransferKind = "split"
+	TransferKindSplitCredit TransferKind = "split_credit"
+	// Money into an escrow has no destination, the bank holds it. Coming out
+	// it goes to the payee, or back to the payer.
+	TransferKindEscrowDeposit TransferKind = "escrow_deposit"
+	TransferKindEscrowRelease TransferKind = "escrow_release"
+	TransferKindEscrowRefund  TransferKind = "escrow_refund"
+	// Fees go to the revenue account, pointing back to the transfer charged.
+	TransferKindFee TransferKind = "fee"
+)
+
+// SentTransferKinds take money out of the origin account, what the daily limit
+// counts. Split credits are counted in their split payment, escrow releases and
+// refunds in their deposit, and fees are not sent by the holder.
+var SentTransferKinds = []TransferKind{TransferKindTransfer, TransferKindBoletoPayment, TransferKindSplit, TransferKindEscrowDeposit}
+
+// ErrTransferInDoubt is returned when the transfer may have been made, as its
+// database transaction failed committing. What it paid must not be released.
+var ErrTransferInDoubt = fmt.Errorf("Transfer may have been made, check the account statement before trying again!")
+
 type Transfer struct {
 	ID                   int
 	AccountOriginID      int
 	AccountDestinationID int
 	Amount               int
 	CreatedAt            time.Time
+
+	Kind TransferKind
+	// Barcode of the boleto paid, only for boleto payments.
+	Barcode string
+	// Split payment a split credit is part of, or transfer a fee was
+	// charged on.
+	ParentID int
+	// Escrow the money went into or came out of.
+	EscrowID string
+	// Fee charged on the transfer, made with it as its own transfer.
+	Fee *Transfer
+	// How the fee is priced when the transfer is made, nil when it has none.
+	FeeCharge *FeeCharge
+	// Most the origin may send, checked again as the transfer is made, nil
+	// when unlimited.
+	Limit *TransferLimit
+
+	// Opaque IDs of the transfer and of both accounts, shown to clients.
+	PublicID                   string
+	AccountOriginPublicID      string
+	AccountDestinationPublicID string
+	ParentPublicID             string
 }
 
 func NewTransfer(id, accountOriginID, accountDestinationID, amount int, createdAt time.Time) *Transfer {
@@ -20,6 +71,7 @@ func NewTransfer(id, accountOriginID, accountDestinationID, amount int, createdA
 		AccountDestinationID: accountDestinationID,
 		Amount:               amount,
 		CreatedAt:            createdAt,
+		Kind:                 TransferKindTransfer,
 	}
 }
 
@@ -35,3 +87,36 @@ func (t Transfer) IsValid() (bool, error) {
 
 	return true, nil
 }
+
+// TransferLimit is the most an account may send, in any of the
+// SentTransferKinds, since the time given, the start of the day for the daily limit.
+type TransferLimit struct {
+	Amount int
+	Since  time.Time
+}
+
+// Remaining is what the account may still send, having sent the amount given.
+func (l TransferLimit) Remaining(sent int) int {
+
+	if sent >= l.Amount {
+		return 0
+	}
+
+	return l.Amount - sent
+}
+
+// StartOfDay is when the transfers of the day containing now start being
+// counted for the daily limit.
+func StartOfDay(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+// TransferFilter narrows a search over transfers of every account. Zero values
+// mean "no restriction".
+type TransferFilter struct {
+	AccountID int
+	MinAmount int
+	MaxAmount int
+	From      time.Time
+	To        time.Time
+}
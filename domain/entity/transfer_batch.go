@@ -0,0 +1,152 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	TRANSFER_BATCH_ID_PREFIX = "bat"
+
+	MAX_TRANSFER_BATCH_ITEMS = 1000
+)
+
+type TransferBatchMode string
+
+const (
+	// Every transfer is made or none is.
+	BatchAtomic TransferBatchMode = "atomic"
+	// Each transfer is made on its own, failures do not stop the others.
+	BatchBestEffort TransferBatchMode = "best_effort"
+)
+
+type TransferBatchStatus string
+
+const (
+	BatchPending    TransferBatchStatus = "pending"
+	BatchProcessing TransferBatchStatus = "processing"
+	BatchCompleted  TransferBatchStatus = "completed"
+	// Best effort batches where only some of the transfers were made.
+	BatchPartiallyCompleted TransferBatchStatus = "partially_completed"
+	BatchFailed             TransferBatchStatus = "failed"
+)
+
+type TransferBatchItemStatus string
+
+const (
+	BatchItemPending   TransferBatchItemStatus = "pending"
+	BatchItemSucceeded TransferBatchItemStatus = "succeeded"
+	BatchItemFailed    TransferBatchItemStatus = "failed"
+	// The transfer may have been made, the holder must check the account
+	// statement before sending it again.
+	BatchItemInDoubt TransferBatchItemStatus = "in_doubt"
+)
+
+// TransferBatchItem keeps the destination already resolved, the CPF or Pix key
+// it was addressed by is not stored.
+type TransferBatchItem struct {
+	DestinationID       int
+	DestinationPublicID string
+	Amount              int
+	Status              TransferBatchItemStatus
+	Error               string
+	// Public ID of the transfer made.
+	TransferID string
+}
+
+// Fail records why the item was not made, or that it may have been when the
+// error is ErrTransferInDoubt.
+func (i *TransferBatchItem) Fail(err error) {
+
+	i.Status = BatchItemFailed
+	if errors.Is(err, ErrTransferInDoubt) {
+		i.Status = BatchItemInDoubt
+	}
+
+	i.Error = err.Error()
+}
+
+// TransferBatch is a list of transfers from the same origin, processed in the
+// background after being validated.
+type TransferBatch struct {
+	ID         string
+	AccountID  int
+	Mode       TransferBatchMode
+	Status     TransferBatchStatus
+	Items      []TransferBatchItem
+	CreatedAt  time.Time
+	FinishedAt *time.Time
+}
+
+func NewTransferBatch(accountId int, mode TransferBatchMode, items []TransferBatchItem, createdAt time.Time) (*TransferBatch, error) {
+
+	if mode != BatchAtomic && mode != BatchBestEffort {
+		return nil, fmt.Errorf("Batch mode must be %s or %s!", BatchAtomic, BatchBestEffort)
+	}
+
+	if len(items) == 0 || len(items) > MAX_TRANSFER_BATCH_ITEMS {
+		return nil, fmt.Errorf("Batch must have between 1 and %d transfers!", MAX_TRANSFER_BATCH_ITEMS)
+	}
+
+	for i := range items {
+		items[i].Status = BatchItemPending
+	}
+
+	return &TransferBatch{
+		ID:        NewPublicID(TRANSFER_BATCH_ID_PREFIX),
+		AccountID: accountId,
+		Mode:      mode,
+		Status:    BatchPending,
+		Items:     items,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+func (b TransferBatch) Total() int {
+
+	total := 0
+	for _, item := range b.Items {
+		total += item.Amount
+	}
+
+	return total
+}
+
+// Interrupt fails the items not made yet and finishes the batch, when it
+// cannot be processed to the end. The item being made when it stopped may have
+// gone through, so the holder is told to check before sending it again.
+func (b *TransferBatch) Interrupt(now time.Time) {
+
+	for i := range b.Items {
+		if b.Items[i].Status == BatchItemPending {
+			b.Items[i].Status = BatchItemFailed
+			b.Items[i].Error = "Interrupted before being made, check the account statement before sending it again."
+		}
+	}
+
+	b.Finish(now)
+}
+
+// Finish sets the batch status from the result of its items. Items in doubt
+// are not counted as made.
+func (b *TransferBatch) Finish(now time.Time) {
+
+	succeeded := 0
+	for _, item := range b.Items {
+		if item.Status == BatchItemSucceeded {
+			succeeded++
+		}
+	}
+
+	switch succeeded {
+	case len(b.Items):
+		b.Status = BatchCompleted
+	case 0:
+		b.Status = BatchFailed
+	default:
+		b.Status = BatchPartiallyCompleted
+	}
+
+	b.FinishedAt = &now
+}
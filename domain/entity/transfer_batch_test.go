@@ -0,0 +1,74 @@
+package entity
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTransferBatch(t *testing.T) {
+
+	now := time.Now()
+
+	t.Run("Should NOT be created with an unknown mode or without items", func(t *testing.T) {
+		if _, err := NewTransferBatch(1, "whatever", []TransferBatchItem{{Amount: 1}}, now); err == nil {
+			t.Errorf("Unknown mode should NOT be accepted")
+		}
+
+		if _, err := NewTransferBatch(1, BatchAtomic, nil, now); err == nil {
+			t.Errorf("Empty batch should NOT be accepted")
+		}
+	})
+
+	t.Run("Should finish with the status of its items", func(t *testing.T) {
+		batch, _ := NewTransferBatch(1, BatchBestEffort, []TransferBatchItem{{Amount: 10}, {Amount: 20}}, now)
+
+		if batch.Total() != 30 {
+			t.Errorf("Wrong total. Got: %d, expected: 30", batch.Total())
+		}
+
+		batch.Items[0].Status = BatchItemSucceeded
+		batch.Items[1].Status = BatchItemFailed
+		batch.Finish(now)
+
+		if batch.Status != BatchPartiallyCompleted || batch.FinishedAt == nil {
+			t.Errorf("Batch should be partially completed. Got: %s", batch.Status)
+		}
+
+		batch.Items[1].Status = BatchItemSucceeded
+		batch.Finish(now)
+
+		if batch.Status != BatchCompleted {
+			t.Errorf("Batch should be completed. Got: %s", batch.Status)
+		}
+	})
+
+	t.Run("Should fail only the items not made when interrupted", func(t *testing.T) {
+		batch, _ := NewTransferBatch(1, BatchBestEffort, []TransferBatchItem{{Amount: 10}, {Amount: 20}}, now)
+
+		batch.Items[0].Status = BatchItemSucceeded
+		batch.Interrupt(now)
+
+		if batch.Items[0].Status != BatchItemSucceeded || batch.Items[1].Status != BatchItemFailed || batch.Items[1].Error == "" {
+			t.Errorf("Only the pending item should fail. Got: %+v", batch.Items)
+		}
+
+		if batch.Status != BatchPartiallyCompleted || batch.FinishedAt == nil {
+			t.Errorf("Batch should be finished as partially completed. Got: %s", batch.Status)
+		}
+	})
+	t.Run("Should keep apart the items that may have been made", func(t *testing.T) {
+		batch, _ := NewTransferBatch(1, BatchAtomic, []TransferBatchItem{{Amount: 10}, {Amount: 20}}, now)
+
+		batch.Items[0].Fail(fmt.Errorf("Could not create transfer! Err: %w", ErrTransferInDoubt))
+		batch.Items[1].Fail(fmt.Errorf("Insufficient funds"))
+
+		if batch.Items[0].Status != BatchItemInDoubt || batch.Items[0].Error == "" {
+			t.Errorf("Item should be in doubt. Got: %+v", batch.Items[0])
+		}
+
+		if batch.Items[1].Status != BatchItemFailed {
+			t.Errorf("Item should be failed. Got: %+v", batch.Items[1])
+		}
+	})
+}
@@ -0,0 +1,170 @@
+package entity
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+)
+
+const (
+	TOTP_PERIOD      = 30 * time.Second
+	TOTP_DIGITS      = 6
+	TOTP_SECRET_SIZE = 20
+	// Steps accepted before and after the current one, to tolerate clock drift.
+	TOTP_SKEW = 1
+
+	RECOVERY_CODES_COUNT = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TwoFactor is the TOTP (RFC 6238) enrolment of an account. It only protects
+// the account after being confirmed with a first valid code.
+type TwoFactor struct {
+	AccountID int
+	Secret    []byte `redact:"secret"`
+	Enabled   bool
+	// SHA-256 of the recovery codes not used yet.
+	RecoveryCodes []string `redact:"secret"`
+	// Last accepted time step, a code cannot be used twice.
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+// NewTwoFactor generates a fresh secret and recovery codes. The plain recovery
+// codes are returned so they can be shown once to the account holder.
+func NewTwoFactor(accountID int, createdAt time.Time) (*TwoFactor, []string, error) {
+
+	secret := make([]byte, TOTP_SECRET_SIZE)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, nil, err
+	}
+
+	codes := []string{}
+	hashes := []string{}
+	for i := 0; i < RECOVERY_CODES_COUNT; i++ {
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+
+		code := hex.EncodeToString(raw)
+		code = code[:5] + "-" + code[5:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return &TwoFactor{
+		AccountID:     accountID,
+		Secret:        secret,
+		RecoveryCodes: hashes,
+		CreatedAt:     createdAt,
+	}, codes, nil
+}
+
+func (t TwoFactor) EncodedSecret() string {
+	return totpEncoding.EncodeToString(t.Secret)
+}
+
+// ProvisioningURI is the otpauth:// URI authenticator apps read from a QR code.
+func (t TwoFactor) ProvisioningURI(issuer, accountName string) string {
+
+	label := url.PathEscape(fmt.Sprintf("%s:%s", issuer, accountName))
+
+	query := url.Values{}
+	query.Set("secret", t.EncodedSecret())
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprintf("%d", TOTP_DIGITS))
+	query.Set("period", fmt.Sprintf("%d", int(TOTP_PERIOD.Seconds())))
+
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, query.Encode())
+}
+
+// VerifyCode accepts a TOTP code of the current step, or of a neighbour step
+// within TOTP_SKEW, that was not used before.
+func (t *TwoFactor) VerifyCode(code string, now time.Time) error {
+
+	current := now.Unix() / int64(TOTP_PERIOD.Seconds())
+
+	for step := current - TOTP_SKEW; step <= current+TOTP_SKEW; step++ {
+		if step <= t.LastUsedStep {
+			continue
+		}
+
+		expected := totpAt(t.Secret, step)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			t.LastUsedStep = step
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Invalid two-factor code!")
+}
+
+// UseRecoveryCode consumes a recovery code, each one works only once.
+func (t *TwoFactor) UseRecoveryCode(code string) error {
+
+	hashed := hashRecoveryCode(code)
+
+	idx := slices.Index(t.RecoveryCodes, hashed)
+	if idx < 0 {
+		return fmt.Errorf("Invalid recovery code!")
+	}
+
+	t.RecoveryCodes = slices.Delete(t.RecoveryCodes, idx, idx+1)
+
+	return nil
+}
+
+// GenerateTOTP returns the code for the instant, as an authenticator app would.
+func GenerateTOTP(secret []byte, at time.Time) string {
+	return totpAt(secret, at.Unix()/int64(TOTP_PERIOD.Seconds()))
+}
+
+// totpAt is HOTP (RFC 4226) with the time step as counter.
+func totpAt(secret []byte, step int64) string {
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	truncated := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < TOTP_DIGITS; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", TOTP_DIGITS, truncated%mod)
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoginChallenge is the pending second step of a login of an account with
+// two-factor enabled.
+type LoginChallenge struct {
+	ID        string
+	AccountID int
+	UserAgent string
+	IP        string
+	Attempts  int
+	ExpiresAt time.Time
+}
@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGenerateTOTP(t *testing.T) {
+
+	// RFC 6238 appendix B vectors for SHA1, truncated to 6 digits.
+	secret := []byte("12345678901234567890")
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+
+	for unix, expected := range vectors {
+		got := GenerateTOTP(secret, time.Unix(unix, 0))
+
+		if got != expected {
+			t.Errorf("Wrong TOTP at %d. Got: %s, expected: %s", unix, got, expected)
+		}
+	}
+}
+
+func TestVerifyCode(t *testing.T) {
+
+	now := time.Now().UTC()
+
+	t.Run("Should accept a code only once", func(t *testing.T) {
+		twoFactor, _, _ := NewTwoFactor(1, now)
+		code := GenerateTOTP(twoFactor.Secret, now)
+
+		if err := twoFactor.VerifyCode(code, now); err != nil {
+			t.Errorf("Valid code should be accepted. Err: %v", err)
+		}
+
+		if err := twoFactor.VerifyCode(code, now); err == nil {
+			t.Errorf("Code should NOT be accepted twice")
+		}
+	})
+
+	t.Run("Should NOT accept a code far from the current step", func(t *testing.T) {
+		twoFactor, _, _ := NewTwoFactor(1, now)
+		code := GenerateTOTP(twoFactor.Secret, now.Add(-5*TOTP_PERIOD))
+
+		if err := twoFactor.VerifyCode(code, now); err == nil {
+			t.Errorf("Old code should NOT be accepted")
+		}
+	})
+
+	t.Run("Should consume recovery codes", func(t *testing.T) {
+		twoFactor, codes, _ := NewTwoFactor(1, now)
+
+		if err := twoFactor.UseRecoveryCode(codes[0]); err != nil {
+			t.Errorf("Recovery code should be accepted. Err: %v", err)
+		}
+
+		if err := twoFactor.UseRecoveryCode(codes[0]); err == nil {
+			t.Errorf("Recovery code should NOT be accepted twice")
+		}
+
+		if len(twoFactor.RecoveryCodes) != RECOVERY_CODES_COUNT-1 {
+			t.Errorf("Expected %d recovery codes left, got %d", RECOVERY_CODES_COUNT-1, len(twoFactor.RecoveryCodes))
+		}
+	})
+}
@@ -1,10 +1,12 @@
 package service
 
 import (
-	"bytes"
 	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"hash"
+	"net/http"
 	"time"
 
 	"github.com/PPAKruNN/golearn/domain/entity"
@@ -16,12 +18,31 @@ type AccountRepository interface {
 	ReadAll() ([]entity.Account, error)
 	// FindByID(id int) (entity.Account, error)
 	ReadByID(id int) (entity.Account, error)
+	ReadByPublicID(publicId string) (entity.Account, error)
+	// ReadByCPF fails unless the CPF belongs to exactly one account.
+	ReadByCPF(cpf string) (entity.Account, error)
+	// ReadByNumber takes the agency and the account number as normalized by
+	// entity.ParseAgency and entity.ParseAccountNumber.
+	ReadByNumber(agency, number string) (entity.Account, error)
 	// FindHashByCPF(cpf string) (int, []byte, error)
 	ReadHashByCPF(cpf string) (int, []byte, error)
-	UpdateBalance(id, balance int) (entity.Account, error)
+	ReadHashByID(id int) ([]byte, error)
+	UpdateName(id int, name string) error
+	UpdateStatus(id int, status entity.AccountStatus) error
+	// Close fails unless the balance is zero when the account is closed, as
+	// money may arrive after it was read.
+	Close(id int) error
+	UpdateSecret(id int, secret hash.Hash) error
+	Search(query string) ([]entity.Account, error)
+	// Anonymize stores the account after entity.Account.Anonymize, removing
+	// its credentials too. Like Close, it fails unless the balance is still
+	// zero when the account is erased.
+	Anonymize(account entity.Account) error
 	Reset() error
 }
 
+var ErrInvalidCredentials = errors.New("Invalid credentials!")
+
 type AccountService struct {
 	Repo     AccountRepository
 	AuthRepo AuthRepository
@@ -31,38 +52,41 @@ func NewAccountService(repo AccountRepository, authRepository AuthRepository) *A
 	return &AccountService{Repo: repo, AuthRepo: authRepository}
 }
 
-func (a AccountService) ReadAccounts() []dto.ReadAccountOutputDTO {
+// ReadAccounts lists the accounts the holder can see, which is only their
+// own. It is still a list, as it was when every account was listed.
+func (a AccountService) ReadAccounts(accountId int) ([]dto.ReadAccountOutputDTO, int, error) {
 
-	accounts, err := a.Repo.ReadAll()
+	account, err := a.Repo.ReadByID(accountId)
 	if err != nil {
+		return nil, http.StatusNotFound, fmt.Errorf("Could not find the account!")
 	}
 
-	mapAccounts := []dto.ReadAccountOutputDTO{}
-
-	for _, account := range accounts {
-
-		dto := dto.ReadAccountOutputDTO{
-			ID:        account.ID,
-			Name:      account.Name,
-			CPF:       account.CPF,
-			Balance:   account.Balance,
-			CreatedAt: account.CreatedAt,
-		}
-
-		mapAccounts = append(mapAccounts, dto)
-	}
-
-	return mapAccounts
+	return []dto.ReadAccountOutputDTO{{
+		ID:        account.PublicID,
+		Agency:    account.Agency,
+		Number:    account.Number,
+		Name:      account.Name,
+		CPF:       account.CPF,
+		Balance:   account.Balance,
+		Status:    string(account.Status),
+		CreatedAt: account.CreatedAt,
+	}}, http.StatusOK, nil
 }
 
-func (a AccountService) ReadAccountBalance(input dto.ReadAccountBalanceInputDTO) dto.ReadAccountBalanceOutputDTO {
-	account, err := a.Repo.ReadByID(input.ID)
+// ReadAccountBalance reads the balance of the account input.ID. Holders can
+// only read their own balance, any other ID is reported as not found.
+func (a AccountService) ReadAccountBalance(accountId int, input dto.ReadAccountBalanceInputDTO) dto.ReadAccountBalanceOutputDTO {
+	account, err := a.Repo.ReadByPublicID(input.ID)
 
-	if err != nil {
+	if err != nil || account.ID != accountId {
 		return dto.ReadAccountBalanceOutputDTO{Balance: -1}
 	}
 
-	return dto.ReadAccountBalanceOutputDTO{Balance: account.Balance}
+	return dto.ReadAccountBalanceOutputDTO{
+		Balance:   account.Balance,
+		Available: account.Available(),
+		Ledger:    account.Balance,
+	}
 }
 
 func (a AccountService) CreateAccount(input dto.CreateAccountInputDTO) (entity.Account, error) {
@@ -92,18 +116,49 @@ func (a AccountService) CreateAccount(input dto.CreateAccountInputDTO) (entity.A
 	return newAccount, nil
 }
 
-func (a AccountService) Authenticate(cpf, secret string) (int, error) {
+// UpdateProfile changes the profile of the account publicId. Holders can only
+// update their own account, any other ID is reported as not found.
+func (a AccountService) UpdateProfile(accountId int, publicId string, input dto.UpdateAccountInputDTO) (dto.ReadAccountOutputDTO, int, error) {
 
-	id, foundSecret, err := a.Repo.ReadHashByCPF(cpf)
+	account, err := a.Repo.ReadByPublicID(publicId)
+	if err != nil || account.ID != accountId {
+		return dto.ReadAccountOutputDTO{}, http.StatusNotFound, errors.New("Account not found!")
+	}
 
-	if err != nil {
-		return 0, fmt.Errorf("Failed to authenticate. Cannot find an account with the credentials provided")
+	if input.Name != nil {
+		if err := account.Rename(*input.Name); err != nil {
+			return dto.ReadAccountOutputDTO{}, http.StatusBadRequest, err
+		}
+
+		if err := a.Repo.UpdateName(account.ID, account.Name); err != nil {
+			return dto.ReadAccountOutputDTO{}, http.StatusInternalServerError, err
+		}
 	}
 
-	// Checking secrets.
+	return dto.ReadAccountOutputDTO{
+		ID:        account.PublicID,
+		Agency:    account.Agency,
+		Number:    account.Number,
+		Name:      account.Name,
+		CPF:       account.CPF,
+		Balance:   account.Balance,
+		Status:    string(account.Status),
+		CreatedAt: account.CreatedAt,
+	}, http.StatusOK, nil
+}
+
+// Authenticate returns ErrInvalidCredentials both for unknown CPFs and wrong
+// secrets, so callers cannot tell them apart.
+func (a AccountService) Authenticate(cpf, secret string) (int, error) {
+
+	id, foundSecret, err := a.Repo.ReadHashByCPF(cpf)
+
+	// Checking secrets even when the account is missing keeps both paths
+	// taking the same time.
 	isCorrectSecret := checkSecret(secret, foundSecret)
-	if !isCorrectSecret {
-		return 0, fmt.Errorf("Failed to authenticate. Invalid secret provided!")
+
+	if err != nil || id == 0 || !isCorrectSecret {
+		return 0, ErrInvalidCredentials
 	}
 
 	return id, nil
@@ -122,5 +177,5 @@ func checkSecret(secret string, accSecret []byte) bool {
 
 	fhash := newHash.Sum(nil)
 
-	return bytes.Equal(fhash, accSecret)
+	return subtle.ConstantTimeCompare(fhash, accSecret) == 1
 }
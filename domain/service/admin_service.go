@@ -0,0 +1,407 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+	"github.com/google/uuid"
+)
+
+type OperatorRepository interface {
+	Create(entity.Operator) (entity.Operator, error)
+	ReadByID(id int) (entity.Operator, error)
+	ReadHashByEmail(email string) (int, []byte, error)
+	// Tokens are stored and looked up by entity.HashSessionToken, like
+	// sessions.
+	RegisterToken(tokenHash string, operatorId int) error
+	DecodeToken(tokenHash string) (int, error)
+	RevokeToken(tokenHash string) error
+	RevokeAllTokens(operatorId int) (int, error)
+	Reset() error
+}
+
+type AdminActionRepository interface {
+	Create(entity.AdminAction) (entity.AdminAction, error)
+	// AdjustBalance moves the balance of the action target by amount relative
+	// to its stored balance and records the action along, or does neither.
+	AdjustBalance(action entity.AdminAction, amount int) (entity.AdminAction, error)
+	ReadAll() ([]entity.AdminAction, error)
+	Reset() error
+}
+
+type AdminService struct {
+	OperatorRepo OperatorRepository
+	ActionRepo   AdminActionRepository
+	AccountRepo  AccountRepository
+	TransferRepo TransferRepository
+}
+
+func NewAdminService(operatorRepo OperatorRepository, actionRepo AdminActionRepository, accountRepo AccountRepository, transferRepo TransferRepository) *AdminService {
+	return &AdminService{
+		OperatorRepo: operatorRepo,
+		ActionRepo:   actionRepo,
+		AccountRepo:  accountRepo,
+		TransferRepo: transferRepo,
+	}
+}
+
+// Bootstrap creates the first admin operator when it does not exist yet, so a
+// fresh deployment has someone able to create the other operators.
+func (s AdminService) Bootstrap(email, secret string) error {
+
+	if _, _, err := s.OperatorRepo.ReadHashByEmail(email); err == nil {
+		return nil
+	}
+
+	if err := entity.ValidateOperatorSecret(secret); err != nil {
+		return err
+	}
+
+	operator := entity.NewOperator(0, "Admin", email, hashSecret(secret), entity.RoleAdmin, []entity.Permission{}, time.Now())
+
+	_, err := s.OperatorRepo.Create(*operator)
+	return err
+}
+
+// Login returns the operator ID along the token, and on wrong secrets too, so
+// the attempt can be audited against the operator.
+func (s AdminService) Login(input dto.AdminLoginInputDTO) (string, int, error) {
+
+	id, foundSecret, err := s.OperatorRepo.ReadHashByEmail(input.Email)
+	if err != nil {
+		return "", 0, fmt.Errorf("Failed to authenticate. Cannot find an operator with the credentials provided")
+	}
+
+	if !checkSecret(input.Secret, foundSecret) {
+		return "", id, fmt.Errorf("Failed to authenticate. Invalid secret provided!")
+	}
+
+	token := uuid.NewString()
+	if err := s.OperatorRepo.RegisterToken(entity.HashSessionToken(token), id); err != nil {
+		return "", id, err
+	}
+
+	return token, id, nil
+}
+
+// Authorize resolves the operator behind the token and checks that it holds
+// the permission required by the action.
+func (s AdminService) Authorize(token string, permission entity.Permission) (entity.Operator, int, error) {
+
+	operatorId, err := s.OperatorRepo.DecodeToken(entity.HashSessionToken(token))
+	if err != nil {
+		return entity.Operator{}, http.StatusUnauthorized, fmt.Errorf("Invalid token provided!")
+	}
+
+	operator, err := s.OperatorRepo.ReadByID(operatorId)
+	if err != nil {
+		return entity.Operator{}, http.StatusUnauthorized, fmt.Errorf("Invalid token provided!")
+	}
+
+	if !operator.Can(permission) {
+		return entity.Operator{}, http.StatusForbidden, fmt.Errorf("Operator does not have the %s permission!", permission)
+	}
+
+	return operator, http.StatusOK, nil
+}
+
+// Logout revokes the token, returning the operator it belonged to. Any valid
+// token can log itself out, whatever its permissions.
+func (s AdminService) Logout(token string) (int, int, error) {
+
+	tokenHash := entity.HashSessionToken(token)
+
+	operatorId, err := s.OperatorRepo.DecodeToken(tokenHash)
+	if err != nil {
+		return 0, http.StatusUnauthorized, fmt.Errorf("Invalid token provided!")
+	}
+
+	if err := s.OperatorRepo.RevokeToken(tokenHash); err != nil {
+		return operatorId, http.StatusInternalServerError, fmt.Errorf("Could not revoke token! Err: %v", err)
+	}
+
+	return operatorId, http.StatusNoContent, nil
+}
+
+// RevokeTokens logs another operator out everywhere, for tokens that may have
+// leaked.
+func (s AdminService) RevokeTokens(actor entity.Operator, operatorId int, input dto.RevokeOperatorTokensInputDTO) (dto.RevokeOperatorTokensOutputDTO, int, error) {
+
+	if input.Reason == "" {
+		return dto.RevokeOperatorTokensOutputDTO{}, http.StatusBadRequest, fmt.Errorf("A reason is required to revoke operator tokens!")
+	}
+
+	if _, err := s.OperatorRepo.ReadByID(operatorId); err != nil {
+		return dto.RevokeOperatorTokensOutputDTO{}, http.StatusNotFound, fmt.Errorf("Could not find the operator!")
+	}
+
+	revoked, err := s.OperatorRepo.RevokeAllTokens(operatorId)
+	if err != nil {
+		return dto.RevokeOperatorTokensOutputDTO{}, http.StatusInternalServerError, fmt.Errorf("Could not revoke operator tokens! Err: %v", err)
+	}
+
+	if err := s.record(entity.NewAdminAction(actor.ID, entity.AdminActionRevokeTokens, operatorId, input.Reason, map[string]interface{}{
+		"revoked": revoked,
+	})); err != nil {
+		return dto.RevokeOperatorTokensOutputDTO{}, http.StatusInternalServerError, err
+	}
+
+	return dto.RevokeOperatorTokensOutputDTO{OperatorID: operatorId, Revoked: revoked}, http.StatusOK, nil
+}
+
+func (s AdminService) CreateOperator(actor entity.Operator, input dto.CreateOperatorInputDTO) (dto.ReadOperatorOutputDTO, int, error) {
+
+	if err := entity.ValidateOperatorSecret(input.Secret); err != nil {
+		return dto.ReadOperatorOutputDTO{}, http.StatusBadRequest, err
+	}
+
+	permissions := []entity.Permission{}
+	for _, p := range input.Permissions {
+		permissions = append(permissions, entity.Permission(p))
+	}
+
+	operator := entity.NewOperator(0, input.Name, input.Email, hashSecret(input.Secret), entity.Role(input.Role), permissions, time.Now())
+
+	valid, err := operator.IsValid()
+	if !valid {
+		return dto.ReadOperatorOutputDTO{}, http.StatusBadRequest, err
+	}
+
+	created, err := s.OperatorRepo.Create(*operator)
+	if err != nil {
+		return dto.ReadOperatorOutputDTO{}, http.StatusInternalServerError, fmt.Errorf("Could not create operator! Err: %v", err)
+	}
+
+	if err := s.record(entity.NewAdminAction(actor.ID, entity.AdminActionCreateOperator, created.ID, "", map[string]interface{}{
+		"email":       created.Email,
+		"role":        created.Role,
+		"permissions": input.Permissions,
+	})); err != nil {
+		return dto.ReadOperatorOutputDTO{}, http.StatusInternalServerError, err
+	}
+
+	return toOperatorDTO(created), http.StatusCreated, nil
+}
+
+func (s AdminService) SearchAccounts(actor entity.Operator, input dto.SearchAccountsInputDTO) ([]dto.ReadAccountOutputDTO, int, error) {
+
+	accounts, err := s.AccountRepo.Search(input.Query)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Could not search accounts! Err: %v", err)
+	}
+
+	if err := s.record(entity.NewAdminAction(actor.ID, entity.AdminActionSearchAccounts, 0, "", map[string]interface{}{
+		"query":   input.Query,
+		"results": len(accounts),
+	})); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	output := []dto.ReadAccountOutputDTO{}
+	for _, account := range accounts {
+		output = append(output, dto.ReadAccountOutputDTO{
+			ID:        account.PublicID,
+			Agency:    account.Agency,
+			Number:    account.Number,
+			Name:      account.Name,
+			CPF:       account.CPF,
+			Balance:   account.Balance,
+			Status:    string(account.Status),
+			CreatedAt: account.CreatedAt,
+		})
+	}
+
+	return output, http.StatusOK, nil
+}
+
+func (s AdminService) SearchTransfers(actor entity.Operator, input dto.SearchTransfersInputDTO) ([]dto.ReadTransfersOutputDTO, int, error) {
+
+	filter := entity.TransferFilter{
+		MinAmount: input.MinAmount,
+		MaxAmount: input.MaxAmount,
+		From:      input.From,
+		To:        input.To,
+	}
+
+	if input.AccountID != "" {
+		account, err := s.AccountRepo.ReadByPublicID(input.AccountID)
+		if err != nil {
+			return nil, http.StatusNotFound, fmt.Errorf("Could not find the account! Err: %v", err)
+		}
+		filter.AccountID = account.ID
+	}
+
+	transfers, err := s.TransferRepo.Search(filter)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Could not search transfers! Err: %v", err)
+	}
+
+	if err := s.record(entity.NewAdminAction(actor.ID, entity.AdminActionSearchTransfers, filter.AccountID, "", map[string]interface{}{
+		"filter":  input,
+		"results": len(transfers),
+	})); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	output := []dto.ReadTransfersOutputDTO{}
+	for _, val := range transfers {
+		output = append(output, toTransferDTO(val))
+	}
+
+	return output, http.StatusOK, nil
+}
+
+func (s AdminService) AdjustBalance(actor entity.Operator, input dto.CreateAdjustmentInputDTO) (dto.CreateAdjustmentOutputDTO, int, error) {
+
+	if input.Reason == "" {
+		return dto.CreateAdjustmentOutputDTO{}, http.StatusBadRequest, fmt.Errorf("A reason is required to adjust an account balance!")
+	}
+
+	account, err := s.AccountRepo.ReadByPublicID(input.AccountID)
+	if err != nil {
+		return dto.CreateAdjustmentOutputDTO{}, http.StatusNotFound, fmt.Errorf("Could not find the account! Err: %v", err)
+	}
+
+	// Checked on the read account for a clear error, the repository checks
+	// again against the stored balance.
+	if err := account.Adjust(input.Amount); err != nil {
+		return dto.CreateAdjustmentOutputDTO{}, http.StatusBadRequest, err
+	}
+
+	action, err := s.ActionRepo.AdjustBalance(*entity.NewAdminAction(actor.ID, entity.AdminActionAdjustBalance, account.ID, input.Reason, map[string]interface{}{
+		"amount": input.Amount,
+	}), input.Amount)
+	if err != nil {
+		return dto.CreateAdjustmentOutputDTO{}, http.StatusConflict, fmt.Errorf("Could not adjust account balance! Err: %v", err)
+	}
+
+	return dto.CreateAdjustmentOutputDTO{
+		AccountID:      account.PublicID,
+		AuditAccountID: account.ID,
+		BalanceBefore:  action.Details["balance_before"].(int),
+		BalanceAfter:   action.Details["balance_after"].(int),
+	}, http.StatusCreated, nil
+}
+
+// ChangeAccountStatus freezes, unfreezes or closes an account. Like balance
+// adjustments, it always needs a reason.
+func (s AdminService) ChangeAccountStatus(actor entity.Operator, input dto.ChangeAccountStatusInputDTO) (dto.ChangeAccountStatusOutputDTO, int, error) {
+
+	if input.Reason == "" {
+		return dto.ChangeAccountStatusOutputDTO{}, http.StatusBadRequest, fmt.Errorf("A reason is required to change an account status!")
+	}
+
+	account, err := s.AccountRepo.ReadByPublicID(input.AccountID)
+	if err != nil {
+		return dto.ChangeAccountStatusOutputDTO{}, http.StatusNotFound, fmt.Errorf("Could not find the account! Err: %v", err)
+	}
+
+	switch status := entity.AccountStatus(input.Status); status {
+	case entity.AccountActive, entity.AccountFrozen, entity.AccountClosed:
+	default:
+		return dto.ChangeAccountStatusOutputDTO{}, http.StatusBadRequest, fmt.Errorf("Invalid account status: %s", status)
+	}
+
+	before := account.Status
+
+	if err := account.ChangeStatus(entity.AccountStatus(input.Status)); err != nil {
+		return dto.ChangeAccountStatusOutputDTO{}, http.StatusConflict, err
+	}
+
+	if account.Status == entity.AccountClosed {
+		if err := s.AccountRepo.Close(account.ID); err != nil {
+			return dto.ChangeAccountStatusOutputDTO{}, http.StatusConflict, fmt.Errorf("Could not close the account! Err: %v", err)
+		}
+	} else if err := s.AccountRepo.UpdateStatus(account.ID, account.Status); err != nil {
+		return dto.ChangeAccountStatusOutputDTO{}, http.StatusInternalServerError, fmt.Errorf("Could not update account status! Err: %v", err)
+	}
+
+	if err := s.record(entity.NewAdminAction(actor.ID, entity.AdminActionChangeStatus, account.ID, input.Reason, map[string]interface{}{
+		"status_before": before,
+		"status_after":  account.Status,
+	})); err != nil {
+		return dto.ChangeAccountStatusOutputDTO{}, http.StatusInternalServerError, err
+	}
+
+	return dto.ChangeAccountStatusOutputDTO{
+		AccountID:      account.PublicID,
+		AuditAccountID: account.ID,
+		StatusBefore:   string(before),
+		StatusAfter:    string(account.Status),
+	}, http.StatusOK, nil
+}
+
+func (s AdminService) ReadActions() ([]dto.ReadAdminActionOutputDTO, int, error) {
+
+	actions, err := s.ActionRepo.ReadAll()
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Could not read admin actions! Err: %v", err)
+	}
+
+	output := []dto.ReadAdminActionOutputDTO{}
+	for _, action := range actions {
+		output = append(output, dto.ReadAdminActionOutputDTO{
+			ID:         action.ID,
+			OperatorID: action.OperatorID,
+			Action:     action.Action,
+			TargetID:   s.targetID(action),
+			Reason:     action.Reason,
+			Details:    action.Details,
+			CreatedAt:  action.CreatedAt,
+		})
+	}
+
+	return output, http.StatusOK, nil
+}
+
+// targetID shows accounts by their public ID. Operators are staff, their
+// sequential IDs are not hidden.
+func (s AdminService) targetID(action entity.AdminAction) string {
+
+	if action.TargetID == 0 {
+		return ""
+	}
+
+	if action.Action == entity.AdminActionCreateOperator || action.Action == entity.AdminActionRevokeTokens {
+		return strconv.Itoa(action.TargetID)
+	}
+
+	account, err := s.AccountRepo.ReadByID(action.TargetID)
+	if err != nil {
+		return ""
+	}
+
+	return account.PublicID
+}
+
+// record fails the request when the action cannot be recorded, operators
+// must not act without leaving a trace.
+func (s AdminService) record(action *entity.AdminAction) error {
+
+	if _, err := s.ActionRepo.Create(*action); err != nil {
+		return fmt.Errorf("Could not record admin action! Err: %v", err)
+	}
+
+	return nil
+}
+
+func toOperatorDTO(operator entity.Operator) dto.ReadOperatorOutputDTO {
+
+	permissions := []string{}
+	for _, p := range operator.Permissions {
+		permissions = append(permissions, string(p))
+	}
+
+	return dto.ReadOperatorOutputDTO{
+		ID:          operator.ID,
+		Name:        operator.Name,
+		Email:       operator.Email,
+		Role:        string(operator.Role),
+		Permissions: permissions,
+		CreatedAt:   operator.CreatedAt,
+	}
+}
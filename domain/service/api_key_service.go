@@ -0,0 +1,242 @@
+package service
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+)
+
+const (
+	// Signed requests older or newer than this are refused.
+	SIGNATURE_TIMESTAMP_WINDOW = 5 * time.Minute
+)
+
+type APIKeyRepository interface {
+	Create(key entity.APIKey) error
+	ReadByID(id string) (entity.APIKey, error)
+	ReadByAccountID(accountId int) ([]entity.APIKey, error)
+	Update(key entity.APIKey) error
+	// UpdateLastUsedAt sets when a key still in use was last used, and nothing
+	// else.
+	UpdateLastUsedAt(id string, usedAt time.Time) error
+	Reset() error
+}
+
+// NonceCache remembers the nonces seen inside the timestamp window, so a
+// captured request cannot be replayed.
+type NonceCache struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+}
+
+func NewNonceCache() *NonceCache {
+	return &NonceCache{nonces: map[string]time.Time{}}
+}
+
+// Use records the nonce and reports false when it was already used.
+func (c *NonceCache) Use(nonce string, now time.Time) bool {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, expiresAt := range c.nonces {
+		if now.After(expiresAt) {
+			delete(c.nonces, key)
+		}
+	}
+
+	if _, seen := c.nonces[nonce]; seen {
+		return false
+	}
+
+	// Twice the window, a timestamp can be that far in the future.
+	c.nonces[nonce] = now.Add(2 * SIGNATURE_TIMESTAMP_WINDOW)
+
+	return true
+}
+
+type APIKeyService struct {
+	Repo   APIKeyRepository
+	Nonces *NonceCache
+}
+
+func NewAPIKeyService(repo APIKeyRepository) *APIKeyService {
+	return &APIKeyService{Repo: repo, Nonces: NewNonceCache()}
+}
+
+func (s APIKeyService) Create(accountId int, input dto.CreateAPIKeyInputDTO) (dto.CreateAPIKeyOutputDTO, int, error) {
+
+	scopes := []entity.Scope{}
+	for _, scope := range input.Scopes {
+		scopes = append(scopes, entity.Scope(scope))
+	}
+
+	key, err := entity.NewAPIKey(accountId, input.Name, scopes, time.Now())
+	if err != nil {
+		return dto.CreateAPIKeyOutputDTO{}, http.StatusBadRequest, err
+	}
+
+	if err := s.Repo.Create(*key); err != nil {
+		return dto.CreateAPIKeyOutputDTO{}, http.StatusInternalServerError, fmt.Errorf("Could not create API key! Err: %v", err)
+	}
+
+	return dto.CreateAPIKeyOutputDTO{
+		ID:     key.ID,
+		Secret: hex.EncodeToString(key.Secret),
+		Scopes: input.Scopes,
+	}, http.StatusCreated, nil
+}
+
+func (s APIKeyService) List(accountId int) ([]dto.ReadAPIKeyOutputDTO, int, error) {
+
+	keys, err := s.Repo.ReadByAccountID(accountId)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Could not read API keys! Err: %v", err)
+	}
+
+	output := []dto.ReadAPIKeyOutputDTO{}
+	for _, key := range keys {
+		scopes := []string{}
+		for _, scope := range key.Scopes {
+			scopes = append(scopes, string(scope))
+		}
+
+		output = append(output, dto.ReadAPIKeyOutputDTO{
+			ID:         key.ID,
+			Name:       key.Name,
+			Scopes:     scopes,
+			CreatedAt:  key.CreatedAt,
+			RotatedAt:  key.RotatedAt,
+			RevokedAt:  key.RevokedAt,
+			LastUsedAt: key.LastUsedAt,
+		})
+	}
+
+	return output, http.StatusOK, nil
+}
+
+func (s APIKeyService) Rotate(accountId int, id string) (dto.CreateAPIKeyOutputDTO, int, error) {
+
+	key, statusCode, err := s.readOwned(accountId, id)
+	if err != nil {
+		return dto.CreateAPIKeyOutputDTO{}, statusCode, err
+	}
+
+	if key.IsRevoked() {
+		return dto.CreateAPIKeyOutputDTO{}, http.StatusConflict, fmt.Errorf("Cannot rotate a revoked API key!")
+	}
+
+	if err := key.Rotate(time.Now()); err != nil {
+		return dto.CreateAPIKeyOutputDTO{}, http.StatusInternalServerError, err
+	}
+
+	if err := s.Repo.Update(key); err != nil {
+		return dto.CreateAPIKeyOutputDTO{}, http.StatusInternalServerError, fmt.Errorf("Could not rotate API key! Err: %v", err)
+	}
+
+	scopes := []string{}
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+
+	return dto.CreateAPIKeyOutputDTO{
+		ID:     key.ID,
+		Secret: hex.EncodeToString(key.Secret),
+		Scopes: scopes,
+	}, http.StatusOK, nil
+}
+
+func (s APIKeyService) Revoke(accountId int, id string) (int, error) {
+
+	key, statusCode, err := s.readOwned(accountId, id)
+	if err != nil {
+		return statusCode, err
+	}
+
+	if key.IsRevoked() {
+		return http.StatusNoContent, nil
+	}
+
+	now := time.Now()
+	key.RevokedAt = &now
+
+	if err := s.Repo.Update(key); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Could not revoke API key! Err: %v", err)
+	}
+
+	return http.StatusNoContent, nil
+}
+
+// Authenticate verifies a signed request and returns the key that signed it.
+func (s APIKeyService) Authenticate(input dto.SignedRequestDTO) (entity.APIKey, error) {
+
+	unix, err := strconv.ParseInt(input.Timestamp, 10, 64)
+	if err != nil {
+		return entity.APIKey{}, fmt.Errorf("Invalid signature timestamp!")
+	}
+
+	now := time.Now()
+	signedAt := time.Unix(unix, 0)
+	if signedAt.Before(now.Add(-SIGNATURE_TIMESTAMP_WINDOW)) || signedAt.After(now.Add(SIGNATURE_TIMESTAMP_WINDOW)) {
+		return entity.APIKey{}, fmt.Errorf("Signature timestamp outside of the accepted window!")
+	}
+
+	key, err := s.Repo.ReadByID(input.KeyID)
+	if err != nil || key.IsRevoked() {
+		return entity.APIKey{}, fmt.Errorf("Invalid API key!")
+	}
+
+	if input.Nonce == "" || !key.VerifySignature(input.Signature, input.Method, input.Path, input.Timestamp, input.Nonce, input.Body) {
+		return entity.APIKey{}, fmt.Errorf("Invalid request signature!")
+	}
+
+	// Only valid signatures reach the cache, so nobody can burn nonces of
+	// others.
+	if !s.Nonces.Use(key.ID+":"+input.Nonce, now) {
+		return entity.APIKey{}, fmt.Errorf("Nonce already used!")
+	}
+
+	key.LastUsedAt = &now
+	s.Repo.UpdateLastUsedAt(key.ID, now)
+
+	return key, nil
+}
+
+func (s APIKeyService) readOwned(accountId int, id string) (entity.APIKey, int, error) {
+
+	key, err := s.Repo.ReadByID(id)
+	if err != nil || key.AccountID != accountId {
+		return entity.APIKey{}, http.StatusNotFound, fmt.Errorf("Could not find the API key!")
+	}
+
+	return key, http.StatusOK, nil
+}
+
+// revokeAPIKeys revokes every key of the account still in use.
+func revokeAPIKeys(repo APIKeyRepository, accountId int) error {
+
+	keys, err := repo.ReadByAccountID(accountId)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, key := range keys {
+		if key.IsRevoked() {
+			continue
+		}
+
+		key.RevokedAt = &now
+		if err := repo.Update(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
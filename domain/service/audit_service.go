@@ -0,0 +1,51 @@
+package service
+
+import (
+	"github.com/PPAKruNN/golearn/domain/entity"
+)
+
+type AuditRepository interface {
+	// Append chains the event after the last entry and stores it. Appends are
+	// serialized by the repository, so the chain never forks.
+	Append(event entity.AuditEvent) (entity.AuditEvent, error)
+	// ReadAll returns every entry in the order they were appended.
+	ReadAll() ([]entity.AuditEvent, error)
+	// ReadByReference returns the entries with any of the references, as
+	// entity.AuditAccount(id), as actor or subject.
+	ReadByReference(refs ...string) ([]entity.AuditEvent, error)
+}
+
+type AuditService struct {
+	Repo AuditRepository
+}
+
+func NewAuditService(repo AuditRepository) *AuditService {
+	return &AuditService{Repo: repo}
+}
+
+// Record appends the event to the audit log. Failing to record is logged by
+// the repository, the event itself already happened.
+func (s AuditService) Record(event entity.AuditEvent) {
+	s.Repo.Append(event)
+}
+
+// Verify walks the whole chain and returns how many entries it holds and the
+// hash of the last one. Keeping that hash somewhere else also detects entries
+// removed from the end.
+func (s AuditService) Verify() (int, string, error) {
+
+	events, err := s.Repo.ReadAll()
+	if err != nil {
+		return 0, "", err
+	}
+
+	if err := entity.VerifyAuditChain(events); err != nil {
+		return len(events), "", err
+	}
+
+	if len(events) == 0 {
+		return 0, "", nil
+	}
+
+	return len(events), events[len(events)-1].Hash, nil
+}
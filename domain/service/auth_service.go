@@ -2,13 +2,22 @@ package service
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
 	"github.com/google/uuid"
 )
 
 type AuthRepository interface {
-	RegisterToken(token string, accountId int)
-	DecodeToken(token string) (int, error)
+	CreateSession(session entity.Session) error
+	// DecodeToken finds the session by the hash of its token, expired ones
+	// are not found. It also refreshes the session LastUsedAt.
+	DecodeToken(tokenHash string) (entity.Session, error)
+	ReadSessionsByAccountID(accountId int) ([]entity.Session, error)
+	RevokeSession(accountId int, sessionId string) error
+	RevokeToken(tokenHash string) error
+	RevokeAllSessions(accountId int) error
 	Reset() error
 }
 
@@ -20,19 +29,83 @@ func NewAuthService(repo AuthRepository) *AuthService {
 	return &AuthService{Repo: repo}
 }
 
+// CreateToken opens a session without device data, an empty token when it
+// could not be stored.
 func (s AuthService) CreateToken(accountId int) string {
+
+	token, err := s.CreateSession(accountId, "", "")
+	if err != nil {
+		return ""
+	}
+
+	return token
+}
+
+// CreateSession opens a new session for the device and returns its token.
+// Other sessions of the account are kept.
+func (s AuthService) CreateSession(accountId int, userAgent, ip string) (string, error) {
 	t := uuid.NewString()
-	s.Repo.RegisterToken(t, accountId)
 
-	return t
+	session := entity.NewSession(uuid.NewString(), accountId, t, userAgent, ip, time.Now())
+	if err := s.Repo.CreateSession(*session); err != nil {
+		return "", fmt.Errorf("Could not create session! Err: %v", err)
+	}
+
+	return t, nil
 }
 
 func (s AuthService) DecodeToken(token string) (int, error) {
 
-	accountId, err := s.Repo.DecodeToken(token)
+	session, err := s.DecodeSession(token)
+	if err != nil {
+		return 0, err
+	}
+
+	return session.AccountID, nil
+}
+
+func (s AuthService) DecodeSession(token string) (entity.Session, error) {
+
+	session, err := s.Repo.DecodeToken(entity.HashSessionToken(token))
 	if err != nil {
-		return 0, fmt.Errorf("AccountId returned is not found. AccountId returned: %d", accountId)
+		return entity.Session{}, fmt.Errorf("Session not found for the token provided!")
 	}
 
-	return accountId, nil
+	return session, nil
+}
+
+func (s AuthService) ReadSessions(current entity.Session) ([]dto.ReadSessionOutputDTO, error) {
+
+	sessions, err := s.Repo.ReadSessionsByAccountID(current.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read sessions! Err: %v", err)
+	}
+
+	output := []dto.ReadSessionOutputDTO{}
+	for _, session := range sessions {
+		output = append(output, dto.ReadSessionOutputDTO{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			Current:    session.ID == current.ID,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+		})
+	}
+
+	return output, nil
+}
+
+func (s AuthService) Logout(session entity.Session) error {
+	return s.Repo.RevokeToken(session.TokenHash)
+}
+
+func (s AuthService) RevokeSession(accountId int, sessionId string) error {
+	return s.Repo.RevokeSession(accountId, sessionId)
+}
+
+// RevokeAllSessions logs the account out of every device. Must be called
+// whenever the account credentials change.
+func (s AuthService) RevokeAllSessions(accountId int) error {
+	return s.Repo.RevokeAllSessions(accountId)
 }
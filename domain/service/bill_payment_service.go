@@ -0,0 +1,92 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+)
+
+// BoletoClearing reaches the other banks, for boletos not issued here.
+type BoletoClearing interface {
+	// Query returns what the beneficiary bank registered for the boleto.
+	Query(barcode string) (entity.BoletoRegistration, error)
+	// Settle sends the amount paid to the beneficiary bank.
+	Settle(barcode string, amount int) error
+}
+
+// BillPaymentService pays boletos of any bank from the account balance.
+type BillPaymentService struct {
+	Boletos   *BoletoService
+	Transfers *TransferService
+	Clearing  BoletoClearing
+}
+
+func NewBillPaymentService(boletos *BoletoService, transfers *TransferService, clearing BoletoClearing) *BillPaymentService {
+	return &BillPaymentService{
+		Boletos:   boletos,
+		Transfers: transfers,
+		Clearing:  clearing,
+	}
+}
+
+func (s BillPaymentService) PayBoleto(originId int, input dto.PayBillInputDTO) (dto.PayBillOutputDTO, int, error) {
+
+	now := time.Now()
+
+	code, err := entity.ParseBoletoCode(input.Code, now)
+	if err != nil {
+		return dto.PayBillOutputDTO{}, http.StatusBadRequest, err
+	}
+
+	output := dto.PayBillOutputDTO{
+		Barcode:  code.Barcode,
+		BankCode: code.BankCode,
+		Amount:   code.Amount,
+	}
+	if code.DueDate != nil {
+		output.DueDate = code.DueDate.Format(time.DateOnly)
+	}
+
+	if code.BankCode == entity.BANK_CODE {
+		paid, statusCode, err := s.Boletos.PayBarcode(originId, code.Barcode, input.Pin, input.TOTPCode)
+		if err != nil {
+			return dto.PayBillOutputDTO{}, statusCode, err
+		}
+
+		output.AmountPaid = paid.AmountPaid
+		output.PaidAt = paid.PaidAt
+		return output, statusCode, nil
+	}
+
+	registration, err := s.Clearing.Query(code.Barcode)
+	if err != nil {
+		return dto.PayBillOutputDTO{}, http.StatusNotFound, fmt.Errorf("Boleto not found at the beneficiary bank! Err: %v", err)
+	}
+
+	if registration.Paid {
+		return dto.PayBillOutputDTO{}, http.StatusConflict, fmt.Errorf("Boleto was already paid!")
+	}
+
+	if code.Amount != 0 && code.Amount != registration.Amount {
+		return dto.PayBillOutputDTO{}, http.StatusConflict, fmt.Errorf("Boleto amount does not match the beneficiary bank registration!")
+	}
+
+	output.Amount = registration.Amount
+	output.BeneficiaryName = registration.BeneficiaryName
+	output.AmountPaid = registration.AmountDue(now)
+	output.PaidAt = now
+
+	statusCode, err := s.Transfers.PayBoleto(originId, 0, code.Barcode, dto.CreateTrasnferInputDTO{
+		Amount:   output.AmountPaid,
+		Pin:      input.Pin,
+		TOTPCode: input.TOTPCode,
+	}, s.Clearing)
+	if err != nil {
+		return dto.PayBillOutputDTO{}, statusCode, err
+	}
+
+	return output, statusCode, nil
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+)
+
+type BoletoRepository interface {
+	Create(boleto entity.Boleto) error
+	ReadByID(id string) (entity.Boleto, error)
+	ReadByOurNumber(ourNumber string) (entity.Boleto, error)
+	ReadByAccountID(accountId int) ([]entity.Boleto, error)
+	// MarkPaid fails when the boleto is no longer open, so it is only paid
+	// once even with concurrent payers.
+	MarkPaid(boleto entity.Boleto) error
+	// ReleasePayment undoes MarkPaid when the transfer was not made.
+	ReleasePayment(id string) error
+	// Cancel fails when the boleto is no longer open.
+	Cancel(id string) error
+	Reset() error
+}
+
+// BoletoService issues boletos and pays the ones issued here, crediting the
+// issuer account through the transfer service.
+type BoletoService struct {
+	Repo        BoletoRepository
+	AccountRepo AccountRepository
+	Transfers   *TransferService
+}
+
+func NewBoletoService(repo BoletoRepository, accountRepo AccountRepository, transfers *TransferService) *BoletoService {
+	return &BoletoService{
+		Repo:        repo,
+		AccountRepo: accountRepo,
+		Transfers:   transfers,
+	}
+}
+
+func (s BoletoService) Issue(accountId int, input dto.CreateBoletoInputDTO) (dto.ReadBoletoOutputDTO, int, error) {
+
+	account, err := s.AccountRepo.ReadByID(accountId)
+	if err != nil {
+		return dto.ReadBoletoOutputDTO{}, http.StatusNotFound, fmt.Errorf("Could not find the account!")
+	}
+
+	if err := account.CheckActive(); err != nil {
+		return dto.ReadBoletoOutputDTO{}, http.StatusForbidden, err
+	}
+
+	dueDate, err := time.Parse(time.DateOnly, input.DueDate)
+	if err != nil {
+		return dto.ReadBoletoOutputDTO{}, http.StatusBadRequest, fmt.Errorf("Due date must be formatted as YYYY-MM-DD!")
+	}
+
+	boleto, err := entity.NewBoleto(accountId, input.Amount, dueDate, input.PayerName, input.PayerDocument, input.FineRate, input.InterestRate, time.Now())
+	if err != nil {
+		return dto.ReadBoletoOutputDTO{}, http.StatusBadRequest, err
+	}
+
+	if err := s.Repo.Create(*boleto); err != nil {
+		return dto.ReadBoletoOutputDTO{}, http.StatusInternalServerError, fmt.Errorf("Could not issue boleto! Err: %v", err)
+	}
+
+	return toBoletoDTO(*boleto, time.Now()), http.StatusCreated, nil
+}
+
+func (s BoletoService) ReadBoletos(accountId int) ([]dto.ReadBoletoOutputDTO, int, error) {
+
+	boletos, err := s.Repo.ReadByAccountID(accountId)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Could not read boletos! Err: %v", err)
+	}
+
+	now := time.Now()
+	output := []dto.ReadBoletoOutputDTO{}
+	for _, boleto := range boletos {
+		output = append(output, toBoletoDTO(boleto, now))
+	}
+
+	return output, http.StatusOK, nil
+}
+
+func (s BoletoService) ReadBoleto(accountId int, id string) (dto.ReadBoletoOutputDTO, int, error) {
+
+	boleto, err := s.Repo.ReadByID(id)
+	if err != nil || boleto.AccountID != accountId {
+		return dto.ReadBoletoOutputDTO{}, http.StatusNotFound, fmt.Errorf("Boleto not found!")
+	}
+
+	return toBoletoDTO(boleto, time.Now()), http.StatusOK, nil
+}
+
+func (s BoletoService) Cancel(accountId int, id string) (int, error) {
+
+	boleto, err := s.Repo.ReadByID(id)
+	if err != nil || boleto.AccountID != accountId {
+		return http.StatusNotFound, fmt.Errorf("Boleto not found!")
+	}
+
+	if err := boleto.Cancel(); err != nil {
+		return http.StatusConflict, err
+	}
+
+	if err := s.Repo.Cancel(boleto.ID); err != nil {
+		return http.StatusConflict, fmt.Errorf("Boleto is no longer open!")
+	}
+
+	return http.StatusNoContent, nil
+}
+
+// Pay settles a boleto issued here, fine and interest included, transferring
+// from the origin account to the issuer.
+func (s BoletoService) Pay(originId int, input dto.PayBoletoInputDTO) (dto.PayBoletoOutputDTO, int, error) {
+
+	barcode, err := entity.DigitableLineToBarcode(input.DigitableLine)
+	if err != nil {
+		return dto.PayBoletoOutputDTO{}, http.StatusBadRequest, err
+	}
+
+	if barcode[:3] != entity.BANK_CODE {
+		return dto.PayBoletoOutputDTO{}, http.StatusUnprocessableEntity, fmt.Errorf("Only boletos issued by this bank can be paid!")
+	}
+
+	return s.PayBarcode(originId, barcode, input.Pin, input.TOTPCode)
+}
+
+// PayBarcode is Pay for a barcode already known to be from this bank.
+func (s BoletoService) PayBarcode(originId int, barcode, pin, totpCode string) (dto.PayBoletoOutputDTO, int, error) {
+
+	// The barcode carries the amount and due date, it must match the boleto
+	// exactly or it was forged from its number.
+	boleto, err := s.Repo.ReadByOurNumber(barcode[19:])
+	if err != nil || boleto.Barcode() != barcode {
+		return dto.PayBoletoOutputDTO{}, http.StatusNotFound, fmt.Errorf("Boleto not found!")
+	}
+
+	now := time.Now()
+	amount, err := boleto.Pay(now)
+	if err != nil {
+		return dto.PayBoletoOutputDTO{}, http.StatusConflict, err
+	}
+
+	if err := s.Repo.MarkPaid(boleto); err != nil {
+		return dto.PayBoletoOutputDTO{}, http.StatusConflict, fmt.Errorf("Boleto is no longer open!")
+	}
+
+	statusCode, err := s.Transfers.PayBoleto(originId, boleto.AccountID, barcode, dto.CreateTrasnferInputDTO{
+		Amount:   amount,
+		Pin:      pin,
+		TOTPCode: totpCode,
+	}, nil)
+	// A payment that may have been made keeps the boleto paid, releasing it
+	// could have it paid twice.
+	if err == entity.ErrTransferInDoubt {
+		return dto.PayBoletoOutputDTO{}, statusCode, err
+	}
+
+	if err != nil {
+		if releaseErr := s.Repo.ReleasePayment(boleto.ID); releaseErr != nil {
+			return dto.PayBoletoOutputDTO{}, http.StatusInternalServerError, fmt.Errorf("Payment failed and the boleto could not be released! Err: %v", releaseErr)
+		}
+
+		return dto.PayBoletoOutputDTO{}, statusCode, err
+	}
+
+	return dto.PayBoletoOutputDTO{BoletoID: boleto.ID, AmountPaid: amount, PaidAt: now}, statusCode, nil
+}
+
+func toBoletoDTO(boleto entity.Boleto, now time.Time) dto.ReadBoletoOutputDTO {
+
+	amountDue := 0
+	if boleto.Status == entity.BoletoOpen {
+		amountDue = boleto.AmountDue(now)
+	}
+
+	return dto.ReadBoletoOutputDTO{
+		ID:            boleto.ID,
+		Amount:        boleto.Amount,
+		AmountDue:     amountDue,
+		DueDate:       boleto.DueDate.Format(time.DateOnly),
+		PayerName:     boleto.PayerName,
+		PayerDocument: boleto.PayerDocument,
+		FineRate:      boleto.FineRate,
+		InterestRate:  boleto.InterestRate,
+		Status:        string(boleto.Status),
+		Barcode:       boleto.Barcode(),
+		DigitableLine: entity.FormatDigitableLine(boleto.DigitableLine()),
+		PaidAt:        boleto.PaidAt,
+		PaidAmount:    boleto.PaidAmount,
+		CreatedAt:     boleto.CreatedAt,
+	}
+}
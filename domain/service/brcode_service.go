@@ -0,0 +1,186 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+)
+
+type PixChargeRepository interface {
+	Create(charge entity.PixCharge) error
+	ReadByID(id string) (entity.PixCharge, error)
+	ReadByTxID(txid string) (entity.PixCharge, error)
+	// MarkPaid fails when the charge was already paid, so it is only paid
+	// once even with concurrent payers.
+	MarkPaid(charge entity.PixCharge) error
+	// ReleasePayment undoes MarkPaid when the transfer was not made.
+	ReleasePayment(id string) error
+	Reset() error
+}
+
+// BRCodeService generates the Pix QR code payloads and pays them through the
+// transfer service.
+type BRCodeService struct {
+	ChargeRepo  PixChargeRepository
+	PixKeyRepo  PixKeyRepository
+	AccountRepo AccountRepository
+	Transfers   *TransferService
+}
+
+func NewBRCodeService(chargeRepo PixChargeRepository, pixKeyRepo PixKeyRepository, accountRepo AccountRepository, transfers *TransferService) *BRCodeService {
+	return &BRCodeService{
+		ChargeRepo:  chargeRepo,
+		PixKeyRepo:  pixKeyRepo,
+		AccountRepo: accountRepo,
+		Transfers:   transfers,
+	}
+}
+
+// CreateStatic returns a reusable code for one of the holder keys.
+func (s BRCodeService) CreateStatic(accountId int, input dto.CreateStaticBRCodeInputDTO) (dto.BRCodeOutputDTO, int, error) {
+
+	if input.Amount < 0 {
+		return dto.BRCodeOutputDTO{}, http.StatusBadRequest, fmt.Errorf("Amount cannot be negative!")
+	}
+
+	key, account, statusCode, err := s.ownKey(accountId, input.PixKey)
+	if err != nil {
+		return dto.BRCodeOutputDTO{}, statusCode, err
+	}
+
+	code := entity.BRCode{
+		PixKey:       key.Value,
+		Amount:       input.Amount,
+		MerchantName: account.Name,
+	}
+
+	return dto.BRCodeOutputDTO{Payload: code.Encode()}, http.StatusCreated, nil
+}
+
+// CreateCharge returns a single use code for a fixed amount.
+func (s BRCodeService) CreateCharge(accountId int, input dto.CreatePixChargeInputDTO) (dto.ReadPixChargeOutputDTO, int, error) {
+
+	key, account, statusCode, err := s.ownKey(accountId, input.PixKey)
+	if err != nil {
+		return dto.ReadPixChargeOutputDTO{}, statusCode, err
+	}
+
+	charge, err := entity.NewPixCharge(key, input.Amount, time.Duration(input.ExpiresIn)*time.Second, time.Now())
+	if err != nil {
+		return dto.ReadPixChargeOutputDTO{}, http.StatusBadRequest, err
+	}
+
+	if err := s.ChargeRepo.Create(*charge); err != nil {
+		return dto.ReadPixChargeOutputDTO{}, http.StatusInternalServerError, fmt.Errorf("Could not create charge! Err: %v", err)
+	}
+
+	return toPixChargeDTO(*charge, account), http.StatusCreated, nil
+}
+
+func (s BRCodeService) ReadCharge(accountId int, id string) (dto.ReadPixChargeOutputDTO, int, error) {
+
+	charge, err := s.ChargeRepo.ReadByID(id)
+	if err != nil || charge.AccountID != accountId {
+		return dto.ReadPixChargeOutputDTO{}, http.StatusNotFound, fmt.Errorf("Charge not found!")
+	}
+
+	account, err := s.AccountRepo.ReadByID(accountId)
+	if err != nil {
+		return dto.ReadPixChargeOutputDTO{}, http.StatusNotFound, fmt.Errorf("Could not find the account!")
+	}
+
+	return toPixChargeDTO(charge, account), http.StatusOK, nil
+}
+
+// Pay transfers what the code asks from the origin account. Dynamic codes pay
+// their charge, which cannot be paid again.
+func (s BRCodeService) Pay(originId int, input dto.PayBRCodeInputDTO) (dto.ReadTransfersOutputDTO, int, error) {
+
+	code, err := entity.ParseBRCode(input.Payload)
+	if err != nil {
+		return dto.ReadTransfersOutputDTO{}, http.StatusBadRequest, err
+	}
+
+	transfer := dto.CreateTrasnferInputDTO{
+		PixKey:   code.PixKey,
+		Amount:   code.Amount,
+		Pin:      input.Pin,
+		TOTPCode: input.TOTPCode,
+	}
+
+	if !code.Dynamic {
+		if code.Amount == 0 {
+			transfer.Amount = input.Amount
+		} else if input.Amount != 0 && input.Amount != code.Amount {
+			return dto.ReadTransfersOutputDTO{}, http.StatusBadRequest, fmt.Errorf("The BR Code has a fixed amount of %d!", code.Amount)
+		}
+
+		return s.Transfers.CreateTransfer(originId, transfer)
+	}
+
+	charge, err := s.ChargeRepo.ReadByTxID(code.TxID)
+	if err != nil {
+		return dto.ReadTransfersOutputDTO{}, http.StatusNotFound, fmt.Errorf("Charge not found!")
+	}
+
+	if err := charge.CheckPayable(code, time.Now()); err != nil {
+		return dto.ReadTransfersOutputDTO{}, http.StatusConflict, err
+	}
+
+	// The charge account is credited, not whoever holds the key by now. A
+	// key moved to another account since would pay a stranger.
+	key, receiver, err := resolvePixKey(s.PixKeyRepo, s.AccountRepo, code.PixKey)
+	if err != nil || key.AccountID != charge.AccountID {
+		return dto.ReadTransfersOutputDTO{}, http.StatusConflict, fmt.Errorf("The Pix key of the charge no longer belongs to its receiver!")
+	}
+
+	transfer.PixKey = ""
+	transfer.AccountDestinationID = receiver.PublicID
+
+	now := time.Now()
+	charge.PaidAt = &now
+
+	if err := s.ChargeRepo.MarkPaid(charge); err != nil {
+		return dto.ReadTransfersOutputDTO{}, http.StatusConflict, fmt.Errorf("Charge was already paid!")
+	}
+
+	created, statusCode, err := s.Transfers.CreateTransfer(originId, transfer)
+	if err == entity.ErrTransferInDoubt {
+		return dto.ReadTransfersOutputDTO{}, statusCode, err
+	}
+
+	if err != nil {
+		if releaseErr := s.ChargeRepo.ReleasePayment(charge.ID); releaseErr != nil {
+			return dto.ReadTransfersOutputDTO{}, http.StatusInternalServerError, fmt.Errorf("Transfer failed and the charge could not be released! Err: %v", releaseErr)
+		}
+
+		return dto.ReadTransfersOutputDTO{}, statusCode, err
+	}
+
+	return created, statusCode, nil
+}
+
+// ownKey reads one of the account verified keys.
+func (s BRCodeService) ownKey(accountId int, value string) (entity.PixKey, entity.Account, int, error) {
+
+	key, account, err := resolvePixKey(s.PixKeyRepo, s.AccountRepo, value)
+	if err != nil || key.AccountID != accountId {
+		return entity.PixKey{}, entity.Account{}, http.StatusNotFound, fmt.Errorf("Pix key not found among the account verified keys!")
+	}
+
+	return key, account, http.StatusOK, nil
+}
+
+func toPixChargeDTO(charge entity.PixCharge, account entity.Account) dto.ReadPixChargeOutputDTO {
+	return dto.ReadPixChargeOutputDTO{
+		ID:        charge.ID,
+		Amount:    charge.Amount,
+		Payload:   charge.BRCode(account.Name).Encode(),
+		ExpiresAt: charge.ExpiresAt,
+		PaidAt:    charge.PaidAt,
+		CreatedAt: charge.CreatedAt,
+	}
+}
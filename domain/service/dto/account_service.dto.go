@@ -5,33 +5,64 @@ import (
 )
 
 type ReadAccountOutputDTO struct {
-	ID        int       `json:"id"`
+	ID        string    `json:"id"`
+	Agency    string    `json:"agency"`
+	Number    string    `json:"number"`
 	Name      string    `json:"name"`
-	CPF       string    `json:"cpf"`
+	CPF       string    `json:"cpf" redact:"cpf"`
 	Balance   int       `json:"balance"`
+	Status    string    `json:"status"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// UpdateAccountInputDTO holds the profile fields an account holder may change.
+// Missing fields are left untouched.
+type UpdateAccountInputDTO struct {
+	Name *string `json:"name"`
+}
+
 type ReadAccountBalanceInputDTO struct {
-	ID int `json:"id"`
+	ID string `json:"id"`
 }
 
+// ReadAccountBalanceOutputDTO keeps balance, the same as ledger, for older
+// clients. Available is the ledger balance minus the funds held.
 type ReadAccountBalanceOutputDTO struct {
-	Balance int `json:"balance"`
+	Balance   int `json:"balance"`
+	Available int `json:"available"`
+	Ledger    int `json:"ledger"`
 }
 
 type CreateAccountInputDTO struct {
 	Name    string `json:"name"`
-	CPF     string `json:"cpf"`
-	Secret  string `json:"secret"`
+	CPF     string `json:"cpf" redact:"cpf"`
+	Secret  string `json:"secret" redact:"secret"`
 	Balance int    `json:"balance"`
 }
 
 type LoginInputDTO struct {
-	CPF    string `json:"cpf"`
-	Secret string `json:"secret"`
+	CPF    string `json:"cpf" redact:"cpf"`
+	Secret string `json:"secret" redact:"secret"`
 }
 
+// LoginOutputDTO carries either the token, or the challenge to be completed on
+// /login/2fa when the account has two-factor enabled.
 type LoginOutputDTO struct {
-	Token string `json:"token"`
+	Token       string `json:"token,omitempty" redact:"secret"`
+	MFARequired bool   `json:"mfa_required,omitempty"`
+	Challenge   string `json:"challenge,omitempty"`
+}
+
+type ChangeSecretInputDTO struct {
+	CurrentSecret string `json:"current_secret" redact:"secret"`
+	NewSecret     string `json:"new_secret" redact:"secret"`
+}
+
+type RequestSecretResetInputDTO struct {
+	CPF string `json:"cpf" redact:"cpf"`
+}
+
+type ConfirmSecretResetInputDTO struct {
+	Token     string `json:"token" redact:"secret"`
+	NewSecret string `json:"new_secret" redact:"secret"`
 }
@@ -0,0 +1,87 @@
+package dto
+
+import "time"
+
+type AdminLoginInputDTO struct {
+	Email  string `json:"email"`
+	Secret string `json:"secret" redact:"secret"`
+}
+
+type CreateOperatorInputDTO struct {
+	Name        string   `json:"name"`
+	Email       string   `json:"email"`
+	Secret      string   `json:"secret" redact:"secret"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+type ReadOperatorOutputDTO struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type RevokeOperatorTokensInputDTO struct {
+	Reason string `json:"reason"`
+}
+
+type RevokeOperatorTokensOutputDTO struct {
+	OperatorID int `json:"operator_id"`
+	Revoked    int `json:"revoked"`
+}
+
+type SearchAccountsInputDTO struct {
+	Query string `json:"query"`
+}
+
+type SearchTransfersInputDTO struct {
+	AccountID string    `json:"account_id"`
+	MinAmount int       `json:"min_amount"`
+	MaxAmount int       `json:"max_amount"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+}
+
+type CreateAdjustmentInputDTO struct {
+	AccountID string `json:"account_id"`
+	Amount    int    `json:"amount"`
+	Reason    string `json:"reason"`
+}
+
+type CreateAdjustmentOutputDTO struct {
+	AccountID     string `json:"account_id"`
+	BalanceBefore int    `json:"balance_before"`
+	BalanceAfter  int    `json:"balance_after"`
+
+	// Internal ID the audit log references the account by, never shown.
+	AuditAccountID int `json:"-"`
+}
+
+type ChangeAccountStatusInputDTO struct {
+	AccountID string `json:"account_id"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason"`
+}
+
+type ChangeAccountStatusOutputDTO struct {
+	AccountID    string `json:"account_id"`
+	StatusBefore string `json:"status_before"`
+	StatusAfter  string `json:"status_after"`
+
+	// Internal ID the audit log references the account by, never shown.
+	AuditAccountID int `json:"-"`
+}
+
+type ReadAdminActionOutputDTO struct {
+	ID         int    `json:"id"`
+	OperatorID int    `json:"operator_id"`
+	Action     string `json:"action"`
+	// Public ID of the account, or ID of the operator, the action was done to.
+	TargetID  string                 `json:"target_id"`
+	Reason    string                 `json:"reason"`
+	Details   map[string]interface{} `json:"details"`
+	CreatedAt time.Time              `json:"created_at"`
+}
@@ -0,0 +1,36 @@
+package dto
+
+import "time"
+
+type CreateAPIKeyInputDTO struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// CreateAPIKeyOutputDTO is the only time the secret is shown.
+type CreateAPIKeyOutputDTO struct {
+	ID     string   `json:"id"`
+	Secret string   `json:"secret" redact:"secret"`
+	Scopes []string `json:"scopes"`
+}
+
+type ReadAPIKeyOutputDTO struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RotatedAt  time.Time  `json:"rotated_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// SignedRequestDTO holds what the API key middleware read from the request.
+type SignedRequestDTO struct {
+	KeyID     string
+	Signature string `redact:"secret"`
+	Timestamp string
+	Nonce     string
+	Method    string
+	Path      string
+	Body      []byte
+}
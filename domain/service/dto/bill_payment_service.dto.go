@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+type PayBillInputDTO struct {
+	// Linha digitável or barcode, with or without the dots and spaces.
+	Code     string `json:"code"`
+	Pin      string `json:"pin,omitempty" redact:"secret"`
+	TOTPCode string `json:"totp_code,omitempty" redact:"secret"`
+}
+
+type PayBillOutputDTO struct {
+	Barcode  string `json:"barcode"`
+	BankCode string `json:"bank_code"`
+	// Empty when the boleto has no due date.
+	DueDate         string `json:"due_date,omitempty"`
+	BeneficiaryName string `json:"beneficiary_name,omitempty"`
+	Amount          int    `json:"amount"`
+	// Amount plus fine and interest when paid late.
+	AmountPaid int       `json:"amount_paid"`
+	PaidAt     time.Time `json:"paid_at"`
+}
@@ -0,0 +1,46 @@
+package dto
+
+import "time"
+
+type CreateBoletoInputDTO struct {
+	Amount int `json:"amount"`
+	// As "2006-01-02".
+	DueDate       string `json:"due_date"`
+	PayerName     string `json:"payer_name"`
+	PayerDocument string `json:"payer_document" redact:"cpf"`
+	// Fine charged once after the due date, in basis points.
+	FineRate int `json:"fine_rate"`
+	// Interest per month late, in basis points.
+	InterestRate int `json:"interest_rate"`
+}
+
+type ReadBoletoOutputDTO struct {
+	ID            string     `json:"id"`
+	Amount        int        `json:"amount"`
+	AmountDue     int        `json:"amount_due"`
+	DueDate       string     `json:"due_date"`
+	PayerName     string     `json:"payer_name"`
+	PayerDocument string     `json:"payer_document" redact:"cpf"`
+	FineRate      int        `json:"fine_rate"`
+	InterestRate  int        `json:"interest_rate"`
+	Status        string     `json:"status"`
+	Barcode       string     `json:"barcode"`
+	DigitableLine string     `json:"digitable_line"`
+	PaidAt        *time.Time `json:"paid_at"`
+	PaidAmount    int        `json:"paid_amount"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type PayBoletoInputDTO struct {
+	// Linha digitável, with or without the dots and spaces.
+	DigitableLine string `json:"digitable_line"`
+	Pin           string `json:"pin,omitempty" redact:"secret"`
+	TOTPCode      string `json:"totp_code,omitempty" redact:"secret"`
+}
+
+type PayBoletoOutputDTO struct {
+	BoletoID string `json:"boleto_id"`
+	// Amount of the boleto plus fine and interest when paid late.
+	AmountPaid int       `json:"amount_paid"`
+	PaidAt     time.Time `json:"paid_at"`
+}
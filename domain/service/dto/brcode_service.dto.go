@@ -0,0 +1,38 @@
+package dto
+
+import "time"
+
+type CreateStaticBRCodeInputDTO struct {
+	// One of the holder verified keys, where the money is received.
+	PixKey string `json:"pix_key" redact:"secret"`
+	// Optional, without it the payer chooses the amount.
+	Amount int `json:"amount"`
+}
+
+type BRCodeOutputDTO struct {
+	Payload string `json:"payload"`
+}
+
+type CreatePixChargeInputDTO struct {
+	PixKey string `json:"pix_key" redact:"secret"`
+	Amount int    `json:"amount"`
+	// Seconds until the charge expires, 30 minutes when not given.
+	ExpiresIn int `json:"expires_in"`
+}
+
+type ReadPixChargeOutputDTO struct {
+	ID        string     `json:"id"`
+	Amount    int        `json:"amount"`
+	Payload   string     `json:"payload"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	PaidAt    *time.Time `json:"paid_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type PayBRCodeInputDTO struct {
+	Payload string `json:"payload"`
+	// Only for static codes without amount.
+	Amount   int    `json:"amount,omitempty"`
+	Pin      string `json:"pin,omitempty" redact:"secret"`
+	TOTPCode string `json:"totp_code,omitempty" redact:"secret"`
+}
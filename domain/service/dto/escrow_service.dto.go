@@ -0,0 +1,42 @@
+package dto
+
+import "time"
+
+// CreateEscrowInputDTO takes the payee as a transfer takes its destination.
+type CreateEscrowInputDTO struct {
+	AccountDestinationID     string `json:"account_destination_id,omitempty"`
+	PixKey                   string `json:"pix_key,omitempty" redact:"secret"`
+	AccountDestinationCPF    string `json:"account_destination_cpf,omitempty" redact:"cpf"`
+	AccountDestinationAgency string `json:"account_destination_agency,omitempty"`
+	AccountDestinationNumber string `json:"account_destination_number,omitempty"`
+	Amount                   int    `json:"amount"`
+	Description              string `json:"description,omitempty"`
+	// Seconds until the escrow is released without confirmation, defaults to
+	// two weeks.
+	ReleaseIn int    `json:"release_in,omitempty"`
+	Pin       string `json:"pin,omitempty" redact:"secret"`
+	TOTPCode  string `json:"totp_code,omitempty" redact:"secret"`
+}
+
+// ReadEscrowEventOutputDTO shows who did it as payer, payee or system.
+type ReadEscrowEventOutputDTO struct {
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ReadEscrowOutputDTO struct {
+	ID          string     `json:"id"`
+	PayerID     string     `json:"payer_id"`
+	PayeeID     string     `json:"payee_id"`
+	Amount      int        `json:"amount"`
+	Balance     int        `json:"balance"`
+	Description string     `json:"description,omitempty"`
+	Status      string     `json:"status"`
+	ReleaseAt   time.Time  `json:"release_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	SettledAt   *time.Time `json:"settled_at,omitempty"`
+	// Only when reading a single escrow.
+	Events []ReadEscrowEventOutputDTO `json:"events,omitempty"`
+}
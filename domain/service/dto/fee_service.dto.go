@@ -0,0 +1,29 @@
+package dto
+
+type FeeTierOutputDTO struct {
+	// Zero on the last tier, taking any amount.
+	UpTo int `json:"up_to"`
+	Flat int `json:"flat"`
+	Rate int `json:"rate"`
+}
+
+// FeeScheduleOutputDTO has amounts in cents and rates in basis points.
+type FeeScheduleOutputDTO struct {
+	Kind         string             `json:"kind"`
+	Flat         int                `json:"flat"`
+	Rate         int                `json:"rate"`
+	Tiers        []FeeTierOutputDTO `json:"tiers,omitempty"`
+	Min          int                `json:"min"`
+	Max          int                `json:"max,omitempty"`
+	FreePerMonth int                `json:"free_per_month"`
+}
+
+type FeeQuoteOutputDTO struct {
+	Kind   string `json:"kind"`
+	Amount int    `json:"amount"`
+	Fee    int    `json:"fee"`
+	// Amount plus fee, what leaves the account.
+	Total int `json:"total"`
+	// Free transfers of the kind left this month, this one not counted.
+	FreeRemaining int `json:"free_remaining"`
+}
@@ -0,0 +1,37 @@
+package dto
+
+import "time"
+
+// CreateHoldInputDTO takes the same destination a transfer would, the one
+// that later captures or voids the hold.
+type CreateHoldInputDTO struct {
+	AccountDestinationID     string `json:"account_destination_id,omitempty"`
+	PixKey                   string `json:"pix_key,omitempty" redact:"secret"`
+	AccountDestinationCPF    string `json:"account_destination_cpf,omitempty" redact:"cpf"`
+	AccountDestinationAgency string `json:"account_destination_agency,omitempty"`
+	AccountDestinationNumber string `json:"account_destination_number,omitempty"`
+	Amount                   int    `json:"amount"`
+	// Seconds until the hold expires, defaults to a week.
+	ExpiresIn int    `json:"expires_in,omitempty"`
+	Pin       string `json:"pin,omitempty" redact:"secret"`
+	TOTPCode  string `json:"totp_code,omitempty" redact:"secret"`
+}
+
+type CaptureHoldInputDTO struct {
+	// Up to the amount held, the whole hold when missing.
+	Amount int `json:"amount,omitempty"`
+}
+
+type ReadHoldOutputDTO struct {
+	ID                   string `json:"id"`
+	AccountOriginID      string `json:"account_origin_id"`
+	AccountDestinationID string `json:"account_destination_id"`
+	Amount               int    `json:"amount"`
+	CapturedAmount       int    `json:"captured_amount"`
+	Status               string `json:"status"`
+	// Transfer made by the capture.
+	TransferID string     `json:"transfer_id,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,7 @@
+package dto
+
+type SetPinInputDTO struct {
+	// Login secret, required to set or change the PIN.
+	Secret string `json:"secret" redact:"secret"`
+	Pin    string `json:"pin" redact:"secret"`
+}
@@ -0,0 +1,29 @@
+package dto
+
+import "time"
+
+type CreatePixKeyInputDTO struct {
+	// One of cpf, email, phone or evp. Random (evp) keys need no value.
+	Type  string `json:"type"`
+	Value string `json:"value" redact:"secret"`
+}
+
+type VerifyPixKeyInputDTO struct {
+	Code string `json:"code" redact:"secret"`
+}
+
+type ReadPixKeyOutputDTO struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Value     string    `json:"value"`
+	Verified  bool      `json:"verified"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LookupPixKeyOutputDTO shows the holder masked, as the payee confirmation,
+// and not the account ID.
+type LookupPixKeyOutputDTO struct {
+	Key  string `json:"key"`
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+import "time"
+
+// DataExportDTO bundles every personal data kept about an account holder.
+type DataExportDTO struct {
+	ExportedAt  time.Time                 `json:"exported_at"`
+	Account     ReadAccountOutputDTO      `json:"account"`
+	Transfers   []ReadTransfersOutputDTO  `json:"transfers"`
+	Sessions    []ReadSessionOutputDTO    `json:"sessions"`
+	AuditEvents []ReadAuditEventOutputDTO `json:"audit_events"`
+	PixKeys     []ReadPixKeyOutputDTO     `json:"pix_keys,omitempty"`
+}
+
+type ReadAuditEventOutputDTO struct {
+	ID        int                    `json:"id"`
+	Type      string                 `json:"type"`
+	Actor     string                 `json:"actor"`
+	IP        string                 `json:"ip"`
+	RequestID string                 `json:"request_id"`
+	Subject   string                 `json:"subject"`
+	Before    map[string]interface{} `json:"before,omitempty"`
+	After     map[string]interface{} `json:"after,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+type EraseAccountInputDTO struct {
+	// The account secret, confirming the holder really wants the erasure.
+	Secret string `json:"secret" redact:"secret"`
+}
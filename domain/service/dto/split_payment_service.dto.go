@@ -0,0 +1,20 @@
+package dto
+
+// SplitRuleInputDTO takes the same destination a transfer would, and its
+// share: a fixed value in cents, or a percentage in basis points.
+type SplitRuleInputDTO struct {
+	AccountDestinationID     string `json:"account_destination_id,omitempty"`
+	PixKey                   string `json:"pix_key,omitempty" redact:"secret"`
+	AccountDestinationCPF    string `json:"account_destination_cpf,omitempty" redact:"cpf"`
+	AccountDestinationAgency string `json:"account_destination_agency,omitempty"`
+	AccountDestinationNumber string `json:"account_destination_number,omitempty"`
+	Type                     string `json:"type"`
+	Value                    int    `json:"value"`
+}
+
+type CreateSplitPaymentInputDTO struct {
+	Amount   int                 `json:"amount"`
+	Rules    []SplitRuleInputDTO `json:"rules"`
+	Pin      string              `json:"pin,omitempty" redact:"secret"`
+	TOTPCode string              `json:"totp_code,omitempty" redact:"secret"`
+}
@@ -0,0 +1,31 @@
+package dto
+
+import "time"
+
+// CreateTransferBatchInputDTO is authorized once, for the total, by its PIN
+// and two-factor code. The ones of each transfer are ignored.
+type CreateTransferBatchInputDTO struct {
+	// "atomic" or "best_effort".
+	Mode      string                   `json:"mode"`
+	Pin       string                   `json:"pin,omitempty" redact:"secret"`
+	TOTPCode  string                   `json:"totp_code,omitempty" redact:"secret"`
+	Transfers []CreateTrasnferInputDTO `json:"transfers"`
+}
+
+type ReadTransferBatchItemOutputDTO struct {
+	AccountDestinationID string `json:"account_destination_id"`
+	Amount               int    `json:"amount"`
+	Status               string `json:"status"`
+	Error                string `json:"error,omitempty"`
+	TransferID           string `json:"transfer_id,omitempty"`
+}
+
+type ReadTransferBatchOutputDTO struct {
+	ID         string                           `json:"id"`
+	Mode       string                           `json:"mode"`
+	Status     string                           `json:"status"`
+	Total      int                              `json:"total"`
+	Items      []ReadTransferBatchItemOutputDTO `json:"items"`
+	CreatedAt  time.Time                        `json:"created_at"`
+	FinishedAt *time.Time                       `json:"finished_at"`
+}
@@ -3,15 +3,72 @@ package dto
 import "time"
 
 type ReadTransfersOutputDTO struct {
-	ID                   int       `json:"id"`
-	AccountOriginID      int       `json:"account_origin_id"`
-	AccountDestinationID int       `json:"account_destination_id"`
+	ID              string `json:"id"`
+	Kind            string `json:"kind"`
+	AccountOriginID string `json:"account_origin_id"`
+	// Empty for boletos paid to other banks.
+	AccountDestinationID string    `json:"account_destination_id,omitempty"`
+	Barcode              string    `json:"barcode,omitempty"`
 	Amount               int       `json:"amount"`
 	CreatedAt            time.Time `json:"created_at"`
+	// Split payment a split credit is part of.
+	ParentID string `json:"parent_id,omitempty"`
+	// Escrow the money went into or came out of.
+	EscrowID string `json:"escrow_id,omitempty"`
+	// Credits of a split payment, shown to the payer only.
+	Splits []ReadTransfersOutputDTO `json:"splits,omitempty"`
 }
 
+// CreateTrasnferInputDTO has no origin, it is always the authenticated
+// account. The destination is one of an account ID, a Pix key, a CPF or an
+// account number.
 type CreateTrasnferInputDTO struct {
-	AccountOriginID      int `json:"account_origin_id"`
-	AccountDestinationID int `json:"account_destination_id"`
-	Amount               int `json:"amount"`
+	AccountDestinationID  string `json:"account_destination_id,omitempty"`
+	PixKey                string `json:"pix_key,omitempty" redact:"secret"`
+	AccountDestinationCPF string `json:"account_destination_cpf,omitempty" redact:"cpf"`
+	// Agency is optional, accounts are all opened in the same one.
+	AccountDestinationAgency string `json:"account_destination_agency,omitempty"`
+	AccountDestinationNumber string `json:"account_destination_number,omitempty"`
+	Amount                   int    `json:"amount"`
+	// Transaction PIN of the origin account.
+	Pin string `json:"pin,omitempty" redact:"secret"`
+	// Two-factor code or recovery code, required above the step-up threshold.
+	TOTPCode string `json:"totp_code,omitempty" redact:"secret"`
+}
+
+// PreviewTransferOutputDTO is what a transfer would do if sent now.
+// PreviewTransferOutputDTO does not show the destination account ID, it is
+// found from keys, CPFs and numbers.
+type PreviewTransferOutputDTO struct {
+	// Masked, as on the payee confirmation.
+	Name   string `json:"name"`
+	Amount int    `json:"amount"`
+	Fee    int    `json:"fee"`
+	// Amount plus fee, what leaves the account.
+	Total          int `json:"total"`
+	BalanceAfter   int `json:"balance_after"`
+	AvailableAfter int `json:"available_after"`
+	// Whether sending needs a two-factor code besides the PIN.
+	RequiresTwoFactor bool `json:"requires_two_factor"`
+	// What can still be sent today once this transfer is, only when there is
+	// a daily limit.
+	RemainingDailyLimit *int `json:"remaining_daily_limit,omitempty"`
+}
+
+// ConfirmPayeeInputDTO takes the same destination a transfer would.
+type ConfirmPayeeInputDTO struct {
+	AccountDestinationID     string `json:"account_destination_id,omitempty"`
+	PixKey                   string `json:"pix_key,omitempty" redact:"secret"`
+	AccountDestinationCPF    string `json:"account_destination_cpf,omitempty" redact:"cpf"`
+	AccountDestinationAgency string `json:"account_destination_agency,omitempty"`
+	AccountDestinationNumber string `json:"account_destination_number,omitempty"`
+}
+
+// ConfirmPayeeOutputDTO only shows masked holder data, for the payer to check
+// before sending. The account ID is not shown, anyone could otherwise find it
+// from a CPF.
+type ConfirmPayeeOutputDTO struct {
+	Name       string `json:"name"`
+	CPF        string `json:"cpf"`
+	CanReceive bool   `json:"can_receive"`
 }
@@ -0,0 +1,16 @@
+package dto
+
+type EnrollTwoFactorOutputDTO struct {
+	Secret          string   `json:"secret" redact:"secret"`
+	ProvisioningURI string   `json:"provisioning_uri"`
+	RecoveryCodes   []string `json:"recovery_codes" redact:"secret"`
+}
+
+type TwoFactorCodeInputDTO struct {
+	Code string `json:"code" redact:"secret"`
+}
+
+type LoginTwoFactorInputDTO struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code" redact:"secret"`
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+)
+
+type EscrowRepository interface {
+	// Create debits the payer and funds the escrow, or does nothing. The
+	// deposit counts to the limit, when given.
+	Create(escrow entity.Escrow, event entity.EscrowEvent, limit *entity.TransferLimit) (entity.Transfer, error)
+	// Settle credits the beneficiary of an escrow released or refunded, or
+	// does nothing.
+	Settle(escrow entity.Escrow, event entity.EscrowEvent) (entity.Transfer, error)
+	ReadByID(id string) (entity.Escrow, error)
+	ReadByAccountID(accountId int) ([]entity.Escrow, error)
+	ReadDue(now time.Time) ([]entity.Escrow, error)
+	ReadEvents(escrowId string) ([]entity.EscrowEvent, error)
+	SaveReleaseFailures(escrow entity.Escrow) error
+	// Fail stores the escrow failed, moving no money, or does nothing.
+	Fail(escrow entity.Escrow, event entity.EscrowEvent) error
+	Reset() error
+}
+
+// EscrowService takes money of the payer into an escrow, released to the
+// payee when the payer confirms it, or when due, and refunded when the payee
+// cancels it.
+type EscrowService struct {
+	Repo      EscrowRepository
+	Transfers *TransferService
+
+	// Optional. When set, escrows settled when due are recorded in the audit
+	// log, the others are recorded by the handlers.
+	Audit *AuditService
+}
+
+func NewEscrowService(repo EscrowRepository, transfers *TransferService) *EscrowService {
+	return &EscrowService{Repo: repo, Transfers: transfers}
+}
+
+func (s EscrowService) Create(payerId int, input dto.CreateEscrowInputDTO) (dto.ReadEscrowOutputDTO, int, error) {
+
+	if statusCode, err := s.Transfers.authorize(payerId, dto.CreateTrasnferInputDTO{Amount: input.Amount, Pin: input.Pin, TOTPCode: input.TOTPCode}); err != nil {
+		return dto.ReadEscrowOutputDTO{}, statusCode, err
+	}
+
+	payee, statusCode, err := s.Transfers.readDestination(dto.CreateTrasnferInputDTO{
+		AccountDestinationID:     input.AccountDestinationID,
+		PixKey:                   input.PixKey,
+		AccountDestinationCPF:    input.AccountDestinationCPF,
+		AccountDestinationAgency: input.AccountDestinationAgency,
+		AccountDestinationNumber: input.AccountDestinationNumber,
+	})
+	if err != nil {
+		return dto.ReadEscrowOutputDTO{}, statusCode, err
+	}
+
+	payer, err := s.Transfers.AccountRepo.ReadByID(payerId)
+	if err != nil {
+		return dto.ReadEscrowOutputDTO{}, http.StatusNotFound, fmt.Errorf("Could not find the payer account!")
+	}
+
+	if err := payer.CheckActive(); err != nil {
+		return dto.ReadEscrowOutputDTO{}, http.StatusForbidden, err
+	}
+
+	if err := payee.CheckActive(); err != nil {
+		return dto.ReadEscrowOutputDTO{}, http.StatusConflict, fmt.Errorf("Payee account cannot receive transfers. Err: %v", err)
+	}
+
+	now := time.Now()
+
+	escrow, err := entity.NewEscrow(payer.ID, payee.ID, input.Amount, input.Description, time.Duration(input.ReleaseIn)*time.Second, now)
+	if err != nil {
+		return dto.ReadEscrowOutputDTO{}, http.StatusBadRequest, err
+	}
+
+	if payer.Available() < escrow.Amount {
+		return dto.ReadEscrowOutputDTO{}, http.StatusBadRequest, fmt.Errorf("Cannot fund escrow because insufficient funds. Available Balance: %d, Escrow amount: %d", payer.Available(), escrow.Amount)
+	}
+
+	event := entity.NewEscrowEvent(escrow.ID, "", escrow.Status, entity.AuditAccount(payer.ID), now)
+	if _, err := s.Repo.Create(*escrow, *event, s.Transfers.dailyLimit()); err != nil {
+		return dto.ReadEscrowOutputDTO{}, http.StatusConflict, fmt.Errorf("Could not fund escrow! Err: %v", err)
+	}
+
+	escrow.PayerPublicID = payer.PublicID
+	escrow.PayeePublicID = payee.PublicID
+
+	return toEscrowDTO(*escrow, []entity.EscrowEvent{*event}), http.StatusCreated, nil
+}
+
+func (s EscrowService) ReadEscrows(accountId int) ([]dto.ReadEscrowOutputDTO, int, error) {
+
+	escrows, err := s.Repo.ReadByAccountID(accountId)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Could not read escrows! Err: %v", err)
+	}
+
+	output := []dto.ReadEscrowOutputDTO{}
+	for _, escrow := range escrows {
+		output = append(output, toEscrowDTO(escrow, nil))
+	}
+
+	return output, http.StatusOK, nil
+}
+
+// Read shows the escrow, with its events, to the payer and the payee.
+func (s EscrowService) Read(accountId int, id string) (dto.ReadEscrowOutputDTO, int, error) {
+
+	escrow, err := s.Repo.ReadByID(id)
+	if err != nil || (escrow.PayerID != accountId && escrow.PayeeID != accountId) {
+		return dto.ReadEscrowOutputDTO{}, http.StatusNotFound, fmt.Errorf("Escrow not found!")
+	}
+
+	events, err := s.Repo.ReadEvents(escrow.ID)
+	if err != nil {
+		return dto.ReadEscrowOutputDTO{}, http.StatusInternalServerError, fmt.Errorf("Could not read escrow events! Err: %v", err)
+	}
+
+	return toEscrowDTO(escrow, events), http.StatusOK, nil
+}
+
+// Release is the payer confirming the delivery.
+func (s EscrowService) Release(accountId int, id string) (dto.ReadEscrowOutputDTO, int, error) {
+
+	escrow, err := s.Repo.ReadByID(id)
+	if err != nil || escrow.PayerID != accountId {
+		return dto.ReadEscrowOutputDTO{}, http.StatusNotFound, fmt.Errorf("Escrow not found!")
+	}
+
+	return s.settle(&escrow, entity.AuditAccount(accountId), entity.EscrowReleased)
+}
+
+// Refund is the payee cancelling, before the escrow is due.
+func (s EscrowService) Refund(accountId int, id string) (dto.ReadEscrowOutputDTO, int, error) {
+
+	escrow, err := s.Repo.ReadByID(id)
+	if err != nil || escrow.PayeeID != accountId {
+		return dto.ReadEscrowOutputDTO{}, http.StatusNotFound, fmt.Errorf("Escrow not found!")
+	}
+
+	return s.settle(&escrow, entity.AuditAccount(accountId), entity.EscrowRefunded)
+}
+
+// ReleaseDue releases to the payees the escrows past their release date.
+// Escrows that fail are left funded, to be tried again, up to
+// ESCROW_MAX_RELEASE_FAILURES times. They are then refunded to the payer, or
+// failed when the payer cannot be credited either. Returns how many were
+// released.
+func (s EscrowService) ReleaseDue() (int, error) {
+
+	escrows, err := s.Repo.ReadDue(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, escrow := range escrows {
+		due := escrow
+		if _, _, err := s.settle(&due, entity.AUDIT_ACTOR_SYSTEM, entity.EscrowReleased); err == nil {
+			released++
+			s.audit(entity.AuditEscrowReleased, due)
+			continue
+		}
+
+		if !escrow.FailRelease() {
+			s.Repo.SaveReleaseFailures(escrow)
+			continue
+		}
+
+		s.giveUp(escrow)
+	}
+
+	return released, nil
+}
+
+// giveUp refunds to the payer an escrow its payee cannot receive, or fails it
+// when the payer cannot be credited either, leaving it to the operators.
+func (s EscrowService) giveUp(escrow entity.Escrow) {
+
+	now := time.Now()
+
+	refund := escrow
+	if err := refund.RefundUnreleased(now); err == nil {
+		event := entity.NewEscrowEvent(refund.ID, entity.EscrowFunded, refund.Status, entity.AUDIT_ACTOR_SYSTEM, now)
+		if _, err := s.Repo.Settle(refund, *event); err == nil {
+			s.audit(entity.AuditEscrowRefunded, refund)
+			return
+		}
+	}
+
+	if err := escrow.Fail(now); err != nil {
+		return
+	}
+
+	event := entity.NewEscrowEvent(escrow.ID, entity.EscrowFunded, escrow.Status, entity.AUDIT_ACTOR_SYSTEM, now)
+	if err := s.Repo.Fail(escrow, *event); err != nil {
+		return
+	}
+
+	s.audit(entity.AuditEscrowFailed, escrow)
+}
+
+// audit records the escrows settled by the system, the others are recorded by
+// the handlers.
+func (s EscrowService) audit(eventType string, escrow entity.Escrow) {
+
+	if s.Audit == nil {
+		return
+	}
+
+	s.Audit.Record(*entity.NewAuditEvent(eventType, entity.AUDIT_ACTOR_SYSTEM, entity.AuditEscrow(escrow.ID),
+		map[string]interface{}{"status": entity.EscrowFunded, "balance": escrow.Amount},
+		map[string]interface{}{"status": string(escrow.Status), "balance": escrow.Balance(), "automatic": true},
+	))
+}
+
+// settle releases or refunds the escrow, by the status given, and moves the
+// money.
+func (s EscrowService) settle(escrow *entity.Escrow, actor string, status entity.EscrowStatus) (dto.ReadEscrowOutputDTO, int, error) {
+
+	now := time.Now()
+	from := escrow.Status
+
+	var err error
+	if status == entity.EscrowRefunded {
+		err = escrow.Refund(now)
+	} else {
+		err = escrow.Release(now)
+	}
+
+	if err != nil {
+		return dto.ReadEscrowOutputDTO{}, http.StatusConflict, err
+	}
+
+	event := entity.NewEscrowEvent(escrow.ID, from, escrow.Status, actor, now)
+	if _, err := s.Repo.Settle(*escrow, *event); err != nil {
+		return dto.ReadEscrowOutputDTO{}, http.StatusConflict, fmt.Errorf("Could not settle escrow! Err: %v", err)
+	}
+
+	return toEscrowDTO(*escrow, nil), http.StatusOK, nil
+}
+
+func toEscrowDTO(escrow entity.Escrow, events []entity.EscrowEvent) dto.ReadEscrowOutputDTO {
+
+	output := dto.ReadEscrowOutputDTO{
+		ID:          escrow.ID,
+		PayerID:     escrow.PayerPublicID,
+		PayeeID:     escrow.PayeePublicID,
+		Amount:      escrow.Amount,
+		Balance:     escrow.Balance(),
+		Description: escrow.Description,
+		Status:      string(escrow.Status),
+		ReleaseAt:   escrow.ReleaseAt,
+		CreatedAt:   escrow.CreatedAt,
+		SettledAt:   escrow.SettledAt,
+	}
+
+	// Account actors carry the sequential IDs, they are shown by their role.
+	for _, event := range events {
+		actor := event.Actor
+		switch actor {
+		case entity.AuditAccount(escrow.PayerID):
+			actor = "payer"
+		case entity.AuditAccount(escrow.PayeeID):
+			actor = "payee"
+		}
+
+		output.Events = append(output.Events, dto.ReadEscrowEventOutputDTO{
+			From:      string(event.From),
+			To:        string(event.To),
+			Actor:     actor,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+
+	return output
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+)
+
+// FeeService prices transfers by the schedule of their kind. Fees are made as
+// transfers of their own to the revenue account, kinds without a schedule are
+// free.
+type FeeService struct {
+	Schedules        map[entity.TransferKind]entity.FeeSchedule
+	RevenueAccountID int
+	TransferRepo     TransferRepository
+}
+
+func NewFeeService(schedules []entity.FeeSchedule, revenueAccountID int, transferRepo TransferRepository) (*FeeService, error) {
+
+	byKind := map[entity.TransferKind]entity.FeeSchedule{}
+	for _, schedule := range schedules {
+		if err := schedule.Validate(); err != nil {
+			return nil, err
+		}
+
+		if _, ok := byKind[schedule.Kind]; ok {
+			return nil, fmt.Errorf("Fee schedule of %s given more than once!", schedule.Kind)
+		}
+
+		byKind[schedule.Kind] = schedule
+	}
+
+	return &FeeService{Schedules: byKind, RevenueAccountID: revenueAccountID, TransferRepo: transferRepo}, nil
+}
+
+func (s FeeService) ReadSchedules() []dto.FeeScheduleOutputDTO {
+
+	output := []dto.FeeScheduleOutputDTO{}
+	for _, kind := range []entity.TransferKind{entity.TransferKindTransfer, entity.TransferKindBoletoPayment} {
+		schedule, ok := s.Schedules[kind]
+		if !ok {
+			continue
+		}
+
+		tiers := []dto.FeeTierOutputDTO{}
+		for _, tier := range schedule.Tiers {
+			tiers = append(tiers, dto.FeeTierOutputDTO{UpTo: tier.UpTo, Flat: tier.Flat, Rate: tier.Rate})
+		}
+
+		output = append(output, dto.FeeScheduleOutputDTO{
+			Kind:         string(schedule.Kind),
+			Flat:         schedule.Flat,
+			Rate:         schedule.Rate,
+			Tiers:        tiers,
+			Min:          schedule.Min,
+			Max:          schedule.Max,
+			FreePerMonth: schedule.FreePerMonth,
+		})
+	}
+
+	return output
+}
+
+// Quote tells the account how much it would pay on a transfer of the kind
+// and amount made now.
+func (s FeeService) Quote(originId int, kind string, amount int) (dto.FeeQuoteOutputDTO, int, error) {
+
+	transferKind := entity.TransferKind(kind)
+	if transferKind != entity.TransferKindTransfer && transferKind != entity.TransferKindBoletoPayment {
+		return dto.FeeQuoteOutputDTO{}, http.StatusBadRequest, fmt.Errorf("Fees can only be quoted for %s and %s!", entity.TransferKindTransfer, entity.TransferKindBoletoPayment)
+	}
+
+	if amount <= 0 {
+		return dto.FeeQuoteOutputDTO{}, http.StatusBadRequest, fmt.Errorf("Amount must be greater than 0!")
+	}
+
+	output := dto.FeeQuoteOutputDTO{Kind: kind, Amount: amount, Total: amount}
+
+	schedule, ok := s.Schedules[transferKind]
+	if !ok || originId == s.RevenueAccountID {
+		return output, http.StatusOK, nil
+	}
+
+	used, err := s.usedThisMonth(originId, transferKind)
+	if err != nil {
+		return dto.FeeQuoteOutputDTO{}, http.StatusInternalServerError, fmt.Errorf("Could not quote the fee! Err: %v", err)
+	}
+
+	output.Fee = schedule.Fee(amount, used)
+	output.Total += output.Fee
+	output.FreeRemaining = schedule.FreeRemaining(used + 1)
+
+	return output, http.StatusOK, nil
+}
+
+// fees prices transfers of the kind made one after another, the earlier ones
+// using up the free transfers of the month. The fees are ready to be made,
+// nil when free.
+func (s FeeService) fees(originId int, kind entity.TransferKind, amounts []int) ([]*entity.Transfer, error) {
+
+	fees := make([]*entity.Transfer, len(amounts))
+
+	schedule, ok := s.Schedules[kind]
+	if !ok || originId == s.RevenueAccountID {
+		return fees, nil
+	}
+
+	used, err := s.usedThisMonth(originId, kind)
+	if err != nil {
+		return nil, fmt.Errorf("Could not price the fee! Err: %v", err)
+	}
+
+	for i, amount := range amounts {
+		if fee := schedule.Fee(amount, used+i); fee > 0 {
+			fees[i] = entity.NewTransfer(0, originId, s.RevenueAccountID, fee, time.Now())
+			fees[i].Kind = entity.TransferKindFee
+		}
+	}
+
+	return fees, nil
+}
+
+// fee prices a single transfer.
+func (s FeeService) fee(originId int, kind entity.TransferKind, amount int) (*entity.Transfer, error) {
+
+	fees, err := s.fees(originId, kind, []int{amount})
+	if err != nil {
+		return nil, err
+	}
+
+	return fees[0], nil
+}
+
+// charge is how a transfer of the kind is priced as it is made, nil when the
+// kind is free.
+func (s FeeService) charge(originId int, kind entity.TransferKind) *entity.FeeCharge {
+
+	schedule, ok := s.Schedules[kind]
+	if !ok || originId == s.RevenueAccountID {
+		return nil
+	}
+
+	return &entity.FeeCharge{Schedule: schedule, RevenueAccountID: s.RevenueAccountID, Since: entity.StartOfMonth(time.Now())}
+}
+
+func (s FeeService) usedThisMonth(originId int, kind entity.TransferKind) (int, error) {
+	return s.TransferRepo.CountByOrigin(originId, kind, entity.StartOfMonth(time.Now()))
+}
+
+// feeAmount is the amount of a fee that may not be charged.
+func feeAmount(fee *entity.Transfer) int {
+
+	if fee == nil {
+		return 0
+	}
+
+	return fee.Amount
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+)
+
+type HoldRepository interface {
+	// Create fails when the account has not enough available balance.
+	Create(hold entity.Hold) error
+	ReadByID(id string) (entity.Hold, error)
+	// Capture transfers the amount captured and releases the hold, or does
+	// nothing. The capture counts to the limit, when given.
+	Capture(hold entity.Hold, limit *entity.TransferLimit) (entity.Transfer, error)
+	Void(hold entity.Hold) error
+	ExpireStale(now time.Time) (int, error)
+	Reset() error
+}
+
+// HoldService reserves funds of the account holder, authorizing a hold, for
+// the destination to capture or void it later, like a card payment.
+type HoldService struct {
+	Repo      HoldRepository
+	Transfers *TransferService
+}
+
+func NewHoldService(repo HoldRepository, transfers *TransferService) *HoldService {
+	return &HoldService{Repo: repo, Transfers: transfers}
+}
+
+func (s HoldService) Authorize(originId int, input dto.CreateHoldInputDTO) (dto.ReadHoldOutputDTO, int, error) {
+
+	if statusCode, err := s.Transfers.authorize(originId, dto.CreateTrasnferInputDTO{Amount: input.Amount, Pin: input.Pin, TOTPCode: input.TOTPCode}); err != nil {
+		return dto.ReadHoldOutputDTO{}, statusCode, err
+	}
+
+	destination, statusCode, err := s.Transfers.readDestination(dto.CreateTrasnferInputDTO{
+		AccountDestinationID:     input.AccountDestinationID,
+		PixKey:                   input.PixKey,
+		AccountDestinationCPF:    input.AccountDestinationCPF,
+		AccountDestinationAgency: input.AccountDestinationAgency,
+		AccountDestinationNumber: input.AccountDestinationNumber,
+	})
+	if err != nil {
+		return dto.ReadHoldOutputDTO{}, statusCode, err
+	}
+
+	origin, err := s.Transfers.AccountRepo.ReadByID(originId)
+	if err != nil {
+		return dto.ReadHoldOutputDTO{}, http.StatusNotFound, fmt.Errorf("Could not find the origin account!")
+	}
+
+	if err := origin.CheckActive(); err != nil {
+		return dto.ReadHoldOutputDTO{}, http.StatusForbidden, err
+	}
+
+	if err := destination.CheckActive(); err != nil {
+		return dto.ReadHoldOutputDTO{}, http.StatusConflict, fmt.Errorf("Destination account cannot receive transfers. Err: %v", err)
+	}
+
+	hold, err := entity.NewHold(origin.ID, destination.ID, input.Amount, time.Duration(input.ExpiresIn)*time.Second, time.Now())
+	if err != nil {
+		return dto.ReadHoldOutputDTO{}, http.StatusBadRequest, err
+	}
+
+	if origin.Available() < hold.Amount {
+		return dto.ReadHoldOutputDTO{}, http.StatusBadRequest, fmt.Errorf("Cannot hold funds because insufficient funds. Available Balance: %d, Hold amount: %d", origin.Available(), hold.Amount)
+	}
+
+	// Checked again on capture, what was sent in between counts too.
+	if _, statusCode, err := s.Transfers.checkDailyLimit(origin.ID, hold.Amount); err != nil {
+		return dto.ReadHoldOutputDTO{}, statusCode, err
+	}
+
+	if err := s.Repo.Create(*hold); err != nil {
+		return dto.ReadHoldOutputDTO{}, http.StatusConflict, fmt.Errorf("Could not hold funds! Err: %v", err)
+	}
+
+	hold.AccountPublicID = origin.PublicID
+	hold.DestinationPublicID = destination.PublicID
+
+	return toHoldDTO(*hold, time.Now()), http.StatusCreated, nil
+}
+
+// Read shows the hold to both the account holder and the destination.
+func (s HoldService) Read(accountId int, id string) (dto.ReadHoldOutputDTO, int, error) {
+
+	hold, err := s.Repo.ReadByID(id)
+	if err != nil || (hold.AccountID != accountId && hold.DestinationID != accountId) {
+		return dto.ReadHoldOutputDTO{}, http.StatusNotFound, fmt.Errorf("Hold not found!")
+	}
+
+	return toHoldDTO(hold, time.Now()), http.StatusOK, nil
+}
+
+// Capture is done by the destination, once it knows the final amount.
+func (s HoldService) Capture(accountId int, id string, input dto.CaptureHoldInputDTO) (dto.ReadHoldOutputDTO, int, error) {
+
+	hold, err := s.Repo.ReadByID(id)
+	if err != nil || hold.DestinationID != accountId {
+		return dto.ReadHoldOutputDTO{}, http.StatusNotFound, fmt.Errorf("Hold not found!")
+	}
+
+	now := time.Now()
+
+	if input.Amount < 0 || input.Amount > hold.Amount {
+		return dto.ReadHoldOutputDTO{}, http.StatusBadRequest, fmt.Errorf("Capture amount must be between 1 and the amount held. Held: %d, Capture: %d", hold.Amount, input.Amount)
+	}
+
+	if err := hold.Capture(input.Amount, now); err != nil {
+		return dto.ReadHoldOutputDTO{}, http.StatusConflict, err
+	}
+
+	transfer, err := s.Repo.Capture(hold, s.Transfers.dailyLimit())
+	if err != nil {
+		return dto.ReadHoldOutputDTO{}, http.StatusConflict, fmt.Errorf("Could not capture hold! Err: %v", err)
+	}
+
+	hold.TransferID = transfer.PublicID
+
+	return toHoldDTO(hold, now), http.StatusOK, nil
+}
+
+// Void is done by the destination, releasing the whole hold.
+func (s HoldService) Void(accountId int, id string) (dto.ReadHoldOutputDTO, int, error) {
+
+	hold, err := s.Repo.ReadByID(id)
+	if err != nil || hold.DestinationID != accountId {
+		return dto.ReadHoldOutputDTO{}, http.StatusNotFound, fmt.Errorf("Hold not found!")
+	}
+
+	now := time.Now()
+
+	if err := hold.Void(now); err != nil {
+		return dto.ReadHoldOutputDTO{}, http.StatusConflict, err
+	}
+
+	if err := s.Repo.Void(hold); err != nil {
+		return dto.ReadHoldOutputDTO{}, http.StatusConflict, fmt.Errorf("Could not void hold! Err: %v", err)
+	}
+
+	return toHoldDTO(hold, now), http.StatusOK, nil
+}
+
+// ExpireStale marks the holds past their expiry. They stop reserving funds
+// when they expire, marking them only keeps their status right.
+func (s HoldService) ExpireStale() (int, error) {
+	return s.Repo.ExpireStale(time.Now())
+}
+
+func toHoldDTO(hold entity.Hold, now time.Time) dto.ReadHoldOutputDTO {
+	return dto.ReadHoldOutputDTO{
+		ID:                   hold.ID,
+		AccountOriginID:      hold.AccountPublicID,
+		AccountDestinationID: hold.DestinationPublicID,
+		Amount:               hold.Amount,
+		CapturedAmount:       hold.CapturedAmount,
+		Status:               string(hold.StatusAt(now)),
+		TransferID:           hold.TransferID,
+		ExpiresAt:            hold.ExpiresAt,
+		CreatedAt:            hold.CreatedAt,
+		FinishedAt:           hold.FinishedAt,
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+)
+
+// Logins wait this long when their attempts cannot be counted, as letting
+// them through would lift the lockout whenever the store is down.
+const LOGIN_GUARD_UNAVAILABLE_WAIT = 30 * time.Second
+
+type LoginAttemptRepository interface {
+	// Attempt counts an attempt on the key unless it is locked, reporting
+	// whether it may go on. Parallel attempts are counted one after another.
+	Attempt(key string, now time.Time) (entity.LoginAttempt, bool, error)
+	// Refund forgets an attempt that succeeded.
+	Refund(key string) error
+	Delete(key string) error
+	Reset() error
+}
+
+// LoginGuardService throttles logins by tracking failures per CPF and per
+// client IP. Attempts are counted before the credentials are checked, and
+// given back when they succeed.
+type LoginGuardService struct {
+	Repo LoginAttemptRepository
+}
+
+func NewLoginGuardService(repo LoginAttemptRepository) *LoginGuardService {
+	return &LoginGuardService{Repo: repo}
+}
+
+// Attempt counts a login and returns how long the caller must wait before
+// trying again, or zero when the login may proceed.
+func (s LoginGuardService) Attempt(cpf, ip string) time.Duration {
+	return s.attempt(attemptKeys(cpf, ip)...)
+}
+
+// RegisterSuccess forgets the failures of the CPF. The IP only gets the
+// attempt back, so an attacker cannot reset it by logging into an account of
+// their own.
+func (s LoginGuardService) RegisterSuccess(cpf, ip string) {
+	s.Repo.Delete(cpfKey(cpf))
+	s.Repo.Refund(ipKey(ip))
+}
+
+// AttemptOperator counts an operator login the same way, keyed by email.
+func (s LoginGuardService) AttemptOperator(email, ip string) time.Duration {
+	return s.attempt(operatorKey(email), ipKey(ip))
+}
+
+func (s LoginGuardService) RegisterOperatorSuccess(email, ip string) {
+	s.Repo.Delete(operatorKey(email))
+	s.Repo.Refund(ipKey(ip))
+}
+
+// AttemptAccount counts an attempt at a credential of an account already
+// logged in, as its two-factor code, with the same lockout of logins.
+func (s LoginGuardService) AttemptAccount(accountId int, credential string) time.Duration {
+	return s.attempt(accountKey(accountId, credential))
+}
+
+func (s LoginGuardService) RegisterAccountSuccess(accountId int, credential string) {
+	s.Repo.Delete(accountKey(accountId, credential))
+}
+
+// Forget deletes every failure kept for the account, when it is erased.
+func (s LoginGuardService) Forget(accountId int, cpf string) error {
+
+	keys := []string{cpfKey(cpf)}
+	for _, credential := range accountCredentials {
+		keys = append(keys, accountKey(accountId, credential))
+	}
+
+	for _, key := range keys {
+		if err := s.Repo.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// attempt counts the attempt on every key, giving it back to all of them when
+// one is locked or cannot be counted.
+func (s LoginGuardService) attempt(keys ...string) time.Duration {
+
+	now := time.Now()
+	counted := []string{}
+	var wait time.Duration
+
+	for _, key := range keys {
+		attempt, allowed, err := s.Repo.Attempt(key, now)
+		if err != nil {
+			wait = max(wait, LOGIN_GUARD_UNAVAILABLE_WAIT)
+			continue
+		}
+
+		if !allowed {
+			wait = max(wait, attempt.LockedUntil.Sub(now))
+			continue
+		}
+
+		counted = append(counted, key)
+	}
+
+	if wait > 0 {
+		for _, key := range counted {
+			s.Repo.Refund(key)
+		}
+	}
+
+	return wait
+}
+
+func attemptKeys(cpf, ip string) []string {
+	return []string{cpfKey(cpf), ipKey(ip)}
+}
+
+func cpfKey(cpf string) string {
+	return fmt.Sprintf("cpf:%s", cpf)
+}
+
+func ipKey(ip string) string {
+	return fmt.Sprintf("ip:%s", ip)
+}
+
+func operatorKey(email string) string {
+	return fmt.Sprintf("operator:%s", strings.ToLower(email))
+}
+
+// Credentials counted with AttemptAccount.
+var accountCredentials = []string{TOTP_CREDENTIAL, SECRET_CREDENTIAL}
+
+func accountKey(accountId int, credential string) string {
+	return fmt.Sprintf("%s:account:%d", credential, accountId)
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+)
+
+type PinRepository interface {
+	Read(accountId int) (entity.TransactionPin, error)
+	Save(pin entity.TransactionPin) error
+	// Update changes the stored PIN, parallel changes of the same PIN are made
+	// one after another.
+	Update(accountId int, change func(pin *entity.TransactionPin)) error
+	Delete(accountId int) error
+	Reset() error
+}
+
+type PinService struct {
+	Repo        PinRepository
+	AccountRepo AccountRepository
+
+	// Optional. When set, wrong secrets lock the account out of PIN changes,
+	// sharing the lockout of secret changes.
+	Guard *LoginGuardService
+}
+
+func NewPinService(repo PinRepository, accountRepo AccountRepository) *PinService {
+	return &PinService{Repo: repo, AccountRepo: accountRepo}
+}
+
+// SetPin sets or changes the transaction PIN. The login secret is asked so a
+// stolen session alone cannot replace the PIN.
+func (s PinService) SetPin(accountId int, input dto.SetPinInputDTO) (int, error) {
+
+	if _, err := s.AccountRepo.ReadByID(accountId); err != nil {
+		return http.StatusNotFound, fmt.Errorf("Could not find the account!")
+	}
+
+	if s.Guard != nil {
+		if wait := s.Guard.AttemptAccount(accountId, SECRET_CREDENTIAL); wait > 0 {
+			return http.StatusTooManyRequests, fmt.Errorf("Too many wrong secrets! Try again in %v.", wait.Round(time.Second))
+		}
+	}
+
+	foundSecret, err := s.AccountRepo.ReadHashByID(accountId)
+	if err != nil || !checkSecret(input.Secret, foundSecret) {
+		return http.StatusForbidden, ErrInvalidCredentials
+	}
+
+	if s.Guard != nil {
+		s.Guard.RegisterAccountSuccess(accountId, SECRET_CREDENTIAL)
+	}
+
+	pin, err := entity.NewTransactionPin(accountId, input.Pin, time.Now())
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if err := s.Repo.Save(*pin); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Could not save transaction PIN! Err: %v", err)
+	}
+
+	return http.StatusNoContent, nil
+}
+
+func (s PinService) Verify(accountId int, pin string) (int, error) {
+
+	// Saved on success too, it resets the failures count.
+	var verifyErr error
+	err := s.Repo.Update(accountId, func(stored *entity.TransactionPin) {
+		verifyErr = stored.Verify(pin, time.Now())
+	})
+	if err != nil {
+		return http.StatusForbidden, fmt.Errorf("A transaction PIN must be set before moving money!")
+	}
+
+	if verifyErr == entity.ErrPinLocked {
+		return http.StatusLocked, verifyErr
+	}
+
+	if verifyErr != nil {
+		return http.StatusForbidden, verifyErr
+	}
+
+	return http.StatusOK, nil
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+)
+
+type PixKeyRepository interface {
+	Create(key entity.PixKey) (entity.PixKey, error)
+	ReadByID(id string) (entity.PixKey, error)
+	ReadByAccountID(accountId int) ([]entity.PixKey, error)
+	// ReadVerifiedByValue ignores keys still waiting for verification, they
+	// do not address transfers yet.
+	ReadVerifiedByValue(keyType entity.PixKeyType, value string) (entity.PixKey, error)
+	// Save fails when verifying a key someone else verified first.
+	Save(key entity.PixKey) error
+	Delete(id string) error
+	DeleteByAccountID(accountId int) error
+	// UpdateCodeLimit changes the stored limit of the key, parallel changes of
+	// the same limit are made one after another.
+	UpdateCodeLimit(key string, change func(limit *entity.PixCodeLimit)) error
+	Reset() error
+}
+
+type PixKeyService struct {
+	Repo        PixKeyRepository
+	AccountRepo AccountRepository
+	Notifier    Notifier
+}
+
+func NewPixKeyService(repo PixKeyRepository, accountRepo AccountRepository, notifier Notifier) *PixKeyService {
+	return &PixKeyService{Repo: repo, AccountRepo: accountRepo, Notifier: notifier}
+}
+
+func (s PixKeyService) Register(accountId int, input dto.CreatePixKeyInputDTO) (dto.ReadPixKeyOutputDTO, int, error) {
+
+	account, err := s.AccountRepo.ReadByID(accountId)
+	if err != nil {
+		return dto.ReadPixKeyOutputDTO{}, http.StatusNotFound, fmt.Errorf("Could not find the account!")
+	}
+
+	if err := account.CheckActive(); err != nil {
+		return dto.ReadPixKeyOutputDTO{}, http.StatusForbidden, err
+	}
+
+	keys, err := s.Repo.ReadByAccountID(accountId)
+	if err != nil {
+		return dto.ReadPixKeyOutputDTO{}, http.StatusInternalServerError, fmt.Errorf("Could not read Pix keys! Err: %v", err)
+	}
+
+	if len(keys) >= entity.MAX_PIX_KEYS_PER_ACCOUNT {
+		return dto.ReadPixKeyOutputDTO{}, http.StatusConflict, fmt.Errorf("An account can have at most %d Pix keys!", entity.MAX_PIX_KEYS_PER_ACCOUNT)
+	}
+
+	now := time.Now()
+
+	key, code, err := entity.NewPixKey(account, entity.PixKeyType(input.Type), input.Value, now)
+	if err != nil {
+		return dto.ReadPixKeyOutputDTO{}, http.StatusBadRequest, err
+	}
+
+	if _, err := s.Repo.ReadVerifiedByValue(key.Type, key.Value); err == nil {
+		return dto.ReadPixKeyOutputDTO{}, http.StatusConflict, fmt.Errorf("Pix key is already registered!")
+	}
+
+	if code != "" {
+		if statusCode, err := s.limitSend(*key, now); err != nil {
+			return dto.ReadPixKeyOutputDTO{}, statusCode, err
+		}
+	}
+
+	created, err := s.Repo.Create(*key)
+	if err != nil {
+		return dto.ReadPixKeyOutputDTO{}, http.StatusConflict, fmt.Errorf("Could not register Pix key! Err: %v", err)
+	}
+
+	if code != "" {
+		s.sendCode(created, code)
+	}
+
+	return toPixKeyDTO(created), http.StatusCreated, nil
+}
+
+func (s PixKeyService) Verify(accountId int, keyId string, input dto.VerifyPixKeyInputDTO) (dto.ReadPixKeyOutputDTO, int, error) {
+
+	key, err := s.Repo.ReadByID(keyId)
+	if err != nil || key.AccountID != accountId {
+		return dto.ReadPixKeyOutputDTO{}, http.StatusNotFound, fmt.Errorf("Pix key not found!")
+	}
+
+	now := time.Now()
+	address := entity.PixCodeAddressKey(key.Type, key.Value)
+
+	// Counted before the code is checked and given back when it is right.
+	allowed := false
+	if err := s.Repo.UpdateCodeLimit(address, func(limit *entity.PixCodeLimit) {
+		allowed = limit.Attempt(now)
+	}); err != nil {
+		return dto.ReadPixKeyOutputDTO{}, http.StatusInternalServerError, fmt.Errorf("Could not count the attempt! Err: %v", err)
+	}
+
+	if !allowed {
+		return dto.ReadPixKeyOutputDTO{}, http.StatusTooManyRequests, entity.ErrPixKeyCodeExhausted
+	}
+
+	if err := key.Verify(input.Code, now); err != nil {
+		return dto.ReadPixKeyOutputDTO{}, http.StatusBadRequest, err
+	}
+
+	s.Repo.UpdateCodeLimit(address, func(limit *entity.PixCodeLimit) {
+		limit.Refund()
+	})
+
+	if err := s.Repo.Save(key); err != nil {
+		return dto.ReadPixKeyOutputDTO{}, http.StatusConflict, fmt.Errorf("Could not verify Pix key, it may be registered by another account! Err: %v", err)
+	}
+
+	return toPixKeyDTO(key), http.StatusOK, nil
+}
+
+func (s PixKeyService) ResendCode(accountId int, keyId string) (int, error) {
+
+	key, err := s.Repo.ReadByID(keyId)
+	if err != nil || key.AccountID != accountId {
+		return http.StatusNotFound, fmt.Errorf("Pix key not found!")
+	}
+
+	now := time.Now()
+
+	code, err := key.ResendCode(now)
+	if err != nil {
+		return http.StatusConflict, err
+	}
+
+	if statusCode, err := s.limitSend(key, now); err != nil {
+		return statusCode, err
+	}
+
+	if err := s.Repo.Save(key); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Could not save Pix key! Err: %v", err)
+	}
+
+	s.sendCode(key, code)
+
+	return http.StatusAccepted, nil
+}
+
+func (s PixKeyService) ReadKeys(accountId int) ([]dto.ReadPixKeyOutputDTO, int, error) {
+
+	keys, err := s.Repo.ReadByAccountID(accountId)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Could not read Pix keys! Err: %v", err)
+	}
+
+	output := []dto.ReadPixKeyOutputDTO{}
+	for _, key := range keys {
+		output = append(output, toPixKeyDTO(key))
+	}
+
+	return output, http.StatusOK, nil
+}
+
+func (s PixKeyService) Delete(accountId int, keyId string) (int, error) {
+
+	key, err := s.Repo.ReadByID(keyId)
+	if err != nil || key.AccountID != accountId {
+		return http.StatusNotFound, fmt.Errorf("Pix key not found!")
+	}
+
+	if err := s.Repo.Delete(key.ID); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Could not delete Pix key! Err: %v", err)
+	}
+
+	return http.StatusNoContent, nil
+}
+
+// Lookup tells whose a key is, the type is guessed from the value.
+func (s PixKeyService) Lookup(value string) (dto.LookupPixKeyOutputDTO, int, error) {
+
+	key, account, err := resolvePixKey(s.Repo, s.AccountRepo, value)
+	if err != nil {
+		return dto.LookupPixKeyOutputDTO{}, http.StatusNotFound, err
+	}
+
+	return dto.LookupPixKeyOutputDTO{
+		Key:  key.Value,
+		Type: string(key.Type),
+		Name: entity.MaskName(account.Name),
+	}, http.StatusOK, nil
+}
+
+// limitSend counts a code about to be sent to the key, refusing it once too
+// many codes went to its address or were sent by its account.
+func (s PixKeyService) limitSend(key entity.PixKey, now time.Time) (int, error) {
+
+	limits := []struct {
+		key      string
+		maxSends int
+	}{
+		{entity.PixCodeAddressKey(key.Type, key.Value), entity.PIX_KEY_CODE_MAX_SENDS},
+		{entity.PixCodeAccountKey(key.AccountID), entity.PIX_KEY_CODE_MAX_ACCOUNT_SENDS},
+	}
+
+	for _, l := range limits {
+		allowed := false
+		if err := s.Repo.UpdateCodeLimit(l.key, func(limit *entity.PixCodeLimit) {
+			allowed = limit.Send(now, l.maxSends)
+		}); err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("Could not count the code sent! Err: %v", err)
+		}
+
+		if !allowed {
+			return http.StatusTooManyRequests, entity.ErrPixKeyCodeExhausted
+		}
+	}
+
+	return http.StatusOK, nil
+}
+
+// sendCode delivers the code to the email or phone itself, receiving it is
+// what proves the holder owns the key.
+func (s PixKeyService) sendCode(key entity.PixKey, code string) {
+
+	message := fmt.Sprintf("Use the code %s to verify the Pix key %s. It expires at %s.", code, key.Value, key.CodeExpiresAt.UTC().Format(time.RFC3339))
+	s.Notifier.NotifyAddress(key.Value, "Pix key verification", message)
+}
+
+// resolvePixKey finds the account a verified key addresses.
+func resolvePixKey(repo PixKeyRepository, accountRepo AccountRepository, value string) (entity.PixKey, entity.Account, error) {
+
+	keyType := entity.DetectPixKeyType(value)
+
+	normalized, err := entity.NormalizePixKey(keyType, value)
+	if err != nil {
+		return entity.PixKey{}, entity.Account{}, fmt.Errorf("Pix key not found!")
+	}
+
+	key, err := repo.ReadVerifiedByValue(keyType, normalized)
+	if err != nil {
+		return entity.PixKey{}, entity.Account{}, fmt.Errorf("Pix key not found!")
+	}
+
+	account, err := accountRepo.ReadByID(key.AccountID)
+	if err != nil {
+		return entity.PixKey{}, entity.Account{}, fmt.Errorf("Pix key not found!")
+	}
+
+	return key, account, nil
+}
+
+func toPixKeyDTO(key entity.PixKey) dto.ReadPixKeyOutputDTO {
+	return dto.ReadPixKeyOutputDTO{
+		ID:        key.ID,
+		Type:      string(key.Type),
+		Value:     key.Value,
+		Verified:  key.IsVerified(),
+		CreatedAt: key.CreatedAt,
+	}
+}
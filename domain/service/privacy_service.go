@@ -0,0 +1,186 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+)
+
+// PrivacyService serves the LGPD rights of the account holders: a copy of
+// their personal data and its erasure.
+type PrivacyService struct {
+	AccountRepo  AccountRepository
+	TransferRepo TransferRepository
+	AuthRepo     AuthRepository
+	APIKeyRepo   APIKeyRepository
+	AuditRepo    AuditRepository
+
+	// Optional. When set, Pix keys are exported and deleted on erasure.
+	PixKeyRepo PixKeyRepository
+
+	// Optional. When set, the two-factor enrolment, the transaction PIN and
+	// the login failures of the account are deleted on erasure.
+	TwoFactorRepo TwoFactorRepository
+	PinRepo       PinRepository
+	LoginGuard    *LoginGuardService
+}
+
+func NewPrivacyService(accountRepo AccountRepository, transferRepo TransferRepository, authRepo AuthRepository, apiKeyRepo APIKeyRepository, auditRepo AuditRepository) *PrivacyService {
+	return &PrivacyService{
+		AccountRepo:  accountRepo,
+		TransferRepo: transferRepo,
+		AuthRepo:     authRepo,
+		APIKeyRepo:   apiKeyRepo,
+		AuditRepo:    auditRepo,
+	}
+}
+
+func (s PrivacyService) Export(accountId int) (dto.DataExportDTO, int, error) {
+
+	account, err := s.AccountRepo.ReadByID(accountId)
+	if err != nil {
+		return dto.DataExportDTO{}, http.StatusNotFound, fmt.Errorf("Could not find the account! Err: %v", err)
+	}
+
+	internalRef := entity.AuditAccount(accountId)
+
+	events, err := s.AuditRepo.ReadByReference(internalRef)
+	if err != nil {
+		return dto.DataExportDTO{}, http.StatusInternalServerError, fmt.Errorf("Could not read audit events! Err: %v", err)
+	}
+
+	sessions, err := s.AuthRepo.ReadSessionsByAccountID(accountId)
+	if err != nil {
+		return dto.DataExportDTO{}, http.StatusInternalServerError, fmt.Errorf("Could not read sessions! Err: %v", err)
+	}
+
+	output := dto.DataExportDTO{
+		ExportedAt: time.Now().UTC(),
+		Account: dto.ReadAccountOutputDTO{
+			ID:        account.PublicID,
+			Agency:    account.Agency,
+			Number:    account.Number,
+			Name:      account.Name,
+			CPF:       account.CPF,
+			Balance:   account.Balance,
+			CreatedAt: account.CreatedAt,
+		},
+		Transfers:   []dto.ReadTransfersOutputDTO{},
+		Sessions:    []dto.ReadSessionOutputDTO{},
+		AuditEvents: []dto.ReadAuditEventOutputDTO{},
+	}
+
+	for _, val := range s.TransferRepo.ReadTransfersByAccountID(accountId) {
+		output.Transfers = append(output.Transfers, toTransferDTO(val))
+	}
+
+	if s.PixKeyRepo != nil {
+		keys, err := s.PixKeyRepo.ReadByAccountID(accountId)
+		if err != nil {
+			return dto.DataExportDTO{}, http.StatusInternalServerError, fmt.Errorf("Could not read Pix keys! Err: %v", err)
+		}
+
+		for _, key := range keys {
+			output.PixKeys = append(output.PixKeys, toPixKeyDTO(key))
+		}
+	}
+
+	for _, session := range sessions {
+		output.Sessions = append(output.Sessions, dto.ReadSessionOutputDTO{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+		})
+	}
+
+	// The sequential ID in the audit log references is not shown either, the
+	// export shows the public ID in its place.
+	toPublic := func(ref string) string {
+		if ref == internalRef {
+			return fmt.Sprintf("account:%s", account.PublicID)
+		}
+		return ref
+	}
+
+	for _, event := range events {
+		output.AuditEvents = append(output.AuditEvents, dto.ReadAuditEventOutputDTO{
+			ID:        event.ID,
+			Type:      event.Type,
+			Actor:     toPublic(event.Actor),
+			IP:        event.IP,
+			RequestID: event.RequestID,
+			Subject:   toPublic(event.Subject),
+			Before:    event.Before,
+			After:     event.After,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+
+	return output, http.StatusOK, nil
+}
+
+// Erase anonymizes the account holder personal data and cuts every access to
+// the account. Transfers and the audit log are kept, as required by the
+// financial regulation, and neither holds the name or the CPF in clear.
+func (s PrivacyService) Erase(accountId int, input dto.EraseAccountInputDTO) (int, error) {
+
+	account, err := s.AccountRepo.ReadByID(accountId)
+	if err != nil {
+		return http.StatusNotFound, fmt.Errorf("Could not find the account! Err: %v", err)
+	}
+
+	hash, err := s.AccountRepo.ReadHashByID(accountId)
+	if err != nil || !checkSecret(input.Secret, hash) {
+		return http.StatusUnauthorized, ErrInvalidCredentials
+	}
+
+	// Login failures are kept under the CPF, gone once anonymized.
+	cpf := account.CPF
+
+	if err := account.Anonymize(); err != nil {
+		return http.StatusConflict, err
+	}
+
+	if err := s.AccountRepo.Anonymize(account); err != nil {
+		return http.StatusConflict, fmt.Errorf("Could not erase account! Err: %v", err)
+	}
+
+	if err := revokeAPIKeys(s.APIKeyRepo, accountId); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Could not revoke API keys! Err: %v", err)
+	}
+
+	if s.PixKeyRepo != nil {
+		if err := s.PixKeyRepo.DeleteByAccountID(accountId); err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("Could not delete Pix keys! Err: %v", err)
+		}
+	}
+
+	if s.TwoFactorRepo != nil {
+		if err := s.TwoFactorRepo.Delete(accountId); err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("Could not delete two-factor enrolment! Err: %v", err)
+		}
+	}
+
+	if s.PinRepo != nil {
+		if err := s.PinRepo.Delete(accountId); err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("Could not delete transaction PIN! Err: %v", err)
+		}
+	}
+
+	if s.LoginGuard != nil {
+		if err := s.LoginGuard.Forget(accountId, cpf); err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("Could not delete login failures! Err: %v", err)
+		}
+	}
+
+	if err := s.AuthRepo.RevokeAllSessions(accountId); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Could not revoke sessions! Err: %v", err)
+	}
+
+	return http.StatusNoContent, nil
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+)
+
+type SecretResetRepository interface {
+	Create(token entity.SecretResetToken) error
+	ReadByHash(hash []byte) (entity.SecretResetToken, error)
+	// MarkUsed fails when the token was already used, so two concurrent
+	// resets cannot both go through.
+	MarkUsed(token entity.SecretResetToken) error
+	Reset() error
+}
+
+var ErrEmptySecret = errors.New("New secret cannot be empty!")
+
+// Notifier delivers messages to the account holder, out of the API.
+type Notifier interface {
+	Notify(account entity.Account, subject, message string) error
+	// NotifyAddress delivers to an email or phone the account may not own
+	// yet, as the Pix keys being verified.
+	NotifyAddress(address, subject, message string) error
+}
+
+const (
+	// Credential wrong current secrets are counted under.
+	SECRET_CREDENTIAL = "secret"
+)
+
+type SecretService struct {
+	AccountRepo AccountRepository
+	AuthRepo    AuthRepository
+	ResetRepo   SecretResetRepository
+	Notifier    Notifier
+
+	// Optional. When set, wrong current secrets lock the account out of
+	// secret changes, as wrong secrets do with logins.
+	Guard *LoginGuardService
+
+	// Optional. When set, API keys are revoked with the sessions when the
+	// secret changes.
+	APIKeyRepo APIKeyRepository
+}
+
+func NewSecretService(accountRepo AccountRepository, authRepo AuthRepository, resetRepo SecretResetRepository, notifier Notifier) *SecretService {
+	return &SecretService{
+		AccountRepo: accountRepo,
+		AuthRepo:    authRepo,
+		ResetRepo:   resetRepo,
+		Notifier:    notifier,
+	}
+}
+
+// ChangeSecret replaces the secret of a logged in holder. Every session is
+// revoked afterwards, including the one used for the change.
+func (s SecretService) ChangeSecret(accountId int, input dto.ChangeSecretInputDTO) (int, error) {
+
+	if input.NewSecret == "" {
+		return http.StatusBadRequest, ErrEmptySecret
+	}
+
+	if _, err := s.AccountRepo.ReadByID(accountId); err != nil {
+		return http.StatusNotFound, fmt.Errorf("Could not find the account!")
+	}
+
+	if s.Guard != nil {
+		if wait := s.Guard.AttemptAccount(accountId, SECRET_CREDENTIAL); wait > 0 {
+			return http.StatusTooManyRequests, fmt.Errorf("Too many wrong secrets! Try again in %v.", wait.Round(time.Second))
+		}
+	}
+
+	foundSecret, err := s.AccountRepo.ReadHashByID(accountId)
+	if err != nil || !checkSecret(input.CurrentSecret, foundSecret) {
+		return http.StatusForbidden, ErrInvalidCredentials
+	}
+
+	if s.Guard != nil {
+		s.Guard.RegisterAccountSuccess(accountId, SECRET_CREDENTIAL)
+	}
+
+	return s.setSecret(accountId, input.NewSecret)
+}
+
+// RequestReset sends a reset token to the holder of the CPF. It answers the
+// same whether the CPF exists or not, so it cannot be used to find accounts.
+// CPFs with more than one account are not reset, it cannot be told which.
+func (s SecretService) RequestReset(input dto.RequestSecretResetInputDTO) (int, int, error) {
+
+	account, err := s.AccountRepo.ReadByCPF(input.CPF)
+	if err != nil {
+		return 0, http.StatusAccepted, nil
+	}
+
+	id := account.ID
+
+	token, reset, err := entity.NewSecretResetToken(id, time.Now())
+	if err != nil {
+		return 0, http.StatusInternalServerError, fmt.Errorf("Could not create reset token! Err: %v", err)
+	}
+
+	if err := s.ResetRepo.Create(*reset); err != nil {
+		return 0, http.StatusInternalServerError, fmt.Errorf("Could not save reset token! Err: %v", err)
+	}
+
+	message := fmt.Sprintf("Use the token %s to set a new secret. It expires at %s.", token, reset.ExpiresAt.UTC().Format(time.RFC3339))
+	// Failing to deliver is logged by the notifier. Telling the caller would
+	// reveal the account exists.
+	s.Notifier.Notify(account, "Secret reset", message)
+
+	return id, http.StatusAccepted, nil
+}
+
+// ConfirmReset sets the new secret when the token is valid, and returns the
+// account it belonged to.
+func (s SecretService) ConfirmReset(input dto.ConfirmSecretResetInputDTO) (int, int, error) {
+
+	// Checked before using the token, a bad request should not burn it.
+	if input.NewSecret == "" {
+		return 0, http.StatusBadRequest, ErrEmptySecret
+	}
+
+	reset, err := s.ResetRepo.ReadByHash(entity.HashSecretResetToken(input.Token))
+	if err != nil {
+		return 0, http.StatusBadRequest, entity.ErrSecretResetTokenInvalid
+	}
+
+	if err := reset.Use(time.Now()); err != nil {
+		return 0, http.StatusBadRequest, err
+	}
+
+	if err := s.ResetRepo.MarkUsed(reset); err != nil {
+		return 0, http.StatusBadRequest, entity.ErrSecretResetTokenInvalid
+	}
+
+	statusCode, err := s.setSecret(reset.AccountID, input.NewSecret)
+	return reset.AccountID, statusCode, err
+}
+
+func (s SecretService) setSecret(accountId int, secret string) (int, error) {
+
+	if err := s.AccountRepo.UpdateSecret(accountId, hashSecret(secret)); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Could not update secret! Err: %v", err)
+	}
+
+	if err := s.AuthRepo.RevokeAllSessions(accountId); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Secret changed, but could not revoke sessions! Err: %v", err)
+	}
+
+	if s.APIKeyRepo != nil {
+		if err := revokeAPIKeys(s.APIKeyRepo, accountId); err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("Secret changed, but could not revoke API keys! Err: %v", err)
+		}
+	}
+
+	return http.StatusNoContent, nil
+}
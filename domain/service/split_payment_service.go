@@ -0,0 +1,90 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+)
+
+// SplitPaymentService debits a payment once and credits its shares, by the
+// split rules, to several accounts, all of them or none.
+type SplitPaymentService struct {
+	Transfers *TransferService
+}
+
+func NewSplitPaymentService(transfers *TransferService) *SplitPaymentService {
+	return &SplitPaymentService{Transfers: transfers}
+}
+
+// Pay returns the split payment with its credits.
+func (s SplitPaymentService) Pay(originId int, input dto.CreateSplitPaymentInputDTO) (dto.ReadTransfersOutputDTO, int, error) {
+
+	if statusCode, err := s.Transfers.authorize(originId, dto.CreateTrasnferInputDTO{Amount: input.Amount, Pin: input.Pin, TOTPCode: input.TOTPCode}); err != nil {
+		return dto.ReadTransfersOutputDTO{}, statusCode, err
+	}
+
+	rules := []entity.SplitRule{}
+	for _, rule := range input.Rules {
+		rules = append(rules, entity.SplitRule{Type: entity.SplitRuleType(rule.Type), Value: rule.Value})
+	}
+
+	shares, err := entity.SplitAmount(input.Amount, rules)
+	if err != nil {
+		return dto.ReadTransfersOutputDTO{}, http.StatusBadRequest, err
+	}
+
+	origin, err := s.Transfers.AccountRepo.ReadByID(originId)
+	if err != nil {
+		return dto.ReadTransfersOutputDTO{}, http.StatusNotFound, fmt.Errorf("Could not find the origin account!")
+	}
+
+	if err := origin.CheckActive(); err != nil {
+		return dto.ReadTransfersOutputDTO{}, http.StatusForbidden, err
+	}
+
+	credits := []entity.Transfer{}
+	for i, rule := range input.Rules {
+		destination, statusCode, err := s.Transfers.readDestination(dto.CreateTrasnferInputDTO{
+			AccountDestinationID:     rule.AccountDestinationID,
+			PixKey:                   rule.PixKey,
+			AccountDestinationCPF:    rule.AccountDestinationCPF,
+			AccountDestinationAgency: rule.AccountDestinationAgency,
+			AccountDestinationNumber: rule.AccountDestinationNumber,
+		})
+		if err != nil {
+			return dto.ReadTransfersOutputDTO{}, statusCode, fmt.Errorf("Split rule %d: %v", i+1, err)
+		}
+
+		if destination.ID == origin.ID {
+			return dto.ReadTransfersOutputDTO{}, http.StatusBadRequest, fmt.Errorf("Split rule %d: payment cannot have the origin as destination!", i+1)
+		}
+
+		if err := destination.CheckActive(); err != nil {
+			return dto.ReadTransfersOutputDTO{}, http.StatusConflict, fmt.Errorf("Split rule %d: destination account cannot receive transfers. Err: %v", i+1, err)
+		}
+
+		credits = append(credits, entity.Transfer{AccountDestinationID: destination.ID, Amount: shares[i]})
+	}
+
+	if origin.Available() < input.Amount {
+		return dto.ReadTransfersOutputDTO{}, http.StatusBadRequest, fmt.Errorf("Cannot create split payment because insufficient funds. Available Balance: %d, Payment amount: %d", origin.Available(), input.Amount)
+	}
+
+	created, err := s.Transfers.TransferRepo.CreateSplitPayment(origin.ID, credits, s.Transfers.dailyLimit())
+	if err == entity.ErrTransferInDoubt {
+		return dto.ReadTransfersOutputDTO{}, http.StatusInternalServerError, err
+	}
+
+	if err != nil {
+		return dto.ReadTransfersOutputDTO{}, http.StatusConflict, fmt.Errorf("Could not make the split payment! Err: %v", err)
+	}
+
+	payment := toTransferDTO(created[0])
+	for _, credit := range created[1:] {
+		payment.Splits = append(payment.Splits, toTransferDTO(credit))
+	}
+
+	return payment, http.StatusCreated, nil
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+)
+
+type TransferBatchRepository interface {
+	Create(batch entity.TransferBatch) error
+	ReadByID(id string) (entity.TransferBatch, error)
+	// Save stores the status and the items of the batch.
+	Save(batch entity.TransferBatch) error
+	// ReadUnfinished returns the batches still pending or processing.
+	ReadUnfinished() ([]entity.TransferBatch, error)
+	Reset() error
+}
+
+// TransferBatchService validates every transfer of a batch up front, then
+// makes them in the background. Batches interrupted by a restart are picked
+// up by RecoverInterrupted.
+type TransferBatchService struct {
+	Repo      TransferBatchRepository
+	Transfers *TransferService
+}
+
+func NewTransferBatchService(repo TransferBatchRepository, transfers *TransferService) *TransferBatchService {
+	return &TransferBatchService{Repo: repo, Transfers: transfers}
+}
+
+func (s TransferBatchService) Create(originId int, input dto.CreateTransferBatchInputDTO) (dto.ReadTransferBatchOutputDTO, int, error) {
+
+	if len(input.Transfers) == 0 || len(input.Transfers) > entity.MAX_TRANSFER_BATCH_ITEMS {
+		return dto.ReadTransferBatchOutputDTO{}, http.StatusBadRequest, fmt.Errorf("Batch must have between 1 and %d transfers!", entity.MAX_TRANSFER_BATCH_ITEMS)
+	}
+
+	total := 0
+	for _, transfer := range input.Transfers {
+		total += transfer.Amount
+	}
+
+	if statusCode, err := s.Transfers.authorize(originId, dto.CreateTrasnferInputDTO{Amount: total, Pin: input.Pin, TOTPCode: input.TOTPCode}); err != nil {
+		return dto.ReadTransferBatchOutputDTO{}, statusCode, err
+	}
+
+	origin, err := s.Transfers.AccountRepo.ReadByID(originId)
+	if err != nil {
+		return dto.ReadTransferBatchOutputDTO{}, http.StatusNotFound, fmt.Errorf("Could not find the origin account!")
+	}
+
+	if err := origin.CheckActive(); err != nil {
+		return dto.ReadTransferBatchOutputDTO{}, http.StatusForbidden, err
+	}
+
+	items := []entity.TransferBatchItem{}
+	invalid := []string{}
+
+	for i, transfer := range input.Transfers {
+		destination, err := s.validate(origin, transfer)
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("Transfer %d: %v", i+1, err))
+			continue
+		}
+
+		items = append(items, entity.TransferBatchItem{
+			DestinationID:       destination.ID,
+			DestinationPublicID: destination.PublicID,
+			Amount:              transfer.Amount,
+		})
+	}
+
+	if len(invalid) > 0 {
+		return dto.ReadTransferBatchOutputDTO{}, http.StatusUnprocessableEntity, fmt.Errorf("Batch has invalid transfers! %s", strings.Join(invalid, "; "))
+	}
+
+	batch, err := entity.NewTransferBatch(originId, entity.TransferBatchMode(input.Mode), items, time.Now())
+	if err != nil {
+		return dto.ReadTransferBatchOutputDTO{}, http.StatusBadRequest, err
+	}
+
+	if batch.Mode == entity.BatchAtomic {
+		fees, err := s.fees(*batch)
+		if err != nil {
+			return dto.ReadTransferBatchOutputDTO{}, http.StatusInternalServerError, err
+		}
+
+		for _, fee := range fees {
+			total += feeAmount(fee)
+		}
+
+		if origin.Available() < total {
+			return dto.ReadTransferBatchOutputDTO{}, http.StatusBadRequest, fmt.Errorf("Insufficient funds for the whole batch. Available Balance: %d, Batch total: %d", origin.Available(), total)
+		}
+	}
+
+	if err := s.Repo.Create(*batch); err != nil {
+		return dto.ReadTransferBatchOutputDTO{}, http.StatusInternalServerError, fmt.Errorf("Could not create batch! Err: %v", err)
+	}
+
+	// Read before processing starts, the items are updated as they are made.
+	output := toTransferBatchDTO(*batch)
+
+	go s.process(*batch)
+
+	return output, http.StatusAccepted, nil
+}
+
+func (s TransferBatchService) Read(accountId int, id string) (dto.ReadTransferBatchOutputDTO, int, error) {
+
+	batch, err := s.Repo.ReadByID(id)
+	if err != nil || batch.AccountID != accountId {
+		return dto.ReadTransferBatchOutputDTO{}, http.StatusNotFound, fmt.Errorf("Batch not found!")
+	}
+
+	return toTransferBatchDTO(batch), http.StatusOK, nil
+}
+
+// validate checks a transfer as far as it can be before making it.
+func (s TransferBatchService) validate(origin entity.Account, transfer dto.CreateTrasnferInputDTO) (entity.Account, error) {
+
+	if transfer.Amount <= 0 {
+		return entity.Account{}, fmt.Errorf("amount must be greater than 0")
+	}
+
+	destination, _, err := s.Transfers.readDestination(transfer)
+	if err != nil {
+		return entity.Account{}, err
+	}
+
+	if destination.ID == origin.ID {
+		return entity.Account{}, fmt.Errorf("transfer cannot have the origin as destination")
+	}
+
+	if err := destination.CheckActive(); err != nil {
+		return entity.Account{}, fmt.Errorf("destination account cannot receive transfers")
+	}
+
+	return destination, nil
+}
+
+// RecoverInterrupted handles the batches a restart stopped. It must run on
+// startup, before batches are created, as every unfinished batch is taken for
+// interrupted. Batches not started yet are processed, the others get the
+// transfers not made failed, as the last one may have gone through.
+func (s TransferBatchService) RecoverInterrupted() (int, error) {
+
+	batches, err := s.Repo.ReadUnfinished()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, batch := range batches {
+		if batch.Status == entity.BatchPending {
+			go s.process(batch)
+			continue
+		}
+
+		batch.Interrupt(time.Now())
+		if err := s.Repo.Save(batch); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(batches), nil
+}
+
+func (s TransferBatchService) process(batch entity.TransferBatch) {
+
+	// A panic fails what is left instead of leaving the batch processing.
+	defer func() {
+		if r := recover(); r != nil {
+			batch.Interrupt(time.Now())
+			s.Repo.Save(batch)
+		}
+	}()
+
+	batch.Status = entity.BatchProcessing
+	s.Repo.Save(batch)
+
+	if batch.Mode == entity.BatchAtomic {
+		s.processAtomic(&batch)
+	} else {
+		s.processBestEffort(&batch)
+	}
+
+	batch.Finish(time.Now())
+	s.Repo.Save(batch)
+}
+
+func (s TransferBatchService) processAtomic(batch *entity.TransferBatch) {
+
+	// The fees were only checked on creation, they are priced as made, and
+	// the daily limit checked.
+	transfers := []entity.Transfer{}
+	for _, item := range batch.Items {
+		transfer := entity.NewTransfer(0, batch.AccountID, item.DestinationID, item.Amount, time.Now())
+		transfer.FeeCharge = s.Transfers.feeCharge(batch.AccountID, entity.TransferKindTransfer)
+		transfer.Limit = s.Transfers.dailyLimit()
+		transfers = append(transfers, *transfer)
+	}
+
+	created, err := s.Transfers.TransferRepo.CreateTransfers(transfers)
+
+	for i := range batch.Items {
+		if err != nil {
+			batch.Items[i].Fail(err)
+			continue
+		}
+
+		batch.Items[i].Status = entity.BatchItemSucceeded
+		batch.Items[i].TransferID = created[i].PublicID
+	}
+}
+
+func (s TransferBatchService) processBestEffort(batch *entity.TransferBatch) {
+
+	for i, item := range batch.Items {
+		transfer, err := s.sendItem(batch.AccountID, item)
+		if err != nil {
+			batch.Items[i].Fail(err)
+		} else {
+			batch.Items[i].Status = entity.BatchItemSucceeded
+			batch.Items[i].TransferID = transfer.PublicID
+		}
+
+		// Saved as it goes, a restart only leaves the pending items in doubt.
+		s.Repo.Save(*batch)
+	}
+}
+
+// fees prices the transfers of the batch in order, nil for those free.
+func (s TransferBatchService) fees(batch entity.TransferBatch) ([]*entity.Transfer, error) {
+
+	if s.Transfers.Fees == nil {
+		return make([]*entity.Transfer, len(batch.Items)), nil
+	}
+
+	amounts := []int{}
+	for _, item := range batch.Items {
+		amounts = append(amounts, item.Amount)
+	}
+
+	return s.Transfers.Fees.fees(batch.AccountID, entity.TransferKindTransfer, amounts)
+}
+
+// sendItem reads the destination again, it may have changed since the batch
+// was validated.
+func (s TransferBatchService) sendItem(originId int, item entity.TransferBatchItem) (entity.Transfer, error) {
+
+	destination, err := s.Transfers.AccountRepo.ReadByID(item.DestinationID)
+	if err != nil {
+		return entity.Transfer{}, fmt.Errorf("Could not find the destination account!")
+	}
+
+	transfer, _, err := s.Transfers.send(originId, destination, item.Amount)
+	return transfer, err
+}
+
+func toTransferBatchDTO(batch entity.TransferBatch) dto.ReadTransferBatchOutputDTO {
+
+	items := []dto.ReadTransferBatchItemOutputDTO{}
+	for _, item := range batch.Items {
+		items = append(items, dto.ReadTransferBatchItemOutputDTO{
+			AccountDestinationID: item.DestinationPublicID,
+			Amount:               item.Amount,
+			Status:               string(item.Status),
+			Error:                item.Error,
+			TransferID:           item.TransferID,
+		})
+	}
+
+	return dto.ReadTransferBatchOutputDTO{
+		ID:         batch.ID,
+		Mode:       string(batch.Mode),
+		Status:     string(batch.Status),
+		Total:      batch.Total(),
+		Items:      items,
+		CreatedAt:  batch.CreatedAt,
+		FinishedAt: batch.FinishedAt,
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/PPAKruNN/golearn/domain/entity"
 	"github.com/PPAKruNN/golearn/domain/service/dto"
@@ -10,13 +11,48 @@ import (
 
 type TransferRepository interface {
 	ReadTransfersByAccountID(id int) []entity.Transfer
-	CreateTransfer(accountOriginID, destinationOriginID, amount int) *entity.Transfer
+	// CreateTransfers makes all the transfers, balances and fees included, or
+	// none. Fails with entity.ErrTransferInDoubt when they may have been made.
+	CreateTransfers(transfers []entity.Transfer) ([]entity.Transfer, error)
+	// CreateBoletoPayment makes the payment like CreateTransfers, calling
+	// settle, when given, before it is committed.
+	CreateBoletoPayment(payment entity.Transfer, settle func() error) (entity.Transfer, error)
+	// CreateSplitPayment debits the sum of the credits once and makes each
+	// credit, or nothing. The split payment comes first, then its credits.
+	// Fails with entity.ErrTransferInDoubt like CreateTransfers. The split
+	// payment counts to the limit, when given.
+	CreateSplitPayment(accountOriginID int, credits []entity.Transfer, limit *entity.TransferLimit) ([]entity.Transfer, error)
+	// CountByOrigin counts the transfers of the kind the account made since.
+	CountByOrigin(accountId int, kind entity.TransferKind, since time.Time) (int, error)
+	// SumSentByOrigin adds up what the account sent since, in any of the
+	// entity.SentTransferKinds.
+	SumSentByOrigin(accountId int, since time.Time) (int, error)
+	Search(filter entity.TransferFilter) ([]entity.Transfer, error)
 	Reset() error
 }
 
 type TransferService struct {
 	TransferRepo TransferRepository
 	AccountRepo  AccountRepository
+
+	// Optional. When set, transfers above StepUpThreshold from accounts with
+	// two-factor enabled must carry a valid code.
+	TwoFactor       *TwoFactorService
+	StepUpThreshold int
+
+	// Optional. When set, every transfer must carry the origin transaction PIN.
+	Pin *PinService
+
+	// Optional. When set, transfers can be addressed by Pix key.
+	PixKeyRepo PixKeyRepository
+
+	// Optional. When set, transfers and boleto payments are charged the fees
+	// of their schedules.
+	Fees *FeeService
+
+	// Optional. When above zero, what an account sends in a day, boleto, split,
+	// escrow and hold payments included, cannot add up to more.
+	DailyLimit int
 }
 
 func NewTransferService(transferRepo TransferRepository, accountRepo AccountRepository) *TransferService {
@@ -29,51 +65,417 @@ func (t TransferService) ReadTransfersByAccount(accountId int) []dto.ReadTransfe
 
 	parsedTransfer := []dto.ReadTransfersOutputDTO{}
 
+	// Split payments come before their credits, which the payer sees
+	// within them, and the receivers on their own.
+	splits := map[string]int{}
+
 	// Mapping entity.Transfer to dto.ReadTrasnferOutputDTO
 	for _, val := range transfers {
-
-		newTransfer := dto.ReadTransfersOutputDTO{
-			ID:                   val.ID,
-			AccountOriginID:      val.AccountOriginID,
-			AccountDestinationID: val.AccountDestinationID,
-			Amount:               val.Amount,
-			CreatedAt:            val.CreatedAt,
+		if i, ok := splits[val.ParentPublicID]; ok && val.Kind == entity.TransferKindSplitCredit && val.AccountOriginID == accountId {
+			parsedTransfer[i].Splits = append(parsedTransfer[i].Splits, toTransferDTO(val))
+			continue
 		}
-		parsedTransfer = append(parsedTransfer, newTransfer)
 
+		parsedTransfer = append(parsedTransfer, toTransferDTO(val))
+
+		if val.Kind == entity.TransferKindSplit {
+			splits[val.PublicID] = len(parsedTransfer) - 1
+		}
 	}
 
 	return parsedTransfer
 }
 
-func (t *TransferService) CreateTransfer(input dto.CreateTrasnferInputDTO) (int, error) {
+// CreateTransfer moves money out of the origin account, the one authenticated
+// on the request, to the destination public ID.
+func (t *TransferService) CreateTransfer(originId int, input dto.CreateTrasnferInputDTO) (dto.ReadTransfersOutputDTO, int, error) {
+
+	if statusCode, err := t.authorize(originId, input); err != nil {
+		return dto.ReadTransfersOutputDTO{}, statusCode, err
+	}
 
-	// FIXME: Remove this 2 queries and turn it into one.
-	origin, err := t.AccountRepo.ReadByID(input.AccountOriginID)
+	destination, statusCode, err := t.readDestination(input)
 	if err != nil {
-		return http.StatusNotFound, fmt.Errorf("Could not find the origin account! Err: %v", err)
+		return dto.ReadTransfersOutputDTO{}, statusCode, err
 	}
 
-	destination, err := t.AccountRepo.ReadByID(input.AccountDestinationID)
+	transfer, statusCode, err := t.send(originId, destination, input.Amount)
+	if err != nil {
+		return dto.ReadTransfersOutputDTO{}, statusCode, err
+	}
+
+	return toTransferDTO(transfer), statusCode, nil
+}
+
+// transferPlan is a transfer checked and priced, not made yet. The accounts
+// have the balances they will have once it is.
+type transferPlan struct {
+	origin      entity.Account
+	destination entity.Account
+	transfer    entity.Transfer
+	fee         *entity.Transfer
+	// What can still be sent today after the transfer, nil when unlimited.
+	remainingDailyLimit *int
+}
+
+// plan runs every check a transfer goes through, without moving any money.
+func (t *TransferService) plan(originId int, destination entity.Account, amount int) (transferPlan, int, error) {
+
+	origin, err := t.AccountRepo.ReadByID(originId)
 	if err != nil {
-		return http.StatusNotFound, fmt.Errorf("Could not find the destination account!")
+		return transferPlan{}, http.StatusNotFound, fmt.Errorf("Could not find the origin account! Err: %v", err)
+	}
+
+	if err := origin.CheckActive(); err != nil {
+		return transferPlan{}, http.StatusForbidden, err
+	}
+
+	if err := destination.CheckActive(); err != nil {
+		return transferPlan{}, http.StatusConflict, fmt.Errorf("Destination account cannot receive transfers. Err: %v", err)
+	}
+
+	transfer, err := origin.TransferTo(&destination, amount)
+	if err != nil {
+		return transferPlan{}, http.StatusBadRequest, err
+	}
+
+	// Priced once the amount is known to be valid, the fee comes out of what
+	// is left.
+	fee, err := t.fee(originId, entity.TransferKindTransfer, amount)
+	if err != nil {
+		return transferPlan{}, http.StatusInternalServerError, err
+	}
+
+	if fee != nil {
+		if origin.Available() < fee.Amount {
+			return transferPlan{}, http.StatusBadRequest, fmt.Errorf("Cannot create transfer because insuficiend funds for the fee. Available Balance: %d, Transfer amount: %d, Fee: %d", origin.Available()+amount, amount, fee.Amount)
+		}
+
+		origin.Balance -= fee.Amount
+	}
+
+	plan := transferPlan{origin: origin, destination: destination, transfer: transfer, fee: fee}
+
+	remaining, statusCode, err := t.checkDailyLimit(originId, amount)
+	if err != nil {
+		return transferPlan{}, statusCode, err
+	}
+	plan.remainingDailyLimit = remaining
+
+	return plan, http.StatusOK, nil
+}
+
+// checkDailyLimit fails when the amount exceeds what the origin can still send
+// today, and returns what would remain after it, nil when unlimited. The
+// repositories check the limit again as the money moves.
+func (t *TransferService) checkDailyLimit(originId int, amount int) (*int, int, error) {
+
+	limit := t.dailyLimit()
+	if limit == nil {
+		return nil, http.StatusOK, nil
+	}
+
+	sent, err := t.TransferRepo.SumSentByOrigin(originId, limit.Since)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Could not check the daily limit! Err: %v", err)
+	}
+
+	if remaining := limit.Remaining(sent); amount > remaining {
+		return nil, http.StatusBadRequest, fmt.Errorf("Cannot send because it exceeds the daily limit. Remaining today: %d, Amount: %d", remaining, amount)
+	}
+
+	remaining := limit.Remaining(sent + amount)
+	return &remaining, http.StatusOK, nil
+}
+
+// send moves the money of an already authorized transfer.
+func (t *TransferService) send(originId int, destination entity.Account, amount int) (entity.Transfer, int, error) {
+
+	plan, statusCode, err := t.plan(originId, destination, amount)
+	if err != nil {
+		return entity.Transfer{}, statusCode, err
+	}
+
+	// Made with its fee, if any, balances moved by the repository, all or
+	// nothing. The balances and the fee of the plan were only read to check
+	// it, the fee is priced again as it is made.
+	plan.transfer.FeeCharge = t.feeCharge(originId, entity.TransferKindTransfer)
+	plan.transfer.Limit = t.dailyLimit()
+
+	created, err := t.TransferRepo.CreateTransfers([]entity.Transfer{plan.transfer})
+	if err == entity.ErrTransferInDoubt {
+		return entity.Transfer{}, http.StatusInternalServerError, err
+	}
+
+	if err != nil {
+		return entity.Transfer{}, http.StatusBadRequest, fmt.Errorf("Could not create transfer! Err: %v", err)
+	}
+
+	return created[0], http.StatusCreated, nil
+}
+
+// PreviewTransfer runs the checks of CreateTransfer, the daily limit
+// included, and tells what the transfer would cost, failing as the transfer
+// would. Nothing is moved, and
+// the PIN and two-factor code are not checked, only whether the code will be
+// needed.
+func (t *TransferService) PreviewTransfer(originId int, input dto.CreateTrasnferInputDTO) (dto.PreviewTransferOutputDTO, int, error) {
+
+	destination, statusCode, err := t.readDestination(input)
+	if err != nil {
+		return dto.PreviewTransferOutputDTO{}, statusCode, err
+	}
+
+	plan, statusCode, err := t.plan(originId, destination, input.Amount)
+	if err != nil {
+		return dto.PreviewTransferOutputDTO{}, statusCode, err
+	}
+
+	output := dto.PreviewTransferOutputDTO{
+		Name:                entity.MaskName(destination.Name),
+		Amount:              plan.transfer.Amount,
+		Fee:                 feeAmount(plan.fee),
+		BalanceAfter:        plan.origin.Balance,
+		AvailableAfter:      plan.origin.Available(),
+		RequiresTwoFactor:   t.TwoFactor != nil && input.Amount > t.StepUpThreshold && t.TwoFactor.IsEnabled(originId),
+		RemainingDailyLimit: plan.remainingDailyLimit,
+	}
+	output.Total = output.Amount + output.Fee
+
+	return output, http.StatusOK, nil
+}
+
+// PayBoleto debits a boleto from the origin account and records it with its
+// barcode and fee, all or nothing. Boletos issued here credit the issuer,
+// issuerId. The others are sent to the clearing, only once the payment is
+// authorized and funded, before it is committed. Fails with
+// entity.ErrTransferInDoubt when the payment may have been made.
+func (t *TransferService) PayBoleto(originId, issuerId int, barcode string, input dto.CreateTrasnferInputDTO, clearing BoletoClearing) (int, error) {
+
+	if statusCode, err := t.authorize(originId, input); err != nil {
+		return statusCode, err
+	}
+
+	origin, err := t.AccountRepo.ReadByID(originId)
+	if err != nil {
+		return http.StatusNotFound, fmt.Errorf("Could not find the origin account! Err: %v", err)
+	}
+
+	if err := origin.CheckActive(); err != nil {
+		return http.StatusForbidden, err
 	}
 
-	transfer, err := origin.TransferTo(&destination, input.Amount)
+	fee, err := t.fee(originId, entity.TransferKindBoletoPayment, input.Amount)
 	if err != nil {
-		return http.StatusBadRequest, err
+		return http.StatusInternalServerError, err
+	}
+
+	if fee != nil && origin.Available() < input.Amount+fee.Amount {
+		return http.StatusBadRequest, fmt.Errorf("Cannot pay boleto because insuficiend funds for the fee. Available Balance: %d, Boleto amount: %d, Fee: %d", origin.Available(), input.Amount, fee.Amount)
 	}
 
-	t.AccountRepo.UpdateBalance(origin.ID, origin.Balance)
-	t.AccountRepo.UpdateBalance(destination.ID, destination.Balance)
+	// The balances are only checked here, they are moved by the repository.
+	var settle func() error
+	var clearingErr error
 
-	persistedTransfer := t.TransferRepo.CreateTransfer(transfer.AccountOriginID, transfer.AccountDestinationID, transfer.Amount)
+	if issuerId != 0 {
+		issuer, err := t.AccountRepo.ReadByID(issuerId)
+		if err != nil {
+			return http.StatusNotFound, fmt.Errorf("Could not find the issuer account!")
+		}
+
+		if err := issuer.CheckActive(); err != nil {
+			return http.StatusConflict, fmt.Errorf("Boleto issuer cannot receive payments. Err: %v", err)
+		}
 
-	// FIXME: Create better error message.
-	if persistedTransfer == nil {
-		return http.StatusInternalServerError, fmt.Errorf("Error while creating transfer. Internal server error")
+		if _, err := origin.TransferTo(&issuer, input.Amount); err != nil {
+			return http.StatusBadRequest, err
+		}
+	} else {
+		if err := origin.PayBoleto(input.Amount); err != nil {
+			return http.StatusBadRequest, err
+		}
+
+		settle = func() error {
+			clearingErr = clearing.Settle(barcode, input.Amount)
+			return clearingErr
+		}
+	}
+
+	payment := entity.NewTransfer(0, originId, issuerId, input.Amount, time.Now())
+	payment.Kind = entity.TransferKindBoletoPayment
+	payment.Barcode = barcode
+	payment.FeeCharge = t.feeCharge(originId, entity.TransferKindBoletoPayment)
+	payment.Limit = t.dailyLimit()
+
+	_, err = t.TransferRepo.CreateBoletoPayment(*payment, settle)
+	if err == entity.ErrTransferInDoubt {
+		return http.StatusInternalServerError, err
+	}
+
+	if clearingErr != nil {
+		return http.StatusBadGateway, fmt.Errorf("Clearing refused the payment! Err: %v", clearingErr)
+	}
+
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("Could not pay boleto! Err: %v", err)
 	}
 
 	return http.StatusCreated, nil
+}
+
+// fee prices a transfer of the kind, nil when it is free or fees are off.
+func (t *TransferService) fee(originId int, kind entity.TransferKind, amount int) (*entity.Transfer, error) {
+
+	if t.Fees == nil {
+		return nil, nil
+	}
+
+	return t.Fees.fee(originId, kind, amount)
+}
+
+// feeCharge is how a transfer of the kind is priced as it is made, nil when
+// fees are off.
+func (t *TransferService) feeCharge(originId int, kind entity.TransferKind) *entity.FeeCharge {
+
+	if t.Fees == nil {
+		return nil
+	}
+
+	return t.Fees.charge(originId, kind)
+}
+
+// dailyLimit is the limit of what is sent today, nil when unlimited.
+func (t *TransferService) dailyLimit() *entity.TransferLimit {
+
+	if t.DailyLimit <= 0 {
+		return nil
+	}
+
+	return &entity.TransferLimit{Amount: t.DailyLimit, Since: entity.StartOfDay(time.Now())}
+}
+
+// ConfirmPayee shows who the destination of a transfer is, with the name and
+// CPF masked.
+func (t *TransferService) ConfirmPayee(input dto.ConfirmPayeeInputDTO) (dto.ConfirmPayeeOutputDTO, int, error) {
+
+	destination, statusCode, err := t.readDestination(dto.CreateTrasnferInputDTO{
+		AccountDestinationID:     input.AccountDestinationID,
+		PixKey:                   input.PixKey,
+		AccountDestinationCPF:    input.AccountDestinationCPF,
+		AccountDestinationAgency: input.AccountDestinationAgency,
+		AccountDestinationNumber: input.AccountDestinationNumber,
+	})
+	if err != nil {
+		return dto.ConfirmPayeeOutputDTO{}, statusCode, err
+	}
+
+	return dto.ConfirmPayeeOutputDTO{
+		Name:       entity.MaskName(destination.Name),
+		CPF:        entity.MaskCPF(destination.CPF),
+		CanReceive: destination.CheckActive() == nil,
+	}, http.StatusOK, nil
+}
+
+// readDestination finds the destination account by its ID, Pix key, CPF or
+// agency and account number, only one of them can be given.
+func (t *TransferService) readDestination(input dto.CreateTrasnferInputDTO) (entity.Account, int, error) {
 
+	given := 0
+	for _, identifier := range []string{input.AccountDestinationID, input.PixKey, input.AccountDestinationCPF, input.AccountDestinationNumber} {
+		if identifier != "" {
+			given++
+		}
+	}
+
+	if given != 1 || (input.AccountDestinationAgency != "" && input.AccountDestinationNumber == "") {
+		return entity.Account{}, http.StatusBadRequest, fmt.Errorf("Exactly one of account_destination_id, pix_key, account_destination_cpf or account_destination_number must be provided!")
+	}
+
+	switch {
+	case input.PixKey != "":
+		if t.PixKeyRepo == nil {
+			return entity.Account{}, http.StatusBadRequest, fmt.Errorf("Transfers by Pix key are not enabled!")
+		}
+
+		_, destination, err := resolvePixKey(t.PixKeyRepo, t.AccountRepo, input.PixKey)
+		if err != nil {
+			return entity.Account{}, http.StatusNotFound, err
+		}
+
+		return destination, http.StatusOK, nil
+
+	case input.AccountDestinationCPF != "":
+		destination, err := t.AccountRepo.ReadByCPF(input.AccountDestinationCPF)
+		if err != nil {
+			return entity.Account{}, http.StatusNotFound, fmt.Errorf("Could not find an account with the provided CPF!")
+		}
+
+		return destination, http.StatusOK, nil
+
+	case input.AccountDestinationNumber != "":
+		agency, err := entity.ParseAgency(input.AccountDestinationAgency)
+		if err != nil {
+			return entity.Account{}, http.StatusBadRequest, err
+		}
+
+		number, err := entity.ParseAccountNumber(input.AccountDestinationNumber)
+		if err != nil {
+			return entity.Account{}, http.StatusBadRequest, err
+		}
+
+		destination, err := t.AccountRepo.ReadByNumber(agency, number)
+		if err != nil {
+			return entity.Account{}, http.StatusNotFound, fmt.Errorf("Could not find an account with the provided agency and number!")
+		}
+
+		return destination, http.StatusOK, nil
+	}
+
+	destination, err := t.AccountRepo.ReadByPublicID(input.AccountDestinationID)
+	if err != nil {
+		return entity.Account{}, http.StatusNotFound, fmt.Errorf("Could not find the destination account!")
+	}
+
+	return destination, http.StatusOK, nil
+}
+
+// authorize checks the transaction PIN and, above the threshold, the
+// two-factor code of the origin account.
+func (t *TransferService) authorize(originId int, input dto.CreateTrasnferInputDTO) (int, error) {
+
+	if t.Pin != nil {
+		if statusCode, err := t.Pin.Verify(originId, input.Pin); err != nil {
+			return statusCode, err
+		}
+	}
+
+	return t.checkStepUp(originId, input)
+}
+
+func (t *TransferService) checkStepUp(originId int, input dto.CreateTrasnferInputDTO) (int, error) {
+
+	if t.TwoFactor == nil || input.Amount <= t.StepUpThreshold || !t.TwoFactor.IsEnabled(originId) {
+		return http.StatusOK, nil
+	}
+
+	if input.TOTPCode == "" {
+		return http.StatusForbidden, fmt.Errorf("Transfers above %d require a two-factor code!", t.StepUpThreshold)
+	}
+
+	return t.TwoFactor.Verify(originId, input.TOTPCode)
+}
+
+func toTransferDTO(transfer entity.Transfer) dto.ReadTransfersOutputDTO {
+	return dto.ReadTransfersOutputDTO{
+		ID:                   transfer.PublicID,
+		Kind:                 string(transfer.Kind),
+		AccountOriginID:      transfer.AccountOriginPublicID,
+		AccountDestinationID: transfer.AccountDestinationPublicID,
+		Barcode:              transfer.Barcode,
+		Amount:               transfer.Amount,
+		CreatedAt:            transfer.CreatedAt,
+		ParentID:             transfer.ParentPublicID,
+		EscrowID:             transfer.EscrowID,
+	}
 }
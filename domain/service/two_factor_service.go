@@ -0,0 +1,211 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+	"github.com/google/uuid"
+)
+
+const (
+	TOTP_ISSUER = "GoLearn"
+
+	LOGIN_CHALLENGE_TTL          = 5 * time.Minute
+	LOGIN_CHALLENGE_MAX_ATTEMPTS = 5
+
+	// Credential wrong two-factor codes are counted under.
+	TOTP_CREDENTIAL = "totp"
+)
+
+type TwoFactorRepository interface {
+	Read(accountId int) (entity.TwoFactor, error)
+	Save(twoFactor entity.TwoFactor) error
+	// UseStep and ReplaceRecoveryCodes only update when the stored state is
+	// still the one the code was checked against, so a code is accepted once
+	// even by concurrent requests.
+	UseStep(accountId int, step int64) error
+	ReplaceRecoveryCodes(accountId int, previous, remaining []string) error
+	Delete(accountId int) error
+	CreateChallenge(challenge entity.LoginChallenge) error
+	ReadChallenge(id string) (entity.LoginChallenge, error)
+	SaveChallenge(challenge entity.LoginChallenge) error
+	DeleteChallenge(id string) error
+	Reset() error
+}
+
+type TwoFactorService struct {
+	Repo        TwoFactorRepository
+	AccountRepo AccountRepository
+
+	// Optional. When set, wrong codes lock the account out of two-factor
+	// checks, as wrong secrets do with logins. Login challenges are limited
+	// on their own, but a new one comes with every login.
+	Guard *LoginGuardService
+}
+
+func NewTwoFactorService(repo TwoFactorRepository, accountRepo AccountRepository) *TwoFactorService {
+	return &TwoFactorService{Repo: repo, AccountRepo: accountRepo}
+}
+
+// Enroll starts a new enrolment, replacing any unconfirmed one. It is only
+// enforced after Confirm.
+func (s TwoFactorService) Enroll(accountId int) (dto.EnrollTwoFactorOutputDTO, int, error) {
+
+	if s.IsEnabled(accountId) {
+		return dto.EnrollTwoFactorOutputDTO{}, http.StatusConflict, fmt.Errorf("Two-factor is already enabled for this account!")
+	}
+
+	account, err := s.AccountRepo.ReadByID(accountId)
+	if err != nil {
+		return dto.EnrollTwoFactorOutputDTO{}, http.StatusNotFound, fmt.Errorf("Could not find the account!")
+	}
+
+	twoFactor, codes, err := entity.NewTwoFactor(accountId, time.Now())
+	if err != nil {
+		return dto.EnrollTwoFactorOutputDTO{}, http.StatusInternalServerError, fmt.Errorf("Could not generate two-factor secret! Err: %v", err)
+	}
+
+	if err := s.Repo.Save(*twoFactor); err != nil {
+		return dto.EnrollTwoFactorOutputDTO{}, http.StatusInternalServerError, fmt.Errorf("Could not save two-factor enrolment! Err: %v", err)
+	}
+
+	return dto.EnrollTwoFactorOutputDTO{
+		Secret:          twoFactor.EncodedSecret(),
+		ProvisioningURI: twoFactor.ProvisioningURI(TOTP_ISSUER, account.Name),
+		RecoveryCodes:   codes,
+	}, http.StatusCreated, nil
+}
+
+// Confirm enables two-factor once the holder proves their app generates
+// valid codes.
+func (s TwoFactorService) Confirm(accountId int, code string) (int, error) {
+
+	twoFactor, err := s.Repo.Read(accountId)
+	if err != nil {
+		return http.StatusNotFound, fmt.Errorf("There is no two-factor enrolment for this account!")
+	}
+
+	if twoFactor.Enabled {
+		return http.StatusConflict, fmt.Errorf("Two-factor is already enabled for this account!")
+	}
+
+	if err := twoFactor.VerifyCode(code, time.Now()); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	twoFactor.Enabled = true
+	if err := s.Repo.Save(twoFactor); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Could not enable two-factor! Err: %v", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s TwoFactorService) Disable(accountId int, code string) (int, error) {
+
+	if statusCode, err := s.Verify(accountId, code); err != nil {
+		return statusCode, err
+	}
+
+	if err := s.Repo.Delete(accountId); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Could not disable two-factor! Err: %v", err)
+	}
+
+	return http.StatusNoContent, nil
+}
+
+func (s TwoFactorService) IsEnabled(accountId int) bool {
+
+	twoFactor, err := s.Repo.Read(accountId)
+	if err != nil {
+		return false
+	}
+
+	return twoFactor.Enabled
+}
+
+// Verify checks a TOTP code, falling back to the recovery codes.
+func (s TwoFactorService) Verify(accountId int, code string) (int, error) {
+
+	twoFactor, err := s.Repo.Read(accountId)
+	if err != nil || !twoFactor.Enabled {
+		return http.StatusBadRequest, fmt.Errorf("Two-factor is not enabled for this account!")
+	}
+
+	if s.Guard != nil {
+		if wait := s.Guard.AttemptAccount(accountId, TOTP_CREDENTIAL); wait > 0 {
+			return http.StatusTooManyRequests, fmt.Errorf("Too many wrong two-factor codes! Try again in %v.", wait.Round(time.Second))
+		}
+	}
+
+	// UseRecoveryCode removes in place, the stored codes are kept apart.
+	previous := slices.Clone(twoFactor.RecoveryCodes)
+
+	if err := twoFactor.VerifyCode(code, time.Now()); err == nil {
+		if err := s.Repo.UseStep(accountId, twoFactor.LastUsedStep); err != nil {
+			return http.StatusForbidden, fmt.Errorf("Invalid two-factor code!")
+		}
+	} else if recoveryErr := twoFactor.UseRecoveryCode(code); recoveryErr == nil {
+		if err := s.Repo.ReplaceRecoveryCodes(accountId, previous, twoFactor.RecoveryCodes); err != nil {
+			return http.StatusForbidden, fmt.Errorf("Invalid recovery code!")
+		}
+	} else {
+		return http.StatusForbidden, err
+	}
+
+	if s.Guard != nil {
+		s.Guard.RegisterAccountSuccess(accountId, TOTP_CREDENTIAL)
+	}
+
+	return http.StatusOK, nil
+}
+
+// StartChallenge is the first login step for accounts with two-factor, the
+// password was already checked.
+func (s TwoFactorService) StartChallenge(accountId int, userAgent, ip string) (string, error) {
+
+	challenge := entity.LoginChallenge{
+		ID:        uuid.NewString(),
+		AccountID: accountId,
+		UserAgent: userAgent,
+		IP:        ip,
+		ExpiresAt: time.Now().Add(LOGIN_CHALLENGE_TTL),
+	}
+
+	if err := s.Repo.CreateChallenge(challenge); err != nil {
+		return "", err
+	}
+
+	return challenge.ID, nil
+}
+
+// CompleteChallenge checks the code of the second login step and returns the
+// challenge so the session can be opened.
+func (s TwoFactorService) CompleteChallenge(input dto.LoginTwoFactorInputDTO) (entity.LoginChallenge, int, error) {
+
+	challenge, err := s.Repo.ReadChallenge(input.Challenge)
+	if err != nil || time.Now().After(challenge.ExpiresAt) {
+		return entity.LoginChallenge{}, http.StatusUnauthorized, fmt.Errorf("Login challenge not found or expired!")
+	}
+
+	statusCode, err := s.Verify(challenge.AccountID, input.Code)
+	if err != nil {
+		challenge.Attempts++
+
+		if challenge.Attempts >= LOGIN_CHALLENGE_MAX_ATTEMPTS {
+			s.Repo.DeleteChallenge(challenge.ID)
+		} else {
+			s.Repo.SaveChallenge(challenge)
+		}
+
+		return entity.LoginChallenge{}, statusCode, err
+	}
+
+	s.Repo.DeleteChallenge(challenge.ID)
+
+	return challenge, http.StatusOK, nil
+}
@@ -0,0 +1,75 @@
+// Package clearing reaches the other banks for boletos not issued here. Only a
+// local fake exists for now, it stands in for the interbank clearing house.
+package clearing
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// Rules the fake registers every boleto with: 2% fine and 1% interest a
+	// month.
+	FAKE_FINE_RATE     = 200
+	FAKE_INTEREST_RATE = 100
+)
+
+// FakeClearing accepts any valid boleto with an amount, as if every bank had
+// registered it, and keeps the settled ones in memory so they cannot be paid
+// twice.
+type FakeClearing struct {
+	mu      sync.Mutex
+	settled map[string]int
+}
+
+func NewFakeClearing() *FakeClearing {
+	return &FakeClearing{settled: map[string]int{}}
+}
+
+func (c *FakeClearing) Query(barcode string) (entity.BoletoRegistration, error) {
+
+	code, err := entity.ParseBoletoCode(barcode, time.Now())
+	if err != nil {
+		return entity.BoletoRegistration{}, err
+	}
+
+	if code.Amount == 0 {
+		return entity.BoletoRegistration{}, fmt.Errorf("Boleto without amount is not registered")
+	}
+
+	c.mu.Lock()
+	_, paid := c.settled[code.Barcode]
+	c.mu.Unlock()
+
+	return entity.BoletoRegistration{
+		BeneficiaryName: "Beneficiary of bank " + code.BankCode,
+		Amount:          code.Amount,
+		DueDate:         code.DueDate,
+		FineRate:        FAKE_FINE_RATE,
+		InterestRate:    FAKE_INTEREST_RATE,
+		Paid:            paid,
+	}, nil
+}
+
+func (c *FakeClearing) Settle(barcode string, amount int) error {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, paid := c.settled[barcode]; paid {
+		return fmt.Errorf("Boleto was already settled")
+	}
+
+	c.settled[barcode] = amount
+
+	log.Info().
+		Str("Barcode", barcode).
+		Int("Amount", amount).
+		Msg("Boleto settled through the fake clearing!")
+
+	return nil
+}
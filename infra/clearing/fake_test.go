@@ -0,0 +1,31 @@
+package clearing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+)
+
+func TestFakeClearing(t *testing.T) {
+
+	clearing := NewFakeClearing()
+	barcode := entity.BuildBoletoBarcode("341", time.Now().AddDate(0, 0, 5), 15000, "1234567890123456789012345")
+
+	registration, err := clearing.Query(barcode)
+	if err != nil || registration.Amount != 15000 || registration.Paid {
+		t.Fatalf("Boleto should be registered and open. Got: %+v, err: %v", registration, err)
+	}
+
+	if err := clearing.Settle(barcode, 15000); err != nil {
+		t.Errorf("Boleto should be settled, got: %v", err)
+	}
+
+	if registration, _ := clearing.Query(barcode); !registration.Paid {
+		t.Errorf("Settled boleto should be registered as paid")
+	}
+
+	if err := clearing.Settle(barcode, 15000); err == nil {
+		t.Errorf("Boleto should NOT be settled twice")
+	}
+}
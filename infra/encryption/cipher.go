@@ -0,0 +1,241 @@
+// Package encryption implements the envelope encryption of sensitive fields
+// stored by the repositories.
+//
+// Every value gets its own random data key. The value is sealed with the data
+// key (AES-256-GCM) and the data key is sealed with a master key from the
+// config. Stored values look like:
+//
+//	v1:<master key id>:<base64 wrapped data key>:<base64 sealed value>
+//
+// Rotating the master key only needs a new key ID set as active. Old values
+// stay readable while the old key is configured, and Reencrypt moves them to
+// the active key.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+const (
+	ENV_FILE_PATH = ".env"
+	// Comma separated "<id>:<base64 32 bytes key>" pairs.
+	MASTER_KEYS_ENV = "ENCRYPTION_MASTER_KEYS"
+	// ID of the master key used to encrypt new values.
+	ACTIVE_KEY_ENV = "ENCRYPTION_ACTIVE_KEY"
+	// Base64 key of the blind indexes, changing it invalidates every index.
+	BLIND_INDEX_KEY_ENV = "ENCRYPTION_BLIND_INDEX_KEY"
+
+	VERSION  = "v1"
+	KEY_SIZE = 32
+)
+
+type Cipher struct {
+	masterKeys  map[string][]byte
+	activeKeyID string
+	indexKey    []byte
+}
+
+func NewCipher(masterKeys map[string][]byte, activeKeyID string, indexKey []byte) (*Cipher, error) {
+
+	for id, key := range masterKeys {
+		if len(key) != KEY_SIZE {
+			return nil, fmt.Errorf("Master key %s must have %d bytes, it has %d", id, KEY_SIZE, len(key))
+		}
+	}
+
+	if _, ok := masterKeys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("Active master key %q is not configured", activeKeyID)
+	}
+
+	if len(indexKey) < KEY_SIZE {
+		return nil, fmt.Errorf("Blind index key must have at least %d bytes", KEY_SIZE)
+	}
+
+	return &Cipher{
+		masterKeys:  masterKeys,
+		activeKeyID: activeKeyID,
+		indexKey:    indexKey,
+	}, nil
+}
+
+// LoadFromEnv builds the cipher from the environment, or from the .env file
+// outside of production, panicking when it is missing or invalid.
+func LoadFromEnv() *Cipher {
+
+	godotenv.Load(ENV_FILE_PATH)
+
+	masterKeys := map[string][]byte{}
+	for _, pair := range strings.Split(os.Getenv(MASTER_KEYS_ENV), ",") {
+		id, encoded, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found {
+			continue
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			panic(fmt.Sprintf("Could not decode master key %s! err: %v", id, err))
+		}
+		masterKeys[id] = key
+	}
+
+	indexKey, err := base64.StdEncoding.DecodeString(os.Getenv(BLIND_INDEX_KEY_ENV))
+	if err != nil {
+		panic(fmt.Sprintf("Could not decode blind index key! err: %v", err))
+	}
+
+	c, err := NewCipher(masterKeys, os.Getenv(ACTIVE_KEY_ENV), indexKey)
+	if err != nil {
+		panic(fmt.Sprintf("Invalid encryption config! err: %v", err))
+	}
+
+	return c
+}
+
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+
+	dataKey := make([]byte, KEY_SIZE)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+
+	sealed, err := seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	wrapped, err := seal(c.masterKeys[c.activeKeyID], dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{
+		VERSION,
+		c.activeKeyID,
+		base64.StdEncoding.EncodeToString(wrapped),
+		base64.StdEncoding.EncodeToString(sealed),
+	}, ":"), nil
+}
+
+// Decrypt opens a value from Encrypt. Values written before encryption was
+// enabled are returned as they are, so they keep working until re-encrypted.
+func (c *Cipher) Decrypt(stored string) (string, error) {
+
+	if !IsEncrypted(stored) {
+		return stored, nil
+	}
+
+	parts := strings.Split(stored, ":")
+	if len(parts) != 4 {
+		return "", fmt.Errorf("Malformed encrypted value")
+	}
+
+	masterKey, ok := c.masterKeys[parts[1]]
+	if !ok {
+		return "", fmt.Errorf("Master key %s is not configured", parts[1])
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := open(masterKey, wrapped)
+	if err != nil {
+		return "", fmt.Errorf("Could not unwrap data key: %v", err)
+	}
+
+	plaintext, err := open(dataKey, sealed)
+	if err != nil {
+		return "", fmt.Errorf("Could not decrypt value: %v", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// NeedsReencryption reports values in plaintext or sealed by an old key.
+func (c *Cipher) NeedsReencryption(stored string) bool {
+	return !IsEncrypted(stored) || strings.Split(stored, ":")[1] != c.activeKeyID
+}
+
+// Reencrypt seals the value again with the active master key and a new data
+// key.
+func (c *Cipher) Reencrypt(stored string) (string, error) {
+
+	plaintext, err := c.Decrypt(stored)
+	if err != nil {
+		return "", err
+	}
+
+	return c.Encrypt(plaintext)
+}
+
+// BlindIndex is a keyed hash of the value, letting repositories look up an
+// encrypted column by equality without decrypting every row.
+func (c *Cipher) BlindIndex(value string) string {
+
+	mac := hmac.New(sha256.New, c.indexKey)
+	mac.Write([]byte(value))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func IsEncrypted(stored string) bool {
+	return strings.HasPrefix(stored, VERSION+":")
+}
+
+func seal(key, plaintext []byte) ([]byte, error) {
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, sealed []byte) ([]byte, error) {
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("Sealed value too short")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
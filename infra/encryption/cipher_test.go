@@ -0,0 +1,102 @@
+package encryption
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const (
+	MOCKED_CPF = "99988877760"
+)
+
+func mockCipher(t *testing.T, activeKeyID string) *Cipher {
+	t.Helper()
+
+	keys := map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, KEY_SIZE),
+		"k2": bytes.Repeat([]byte{2}, KEY_SIZE),
+	}
+
+	c, err := NewCipher(keys, activeKeyID, bytes.Repeat([]byte{9}, KEY_SIZE))
+	if err != nil {
+		t.Fatalf("Could not create cipher. Err: %v", err)
+	}
+
+	return c
+}
+
+func TestEncrypt(t *testing.T) {
+
+	c := mockCipher(t, "k1")
+
+	t.Run("Should decrypt what was encrypted", func(t *testing.T) {
+		stored, err := c.Encrypt(MOCKED_CPF)
+		if err != nil {
+			t.Fatalf("Could not encrypt. Err: %v", err)
+		}
+
+		if strings.Contains(stored, MOCKED_CPF) {
+			t.Errorf("Stored value should NOT contain the plaintext: %s", stored)
+		}
+
+		got, err := c.Decrypt(stored)
+		if err != nil || got != MOCKED_CPF {
+			t.Errorf("Expected %s, got %s. Err: %v", MOCKED_CPF, got, err)
+		}
+	})
+
+	t.Run("Should NOT decrypt a tampered value", func(t *testing.T) {
+		stored, _ := c.Encrypt(MOCKED_CPF)
+		tampered := stored[:len(stored)-4] + "AAA="
+
+		if _, err := c.Decrypt(tampered); err == nil {
+			t.Errorf("Tampered value should NOT be decrypted")
+		}
+	})
+
+	t.Run("Should return legacy plaintext as it is", func(t *testing.T) {
+		got, err := c.Decrypt(MOCKED_CPF)
+		if err != nil || got != MOCKED_CPF {
+			t.Errorf("Expected %s, got %s. Err: %v", MOCKED_CPF, got, err)
+		}
+	})
+}
+
+func TestReencrypt(t *testing.T) {
+
+	old := mockCipher(t, "k1")
+	rotated := mockCipher(t, "k2")
+
+	stored, _ := old.Encrypt(MOCKED_CPF)
+
+	if !rotated.NeedsReencryption(stored) {
+		t.Errorf("Value sealed by k1 should need re-encryption once k2 is active")
+	}
+
+	reencrypted, err := rotated.Reencrypt(stored)
+	if err != nil {
+		t.Fatalf("Could not re-encrypt. Err: %v", err)
+	}
+
+	if rotated.NeedsReencryption(reencrypted) {
+		t.Errorf("Re-encrypted value should use the active key: %s", reencrypted)
+	}
+
+	if got, _ := rotated.Decrypt(reencrypted); got != MOCKED_CPF {
+		t.Errorf("Expected %s, got %s", MOCKED_CPF, got)
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+
+	c := mockCipher(t, "k1")
+
+	if c.BlindIndex(MOCKED_CPF) != c.BlindIndex(MOCKED_CPF) {
+		t.Errorf("Blind index should be deterministic")
+	}
+
+	if c.BlindIndex(MOCKED_CPF) == c.BlindIndex("15799999970") {
+		t.Errorf("Different values should have different blind indexes")
+	}
+}
@@ -0,0 +1,61 @@
+// Package fees loads the fee schedules charged on transfers.
+package fees
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+)
+
+type tier struct {
+	UpTo int `json:"up_to"`
+	Flat int `json:"flat"`
+	Rate int `json:"rate"`
+}
+
+type schedule struct {
+	Kind         string `json:"kind"`
+	Flat         int    `json:"flat"`
+	Rate         int    `json:"rate"`
+	Tiers        []tier `json:"tiers"`
+	Min          int    `json:"min"`
+	Max          int    `json:"max"`
+	FreePerMonth int    `json:"free_per_month"`
+}
+
+// LoadFile reads the schedules from a JSON array, one per transfer kind.
+// Amounts are in cents and rates in basis points.
+func LoadFile(path string) ([]entity.FeeSchedule, error) {
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []schedule
+	if err := json.Unmarshal(raw, &records); err != nil {
+		return nil, fmt.Errorf("Invalid fee schedules file! Err: %v", err)
+	}
+
+	schedules := []entity.FeeSchedule{}
+	for _, record := range records {
+		tiers := []entity.FeeTier{}
+		for _, t := range record.Tiers {
+			tiers = append(tiers, entity.FeeTier{UpTo: t.UpTo, Flat: t.Flat, Rate: t.Rate})
+		}
+
+		schedules = append(schedules, entity.FeeSchedule{
+			Kind:         entity.TransferKind(record.Kind),
+			Flat:         record.Flat,
+			Rate:         record.Rate,
+			Tiers:        tiers,
+			Min:          record.Min,
+			Max:          record.Max,
+			FreePerMonth: record.FreePerMonth,
+		})
+	}
+
+	return schedules, nil
+}
@@ -0,0 +1,41 @@
+package fees
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+)
+
+func TestLoadFile(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "fees.json")
+	content := `[
+		{"kind": "transfer", "rate": 100, "min": 50, "max": 1000, "free_per_month": 3},
+		{"kind": "boleto_payment", "tiers": [{"up_to": 10000, "flat": 150}, {"flat": 300}]}
+	]`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	schedules, err := LoadFile(path)
+	if err != nil || len(schedules) != 2 {
+		t.Fatalf("Both schedules should be loaded. Got: %+v, err: %v", schedules, err)
+	}
+
+	transfer := schedules[0]
+	if transfer.Kind != entity.TransferKindTransfer || transfer.Rate != 100 || transfer.Min != 50 || transfer.Max != 1000 || transfer.FreePerMonth != 3 {
+		t.Errorf("Transfer schedule not loaded as written. Got: %+v", transfer)
+	}
+
+	boleto := schedules[1]
+	if len(boleto.Tiers) != 2 || boleto.Tiers[0].UpTo != 10000 || boleto.Tiers[1].Flat != 300 {
+		t.Errorf("Boleto tiers not loaded as written. Got: %+v", boleto.Tiers)
+	}
+
+	os.WriteFile(path, []byte(`{"kind": "transfer"}`), 0600)
+	if _, err := LoadFile(path); err == nil {
+		t.Errorf("File not holding a list should NOT be loaded")
+	}
+}
@@ -0,0 +1,91 @@
+// Package notification delivers messages to account holders. The notifiers
+// here are meant for local use, they write the messages where a developer can
+// read them, tokens included.
+package notification
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/rs/zerolog/log"
+)
+
+type message struct {
+	AccountID string    `json:"account_id,omitempty"`
+	Address   string    `json:"address,omitempty"`
+	Subject   string    `json:"subject"`
+	Message   string    `json:"message"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
+// LogNotifier writes the messages to the application log.
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Notify(account entity.Account, subject, text string) error {
+
+	log.Info().
+		Str("AccountID", account.PublicID).
+		Str("Subject", subject).
+		Str("Message", text).
+		Msg("Notification sent!")
+
+	return nil
+}
+
+func (n *LogNotifier) NotifyAddress(address, subject, text string) error {
+
+	log.Info().
+		Str("Address", address).
+		Str("Subject", subject).
+		Str("Message", text).
+		Msg("Notification sent!")
+
+	return nil
+}
+
+// FileNotifier appends the messages to a file, one JSON per line.
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+func (n *FileNotifier) Notify(account entity.Account, subject, text string) error {
+	return n.write(message{AccountID: account.PublicID, Subject: subject, Message: text})
+}
+
+func (n *FileNotifier) NotifyAddress(address, subject, text string) error {
+	return n.write(message{Address: address, Subject: subject, Message: text})
+}
+
+func (n *FileNotifier) write(msg message) error {
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Error().Err(err).Str("Path", n.path).Msg("Could not open notification file")
+		return err
+	}
+	defer file.Close()
+
+	msg.SentAt = time.Now().UTC()
+
+	if err := json.NewEncoder(file).Encode(msg); err != nil {
+		log.Error().Err(err).Str("Path", n.path).Msg("Could not write notification")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+// Package redact masks struct fields tagged as sensitive before they reach the
+// logs.
+//
+// Fields are marked with the `redact` tag:
+//
+//	Secret string `json:"secret" redact:"secret"` // replaced by "[REDACTED]"
+//	CPF    string `json:"cpf" redact:"cpf"`       // masked as "***.456.789-**"
+package redact
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/rs/zerolog"
+)
+
+const (
+	TAG = "redact"
+
+	KIND_SECRET = "secret"
+	KIND_CPF    = "cpf"
+
+	REDACTED = "[REDACTED]"
+)
+
+// Install makes every zerolog Interface() field go through Marshal.
+func Install() {
+	zerolog.InterfaceMarshalFunc = Marshal
+}
+
+// Marshal is json.Marshal with the tagged fields masked.
+func Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(Redact(v))
+}
+
+// Redact returns a copy of v safe to log. Structs become maps keyed by their
+// JSON names.
+func Redact(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	return redactValue(reflect.ValueOf(v))
+}
+
+func redactValue(v reflect.Value) interface{} {
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValue(v.Elem())
+
+	case reflect.Struct:
+		// Types that know how to print themselves are kept as they are.
+		if v.Type() == reflect.TypeOf(time.Time{}) || v.Type().Implements(reflect.TypeOf((*json.Marshaler)(nil)).Elem()) {
+			return v.Interface()
+		}
+		return redactStruct(v)
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+
+		output := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			output[i] = redactValue(v.Index(i))
+		}
+		return output
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+
+		output := map[string]interface{}{}
+		iter := v.MapRange()
+		for iter.Next() {
+			key, _ := json.Marshal(iter.Key().Interface())
+			output[strings.Trim(string(key), `"`)] = redactValue(iter.Value())
+		}
+		return output
+
+	default:
+		if !v.CanInterface() {
+			return nil
+		}
+		return v.Interface()
+	}
+}
+
+func redactStruct(v reflect.Value) map[string]interface{} {
+
+	output := map[string]interface{}{}
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			jsonName := strings.Split(tag, ",")[0]
+			if jsonName == "-" {
+				continue
+			}
+			if jsonName != "" {
+				name = jsonName
+			}
+		}
+
+		value := v.Field(i)
+
+		switch field.Tag.Get(TAG) {
+		case KIND_SECRET:
+			output[name] = REDACTED
+		case KIND_CPF:
+			output[name] = maskCPF(value)
+		default:
+			output[name] = redactValue(value)
+		}
+	}
+
+	return output
+}
+
+func maskCPF(v reflect.Value) string {
+	if v.Kind() != reflect.String {
+		return REDACTED
+	}
+
+	return entity.MaskCPF(v.String())
+}
@@ -0,0 +1,57 @@
+package redact
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/PPAKruNN/golearn/domain/service/dto"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	MOCKED_SECRET = "senhaSegura"
+	MOCKED_PIN    = "4321"
+	MOCKED_CPF    = "99988877760"
+)
+
+func TestLogsDoNotLeakSecrets(t *testing.T) {
+
+	Install()
+
+	// The handlers log through the global logger, so that is the one checked.
+	var output bytes.Buffer
+	previous := log.Logger
+	log.Logger = log.Output(&output)
+	t.Cleanup(func() { log.Logger = previous })
+
+	log.Info().Interface("Account", dto.LoginInputDTO{CPF: MOCKED_CPF, Secret: MOCKED_SECRET}).Msg("Account not found!")
+	log.Info().Interface("Account", &dto.CreateAccountInputDTO{Name: "Zé", CPF: MOCKED_CPF, Secret: MOCKED_SECRET}).Msg("")
+	log.Info().Interface("Pin", dto.SetPinInputDTO{Secret: MOCKED_SECRET, Pin: MOCKED_PIN}).Msg("")
+	log.Info().Interface("Transfers", []dto.CreateTrasnferInputDTO{{Amount: 10, Pin: MOCKED_PIN}}).Msg("")
+
+	logs := output.String()
+
+	for _, leaked := range []string{MOCKED_SECRET, MOCKED_PIN, MOCKED_CPF} {
+		if strings.Contains(logs, leaked) {
+			t.Errorf("Logs leaked %q:\n%s", leaked, logs)
+		}
+	}
+
+	if !strings.Contains(logs, "***.888.777-**") {
+		t.Errorf("Logs should keep the masked CPF:\n%s", logs)
+	}
+}
+
+func TestRedactKeepsOtherFields(t *testing.T) {
+
+	redacted := Redact(dto.CreateTrasnferInputDTO{AccountDestinationID: "acc_2", Amount: 10, Pin: MOCKED_PIN}).(map[string]interface{})
+
+	if redacted["amount"] != 10 || redacted["account_destination_id"] != "acc_2" {
+		t.Errorf("Fields without tag should be kept as they are. Got: %+v", redacted)
+	}
+
+	if redacted["pin"] != REDACTED {
+		t.Errorf("PIN should be redacted. Got: %+v", redacted["pin"])
+	}
+}
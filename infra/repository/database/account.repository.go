@@ -3,17 +3,26 @@ package database
 import (
 	"encoding/hex"
 	"fmt"
+	"hash"
 	"time"
 
 	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/infra/encryption"
 	"github.com/jackc/pgx"
 	"github.com/rs/zerolog/log"
 )
 
+// AccountRepository keeps the CPF encrypted, looked up by its blind index.
 type AccountRepository struct {
 	connection *pgx.ConnPool
+	cipher     *encryption.Cipher
 }
 
+// accountHeld is what the holds of the account still reserve, to be read. The
+// expired ones stop counting before being marked so. Debits check the held
+// column instead, after releasing the expired holds, see releaseExpiredHolds.
+const accountHeld = `("Account".held - (SELECT COALESCE(SUM(h.amount), 0) FROM "Hold" h WHERE h.account_id = "Account".id AND h.status = 'authorized' AND h.expires_at <= NOW()))::bigint`
+
 func NewAccountRepository() *AccountRepository {
 
 	pool, err := pgx.NewConnPool(pgx.ConnPoolConfig{
@@ -25,12 +34,12 @@ func NewAccountRepository() *AccountRepository {
 		panic("Couldn't connect to database")
 	}
 
-	return &AccountRepository{connection: pool}
+	return &AccountRepository{connection: pool, cipher: encryption.LoadFromEnv()}
 }
 
 func (r *AccountRepository) ReadAll() ([]entity.Account, error) {
 
-	rows, err := r.connection.Query(`SELECT * FROM "Account"`)
+	rows, err := r.connection.Query(`SELECT id, public_id, name, cpf, secret, balance, status, created_at, agency, number, ` + accountHeld + ` FROM "Account"`)
 	if err != nil {
 		log.Info().Err(err).Msg("Failed to query all accounts")
 		return []entity.Account{}, err
@@ -40,23 +49,39 @@ func (r *AccountRepository) ReadAll() ([]entity.Account, error) {
 	accounts := []entity.Account{}
 	for rows.Next() {
 		var id int
+		var publicId string
 		var name string
 		var cpf string
 		var secret string
 		var balance int
+		var status string
 		var created_at time.Time
+		var agency string
+		var number string
+		var held int
 
-		err = rows.Scan(&id, &name, &cpf, &secret, &balance, &created_at)
+		err = rows.Scan(&id, &publicId, &name, &cpf, &secret, &balance, &status, &created_at, &agency, &number, &held)
 		if err != nil {
 			log.Info().Err(err).Msg("Failed to scan account")
 			return accounts, err
 		}
 
+		cpf, err = r.cipher.Decrypt(cpf)
+		if err != nil {
+			log.Info().Err(err).Int("id", id).Msg("Failed to decrypt account cpf")
+			return accounts, err
+		}
+
 		accounts = append(accounts, entity.Account{
 			ID:        id,
+			PublicID:  publicId,
 			CPF:       cpf,
 			Name:      name,
 			Balance:   balance,
+			Status:    entity.AccountStatus(status),
+			Agency:    agency,
+			Number:    number,
+			Held:      held,
 			CreatedAt: created_at,
 		})
 	}
@@ -66,8 +91,39 @@ func (r *AccountRepository) ReadAll() ([]entity.Account, error) {
 }
 
 func (r *AccountRepository) ReadByID(id int) (entity.Account, error) {
+	return r.readOne(`WHERE id = $1`, id)
+}
+
+func (r *AccountRepository) ReadByPublicID(publicId string) (entity.Account, error) {
+	return r.readOne(`WHERE public_id = $1`, publicId)
+}
+
+// ReadByCPF ignores erased accounts, their CPF is gone. CPFs are not unique,
+// a CPF with more than one account does not identify any of them.
+func (r *AccountRepository) ReadByCPF(cpf string) (entity.Account, error) {
+
+	where := `WHERE erased_at IS NULL AND (cpf_index = $1 OR (cpf_index IS NULL AND cpf = $2))`
+
+	var count int
+	if err := r.connection.QueryRow(`SELECT count(*) FROM "Account" `+where, r.cipher.BlindIndex(cpf), cpf).Scan(&count); err != nil {
+		log.Info().Err(err).Str("CPF", entity.MaskCPF(cpf)).Msg("Failed to count accounts by cpf")
+		return entity.Account{}, err
+	}
+
+	if count != 1 {
+		return entity.Account{}, fmt.Errorf("CPF matches %d accounts", count)
+	}
+
+	return r.readOne(where, r.cipher.BlindIndex(cpf), cpf)
+}
 
-	rows, err := r.connection.Query(`SELECT * FROM "Account" WHERE id = $1`, id)
+func (r *AccountRepository) ReadByNumber(agency, number string) (entity.Account, error) {
+	return r.readOne(`WHERE agency = $1 AND number = $2`, agency, number)
+}
+
+func (r *AccountRepository) readOne(where string, args ...interface{}) (entity.Account, error) {
+
+	rows, err := r.connection.Query(`SELECT id, public_id, name, cpf, secret, balance, status, created_at, agency, number, `+accountHeld+` FROM "Account" `+where, args...)
 
 	if err != nil {
 		log.Info().Err(err).Msg("Failed to query accounts")
@@ -80,23 +136,39 @@ func (r *AccountRepository) ReadByID(id int) (entity.Account, error) {
 		account := entity.Account{}
 
 		var id int
+		var publicId string
 		var name string
 		var cpf string
 		var secret string
 		var balance int
+		var status string
 		var created_at time.Time
+		var agency string
+		var number string
+		var held int
 
-		err = rows.Scan(&id, &name, &cpf, &secret, &balance, &created_at)
+		err = rows.Scan(&id, &publicId, &name, &cpf, &secret, &balance, &status, &created_at, &agency, &number, &held)
 		if err != nil {
 			log.Info().Err(err).Msg("Failed to scan account")
 			return entity.Account{}, err
 		}
 
+		cpf, err = r.cipher.Decrypt(cpf)
+		if err != nil {
+			log.Info().Err(err).Int("id", id).Msg("Failed to decrypt account cpf")
+			return entity.Account{}, err
+		}
+
 		account = entity.Account{
 			ID:        id,
+			PublicID:  publicId,
 			Name:      name,
 			CPF:       cpf,
 			Balance:   balance,
+			Status:    entity.AccountStatus(status),
+			Agency:    agency,
+			Number:    number,
+			Held:      held,
 			CreatedAt: created_at,
 		}
 
@@ -109,9 +181,10 @@ func (r *AccountRepository) ReadByID(id int) (entity.Account, error) {
 
 func (r *AccountRepository) ReadHashByCPF(cpf string) (int, []byte, error) {
 
-	rows, err := r.connection.Query(`SELECT id, secret FROM "Account" WHERE cpf = $1`, cpf)
+	// Rows not re-encrypted yet have no index and still hold the plain CPF.
+	rows, err := r.connection.Query(`SELECT id, secret FROM "Account" WHERE erased_at IS NULL AND (cpf_index = $1 OR (cpf_index IS NULL AND cpf = $2))`, r.cipher.BlindIndex(cpf), cpf)
 	if err != nil {
-		log.Info().Err(err).Str("CPF", cpf).Msg("Failed to query account hash by cpf")
+		log.Info().Err(err).Str("CPF", entity.MaskCPF(cpf)).Msg("Failed to query account hash by cpf")
 		return 0, []byte{}, err
 	}
 	defer rows.Close()
@@ -130,19 +203,61 @@ func (r *AccountRepository) ReadHashByCPF(cpf string) (int, []byte, error) {
 
 	bs, err := hex.DecodeString(hash)
 	if err != nil {
-		log.Info().Err(err).Str("hash", hash).Msg("Failed to decode hash provided")
+		log.Info().Err(err).Msg("Failed to decode hash provided")
 		return 0, []byte{}, err
 	}
 
 	return id, bs, nil
 }
 
+func (r *AccountRepository) ReadHashByID(id int) ([]byte, error) {
+
+	var hash string
+
+	err := r.connection.QueryRow(`SELECT secret FROM "Account" WHERE id = $1 AND erased_at IS NULL`, id).Scan(&hash)
+	if err != nil {
+		log.Info().Err(err).Int("ID", id).Msg("Failed to query account hash by id")
+		return []byte{}, err
+	}
+
+	bs, err := hex.DecodeString(hash)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to decode hash provided")
+		return []byte{}, err
+	}
+
+	return bs, nil
+}
+
 func (r *AccountRepository) Create(acc entity.Account) (entity.Account, error) {
 
-	log.Info().Str("name", acc.Name).Str("cpf", acc.CPF).Int("balance", acc.Balance).Msg("Creating account")
+	log.Info().Str("name", acc.Name).Str("cpf", entity.MaskCPF(acc.CPF)).Int("balance", acc.Balance).Msg("Creating account")
+
+	if acc.PublicID == "" {
+		acc.PublicID = entity.NewPublicID(entity.ACCOUNT_ID_PREFIX)
+	}
+	if acc.Status == "" {
+		acc.Status = entity.AccountActive
+	}
+	if acc.Number == "" {
+		var sequence int
+		if err := r.connection.QueryRow(`SELECT nextval('"Account_number_seq"')`).Scan(&sequence); err != nil {
+			log.Info().Err(err).Msg("Failed to generate account number")
+			return entity.Account{}, err
+		}
+
+		acc.Agency = entity.ACCOUNT_AGENCY
+		acc.Number = entity.NewAccountNumber(sequence)
+	}
+
+	encryptedCPF, err := r.cipher.Encrypt(acc.CPF)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to encrypt account cpf")
+		return entity.Account{}, err
+	}
 
 	encoded := hex.EncodeToString(acc.Secret.Sum(nil))
-	rows, err := r.connection.Query(`INSERT INTO "Account" (name, cpf, secret, balance) VALUES ($1, $2, $3, $4) RETURNING id, name, cpf, secret, balance, created_at`, acc.Name, acc.CPF, encoded, acc.Balance)
+	rows, err := r.connection.Query(`INSERT INTO "Account" (public_id, name, cpf, cpf_index, secret, balance, status, agency, number) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, public_id, name, cpf, secret, balance, status, created_at, agency, number`, acc.PublicID, acc.Name, encryptedCPF, r.cipher.BlindIndex(acc.CPF), encoded, acc.Balance, string(acc.Status), acc.Agency, acc.Number)
 
 	if err != nil {
 		log.Info().Err(err).Interface("account", acc).Msg("Failed to create account")
@@ -152,13 +267,17 @@ func (r *AccountRepository) Create(acc entity.Account) (entity.Account, error) {
 
 	for rows.Next() {
 		var id int
+		var publicId string
 		var name string
 		var cpf string
 		var secret string
 		var balance int
+		var status string
 		var created_at time.Time
+		var agency string
+		var number string
 
-		err = rows.Scan(&id, &name, &cpf, &secret, &balance, &created_at)
+		err = rows.Scan(&id, &publicId, &name, &cpf, &secret, &balance, &status, &created_at, &agency, &number)
 		if err != nil {
 			log.Info().Err(err).Msg("Failed to scan account")
 			return entity.Account{}, err
@@ -169,44 +288,173 @@ func (r *AccountRepository) Create(acc entity.Account) (entity.Account, error) {
 	return acc, nil
 }
 
-func (r *AccountRepository) UpdateBalance(id, balance int) (entity.Account, error) {
+func (r *AccountRepository) UpdateName(id int, name string) error {
 
-	rows, err := r.connection.Query(`UPDATE "Account" SET balance = $1 WHERE id = $2 RETURNING id, name, cpf, secret, balance, created_at`, balance, id)
+	_, err := r.connection.Exec(`UPDATE "Account" SET name = $1 WHERE id = $2`, name, id)
 	if err != nil {
-		log.Info().Err(err).Int("id", id).Int("balance", balance).Msg("Failed to update account balance")
-		return entity.Account{}, err
+		log.Info().Err(err).Int("id", id).Msg("Failed to update account name")
+		return err
+	}
+
+	return nil
+}
+
+func (r *AccountRepository) UpdateStatus(id int, status entity.AccountStatus) error {
+
+	_, err := r.connection.Exec(`UPDATE "Account" SET status = $1 WHERE id = $2`, string(status), id)
+	if err != nil {
+		log.Info().Err(err).Int("id", id).Str("status", string(status)).Msg("Failed to update account status")
+		return err
+	}
+
+	return nil
+}
+
+func (r *AccountRepository) Close(id int) error {
+
+	tag, err := r.connection.Exec(`UPDATE "Account" SET status = $1 WHERE id = $2 AND balance = 0 AND status <> $1`, string(entity.AccountClosed), id)
+	if err != nil {
+		log.Info().Err(err).Int("id", id).Msg("Failed to close account")
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("Account has balance or is already closed")
+	}
+
+	return nil
+}
+
+func (r *AccountRepository) UpdateSecret(id int, secret hash.Hash) error {
+
+	encoded := hex.EncodeToString(secret.Sum(nil))
+	_, err := r.connection.Exec(`UPDATE "Account" SET secret = $1 WHERE id = $2 AND erased_at IS NULL`, encoded, id)
+	if err != nil {
+		log.Info().Err(err).Int("id", id).Msg("Failed to update account secret")
+		return err
+	}
+
+	return nil
+}
+
+func (r *AccountRepository) Search(query string) ([]entity.Account, error) {
+
+	rows, err := r.connection.Query(`SELECT id, public_id, name, cpf, secret, balance, status, created_at, agency, number, `+accountHeld+` FROM "Account" WHERE cpf_index = $1 OR (cpf_index IS NULL AND cpf = $2) OR name ILIKE '%' || $2 || '%' ORDER BY id`, r.cipher.BlindIndex(query), query)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to search accounts")
+		return []entity.Account{}, err
 	}
 	defer rows.Close()
 
+	accounts := []entity.Account{}
 	for rows.Next() {
-		account := entity.Account{}
-
 		var id int
+		var publicId string
 		var name string
 		var cpf string
 		var secret string
 		var balance int
+		var status string
 		var created_at time.Time
+		var agency string
+		var number string
+		var held int
 
-		err = rows.Scan(&id, &name, &cpf, &secret, &balance, &created_at)
+		err = rows.Scan(&id, &publicId, &name, &cpf, &secret, &balance, &status, &created_at, &agency, &number, &held)
 		if err != nil {
 			log.Info().Err(err).Msg("Failed to scan account")
-			return entity.Account{}, err
+			return accounts, err
 		}
 
-		account = entity.Account{
+		cpf, err = r.cipher.Decrypt(cpf)
+		if err != nil {
+			log.Info().Err(err).Int("id", id).Msg("Failed to decrypt account cpf")
+			return accounts, err
+		}
+
+		accounts = append(accounts, entity.Account{
 			ID:        id,
-			Name:      name,
+			PublicID:  publicId,
 			CPF:       cpf,
+			Name:      name,
 			Balance:   balance,
+			Status:    entity.AccountStatus(status),
+			Agency:    agency,
+			Number:    number,
+			Held:      held,
 			CreatedAt: created_at,
+		})
+	}
+
+	return accounts, nil
+}
+
+func (r *AccountRepository) Anonymize(account entity.Account) error {
+
+	// Without secret nor CPF index nobody can log in to the account anymore.
+	tag, err := r.connection.Exec(`UPDATE "Account" SET name = $1, cpf = $2, cpf_index = NULL, secret = '', status = $3, erased_at = NOW() WHERE id = $4 AND balance = 0`, account.Name, account.CPF, string(account.Status), account.ID)
+	if err != nil {
+		log.Info().Err(err).Int("id", account.ID).Msg("Failed to anonymize account")
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("Account has balance")
+	}
+
+	return nil
+}
+
+// Reencrypt moves every CPF still in plaintext or sealed by an old master key
+// to the active key, filling the blind index. Returns how many were updated.
+func (r *AccountRepository) Reencrypt() (int, error) {
+
+	rows, err := r.connection.Query(`SELECT id, cpf FROM "Account" ORDER BY id`)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to query accounts to re-encrypt")
+		return 0, err
+	}
+
+	pending := map[int]string{}
+	for rows.Next() {
+		var id int
+		var cpf string
+
+		if err = rows.Scan(&id, &cpf); err != nil {
+			rows.Close()
+			log.Info().Err(err).Msg("Failed to scan account")
+			return 0, err
 		}
 
-		return account, nil
+		if r.cipher.NeedsReencryption(cpf) {
+			pending[id] = cpf
+		}
 	}
+	rows.Close()
+
+	updated := 0
+	for id, stored := range pending {
+		plain, err := r.cipher.Decrypt(stored)
+		if err != nil {
+			log.Info().Err(err).Int("id", id).Msg("Failed to decrypt account cpf")
+			return updated, err
+		}
+
+		encrypted, err := r.cipher.Encrypt(plain)
+		if err != nil {
+			return updated, err
+		}
 
-	return entity.Account{}, fmt.Errorf("Couldn't UPDATE account")
+		_, err = r.connection.Exec(`UPDATE "Account" SET cpf = $1, cpf_index = $2 WHERE id = $3`, encrypted, r.cipher.BlindIndex(plain), id)
+		if err != nil {
+			log.Info().Err(err).Int("id", id).Msg("Failed to re-encrypt account cpf")
+			return updated, err
+		}
+
+		updated++
+	}
 
+	return updated, nil
 }
 
 func (r *AccountRepository) Reset() error {
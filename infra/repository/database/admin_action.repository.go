@@ -0,0 +1,157 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/jackc/pgx"
+	"github.com/rs/zerolog/log"
+)
+
+type AdminActionRepository struct {
+	connection *pgx.ConnPool
+}
+
+func NewAdminActionRepository() *AdminActionRepository {
+
+	pool, err := pgx.NewConnPool(pgx.ConnPoolConfig{
+		ConnConfig: loadDatabaseEnvs(),
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to connect to database")
+		panic("Couldn't connect to database")
+	}
+
+	return &AdminActionRepository{connection: pool}
+}
+
+func (r *AdminActionRepository) Create(action entity.AdminAction) (entity.AdminAction, error) {
+
+	details, err := json.Marshal(action.Details)
+	if err != nil {
+		log.Info().Err(err).Str("Action", action.Action).Msg("Failed to encode admin action details")
+		return entity.AdminAction{}, err
+	}
+
+	rows, err := r.connection.Query(`INSERT INTO "AdminAction" (operator_id, action, target_id, reason, details) VALUES ($1, $2, $3, $4, $5::jsonb) RETURNING id, created_at`, action.OperatorID, action.Action, action.TargetID, action.Reason, string(details))
+	if err != nil {
+		log.Error().Err(err).Int("OperatorID", action.OperatorID).Str("Action", action.Action).Msg("Failed to record admin action")
+		return entity.AdminAction{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err = rows.Scan(&action.ID, &action.CreatedAt)
+		if err != nil {
+			log.Info().Err(err).Msg("Failed to scan admin action")
+			return entity.AdminAction{}, err
+		}
+	}
+
+	return action, nil
+}
+
+// AdjustBalance credits (positive amount) or debits (negative amount) the
+// account targeted by the action and records the action, filling its
+// balance_before and balance_after details, in a single database transaction.
+func (r *AdminActionRepository) AdjustBalance(action entity.AdminAction, amount int) (entity.AdminAction, error) {
+
+	tx, err := r.connection.Begin()
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to begin adjustment transaction")
+		return entity.AdminAction{}, err
+	}
+	defer tx.Rollback()
+
+	var after int
+	err = tx.QueryRow(`UPDATE "Account" SET balance = balance + $1 WHERE id = $2 AND balance + $1 >= 0 AND status <> 'closed' RETURNING balance`, amount, action.TargetID).Scan(&after)
+	if err == pgx.ErrNoRows {
+		return entity.AdminAction{}, fmt.Errorf("Account would have a negative balance or is closed")
+	}
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", action.TargetID).Msg("Failed to adjust account balance")
+		return entity.AdminAction{}, err
+	}
+
+	if action.Details == nil {
+		action.Details = map[string]interface{}{}
+	}
+	action.Details["balance_before"] = after - amount
+	action.Details["balance_after"] = after
+
+	details, err := json.Marshal(action.Details)
+	if err != nil {
+		log.Info().Err(err).Str("Action", action.Action).Msg("Failed to encode admin action details")
+		return entity.AdminAction{}, err
+	}
+
+	err = tx.QueryRow(`INSERT INTO "AdminAction" (operator_id, action, target_id, reason, details) VALUES ($1, $2, $3, $4, $5::jsonb) RETURNING id, created_at`, action.OperatorID, action.Action, action.TargetID, action.Reason, string(details)).Scan(&action.ID, &action.CreatedAt)
+	if err != nil {
+		log.Error().Err(err).Int("OperatorID", action.OperatorID).Str("Action", action.Action).Msg("Failed to record admin action")
+		return entity.AdminAction{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Info().Err(err).Msg("Failed to commit adjustment transaction")
+		return entity.AdminAction{}, err
+	}
+
+	return action, nil
+}
+
+func (r *AdminActionRepository) ReadAll() ([]entity.AdminAction, error) {
+
+	rows, err := r.connection.Query(`SELECT id, operator_id, action, target_id, reason, details::text, created_at FROM "AdminAction" ORDER BY id DESC`)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to query admin actions")
+		return []entity.AdminAction{}, err
+	}
+	defer rows.Close()
+
+	actions := []entity.AdminAction{}
+	for rows.Next() {
+		var id int
+		var operatorID int
+		var action string
+		var targetID int
+		var reason string
+		var details string
+		var created_at time.Time
+
+		err = rows.Scan(&id, &operatorID, &action, &targetID, &reason, &details, &created_at)
+		if err != nil {
+			log.Info().Err(err).Msg("Failed to scan admin action")
+			return actions, err
+		}
+
+		parsed := map[string]interface{}{}
+		json.Unmarshal([]byte(details), &parsed)
+
+		actions = append(actions, entity.AdminAction{
+			ID:         id,
+			OperatorID: operatorID,
+			Action:     action,
+			TargetID:   targetID,
+			Reason:     reason,
+			Details:    parsed,
+			CreatedAt:  created_at,
+		})
+	}
+
+	return actions, nil
+}
+
+func (r *AdminActionRepository) Reset() error {
+
+	rows, err := r.connection.Query(`DELETE FROM "AdminAction"`)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to reset admin actions")
+		return err
+	}
+	defer rows.Close()
+
+	return nil
+}
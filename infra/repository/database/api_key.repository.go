@@ -0,0 +1,168 @@
+package database
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/jackc/pgx"
+	"github.com/rs/zerolog/log"
+)
+
+type APIKeyRepository struct {
+	connection *pgx.ConnPool
+}
+
+func NewAPIKeyRepository() *APIKeyRepository {
+
+	pool, err := pgx.NewConnPool(pgx.ConnPoolConfig{
+		ConnConfig: loadDatabaseEnvs(),
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to connect to database")
+		panic("Couldn't connect to database")
+	}
+
+	return &APIKeyRepository{connection: pool}
+}
+
+func (r *APIKeyRepository) Create(key entity.APIKey) error {
+
+	rows, err := r.connection.Query(`INSERT INTO "APIKey" (id, account_id, name, secret, scopes, created_at, rotated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`, key.ID, key.AccountID, key.Name, hex.EncodeToString(key.Secret), scopesToStrings(key.Scopes), key.CreatedAt, key.RotatedAt)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", key.AccountID).Msg("Failed to create API key")
+		return err
+	}
+	defer rows.Close()
+
+	return nil
+}
+
+func (r *APIKeyRepository) ReadByID(id string) (entity.APIKey, error) {
+
+	rows, err := r.connection.Query(`SELECT id, account_id, name, secret, scopes, created_at, rotated_at, revoked_at, last_used_at FROM "APIKey" WHERE id = $1`, id)
+	if err != nil {
+		log.Info().Err(err).Str("KeyID", id).Msg("Failed to query API key")
+		return entity.APIKey{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			log.Info().Err(err).Msg("Failed to scan API key")
+			return entity.APIKey{}, err
+		}
+
+		return key, nil
+	}
+
+	return entity.APIKey{}, fmt.Errorf("Couldn't find an API key with the provided ID")
+}
+
+func (r *APIKeyRepository) ReadByAccountID(accountId int) ([]entity.APIKey, error) {
+
+	rows, err := r.connection.Query(`SELECT id, account_id, name, secret, scopes, created_at, rotated_at, revoked_at, last_used_at FROM "APIKey" WHERE account_id = $1 ORDER BY created_at`, accountId)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", accountId).Msg("Failed to query API keys")
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []entity.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			log.Info().Err(err).Msg("Failed to scan API key")
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepository) Update(key entity.APIKey) error {
+
+	_, err := r.connection.Exec(`UPDATE "APIKey" SET name = $2, secret = $3, scopes = $4, rotated_at = $5, revoked_at = $6, last_used_at = $7 WHERE id = $1`, key.ID, key.Name, hex.EncodeToString(key.Secret), scopesToStrings(key.Scopes), key.RotatedAt, key.RevokedAt, key.LastUsedAt)
+	if err != nil {
+		log.Info().Err(err).Str("KeyID", key.ID).Msg("Failed to update API key")
+		return err
+	}
+
+	return nil
+}
+
+// UpdateLastUsedAt only sets when the key was last used, leaving the rest of
+// a key that may be rotated or revoked meanwhile as it is.
+func (r *APIKeyRepository) UpdateLastUsedAt(id string, usedAt time.Time) error {
+
+	_, err := r.connection.Exec(`UPDATE "APIKey" SET last_used_at = $2 WHERE id = $1 AND revoked_at IS NULL`, id, usedAt)
+	if err != nil {
+		log.Info().Err(err).Str("KeyID", id).Msg("Failed to update API key last use")
+		return err
+	}
+
+	return nil
+}
+
+func (r *APIKeyRepository) Reset() error {
+
+	_, err := r.connection.Exec(`DELETE FROM "APIKey"`)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to reset API keys")
+		return err
+	}
+
+	return nil
+}
+
+func scanAPIKey(rows *pgx.Rows) (entity.APIKey, error) {
+	var id string
+	var accountId int
+	var name string
+	var secret string
+	var scopes []string
+	var created_at time.Time
+	var rotated_at time.Time
+	var revoked_at *time.Time
+	var last_used_at *time.Time
+
+	err := rows.Scan(&id, &accountId, &name, &secret, &scopes, &created_at, &rotated_at, &revoked_at, &last_used_at)
+	if err != nil {
+		return entity.APIKey{}, err
+	}
+
+	bs, err := hex.DecodeString(secret)
+	if err != nil {
+		return entity.APIKey{}, err
+	}
+
+	keyScopes := []entity.Scope{}
+	for _, scope := range scopes {
+		keyScopes = append(keyScopes, entity.Scope(scope))
+	}
+
+	return entity.APIKey{
+		ID:         id,
+		AccountID:  accountId,
+		Name:       name,
+		Secret:     bs,
+		Scopes:     keyScopes,
+		CreatedAt:  created_at,
+		RotatedAt:  rotated_at,
+		RevokedAt:  revoked_at,
+		LastUsedAt: last_used_at,
+	}, nil
+}
+
+func scopesToStrings(scopes []entity.Scope) []string {
+	output := []string{}
+	for _, scope := range scopes {
+		output = append(output, string(scope))
+	}
+	return output
+}
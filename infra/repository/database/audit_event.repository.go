@@ -0,0 +1,119 @@
+package database
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/jackc/pgx"
+	"github.com/rs/zerolog/log"
+)
+
+type AuditEventRepository struct {
+	connection *pgx.ConnPool
+}
+
+func NewAuditEventRepository() *AuditEventRepository {
+
+	pool, err := pgx.NewConnPool(pgx.ConnPoolConfig{
+		ConnConfig: loadDatabaseEnvs(),
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to connect to database")
+		panic("Couldn't connect to database")
+	}
+
+	return &AuditEventRepository{connection: pool}
+}
+
+func (r *AuditEventRepository) Append(event entity.AuditEvent) (entity.AuditEvent, error) {
+
+	before, err := json.Marshal(event.Before)
+	if err != nil {
+		log.Error().Err(err).Str("Type", event.Type).Msg("Failed to encode audit event")
+		return entity.AuditEvent{}, err
+	}
+
+	after, err := json.Marshal(event.After)
+	if err != nil {
+		log.Error().Err(err).Str("Type", event.Type).Msg("Failed to encode audit event")
+		return entity.AuditEvent{}, err
+	}
+
+	tx, err := r.connection.Begin()
+	if err != nil {
+		log.Error().Err(err).Str("Type", event.Type).Msg("Failed to record audit event")
+		return entity.AuditEvent{}, err
+	}
+	defer tx.Rollback()
+
+	// Reads still go through, only other appends wait for the chain head.
+	if _, err := tx.Exec(`LOCK TABLE "AuditEvent" IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		log.Error().Err(err).Str("Type", event.Type).Msg("Failed to lock audit log")
+		return entity.AuditEvent{}, err
+	}
+
+	var prevHash string
+	err = tx.QueryRow(`SELECT hash FROM "AuditEvent" ORDER BY id DESC LIMIT 1`).Scan(&prevHash)
+	if err != nil && err != pgx.ErrNoRows {
+		log.Error().Err(err).Str("Type", event.Type).Msg("Failed to read audit log head")
+		return entity.AuditEvent{}, err
+	}
+
+	event.Chain(prevHash)
+
+	err = tx.QueryRow(`INSERT INTO "AuditEvent" (type, actor, ip, request_id, subject, before, after, created_at, prev_hash, hash) VALUES ($1, $2, $3, $4, $5, $6::jsonb, $7::jsonb, $8, $9, $10) RETURNING id`,
+		event.Type, event.Actor, event.IP, event.RequestID, event.Subject, string(before), string(after), event.CreatedAt, event.PrevHash, event.Hash).Scan(&event.ID)
+	if err != nil {
+		log.Error().Err(err).Str("Type", event.Type).Str("Actor", event.Actor).Str("RequestID", event.RequestID).Msg("Failed to record audit event")
+		return entity.AuditEvent{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error().Err(err).Str("Type", event.Type).Str("Actor", event.Actor).Str("RequestID", event.RequestID).Msg("Failed to record audit event")
+		return entity.AuditEvent{}, err
+	}
+
+	return event, nil
+}
+
+func (r *AuditEventRepository) ReadAll() ([]entity.AuditEvent, error) {
+	return r.query(`SELECT id, type, actor, ip, request_id, subject, before::text, after::text, created_at, prev_hash, hash FROM "AuditEvent" ORDER BY id`)
+}
+
+func (r *AuditEventRepository) ReadByReference(refs ...string) ([]entity.AuditEvent, error) {
+	return r.query(`SELECT id, type, actor, ip, request_id, subject, before::text, after::text, created_at, prev_hash, hash FROM "AuditEvent" WHERE actor = ANY($1) OR subject = ANY($1) ORDER BY id`, refs)
+}
+
+func (r *AuditEventRepository) query(sql string, args ...interface{}) ([]entity.AuditEvent, error) {
+
+	rows, err := r.connection.Query(sql, args...)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to query audit events")
+		return []entity.AuditEvent{}, err
+	}
+	defer rows.Close()
+
+	events := []entity.AuditEvent{}
+	for rows.Next() {
+		var event entity.AuditEvent
+		var before string
+		var after string
+		var created_at time.Time
+
+		err = rows.Scan(&event.ID, &event.Type, &event.Actor, &event.IP, &event.RequestID, &event.Subject, &before, &after, &created_at, &event.PrevHash, &event.Hash)
+		if err != nil {
+			log.Info().Err(err).Msg("Failed to scan audit event")
+			return events, err
+		}
+
+		json.Unmarshal([]byte(before), &event.Before)
+		json.Unmarshal([]byte(after), &event.After)
+		event.CreatedAt = created_at.UTC()
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
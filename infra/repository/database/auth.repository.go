@@ -2,7 +2,9 @@ package database
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/PPAKruNN/golearn/domain/entity"
 	"github.com/jackc/pgx"
 	"github.com/rs/zerolog/log"
 )
@@ -27,52 +29,141 @@ func NewAuthRepository() *AuthRepository {
 	}
 }
 
-func (r AuthRepository) RegisterToken(token string, accountId int) {
-	rows, err := r.connection.Query(`INSERT INTO "Auth" (id, token) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET token = $2`, accountId, token)
+func (r AuthRepository) CreateSession(session entity.Session) error {
+	rows, err := r.connection.Query(`INSERT INTO "Session" (id, token_hash, account_id, user_agent, ip, created_at, last_used_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`, session.ID, session.TokenHash, session.AccountID, session.UserAgent, session.IP, session.CreatedAt, session.LastUsedAt, session.ExpiresAt)
 
 	if err != nil {
-		log.Info().Err(err).Str("Token", token).Msg("Failed to register token on database!")
-		// FIXME: Should return error!
-		return
+		log.Info().Err(err).Int("AccountID", session.AccountID).Msg("Failed to register session on database!")
+		return err
 	}
 
 	defer rows.Close()
+	return nil
 }
 
-func (r AuthRepository) DecodeToken(token string) (int, error) {
+func (r AuthRepository) DecodeToken(tokenHash string) (entity.Session, error) {
 
-	rows, err := r.connection.Query(`SELECT id FROM "Auth" WHERE token = $1`, token)
+	rows, err := r.connection.Query(`UPDATE "Session" SET last_used_at = NOW() WHERE token_hash = $1 AND expires_at > NOW() RETURNING `+sessionColumns, tokenHash)
 
 	if err != nil {
-		log.Info().Err(err).Str("Token", token).Msg("Failed to decode/get accountID from token!")
-		return 0, err
+		log.Info().Err(err).Msg("Failed to decode/get session from token!")
+		return entity.Session{}, err
 	}
 
 	defer rows.Close()
 
 	for rows.Next() {
-		var id int
+		session, err := scanSession(rows)
+		if err != nil {
+			log.Info().Err(err).Msg("Failed to scan session")
+			return entity.Session{}, err
+		}
+
+		return session, nil
+	}
+
+	return entity.Session{}, fmt.Errorf("Failed to get decode/get session from token!")
+}
+
+func (r AuthRepository) ReadSessionsByAccountID(accountId int) ([]entity.Session, error) {
+
+	rows, err := r.connection.Query(`SELECT `+sessionColumns+` FROM "Session" WHERE account_id = $1 AND expires_at > NOW() ORDER BY last_used_at DESC`, accountId)
+
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", accountId).Msg("Failed to query sessions!")
+		return nil, err
+	}
 
-		err = rows.Scan(&id)
+	defer rows.Close()
+
+	sessions := []entity.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
 		if err != nil {
-			log.Info().Err(err).Msg("Failed to scan token")
-			return 0, nil
+			log.Info().Err(err).Msg("Failed to scan session")
+			return nil, err
 		}
 
-		return id, nil
+		sessions = append(sessions, session)
 	}
 
-	return 0, fmt.Errorf("Failed to get decode/get accountId from token!")
+	return sessions, nil
+}
+
+func (r AuthRepository) RevokeSession(accountId int, sessionId string) error {
+
+	tag, err := r.connection.Exec(`DELETE FROM "Session" WHERE id = $1 AND account_id = $2`, sessionId, accountId)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", accountId).Str("SessionID", sessionId).Msg("Failed to revoke session!")
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("Couldn't find a session with the provided ID")
+	}
+
+	return nil
+}
+
+func (r AuthRepository) RevokeToken(tokenHash string) error {
+
+	_, err := r.connection.Exec(`DELETE FROM "Session" WHERE token_hash = $1`, tokenHash)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to revoke session token!")
+		return err
+	}
+
+	return nil
+}
+
+func (r AuthRepository) RevokeAllSessions(accountId int) error {
+
+	_, err := r.connection.Exec(`DELETE FROM "Session" WHERE account_id = $1`, accountId)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", accountId).Msg("Failed to revoke all sessions!")
+		return err
+	}
+
+	return nil
 }
 
 func (r AuthRepository) Reset() error {
-	rows, err := r.connection.Query(`DELETE FROM "Auth"`)
+	rows, err := r.connection.Query(`DELETE FROM "Session"`)
 
 	if err != nil {
-		log.Info().Err(err).Msg("Failed reset tokens from database!")
+		log.Info().Err(err).Msg("Failed reset sessions from database!")
 		return err
 	}
 
 	defer rows.Close()
 	return nil
 }
+
+const sessionColumns = `id, token_hash, account_id, user_agent, ip, created_at, last_used_at, expires_at`
+
+func scanSession(rows *pgx.Rows) (entity.Session, error) {
+	var id string
+	var tokenHash string
+	var accountId int
+	var userAgent string
+	var ip string
+	var created_at time.Time
+	var last_used_at time.Time
+	var expires_at time.Time
+
+	err := rows.Scan(&id, &tokenHash, &accountId, &userAgent, &ip, &created_at, &last_used_at, &expires_at)
+	if err != nil {
+		return entity.Session{}, err
+	}
+
+	return entity.Session{
+		ID:         id,
+		TokenHash:  tokenHash,
+		AccountID:  accountId,
+		UserAgent:  userAgent,
+		IP:         ip,
+		CreatedAt:  created_at,
+		LastUsedAt: last_used_at,
+		ExpiresAt:  expires_at,
+	}, nil
+}
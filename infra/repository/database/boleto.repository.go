@@ -0,0 +1,186 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/infra/encryption"
+	"github.com/jackc/pgx"
+	"github.com/rs/zerolog/log"
+)
+
+const boletoColumns = `id, account_id, our_number, amount, due_date, payer_name, payer_document, fine_rate, interest_rate, status, paid_at, paid_amount, created_at`
+
+type BoletoRepository struct {
+	connection *pgx.ConnPool
+	cipher     *encryption.Cipher
+}
+
+func NewBoletoRepository() *BoletoRepository {
+
+	pool, err := pgx.NewConnPool(pgx.ConnPoolConfig{
+		ConnConfig: loadDatabaseEnvs(),
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to connect to database")
+		panic("Couldn't connect to database")
+	}
+
+	return &BoletoRepository{connection: pool, cipher: encryption.LoadFromEnv()}
+}
+
+func (r *BoletoRepository) Create(boleto entity.Boleto) error {
+
+	encrypted, err := r.cipher.Encrypt(boleto.PayerDocument)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", boleto.AccountID).Msg("Failed to encrypt boleto payer document")
+		return err
+	}
+
+	_, err = r.connection.Exec(`INSERT INTO "Boleto" (id, account_id, our_number, amount, due_date, payer_name, payer_document, fine_rate, interest_rate, status, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		boleto.ID, boleto.AccountID, boleto.OurNumber, boleto.Amount, boleto.DueDate, boleto.PayerName, encrypted, boleto.FineRate, boleto.InterestRate, string(boleto.Status), boleto.CreatedAt)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", boleto.AccountID).Msg("Failed to create boleto")
+		return err
+	}
+
+	return nil
+}
+
+func (r *BoletoRepository) ReadByID(id string) (entity.Boleto, error) {
+	return r.readOne(`id = $1`, id)
+}
+
+func (r *BoletoRepository) ReadByOurNumber(ourNumber string) (entity.Boleto, error) {
+	return r.readOne(`our_number = $1`, ourNumber)
+}
+
+func (r *BoletoRepository) ReadByAccountID(accountId int) ([]entity.Boleto, error) {
+	return r.query(`SELECT `+boletoColumns+` FROM "Boleto" WHERE account_id = $1 ORDER BY created_at DESC`, accountId)
+}
+
+func (r *BoletoRepository) MarkPaid(boleto entity.Boleto) error {
+
+	tag, err := r.connection.Exec(`UPDATE "Boleto" SET status = 'paid', paid_at = $2, paid_amount = $3 WHERE id = $1 AND status = 'open'`,
+		boleto.ID, boleto.PaidAt, boleto.PaidAmount)
+	if err != nil {
+		log.Info().Err(err).Str("BoletoID", boleto.ID).Msg("Failed to mark boleto as paid")
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("Boleto is no longer open")
+	}
+
+	return nil
+}
+
+func (r *BoletoRepository) ReleasePayment(id string) error {
+
+	_, err := r.connection.Exec(`UPDATE "Boleto" SET status = 'open', paid_at = NULL, paid_amount = 0 WHERE id = $1`, id)
+	if err != nil {
+		log.Info().Err(err).Str("BoletoID", id).Msg("Failed to release boleto payment")
+		return err
+	}
+
+	return nil
+}
+
+func (r *BoletoRepository) Cancel(id string) error {
+
+	tag, err := r.connection.Exec(`UPDATE "Boleto" SET status = 'cancelled' WHERE id = $1 AND status = 'open'`, id)
+	if err != nil {
+		log.Info().Err(err).Str("BoletoID", id).Msg("Failed to cancel boleto")
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("Boleto is no longer open")
+	}
+
+	return nil
+}
+
+func (r *BoletoRepository) Reset() error {
+
+	_, err := r.connection.Exec(`DELETE FROM "Boleto"`)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to reset boletos")
+		return err
+	}
+
+	return nil
+}
+
+func (r *BoletoRepository) readOne(where string, arg interface{}) (entity.Boleto, error) {
+
+	boletos, err := r.query(`SELECT `+boletoColumns+` FROM "Boleto" WHERE `+where, arg)
+	if err != nil {
+		return entity.Boleto{}, err
+	}
+
+	if len(boletos) == 0 {
+		return entity.Boleto{}, fmt.Errorf("Couldn't find the boleto")
+	}
+
+	return boletos[0], nil
+}
+
+func (r *BoletoRepository) query(sql string, args ...interface{}) ([]entity.Boleto, error) {
+
+	rows, err := r.connection.Query(sql, args...)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to query boletos")
+		return nil, err
+	}
+	defer rows.Close()
+
+	boletos := []entity.Boleto{}
+	for rows.Next() {
+		var id string
+		var accountId int
+		var our_number string
+		var amount int
+		var due_date time.Time
+		var payer_name string
+		var payer_document string
+		var fine_rate int
+		var interest_rate int
+		var status string
+		var paid_at *time.Time
+		var paid_amount int
+		var created_at time.Time
+
+		err = rows.Scan(&id, &accountId, &our_number, &amount, &due_date, &payer_name, &payer_document, &fine_rate, &interest_rate, &status, &paid_at, &paid_amount, &created_at)
+		if err != nil {
+			log.Info().Err(err).Msg("Failed to scan boleto")
+			return nil, err
+		}
+
+		decrypted, err := r.cipher.Decrypt(payer_document)
+		if err != nil {
+			log.Info().Err(err).Str("BoletoID", id).Msg("Failed to decrypt boleto payer document")
+			return nil, err
+		}
+
+		boletos = append(boletos, entity.Boleto{
+			ID:            id,
+			AccountID:     accountId,
+			OurNumber:     our_number,
+			Amount:        amount,
+			DueDate:       due_date,
+			PayerName:     payer_name,
+			PayerDocument: decrypted,
+			FineRate:      fine_rate,
+			InterestRate:  interest_rate,
+			Status:        entity.BoletoStatus(status),
+			PaidAt:        paid_at,
+			PaidAmount:    paid_amount,
+			CreatedAt:     created_at,
+		})
+	}
+
+	return boletos, nil
+}
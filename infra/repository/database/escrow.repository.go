@@ -0,0 +1,315 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/jackc/pgx"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	escrowColumns = `e.id, e.payer_id, p.public_id, e.payee_id, r.public_id, e.amount, e.description, e.status, e.release_at, e.created_at, e.settled_at, e.release_failures`
+	selectEscrows = `SELECT ` + escrowColumns + ` FROM "Escrow" e JOIN "Account" p ON p.id = e.payer_id JOIN "Account" r ON r.id = e.payee_id`
+)
+
+type EscrowRepository struct {
+	connection *pgx.ConnPool
+}
+
+func NewEscrowRepository() *EscrowRepository {
+
+	pool, err := pgx.NewConnPool(pgx.ConnPoolConfig{
+		ConnConfig: loadDatabaseEnvs(),
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to connect to database")
+		panic("Couldn't connect to database")
+	}
+
+	return &EscrowRepository{connection: pool}
+}
+
+// Create debits the payer and funds the escrow, recording the deposit and the
+// event, in a single database transaction. The deposit counts to the limit of
+// the payer, when given.
+func (r *EscrowRepository) Create(escrow entity.Escrow, event entity.EscrowEvent, limit *entity.TransferLimit) (entity.Transfer, error) {
+
+	tx, err := r.connection.Begin()
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to begin escrow transaction")
+		return entity.Transfer{}, err
+	}
+	defer tx.Rollback()
+
+	if err := releaseExpiredHolds(tx, escrow.PayerID); err != nil {
+		log.Info().Err(err).Int("PayerID", escrow.PayerID).Msg("Failed to release expired holds")
+		return entity.Transfer{}, err
+	}
+
+	tag, err := tx.Exec(`UPDATE "Account" SET balance = balance - $1 WHERE id = $2 AND balance - held >= $1 AND status = 'active'`, escrow.Amount, escrow.PayerID)
+	if err != nil {
+		log.Info().Err(err).Int("PayerID", escrow.PayerID).Msg("Failed to debit escrow payer")
+		return entity.Transfer{}, err
+	}
+	if tag.RowsAffected() == 0 {
+		return entity.Transfer{}, fmt.Errorf("Payer account has insufficient funds or cannot send transfers")
+	}
+
+	if limit != nil {
+		if err := checkLimit(tx, escrow.PayerID, escrow.Amount, *limit); err != nil {
+			log.Info().Err(err).Int("PayerID", escrow.PayerID).Msg("Failed to check the daily limit")
+			return entity.Transfer{}, err
+		}
+	}
+
+	_, err = tx.Exec(`INSERT INTO "Escrow" (id, payer_id, payee_id, amount, description, status, release_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		escrow.ID, escrow.PayerID, escrow.PayeeID, escrow.Amount, escrow.Description, string(escrow.Status), escrow.ReleaseAt, escrow.CreatedAt)
+	if err != nil {
+		log.Info().Err(err).Int("PayerID", escrow.PayerID).Msg("Failed to create escrow")
+		return entity.Transfer{}, err
+	}
+
+	transfer, err := insertTransfer(tx, `INSERT into "Transfer" (public_id, account_origin_id, amount, kind, escrow_id) VALUES ($1, $2, $3, $4, $5)`,
+		entity.NewPublicID(entity.TRANSFER_ID_PREFIX), escrow.PayerID, escrow.Amount, string(entity.TransferKindEscrowDeposit), escrow.ID)
+	if err != nil {
+		log.Info().Err(err).Str("EscrowID", escrow.ID).Msg("Failed to create escrow deposit")
+		return entity.Transfer{}, err
+	}
+
+	if err := insertEscrowEvent(tx, event); err != nil {
+		log.Info().Err(err).Str("EscrowID", escrow.ID).Msg("Failed to create escrow event")
+		return entity.Transfer{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Info().Err(err).Msg("Failed to commit escrow transaction")
+		return entity.Transfer{}, err
+	}
+
+	return transfer, nil
+}
+
+// Settle stores the escrow released or refunded and credits its beneficiary,
+// recording the transfer and the event, in a single database transaction.
+// Only funded escrows are settled.
+func (r *EscrowRepository) Settle(escrow entity.Escrow, event entity.EscrowEvent) (entity.Transfer, error) {
+
+	kind := entity.TransferKindEscrowRelease
+	if escrow.Status == entity.EscrowRefunded {
+		kind = entity.TransferKindEscrowRefund
+	}
+
+	tx, err := r.connection.Begin()
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to begin escrow transaction")
+		return entity.Transfer{}, err
+	}
+	defer tx.Rollback()
+
+	tag, err := tx.Exec(`UPDATE "Escrow" SET status = $2, settled_at = $3 WHERE id = $1 AND status = 'funded'`, escrow.ID, string(escrow.Status), escrow.SettledAt)
+	if err != nil {
+		log.Info().Err(err).Str("EscrowID", escrow.ID).Msg("Failed to settle escrow")
+		return entity.Transfer{}, err
+	}
+	if tag.RowsAffected() == 0 {
+		return entity.Transfer{}, fmt.Errorf("Escrow is no longer funded")
+	}
+
+	tag, err = tx.Exec(`UPDATE "Account" SET balance = balance + $1 WHERE id = $2 AND status = 'active'`, escrow.Amount, escrow.Beneficiary())
+	if err != nil {
+		log.Info().Err(err).Str("EscrowID", escrow.ID).Msg("Failed to credit escrow beneficiary")
+		return entity.Transfer{}, err
+	}
+	if tag.RowsAffected() == 0 {
+		return entity.Transfer{}, fmt.Errorf("Beneficiary account cannot receive transfers")
+	}
+
+	transfer, err := insertTransfer(tx, `INSERT into "Transfer" (public_id, account_origin_id, account_destination_id, amount, kind, escrow_id) VALUES ($1, $2, $3, $4, $5, $6)`,
+		entity.NewPublicID(entity.TRANSFER_ID_PREFIX), escrow.PayerID, escrow.Beneficiary(), escrow.Amount, string(kind), escrow.ID)
+	if err != nil {
+		log.Info().Err(err).Str("EscrowID", escrow.ID).Msg("Failed to create escrow transfer")
+		return entity.Transfer{}, err
+	}
+
+	if err := insertEscrowEvent(tx, event); err != nil {
+		log.Info().Err(err).Str("EscrowID", escrow.ID).Msg("Failed to create escrow event")
+		return entity.Transfer{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Info().Err(err).Msg("Failed to commit escrow transaction")
+		return entity.Transfer{}, err
+	}
+
+	return transfer, nil
+}
+
+// SaveReleaseFailures stores how many times the release of a funded escrow
+// failed.
+func (r *EscrowRepository) SaveReleaseFailures(escrow entity.Escrow) error {
+
+	_, err := r.connection.Exec(`UPDATE "Escrow" SET release_failures = $2 WHERE id = $1 AND status = 'funded'`, escrow.ID, escrow.ReleaseFailures)
+	if err != nil {
+		log.Info().Err(err).Str("EscrowID", escrow.ID).Msg("Failed to save escrow release failures")
+		return err
+	}
+
+	return nil
+}
+
+// Fail stores a funded escrow as failed, recording the event, in a single
+// database transaction. No money is moved.
+func (r *EscrowRepository) Fail(escrow entity.Escrow, event entity.EscrowEvent) error {
+
+	tx, err := r.connection.Begin()
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to begin escrow transaction")
+		return err
+	}
+	defer tx.Rollback()
+
+	tag, err := tx.Exec(`UPDATE "Escrow" SET status = $2, settled_at = $3, release_failures = $4 WHERE id = $1 AND status = 'funded'`,
+		escrow.ID, string(escrow.Status), escrow.SettledAt, escrow.ReleaseFailures)
+	if err != nil {
+		log.Info().Err(err).Str("EscrowID", escrow.ID).Msg("Failed to fail escrow")
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("Escrow is no longer funded")
+	}
+
+	if err := insertEscrowEvent(tx, event); err != nil {
+		log.Info().Err(err).Str("EscrowID", escrow.ID).Msg("Failed to create escrow event")
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Info().Err(err).Msg("Failed to commit escrow transaction")
+		return err
+	}
+
+	return nil
+}
+
+func (r *EscrowRepository) ReadByID(id string) (entity.Escrow, error) {
+
+	escrows, err := r.query(selectEscrows+` WHERE e.id = $1`, id)
+	if err != nil {
+		return entity.Escrow{}, err
+	}
+
+	if len(escrows) == 0 {
+		return entity.Escrow{}, fmt.Errorf("Couldn't find an escrow with the provided ID")
+	}
+
+	return escrows[0], nil
+}
+
+// ReadByAccountID returns the escrows the account pays or receives.
+func (r *EscrowRepository) ReadByAccountID(accountId int) ([]entity.Escrow, error) {
+	return r.query(selectEscrows+` WHERE e.payer_id = $1 OR e.payee_id = $1 ORDER BY e.created_at DESC`, accountId)
+}
+
+// ReadDue returns the funded escrows past their release date.
+func (r *EscrowRepository) ReadDue(now time.Time) ([]entity.Escrow, error) {
+	return r.query(selectEscrows+` WHERE e.status = 'funded' AND e.release_at <= $1 ORDER BY e.release_at`, now)
+}
+
+func (r *EscrowRepository) ReadEvents(escrowId string) ([]entity.EscrowEvent, error) {
+
+	rows, err := r.connection.Query(`SELECT id, escrow_id, from_status, to_status, actor, created_at FROM "EscrowEvent" WHERE escrow_id = $1 ORDER BY id`, escrowId)
+	if err != nil {
+		log.Info().Err(err).Str("EscrowID", escrowId).Msg("Failed to query escrow events")
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []entity.EscrowEvent{}
+	for rows.Next() {
+		var event entity.EscrowEvent
+		var from *string
+		var to string
+
+		if err := rows.Scan(&event.ID, &event.EscrowID, &from, &to, &event.Actor, &event.CreatedAt); err != nil {
+			log.Info().Err(err).Msg("Failed to scan escrow event")
+			return nil, err
+		}
+
+		if from != nil {
+			event.From = entity.EscrowStatus(*from)
+		}
+		event.To = entity.EscrowStatus(to)
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (r *EscrowRepository) Reset() error {
+
+	_, err := r.connection.Exec(`DELETE FROM "Escrow"`)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to reset escrows")
+		return err
+	}
+
+	return nil
+}
+
+func (r *EscrowRepository) query(sql string, args ...interface{}) ([]entity.Escrow, error) {
+
+	rows, err := r.connection.Query(sql, args...)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to query escrows")
+		return nil, err
+	}
+	defer rows.Close()
+
+	escrows := []entity.Escrow{}
+	for rows.Next() {
+		var escrow entity.Escrow
+		var status string
+
+		err := rows.Scan(
+			&escrow.ID,
+			&escrow.PayerID,
+			&escrow.PayerPublicID,
+			&escrow.PayeeID,
+			&escrow.PayeePublicID,
+			&escrow.Amount,
+			&escrow.Description,
+			&status,
+			&escrow.ReleaseAt,
+			&escrow.CreatedAt,
+			&escrow.SettledAt,
+			&escrow.ReleaseFailures,
+		)
+		if err != nil {
+			log.Info().Err(err).Msg("Failed to scan escrow")
+			return nil, err
+		}
+
+		escrow.Status = entity.EscrowStatus(status)
+		escrows = append(escrows, escrow)
+	}
+
+	return escrows, rows.Err()
+}
+
+func insertEscrowEvent(tx *pgx.Tx, event entity.EscrowEvent) error {
+
+	var from *string
+	if event.From != "" {
+		status := string(event.From)
+		from = &status
+	}
+
+	_, err := tx.Exec(`INSERT INTO "EscrowEvent" (escrow_id, from_status, to_status, actor, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		event.EscrowID, from, string(event.To), event.Actor, event.CreatedAt)
+
+	return err
+}
@@ -0,0 +1,264 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/jackc/pgx"
+	"github.com/rs/zerolog/log"
+)
+
+const holdColumns = `h.id, h.account_id, o.public_id, h.destination_id, d.public_id, h.amount, h.captured_amount, h.status, h.transfer_id, h.expires_at, h.created_at, h.finished_at`
+
+type HoldRepository struct {
+	connection *pgx.ConnPool
+}
+
+func NewHoldRepository() *HoldRepository {
+
+	pool, err := pgx.NewConnPool(pgx.ConnPoolConfig{
+		ConnConfig: loadDatabaseEnvs(),
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to connect to database")
+		panic("Couldn't connect to database")
+	}
+
+	return &HoldRepository{connection: pool}
+}
+
+// Create reserves the funds in the account row, checking its available balance
+// in the same statement, so a hold and a debit made at once cannot both spend
+// them.
+func (r *HoldRepository) Create(hold entity.Hold) error {
+
+	tx, err := r.connection.Begin()
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to begin hold transaction")
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := releaseExpiredHolds(tx, hold.AccountID); err != nil {
+		log.Info().Err(err).Int("AccountID", hold.AccountID).Msg("Failed to release expired holds")
+		return err
+	}
+
+	tag, err := tx.Exec(`UPDATE "Account" SET held = held + $1 WHERE id = $2 AND balance - held >= $1 AND status = 'active'`, hold.Amount, hold.AccountID)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", hold.AccountID).Msg("Failed to reserve hold funds")
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("Account has insufficient funds or cannot hold funds")
+	}
+
+	_, err = tx.Exec(`INSERT INTO "Hold" (id, account_id, destination_id, amount, status, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		hold.ID, hold.AccountID, hold.DestinationID, hold.Amount, string(hold.Status), hold.ExpiresAt, hold.CreatedAt)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", hold.AccountID).Msg("Failed to create hold")
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Info().Err(err).Msg("Failed to commit hold transaction")
+		return err
+	}
+
+	return nil
+}
+
+func (r *HoldRepository) ReadByID(id string) (entity.Hold, error) {
+
+	row := r.connection.QueryRow(`SELECT `+holdColumns+` FROM "Hold" h JOIN "Account" o ON o.id = h.account_id JOIN "Account" d ON d.id = h.destination_id WHERE h.id = $1`, id)
+
+	var hold entity.Hold
+	var status string
+	var transfer_id *string
+
+	err := row.Scan(
+		&hold.ID,
+		&hold.AccountID,
+		&hold.AccountPublicID,
+		&hold.DestinationID,
+		&hold.DestinationPublicID,
+		&hold.Amount,
+		&hold.CapturedAmount,
+		&status,
+		&transfer_id,
+		&hold.ExpiresAt,
+		&hold.CreatedAt,
+		&hold.FinishedAt,
+	)
+	if err != nil {
+		log.Info().Err(err).Str("HoldID", id).Msg("Failed to read hold")
+		return entity.Hold{}, err
+	}
+
+	hold.Status = entity.HoldStatus(status)
+	if transfer_id != nil {
+		hold.TransferID = *transfer_id
+	}
+
+	return hold, nil
+}
+
+// Capture marks the hold captured and transfers the amount captured to the
+// destination, in a single database transaction. The rest of the hold is
+// released with it. The capture counts to the limit of the account held, when
+// given.
+func (r *HoldRepository) Capture(hold entity.Hold, limit *entity.TransferLimit) (entity.Transfer, error) {
+
+	tx, err := r.connection.Begin()
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to begin capture transaction")
+		return entity.Transfer{}, err
+	}
+	defer tx.Rollback()
+
+	tag, err := tx.Exec(`UPDATE "Hold" SET status = 'captured', captured_amount = $2, finished_at = $3 WHERE id = $1 AND status = 'authorized' AND expires_at > NOW()`,
+		hold.ID, hold.CapturedAmount, hold.FinishedAt)
+	if err != nil {
+		log.Info().Err(err).Str("HoldID", hold.ID).Msg("Failed to capture hold")
+		return entity.Transfer{}, err
+	}
+	if tag.RowsAffected() == 0 {
+		return entity.Transfer{}, fmt.Errorf("Hold is no longer authorized")
+	}
+
+	if err := releaseExpiredHolds(tx, hold.AccountID); err != nil {
+		log.Info().Err(err).Str("HoldID", hold.ID).Msg("Failed to release expired holds")
+		return entity.Transfer{}, err
+	}
+
+	// The hold no longer counts as held, its funds are available to capture.
+	tag, err = tx.Exec(`UPDATE "Account" SET balance = balance - $1, held = held - $3 WHERE id = $2 AND balance - (held - $3) >= $1 AND status = 'active'`, hold.CapturedAmount, hold.AccountID, hold.Amount)
+	if err != nil {
+		log.Info().Err(err).Str("HoldID", hold.ID).Msg("Failed to debit hold account")
+		return entity.Transfer{}, err
+	}
+	if tag.RowsAffected() == 0 {
+		return entity.Transfer{}, fmt.Errorf("Hold account has insufficient funds or cannot send transfers")
+	}
+
+	if limit != nil {
+		if err := checkLimit(tx, hold.AccountID, hold.CapturedAmount, *limit); err != nil {
+			log.Info().Err(err).Str("HoldID", hold.ID).Msg("Failed to check the daily limit")
+			return entity.Transfer{}, err
+		}
+	}
+
+	tag, err = tx.Exec(`UPDATE "Account" SET balance = balance + $1 WHERE id = $2 AND status = 'active'`, hold.CapturedAmount, hold.DestinationID)
+	if err != nil {
+		log.Info().Err(err).Str("HoldID", hold.ID).Msg("Failed to credit hold destination")
+		return entity.Transfer{}, err
+	}
+	if tag.RowsAffected() == 0 {
+		return entity.Transfer{}, fmt.Errorf("Destination account cannot receive transfers")
+	}
+
+	transfer, err := insertTransfer(tx, `INSERT into "Transfer" (public_id, account_origin_id, account_destination_id, amount) VALUES ($1, $2, $3, $4)`,
+		entity.NewPublicID(entity.TRANSFER_ID_PREFIX), hold.AccountID, hold.DestinationID, hold.CapturedAmount)
+	if err != nil {
+		log.Info().Err(err).Str("HoldID", hold.ID).Msg("Failed to create capture transfer")
+		return entity.Transfer{}, err
+	}
+
+	if _, err := tx.Exec(`UPDATE "Hold" SET transfer_id = $2 WHERE id = $1`, hold.ID, transfer.PublicID); err != nil {
+		log.Info().Err(err).Str("HoldID", hold.ID).Msg("Failed to link capture transfer")
+		return entity.Transfer{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Info().Err(err).Msg("Failed to commit capture transaction")
+		return entity.Transfer{}, err
+	}
+
+	return transfer, nil
+}
+
+// Void releases the funds of the hold with it, in a single database
+// transaction.
+func (r *HoldRepository) Void(hold entity.Hold) error {
+
+	tx, err := r.connection.Begin()
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to begin void transaction")
+		return err
+	}
+	defer tx.Rollback()
+
+	tag, err := tx.Exec(`UPDATE "Hold" SET status = 'voided', finished_at = $2 WHERE id = $1 AND status = 'authorized' AND expires_at > NOW()`, hold.ID, hold.FinishedAt)
+	if err != nil {
+		log.Info().Err(err).Str("HoldID", hold.ID).Msg("Failed to void hold")
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("Hold is no longer authorized")
+	}
+
+	if _, err := tx.Exec(`UPDATE "Account" SET held = held - $1 WHERE id = $2`, hold.Amount, hold.AccountID); err != nil {
+		log.Info().Err(err).Str("HoldID", hold.ID).Msg("Failed to release hold funds")
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Info().Err(err).Msg("Failed to commit void transaction")
+		return err
+	}
+
+	return nil
+}
+
+// ExpireStale marks the authorized holds past their expiry as expired,
+// releasing their funds in the same statement. They already stopped counting
+// as held for reads. Returns how many were marked.
+func (r *HoldRepository) ExpireStale(now time.Time) (int, error) {
+
+	var expired int
+	err := r.connection.QueryRow(`WITH expired AS (
+			UPDATE "Hold" SET status = 'expired', finished_at = expires_at WHERE status = 'authorized' AND expires_at <= $1 RETURNING account_id, amount
+		), released AS (
+			UPDATE "Account" SET held = held - e.total FROM (SELECT account_id, SUM(amount) AS total FROM expired GROUP BY account_id) e WHERE "Account".id = e.account_id
+		)
+		SELECT COUNT(*) FROM expired`, now).Scan(&expired)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to expire holds")
+		return 0, err
+	}
+
+	return expired, nil
+}
+
+// releaseExpiredHolds expires the holds of the account past their expiry and
+// releases their funds, before a debit checks the held column. Without it the
+// funds would stay reserved until ExpireStale runs.
+func releaseExpiredHolds(tx *pgx.Tx, accountId int) error {
+
+	_, err := tx.Exec(`WITH expired AS (
+			UPDATE "Hold" SET status = 'expired', finished_at = expires_at WHERE account_id = $1 AND status = 'authorized' AND expires_at <= NOW() RETURNING amount
+		)
+		UPDATE "Account" SET held = held - (SELECT COALESCE(SUM(amount), 0) FROM expired) WHERE id = $1`, accountId)
+
+	return err
+}
+
+func (r *HoldRepository) Reset() error {
+
+	_, err := r.connection.Exec(`DELETE FROM "Hold"`)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to reset holds")
+		return err
+	}
+
+	_, err = r.connection.Exec(`UPDATE "Account" SET held = 0`)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to reset held funds")
+		return err
+	}
+
+	return nil
+}
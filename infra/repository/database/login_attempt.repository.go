@@ -0,0 +1,113 @@
+package database
+
+import (
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/infra/encryption"
+	"github.com/jackc/pgx"
+	"github.com/rs/zerolog/log"
+)
+
+// LoginAttemptRepository stores attempts under a blind index of their key,
+// CPFs and IPs never reach the table.
+type LoginAttemptRepository struct {
+	connection *pgx.ConnPool
+	cipher     *encryption.Cipher
+}
+
+func NewLoginAttemptRepository() *LoginAttemptRepository {
+
+	pool, err := pgx.NewConnPool(pgx.ConnPoolConfig{
+		ConnConfig: loadDatabaseEnvs(),
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to connect to database")
+		panic("Couldn't connect to database")
+	}
+
+	return &LoginAttemptRepository{connection: pool, cipher: encryption.LoadFromEnv()}
+}
+
+func (r *LoginAttemptRepository) Attempt(key string, now time.Time) (entity.LoginAttempt, bool, error) {
+
+	var allowed bool
+	attempt, err := r.update(key, func(attempt *entity.LoginAttempt) {
+		allowed = attempt.Attempt(now)
+	})
+
+	return attempt, allowed, err
+}
+
+func (r *LoginAttemptRepository) Refund(key string) error {
+
+	_, err := r.update(key, func(attempt *entity.LoginAttempt) {
+		attempt.Refund()
+	})
+
+	return err
+}
+
+// update changes the attempt of the key with its row locked, so parallel
+// attempts on the same key are counted one after another.
+func (r *LoginAttemptRepository) update(key string, change func(attempt *entity.LoginAttempt)) (entity.LoginAttempt, error) {
+
+	index := r.cipher.BlindIndex(key)
+
+	tx, err := r.connection.Begin()
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to begin login attempt transaction")
+		return entity.LoginAttempt{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO "LoginAttempt" (key) VALUES ($1) ON CONFLICT (key) DO NOTHING`, index); err != nil {
+		log.Info().Err(err).Msg("Failed to create login attempt")
+		return entity.LoginAttempt{}, err
+	}
+
+	attempt := entity.NewLoginAttempt(key)
+	err = tx.QueryRow(`SELECT failures, locked_until, last_failure_at FROM "LoginAttempt" WHERE key = $1 FOR UPDATE`, index).Scan(&attempt.Failures, &attempt.LockedUntil, &attempt.LastFailureAt)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to lock login attempt")
+		return entity.LoginAttempt{}, err
+	}
+
+	change(attempt)
+
+	_, err = tx.Exec(`UPDATE "LoginAttempt" SET failures = $2, locked_until = $3, last_failure_at = $4 WHERE key = $1`, index, attempt.Failures, attempt.LockedUntil, attempt.LastFailureAt)
+	if err != nil {
+		log.Info().Err(err).Int("Failures", attempt.Failures).Msg("Failed to save login attempt")
+		return entity.LoginAttempt{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Info().Err(err).Msg("Failed to commit login attempt")
+		return entity.LoginAttempt{}, err
+	}
+
+	return *attempt, nil
+}
+
+func (r *LoginAttemptRepository) Delete(key string) error {
+
+	if _, err := r.connection.Exec(`DELETE FROM "LoginAttempt" WHERE key = $1`, r.cipher.BlindIndex(key)); err != nil {
+		log.Info().Err(err).Msg("Failed to delete login attempt")
+		return err
+	}
+
+	return nil
+}
+
+func (r *LoginAttemptRepository) Reset() error {
+
+	rows, err := r.connection.Query(`DELETE FROM "LoginAttempt"`)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to reset login attempts")
+		return err
+	}
+	defer rows.Close()
+
+	return nil
+}
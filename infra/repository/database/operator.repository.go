@@ -0,0 +1,202 @@
+package database
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/jackc/pgx"
+	"github.com/rs/zerolog/log"
+)
+
+type OperatorRepository struct {
+	connection *pgx.ConnPool
+}
+
+func NewOperatorRepository() *OperatorRepository {
+
+	pool, err := pgx.NewConnPool(pgx.ConnPoolConfig{
+		ConnConfig: loadDatabaseEnvs(),
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to connect to database")
+		panic("Couldn't connect to database")
+	}
+
+	return &OperatorRepository{connection: pool}
+}
+
+func (r *OperatorRepository) Create(op entity.Operator) (entity.Operator, error) {
+
+	log.Info().Str("email", op.Email).Str("role", string(op.Role)).Msg("Creating operator")
+
+	permissions := []string{}
+	for _, p := range op.Permissions {
+		permissions = append(permissions, string(p))
+	}
+
+	encoded := hex.EncodeToString(op.Secret.Sum(nil))
+	rows, err := r.connection.Query(`INSERT INTO "Operator" (name, email, secret, role, permissions) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`, op.Name, op.Email, encoded, string(op.Role), permissions)
+	if err != nil {
+		log.Info().Err(err).Str("email", op.Email).Msg("Failed to create operator")
+		return entity.Operator{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err = rows.Scan(&op.ID, &op.CreatedAt)
+		if err != nil {
+			log.Info().Err(err).Msg("Failed to scan operator")
+			return entity.Operator{}, err
+		}
+	}
+
+	return op, nil
+}
+
+func (r *OperatorRepository) ReadByID(id int) (entity.Operator, error) {
+
+	rows, err := r.connection.Query(`SELECT id, name, email, role, permissions, created_at FROM "Operator" WHERE id = $1`, id)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to query operators")
+		return entity.Operator{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var name string
+		var email string
+		var role string
+		var permissions []string
+		var created_at time.Time
+
+		err = rows.Scan(&id, &name, &email, &role, &permissions, &created_at)
+		if err != nil {
+			log.Info().Err(err).Msg("Failed to scan operator")
+			return entity.Operator{}, err
+		}
+
+		grants := []entity.Permission{}
+		for _, p := range permissions {
+			grants = append(grants, entity.Permission(p))
+		}
+
+		return entity.Operator{
+			ID:          id,
+			Name:        name,
+			Email:       email,
+			Role:        entity.Role(role),
+			Permissions: grants,
+			CreatedAt:   created_at,
+		}, nil
+	}
+
+	return entity.Operator{}, fmt.Errorf("Couldn't find an operator with the provided ID")
+}
+
+func (r *OperatorRepository) ReadHashByEmail(email string) (int, []byte, error) {
+
+	rows, err := r.connection.Query(`SELECT id, secret FROM "Operator" WHERE email = $1`, email)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to query operator hash by email")
+		return 0, []byte{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var hash string
+
+		err = rows.Scan(&id, &hash)
+		if err != nil {
+			log.Info().Err(err).Msg("Failed to scan id and hash of operator")
+			return 0, []byte{}, err
+		}
+
+		bs, err := hex.DecodeString(hash)
+		if err != nil {
+			log.Info().Err(err).Msg("Failed to decode operator hash")
+			return 0, []byte{}, err
+		}
+
+		return id, bs, nil
+	}
+
+	return 0, []byte{}, fmt.Errorf("Couldn't find an operator with the provided email")
+}
+
+func (r *OperatorRepository) RegisterToken(tokenHash string, operatorId int) error {
+
+	rows, err := r.connection.Query(`INSERT INTO "OperatorAuth" (token_hash, operator_id) VALUES ($1, $2)`, tokenHash, operatorId)
+	if err != nil {
+		log.Info().Err(err).Int("OperatorID", operatorId).Msg("Failed to register operator token on database!")
+		return err
+	}
+	defer rows.Close()
+
+	return nil
+}
+
+func (r *OperatorRepository) DecodeToken(tokenHash string) (int, error) {
+
+	rows, err := r.connection.Query(`SELECT operator_id FROM "OperatorAuth" WHERE token_hash = $1 AND created_at > $2`, tokenHash, time.Now().Add(-entity.OPERATOR_TOKEN_TTL))
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to decode/get operatorID from token!")
+		return 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+
+		err = rows.Scan(&id)
+		if err != nil {
+			log.Info().Err(err).Msg("Failed to scan operator token")
+			return 0, err
+		}
+
+		return id, nil
+	}
+
+	return 0, fmt.Errorf("Failed to decode/get operatorId from token!")
+}
+
+func (r *OperatorRepository) RevokeToken(tokenHash string) error {
+
+	_, err := r.connection.Exec(`DELETE FROM "OperatorAuth" WHERE token_hash = $1`, tokenHash)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to revoke operator token")
+		return err
+	}
+
+	return nil
+}
+
+// RevokeAllTokens logs the operator out everywhere, returning how many tokens
+// were revoked.
+func (r *OperatorRepository) RevokeAllTokens(operatorId int) (int, error) {
+
+	tag, err := r.connection.Exec(`DELETE FROM "OperatorAuth" WHERE operator_id = $1`, operatorId)
+	if err != nil {
+		log.Info().Err(err).Int("OperatorID", operatorId).Msg("Failed to revoke operator tokens")
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+func (r *OperatorRepository) Reset() error {
+
+	// Actions reference their operator, they go first.
+	for _, table := range []string{"AdminAction", "OperatorAuth", "Operator"} {
+		if _, err := r.connection.Exec(`DELETE FROM "` + table + `"`); err != nil {
+			log.Info().Err(err).Str("Table", table).Msg("Failed to reset operators")
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/jackc/pgx"
+	"github.com/rs/zerolog/log"
+)
+
+type PinRepository struct {
+	connection *pgx.ConnPool
+}
+
+func NewPinRepository() *PinRepository {
+
+	pool, err := pgx.NewConnPool(pgx.ConnPoolConfig{
+		ConnConfig: loadDatabaseEnvs(),
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to connect to database")
+		panic("Couldn't connect to database")
+	}
+
+	return &PinRepository{connection: pool}
+}
+
+func (r *PinRepository) Read(accountId int) (entity.TransactionPin, error) {
+
+	rows, err := r.connection.Query(`SELECT account_id, hash, failures, locked_until, updated_at FROM "TransactionPin" WHERE account_id = $1`, accountId)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", accountId).Msg("Failed to query transaction PIN")
+		return entity.TransactionPin{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var accountId int
+		var hash string
+		var failures int
+		var locked_until time.Time
+		var updated_at time.Time
+
+		err = rows.Scan(&accountId, &hash, &failures, &locked_until, &updated_at)
+		if err != nil {
+			log.Info().Err(err).Msg("Failed to scan transaction PIN")
+			return entity.TransactionPin{}, err
+		}
+
+		return entity.TransactionPin{
+			AccountID:   accountId,
+			Hash:        []byte(hash),
+			Failures:    failures,
+			LockedUntil: locked_until,
+			UpdatedAt:   updated_at,
+		}, nil
+	}
+
+	return entity.TransactionPin{}, fmt.Errorf("Couldn't find a transaction PIN for the provided account")
+}
+
+// Update changes the PIN of the account with its row locked, so parallel
+// attempts on the same PIN are counted one after another.
+func (r *PinRepository) Update(accountId int, change func(pin *entity.TransactionPin)) error {
+
+	tx, err := r.connection.Begin()
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to begin transaction PIN transaction")
+		return err
+	}
+	defer tx.Rollback()
+
+	var hash string
+	pin := entity.TransactionPin{AccountID: accountId}
+	err = tx.QueryRow(`SELECT hash, failures, locked_until, updated_at FROM "TransactionPin" WHERE account_id = $1 FOR UPDATE`, accountId).Scan(&hash, &pin.Failures, &pin.LockedUntil, &pin.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return fmt.Errorf("Couldn't find a transaction PIN for the provided account")
+	}
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", accountId).Msg("Failed to lock transaction PIN")
+		return err
+	}
+	pin.Hash = []byte(hash)
+
+	change(&pin)
+
+	_, err = tx.Exec(`UPDATE "TransactionPin" SET failures = $2, locked_until = $3 WHERE account_id = $1`, accountId, pin.Failures, pin.LockedUntil)
+	if err != nil {
+		log.Info().Err(err).Int("Failures", pin.Failures).Msg("Failed to save transaction PIN state")
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Info().Err(err).Msg("Failed to commit transaction PIN")
+		return err
+	}
+
+	return nil
+}
+
+func (r *PinRepository) Save(pin entity.TransactionPin) error {
+
+	rows, err := r.connection.Query(`INSERT INTO "TransactionPin" (account_id, hash, failures, locked_until, updated_at) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (account_id) DO UPDATE SET hash = $2, failures = $3, locked_until = $4, updated_at = $5`, pin.AccountID, string(pin.Hash), pin.Failures, pin.LockedUntil, pin.UpdatedAt)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", pin.AccountID).Msg("Failed to save transaction PIN")
+		return err
+	}
+	defer rows.Close()
+
+	return nil
+}
+
+func (r *PinRepository) Delete(accountId int) error {
+
+	_, err := r.connection.Exec(`DELETE FROM "TransactionPin" WHERE account_id = $1`, accountId)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", accountId).Msg("Failed to delete transaction PIN")
+		return err
+	}
+
+	return nil
+}
+
+func (r *PinRepository) Reset() error {
+
+	_, err := r.connection.Exec(`DELETE FROM "TransactionPin"`)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to reset transaction PINs")
+		return err
+	}
+
+	return nil
+}
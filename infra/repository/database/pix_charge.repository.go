@@ -0,0 +1,131 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/infra/encryption"
+	"github.com/jackc/pgx"
+	"github.com/rs/zerolog/log"
+)
+
+const pixChargeColumns = `id, account_id, pix_key, amount, txid, expires_at, paid_at, created_at`
+
+type PixChargeRepository struct {
+	connection *pgx.ConnPool
+	cipher     *encryption.Cipher
+}
+
+func NewPixChargeRepository() *PixChargeRepository {
+
+	pool, err := pgx.NewConnPool(pgx.ConnPoolConfig{
+		ConnConfig: loadDatabaseEnvs(),
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to connect to database")
+		panic("Couldn't connect to database")
+	}
+
+	return &PixChargeRepository{connection: pool, cipher: encryption.LoadFromEnv()}
+}
+
+func (r *PixChargeRepository) Create(charge entity.PixCharge) error {
+
+	encrypted, err := r.cipher.Encrypt(charge.PixKey)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", charge.AccountID).Msg("Failed to encrypt charge Pix key")
+		return err
+	}
+
+	_, err = r.connection.Exec(`INSERT INTO "PixCharge" (id, account_id, pix_key, amount, txid, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		charge.ID, charge.AccountID, encrypted, charge.Amount, charge.TxID, charge.ExpiresAt, charge.CreatedAt)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", charge.AccountID).Msg("Failed to create Pix charge")
+		return err
+	}
+
+	return nil
+}
+
+func (r *PixChargeRepository) ReadByID(id string) (entity.PixCharge, error) {
+	return r.readOne(`id = $1`, id)
+}
+
+func (r *PixChargeRepository) ReadByTxID(txid string) (entity.PixCharge, error) {
+	return r.readOne(`txid = $1`, txid)
+}
+
+func (r *PixChargeRepository) MarkPaid(charge entity.PixCharge) error {
+
+	tag, err := r.connection.Exec(`UPDATE "PixCharge" SET paid_at = $1 WHERE id = $2 AND paid_at IS NULL`, charge.PaidAt, charge.ID)
+	if err != nil {
+		log.Info().Err(err).Str("ChargeID", charge.ID).Msg("Failed to mark Pix charge as paid")
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("Pix charge was already paid")
+	}
+
+	return nil
+}
+
+func (r *PixChargeRepository) ReleasePayment(id string) error {
+
+	_, err := r.connection.Exec(`UPDATE "PixCharge" SET paid_at = NULL WHERE id = $1`, id)
+	if err != nil {
+		log.Info().Err(err).Str("ChargeID", id).Msg("Failed to release Pix charge payment")
+		return err
+	}
+
+	return nil
+}
+
+func (r *PixChargeRepository) Reset() error {
+
+	_, err := r.connection.Exec(`DELETE FROM "PixCharge"`)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to reset Pix charges")
+		return err
+	}
+
+	return nil
+}
+
+func (r *PixChargeRepository) readOne(where string, arg interface{}) (entity.PixCharge, error) {
+
+	var id string
+	var accountId int
+	var pixKey string
+	var amount int
+	var txid string
+	var expires_at time.Time
+	var paid_at *time.Time
+	var created_at time.Time
+
+	err := r.connection.QueryRow(`SELECT `+pixChargeColumns+` FROM "PixCharge" WHERE `+where, arg).
+		Scan(&id, &accountId, &pixKey, &amount, &txid, &expires_at, &paid_at, &created_at)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to read Pix charge")
+		return entity.PixCharge{}, err
+	}
+
+	decrypted, err := r.cipher.Decrypt(pixKey)
+	if err != nil {
+		log.Info().Err(err).Str("ChargeID", id).Msg("Failed to decrypt charge Pix key")
+		return entity.PixCharge{}, err
+	}
+
+	return entity.PixCharge{
+		ID:        id,
+		AccountID: accountId,
+		PixKey:    decrypted,
+		Amount:    amount,
+		TxID:      txid,
+		ExpiresAt: expires_at,
+		PaidAt:    paid_at,
+		CreatedAt: created_at,
+	}, nil
+}
@@ -0,0 +1,248 @@
+package database
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/infra/encryption"
+	"github.com/jackc/pgx"
+	"github.com/rs/zerolog/log"
+)
+
+const pixKeyColumns = `id, account_id, type, value, code_hash, code_expires_at, verified_at, created_at`
+
+// PixKeyRepository keeps key values encrypted, as they are CPFs, emails and
+// phones. Lookups go through the blind index of type and value.
+type PixKeyRepository struct {
+	connection *pgx.ConnPool
+	cipher     *encryption.Cipher
+}
+
+func NewPixKeyRepository() *PixKeyRepository {
+
+	pool, err := pgx.NewConnPool(pgx.ConnPoolConfig{
+		ConnConfig: loadDatabaseEnvs(),
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to connect to database")
+		panic("Couldn't connect to database")
+	}
+
+	return &PixKeyRepository{connection: pool, cipher: encryption.LoadFromEnv()}
+}
+
+func (r *PixKeyRepository) Create(key entity.PixKey) (entity.PixKey, error) {
+
+	encrypted, err := r.cipher.Encrypt(key.Value)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", key.AccountID).Msg("Failed to encrypt Pix key")
+		return entity.PixKey{}, err
+	}
+
+	_, err = r.connection.Exec(`INSERT INTO "PixKey" (id, account_id, type, value, value_index, code_hash, code_expires_at, verified_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		key.ID, key.AccountID, string(key.Type), encrypted, r.valueIndex(key.Type, key.Value), encodeCodeHash(key.CodeHash), key.CodeExpiresAt, key.VerifiedAt, key.CreatedAt)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", key.AccountID).Msg("Failed to create Pix key")
+		return entity.PixKey{}, err
+	}
+
+	return key, nil
+}
+
+func (r *PixKeyRepository) ReadByID(id string) (entity.PixKey, error) {
+
+	keys, err := r.query(`SELECT `+pixKeyColumns+` FROM "PixKey" WHERE id = $1`, id)
+	if err != nil {
+		return entity.PixKey{}, err
+	}
+
+	if len(keys) == 0 {
+		return entity.PixKey{}, fmt.Errorf("Couldn't find a Pix key with the provided ID")
+	}
+
+	return keys[0], nil
+}
+
+func (r *PixKeyRepository) ReadByAccountID(accountId int) ([]entity.PixKey, error) {
+	return r.query(`SELECT `+pixKeyColumns+` FROM "PixKey" WHERE account_id = $1 ORDER BY created_at`, accountId)
+}
+
+func (r *PixKeyRepository) ReadVerifiedByValue(keyType entity.PixKeyType, value string) (entity.PixKey, error) {
+
+	keys, err := r.query(`SELECT `+pixKeyColumns+` FROM "PixKey" WHERE type = $1 AND value_index = $2 AND verified_at IS NOT NULL`, string(keyType), r.valueIndex(keyType, value))
+	if err != nil {
+		return entity.PixKey{}, err
+	}
+
+	if len(keys) == 0 {
+		return entity.PixKey{}, fmt.Errorf("Couldn't find a Pix key with the provided value")
+	}
+
+	return keys[0], nil
+}
+
+func (r *PixKeyRepository) Save(key entity.PixKey) error {
+
+	_, err := r.connection.Exec(`UPDATE "PixKey" SET code_hash = $2, code_expires_at = $3, verified_at = $4 WHERE id = $1`,
+		key.ID, encodeCodeHash(key.CodeHash), key.CodeExpiresAt, key.VerifiedAt)
+	if err != nil {
+		log.Info().Err(err).Str("KeyID", key.ID).Msg("Failed to save Pix key")
+		return err
+	}
+
+	return nil
+}
+
+func (r *PixKeyRepository) Delete(id string) error {
+
+	_, err := r.connection.Exec(`DELETE FROM "PixKey" WHERE id = $1`, id)
+	if err != nil {
+		log.Info().Err(err).Str("KeyID", id).Msg("Failed to delete Pix key")
+		return err
+	}
+
+	return nil
+}
+
+func (r *PixKeyRepository) DeleteByAccountID(accountId int) error {
+
+	_, err := r.connection.Exec(`DELETE FROM "PixKey" WHERE account_id = $1`, accountId)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", accountId).Msg("Failed to delete Pix keys")
+		return err
+	}
+
+	return nil
+}
+
+// UpdateCodeLimit changes the code limit of the key with its row locked, so
+// parallel sends and attempts on the same key are counted one after another.
+func (r *PixKeyRepository) UpdateCodeLimit(key string, change func(limit *entity.PixCodeLimit)) error {
+
+	index := r.cipher.BlindIndex(key)
+
+	tx, err := r.connection.Begin()
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to begin Pix code limit transaction")
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO "PixCodeLimit" (key) VALUES ($1) ON CONFLICT (key) DO NOTHING`, index); err != nil {
+		log.Info().Err(err).Msg("Failed to create Pix code limit")
+		return err
+	}
+
+	limit := entity.NewPixCodeLimit(key)
+	err = tx.QueryRow(`SELECT sends, failures, window_start FROM "PixCodeLimit" WHERE key = $1 FOR UPDATE`, index).Scan(&limit.Sends, &limit.Failures, &limit.WindowStart)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to lock Pix code limit")
+		return err
+	}
+
+	change(limit)
+
+	_, err = tx.Exec(`UPDATE "PixCodeLimit" SET sends = $2, failures = $3, window_start = $4 WHERE key = $1`, index, limit.Sends, limit.Failures, limit.WindowStart)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to save Pix code limit")
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Info().Err(err).Msg("Failed to commit Pix code limit")
+		return err
+	}
+
+	return nil
+}
+
+func (r *PixKeyRepository) Reset() error {
+
+	_, err := r.connection.Exec(`DELETE FROM "PixKey"`)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to reset Pix keys")
+		return err
+	}
+
+	_, err = r.connection.Exec(`DELETE FROM "PixCodeLimit"`)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to reset Pix code limits")
+		return err
+	}
+
+	return nil
+}
+
+func (r *PixKeyRepository) query(sql string, args ...interface{}) ([]entity.PixKey, error) {
+
+	rows, err := r.connection.Query(sql, args...)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to query Pix keys")
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []entity.PixKey{}
+	for rows.Next() {
+		var id string
+		var accountId int
+		var keyType string
+		var value string
+		var code_hash *string
+		var code_expires_at time.Time
+		var verified_at *time.Time
+		var created_at time.Time
+
+		err = rows.Scan(&id, &accountId, &keyType, &value, &code_hash, &code_expires_at, &verified_at, &created_at)
+		if err != nil {
+			log.Info().Err(err).Msg("Failed to scan Pix key")
+			return nil, err
+		}
+
+		decrypted, err := r.cipher.Decrypt(value)
+		if err != nil {
+			log.Info().Err(err).Str("KeyID", id).Msg("Failed to decrypt Pix key")
+			return nil, err
+		}
+
+		key := entity.PixKey{
+			ID:            id,
+			AccountID:     accountId,
+			Type:          entity.PixKeyType(keyType),
+			Value:         decrypted,
+			CodeExpiresAt: code_expires_at,
+			VerifiedAt:    verified_at,
+			CreatedAt:     created_at,
+		}
+
+		if code_hash != nil {
+			key.CodeHash, err = hex.DecodeString(*code_hash)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// valueIndex includes the type, so a CPF key does not share its index with the
+// account CPF.
+func (r *PixKeyRepository) valueIndex(keyType entity.PixKeyType, value string) string {
+	return r.cipher.BlindIndex(string(keyType) + ":" + value)
+}
+
+func encodeCodeHash(hash []byte) *string {
+
+	if hash == nil {
+		return nil
+	}
+
+	encoded := hex.EncodeToString(hash)
+	return &encoded
+}
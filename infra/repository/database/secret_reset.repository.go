@@ -0,0 +1,89 @@
+package database
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/jackc/pgx"
+	"github.com/rs/zerolog/log"
+)
+
+type SecretResetRepository struct {
+	connection *pgx.ConnPool
+}
+
+func NewSecretResetRepository() *SecretResetRepository {
+
+	pool, err := pgx.NewConnPool(pgx.ConnPoolConfig{
+		ConnConfig: loadDatabaseEnvs(),
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to connect to database")
+		panic("Couldn't connect to database")
+	}
+
+	return &SecretResetRepository{connection: pool}
+}
+
+func (r *SecretResetRepository) Create(token entity.SecretResetToken) error {
+
+	_, err := r.connection.Exec(`INSERT INTO "SecretResetToken" (hash, account_id, expires_at, created_at) VALUES ($1, $2, $3, $4)`, hex.EncodeToString(token.Hash), token.AccountID, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", token.AccountID).Msg("Failed to create secret reset token")
+		return err
+	}
+
+	return nil
+}
+
+func (r *SecretResetRepository) ReadByHash(hash []byte) (entity.SecretResetToken, error) {
+
+	var accountId int
+	var expires_at time.Time
+	var used_at *time.Time
+	var created_at time.Time
+
+	err := r.connection.QueryRow(`SELECT account_id, expires_at, used_at, created_at FROM "SecretResetToken" WHERE hash = $1`, hex.EncodeToString(hash)).
+		Scan(&accountId, &expires_at, &used_at, &created_at)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to read secret reset token")
+		return entity.SecretResetToken{}, err
+	}
+
+	return entity.SecretResetToken{
+		Hash:      hash,
+		AccountID: accountId,
+		ExpiresAt: expires_at,
+		UsedAt:    used_at,
+		CreatedAt: created_at,
+	}, nil
+}
+
+func (r *SecretResetRepository) MarkUsed(token entity.SecretResetToken) error {
+
+	tag, err := r.connection.Exec(`UPDATE "SecretResetToken" SET used_at = $1 WHERE hash = $2 AND used_at IS NULL`, token.UsedAt, hex.EncodeToString(token.Hash))
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", token.AccountID).Msg("Failed to mark secret reset token as used")
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("Secret reset token was already used")
+	}
+
+	return nil
+}
+
+func (r *SecretResetRepository) Reset() error {
+
+	_, err := r.connection.Exec(`DELETE FROM "SecretResetToken"`)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to reset secret reset tokens")
+		return err
+	}
+
+	return nil
+}
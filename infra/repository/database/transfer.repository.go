@@ -1,6 +1,7 @@
 package database
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/PPAKruNN/golearn/domain/entity"
@@ -31,9 +32,18 @@ func NewTransferRepository() *TransferRepository {
 
 }
 
+// Every read joins the accounts, and the split payment of split credits, to
+// also bring their public IDs. Boleto payments to other banks and split
+// payments have no destination account.
+const (
+	transferColumns = `t.id, t.public_id, t.account_origin_id, o.public_id, t.account_destination_id, d.public_id, t.amount, t.created_at, t.kind, t.barcode, t.parent_id, p.public_id, t.escrow_id`
+	transferJoins   = `JOIN "Account" o ON o.id = t.account_origin_id LEFT JOIN "Account" d ON d.id = t.account_destination_id LEFT JOIN "Transfer" p ON p.id = t.parent_id`
+	selectTransfers = `SELECT ` + transferColumns + ` FROM "Transfer" t ` + transferJoins
+)
+
 func (r *TransferRepository) ReadTransfersByAccountID(id int) []entity.Transfer {
 
-	rows, err := r.connection.Query(`SELECT * FROM "Transfer"`)
+	rows, err := r.connection.Query(selectTransfers+` WHERE t.account_origin_id = $1 OR t.account_destination_id = $1 ORDER BY t.id`, id)
 	if err != nil {
 		log.Info().Err(err).Int("id", id).Msg("Failed to query all transfers from account")
 		return nil
@@ -42,70 +52,424 @@ func (r *TransferRepository) ReadTransfersByAccountID(id int) []entity.Transfer
 
 	transfers := []entity.Transfer{}
 	for rows.Next() {
-		var id int
-		var accountOriginID int
-		var accountDestinationID int
-		var amount int
-		var created_at time.Time
-
-		err = rows.Scan(&id, &accountOriginID, &accountDestinationID, &amount, &created_at)
+		transfer, err := scanTransfer(rows)
 		if err != nil {
 			log.Info().Err(err).Msg("Failed to scan transfer")
 			return nil
 		}
 
-		transfers = append(transfers, entity.Transfer{
-			ID:                   id,
-			AccountOriginID:      accountOriginID,
-			AccountDestinationID: accountDestinationID,
-			Amount:               amount,
-			CreatedAt:            created_at,
-		})
+		transfers = append(transfers, transfer)
 	}
 
 	return transfers
 }
 
-func (r *TransferRepository) CreateTransfer(accountOriginID, accountDestinationID, amount int) *entity.Transfer {
+// CreateTransfers makes every transfer in a single database transaction, moving
+// the balances too, or none of them. Balances are moved relative to the
+// stored ones, so concurrent transfers are not overwritten, and only the
+// available balance, the one not held, is spent. Holds are kept in the
+// account row, a hold and a debit made at once see each other. The fees of the transfers
+// are charged with them.
+func (r *TransferRepository) CreateTransfers(transfers []entity.Transfer) ([]entity.Transfer, error) {
 
-	rows, err := r.connection.Query(`INSERT into "Transfer" (account_origin_id, account_destination_id, amount) VALUES ($1, $2, $3) RETURNING id, account_origin_id, account_destination_id, amount, created_at`, accountOriginID, accountDestinationID, amount)
+	tx, err := r.connection.Begin()
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to begin transfers transaction")
+		return nil, err
+	}
+	defer tx.Rollback()
 
+	persisted, err := createTransfers(tx, transfers)
 	if err != nil {
-		log.Info().Err(err).
-			Int("OriginID", accountOriginID).
-			Int("DestinationID", accountDestinationID).
-			Int("Amount", amount).
-			Msg("Failed to create transfer")
-		return nil
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Info().Err(err).Msg("Failed to commit transfers transaction")
+		return nil, entity.ErrTransferInDoubt
+	}
+
+	return persisted, nil
+}
+
+// CreateBoletoPayment makes the boleto payment with its fee like
+// CreateTransfers. The destination is the issuer for boletos issued here, 0
+// for other banks. Settle, when given, runs last within the transaction,
+// nothing is made when it fails.
+func (r *TransferRepository) CreateBoletoPayment(payment entity.Transfer, settle func() error) (entity.Transfer, error) {
+
+	tx, err := r.connection.Begin()
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to begin boleto payment transaction")
+		return entity.Transfer{}, err
+	}
+	defer tx.Rollback()
+
+	persisted, err := createTransfers(tx, []entity.Transfer{payment})
+	if err != nil {
+		return entity.Transfer{}, err
+	}
+
+	if settle != nil {
+		if err := settle(); err != nil {
+			log.Info().Err(err).Str("Barcode", payment.Barcode).Msg("Failed to settle boleto payment")
+			return entity.Transfer{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Info().Err(err).Str("Barcode", payment.Barcode).Msg("Failed to commit boleto payment transaction")
+		return entity.Transfer{}, entity.ErrTransferInDoubt
+	}
+
+	return persisted[0], nil
+}
+
+// createTransfers makes the transfers within the transaction. Transfers with
+// no destination, boleto payments to other banks, only debit the origin.
+// Limits and fees are checked after the origin is debited, its row locked
+// until the transaction ends, so concurrent transfers are counted once.
+func createTransfers(tx *pgx.Tx, transfers []entity.Transfer) ([]entity.Transfer, error) {
+
+	persisted := []entity.Transfer{}
+	for i, transfer := range transfers {
+
+		if err := releaseExpiredHolds(tx, transfer.AccountOriginID); err != nil {
+			log.Info().Err(err).Int("Item", i).Msg("Failed to release expired holds")
+			return nil, err
+		}
+
+		tag, err := tx.Exec(`UPDATE "Account" SET balance = balance - $1 WHERE id = $2 AND balance - held >= $1 AND status = 'active'`, transfer.Amount, transfer.AccountOriginID)
+		if err != nil {
+			log.Info().Err(err).Int("Item", i).Msg("Failed to debit origin account")
+			return nil, err
+		}
+		if tag.RowsAffected() == 0 {
+			return nil, fmt.Errorf("Transfer %d: origin account has insufficient funds or cannot send transfers", i+1)
+		}
+
+		var destination *int
+		if transfer.AccountDestinationID != 0 {
+			destination = &transfer.AccountDestinationID
+
+			tag, err = tx.Exec(`UPDATE "Account" SET balance = balance + $1 WHERE id = $2 AND status = 'active'`, transfer.Amount, transfer.AccountDestinationID)
+			if err != nil {
+				log.Info().Err(err).Int("Item", i).Msg("Failed to credit destination account")
+				return nil, err
+			}
+			if tag.RowsAffected() == 0 {
+				return nil, fmt.Errorf("Transfer %d: destination account cannot receive transfers", i+1)
+			}
+		}
+
+		if transfer.Limit != nil {
+			if err := checkLimit(tx, transfer.AccountOriginID, transfer.Amount, *transfer.Limit); err != nil {
+				log.Info().Err(err).Int("Item", i).Msg("Failed to check the daily limit")
+				return nil, fmt.Errorf("Transfer %d: %v", i+1, err)
+			}
+		}
+
+		used := 0
+		if transfer.FeeCharge != nil {
+			err := tx.QueryRow(`SELECT count(*) FROM "Transfer" WHERE account_origin_id = $1 AND kind = $2 AND created_at >= $3`,
+				transfer.AccountOriginID, string(transfer.Kind), transfer.FeeCharge.Since).Scan(&used)
+			if err != nil {
+				log.Info().Err(err).Int("Item", i).Msg("Failed to count transfers for the fee")
+				return nil, err
+			}
+		}
+
+		var barcode *string
+		if transfer.Barcode != "" {
+			barcode = &transfer.Barcode
+		}
+
+		created, err := insertTransfer(tx, `INSERT into "Transfer" (public_id, account_origin_id, account_destination_id, amount, kind, barcode) VALUES ($1, $2, $3, $4, $5, $6)`,
+			entity.NewPublicID(entity.TRANSFER_ID_PREFIX), transfer.AccountOriginID, destination, transfer.Amount, string(transfer.Kind), barcode)
+		if err != nil {
+			log.Info().Err(err).Int("Item", i).Msg("Failed to create transfer")
+			return nil, err
+		}
+
+		if transfer.FeeCharge != nil {
+			if fee := transfer.FeeCharge.NewFee(created, used, time.Now()); fee != nil {
+				charged, err := chargeFee(tx, created, *fee)
+				if err != nil {
+					log.Info().Err(err).Int("Item", i).Msg("Failed to charge transfer fee")
+					return nil, fmt.Errorf("Transfer %d: %v", i+1, err)
+				}
+
+				created.Fee = &charged
+			}
+		}
+
+		persisted = append(persisted, created)
+	}
+
+	return persisted, nil
+}
+
+// CreateSplitPayment debits the whole amount from the origin account once, and
+// credits every receiver with its share, in a single database transaction.
+// The split payment is returned first, then its credits.
+func (r *TransferRepository) CreateSplitPayment(accountOriginID int, credits []entity.Transfer, limit *entity.TransferLimit) ([]entity.Transfer, error) {
+
+	total := 0
+	for _, credit := range credits {
+		total += credit.Amount
+	}
+
+	tx, err := r.connection.Begin()
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to begin split payment transaction")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := releaseExpiredHolds(tx, accountOriginID); err != nil {
+		log.Info().Err(err).Int("OriginID", accountOriginID).Msg("Failed to release expired holds")
+		return nil, err
+	}
+
+	tag, err := tx.Exec(`UPDATE "Account" SET balance = balance - $1 WHERE id = $2 AND balance - held >= $1 AND status = 'active'`, total, accountOriginID)
+	if err != nil {
+		log.Info().Err(err).Int("OriginID", accountOriginID).Msg("Failed to debit origin account")
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, fmt.Errorf("Origin account has insufficient funds or cannot send transfers")
+	}
+
+	if limit != nil {
+		if err := checkLimit(tx, accountOriginID, total, *limit); err != nil {
+			log.Info().Err(err).Int("OriginID", accountOriginID).Msg("Failed to check the daily limit")
+			return nil, err
+		}
+	}
+
+	parent, err := insertTransfer(tx, `INSERT into "Transfer" (public_id, account_origin_id, amount, kind) VALUES ($1, $2, $3, $4)`,
+		entity.NewPublicID(entity.TRANSFER_ID_PREFIX), accountOriginID, total, string(entity.TransferKindSplit))
+	if err != nil {
+		log.Info().Err(err).Int("OriginID", accountOriginID).Msg("Failed to create split payment")
+		return nil, err
+	}
+
+	persisted := []entity.Transfer{parent}
+	for i, credit := range credits {
+
+		tag, err := tx.Exec(`UPDATE "Account" SET balance = balance + $1 WHERE id = $2 AND status = 'active'`, credit.Amount, credit.AccountDestinationID)
+		if err != nil {
+			log.Info().Err(err).Int("Item", i).Msg("Failed to credit destination account")
+			return nil, err
+		}
+		if tag.RowsAffected() == 0 {
+			return nil, fmt.Errorf("Split %d: destination account cannot receive transfers", i+1)
+		}
+
+		created, err := insertTransfer(tx, `INSERT into "Transfer" (public_id, account_origin_id, account_destination_id, amount, kind, parent_id) VALUES ($1, $2, $3, $4, $5, $6)`,
+			entity.NewPublicID(entity.TRANSFER_ID_PREFIX), accountOriginID, credit.AccountDestinationID, credit.Amount, string(entity.TransferKindSplitCredit), parent.ID)
+		if err != nil {
+			log.Info().Err(err).Int("Item", i).Msg("Failed to create split credit")
+			return nil, err
+		}
+
+		persisted = append(persisted, created)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Info().Err(err).Msg("Failed to commit split payment transaction")
+		return nil, entity.ErrTransferInDoubt
+	}
+
+	return persisted, nil
+}
+
+// checkLimit fails when the amount takes what the origin sent over the limit.
+// Called with the origin already debited, its row locked, so what it sends in
+// parallel is counted once.
+func checkLimit(tx *pgx.Tx, originId int, amount int, limit entity.TransferLimit) error {
+
+	var sent int
+	err := tx.QueryRow(`SELECT coalesce(sum(amount), 0) FROM "Transfer" WHERE account_origin_id = $1 AND kind = ANY($2) AND created_at >= $3`,
+		originId, sentTransferKinds(), limit.Since).Scan(&sent)
+	if err != nil {
+		return err
+	}
+
+	if remaining := limit.Remaining(sent); amount > remaining {
+		return fmt.Errorf("exceeds the daily limit, remaining today: %d", remaining)
+	}
+
+	return nil
+}
+
+// chargeFee moves the fee from the origin of the transfer to the revenue
+// account, the destination of the fee, and records it pointing to the
+// transfer.
+func chargeFee(tx *pgx.Tx, parent entity.Transfer, fee entity.Transfer) (entity.Transfer, error) {
+
+	tag, err := tx.Exec(`UPDATE "Account" SET balance = balance - $1 WHERE id = $2 AND balance - held >= $1 AND status = 'active'`, fee.Amount, parent.AccountOriginID)
+	if err != nil {
+		return entity.Transfer{}, err
+	}
+	if tag.RowsAffected() == 0 {
+		return entity.Transfer{}, fmt.Errorf("origin account has insufficient funds for the fee")
+	}
+
+	tag, err = tx.Exec(`UPDATE "Account" SET balance = balance + $1 WHERE id = $2`, fee.Amount, fee.AccountDestinationID)
+	if err != nil {
+		return entity.Transfer{}, err
+	}
+	if tag.RowsAffected() == 0 {
+		return entity.Transfer{}, fmt.Errorf("revenue account not found")
+	}
+
+	return insertTransfer(tx, `INSERT into "Transfer" (public_id, account_origin_id, account_destination_id, amount, kind, parent_id) VALUES ($1, $2, $3, $4, $5, $6)`,
+		entity.NewPublicID(entity.TRANSFER_ID_PREFIX), parent.AccountOriginID, fee.AccountDestinationID, fee.Amount, string(entity.TransferKindFee), parent.ID)
+}
+
+// CountByOrigin counts the transfers of the kind made by the account since
+// the time given.
+func (r *TransferRepository) CountByOrigin(accountId int, kind entity.TransferKind, since time.Time) (int, error) {
+
+	var count int
+	err := r.connection.QueryRow(`SELECT count(*) FROM "Transfer" WHERE account_origin_id = $1 AND kind = $2 AND created_at >= $3`, accountId, string(kind), since).Scan(&count)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", accountId).Msg("Failed to count transfers")
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// SumSentByOrigin adds up the amounts the account sent since the time given,
+// in every kind taking money out of it.
+func (r *TransferRepository) SumSentByOrigin(accountId int, since time.Time) (int, error) {
+
+	var sum int
+	err := r.connection.QueryRow(`SELECT coalesce(sum(amount), 0) FROM "Transfer" WHERE account_origin_id = $1 AND kind = ANY($2) AND created_at >= $3`, accountId, sentTransferKinds(), since).Scan(&sum)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", accountId).Msg("Failed to sum transfers")
+		return 0, err
+	}
+
+	return sum, nil
+}
+
+// insertTransfer runs the insert within the transaction and reads the new
+// transfer back, joined like any other read.
+func insertTransfer(tx *pgx.Tx, insert string, args ...interface{}) (entity.Transfer, error) {
+
+	rows, err := tx.Query(`WITH t AS (`+insert+` RETURNING *) SELECT `+transferColumns+` FROM t `+transferJoins, args...)
+	if err != nil {
+		return entity.Transfer{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return entity.Transfer{}, err
+		}
+		return entity.Transfer{}, fmt.Errorf("Transfer was not created")
+	}
+
+	return scanTransfer(rows)
+}
+
+func (r *TransferRepository) Search(filter entity.TransferFilter) ([]entity.Transfer, error) {
+
+	query := selectTransfers + ` WHERE 1 = 1`
+	args := []interface{}{}
+
+	if filter.AccountID != 0 {
+		args = append(args, filter.AccountID)
+		query += fmt.Sprintf(` AND (t.account_origin_id = $%d OR t.account_destination_id = $%d)`, len(args), len(args))
+	}
+	if filter.MinAmount != 0 {
+		args = append(args, filter.MinAmount)
+		query += fmt.Sprintf(` AND t.amount >= $%d`, len(args))
+	}
+	if filter.MaxAmount != 0 {
+		args = append(args, filter.MaxAmount)
+		query += fmt.Sprintf(` AND t.amount <= $%d`, len(args))
+	}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		query += fmt.Sprintf(` AND t.created_at >= $%d`, len(args))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		query += fmt.Sprintf(` AND t.created_at <= $%d`, len(args))
+	}
+	query += ` ORDER BY t.created_at DESC`
+
+	rows, err := r.connection.Query(query, args...)
+	if err != nil {
+		log.Info().Err(err).Interface("Filter", filter).Msg("Failed to search transfers")
+		return nil, err
 	}
 	defer rows.Close()
 
+	transfers := []entity.Transfer{}
 	for rows.Next() {
-		transfer := entity.Transfer{}
-		var id int
-		var accountOriginID int
-		var accountDestinationID int
-		var amount int
-		var created_at time.Time
-
-		err = rows.Scan(&id, &accountOriginID, &accountDestinationID, &amount, &created_at)
+		transfer, err := scanTransfer(rows)
 		if err != nil {
 			log.Info().Err(err).Msg("Failed to scan transfer")
-			return nil
+			return nil, err
 		}
 
-		transfer = entity.Transfer{
-			ID:                   id,
-			AccountOriginID:      accountOriginID,
-			AccountDestinationID: accountDestinationID,
-			Amount:               amount,
-			CreatedAt:            created_at,
-		}
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, nil
+}
 
-		return &transfer
+func scanTransfer(rows *pgx.Rows) (entity.Transfer, error) {
+
+	var transfer entity.Transfer
+	var created_at time.Time
+	var destination_id *int
+	var destination_public_id *string
+	var kind string
+	var barcode *string
+	var parent_id *int
+	var parent_public_id *string
+	var escrow_id *string
+
+	err := rows.Scan(
+		&transfer.ID,
+		&transfer.PublicID,
+		&transfer.AccountOriginID,
+		&transfer.AccountOriginPublicID,
+		&destination_id,
+		&destination_public_id,
+		&transfer.Amount,
+		&created_at,
+		&kind,
+		&barcode,
+		&parent_id,
+		&parent_public_id,
+		&escrow_id,
+	)
+	transfer.CreatedAt = created_at
+	transfer.Kind = entity.TransferKind(kind)
+
+	if destination_id != nil {
+		transfer.AccountDestinationID = *destination_id
+		transfer.AccountDestinationPublicID = *destination_public_id
+	}
+	if barcode != nil {
+		transfer.Barcode = *barcode
+	}
+	if parent_id != nil {
+		transfer.ParentID = *parent_id
+		transfer.ParentPublicID = *parent_public_id
+	}
+	if escrow_id != nil {
+		transfer.EscrowID = *escrow_id
 	}
 
-	return &entity.Transfer{}
+	return transfer, err
 }
 
 func (r *TransferRepository) Reset() error {
@@ -120,3 +484,11 @@ func (r *TransferRepository) Reset() error {
 
 	return nil
 }
+
+func sentTransferKinds() []string {
+	kinds := []string{}
+	for _, kind := range entity.SentTransferKinds {
+		kinds = append(kinds, string(kind))
+	}
+	return kinds
+}
@@ -0,0 +1,178 @@
+package database
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/jackc/pgx"
+	"github.com/rs/zerolog/log"
+)
+
+const transferBatchColumns = `id, account_id, mode, status, items::text, created_at, finished_at`
+
+type TransferBatchRepository struct {
+	connection *pgx.ConnPool
+}
+
+// transferBatchItem is how the items are kept in the items jsonb column.
+type transferBatchItem struct {
+	DestinationID       int    `json:"destination_id"`
+	DestinationPublicID string `json:"destination_public_id"`
+	Amount              int    `json:"amount"`
+	Status              string `json:"status"`
+	Error               string `json:"error,omitempty"`
+	TransferID          string `json:"transfer_id,omitempty"`
+}
+
+func NewTransferBatchRepository() *TransferBatchRepository {
+
+	pool, err := pgx.NewConnPool(pgx.ConnPoolConfig{
+		ConnConfig: loadDatabaseEnvs(),
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to connect to database")
+		panic("Couldn't connect to database")
+	}
+
+	return &TransferBatchRepository{connection: pool}
+}
+
+func (r *TransferBatchRepository) Create(batch entity.TransferBatch) error {
+
+	items, err := encodeBatchItems(batch.Items)
+	if err != nil {
+		log.Info().Err(err).Str("BatchID", batch.ID).Msg("Failed to encode batch items")
+		return err
+	}
+
+	_, err = r.connection.Exec(`INSERT INTO "TransferBatch" (id, account_id, mode, status, items, created_at) VALUES ($1, $2, $3, $4, $5::jsonb, $6)`,
+		batch.ID, batch.AccountID, string(batch.Mode), string(batch.Status), items, batch.CreatedAt)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", batch.AccountID).Msg("Failed to create transfer batch")
+		return err
+	}
+
+	return nil
+}
+
+func (r *TransferBatchRepository) ReadByID(id string) (entity.TransferBatch, error) {
+
+	var batch entity.TransferBatch
+	var mode string
+	var status string
+	var items string
+	var created_at time.Time
+	var finished_at *time.Time
+
+	err := r.connection.QueryRow(`SELECT `+transferBatchColumns+` FROM "TransferBatch" WHERE id = $1`, id).
+		Scan(&batch.ID, &batch.AccountID, &mode, &status, &items, &created_at, &finished_at)
+	if err != nil {
+		log.Info().Err(err).Str("BatchID", id).Msg("Failed to read transfer batch")
+		return entity.TransferBatch{}, err
+	}
+
+	var records []transferBatchItem
+	if err := json.Unmarshal([]byte(items), &records); err != nil {
+		log.Info().Err(err).Str("BatchID", id).Msg("Failed to decode batch items")
+		return entity.TransferBatch{}, err
+	}
+
+	for _, record := range records {
+		batch.Items = append(batch.Items, entity.TransferBatchItem{
+			DestinationID:       record.DestinationID,
+			DestinationPublicID: record.DestinationPublicID,
+			Amount:              record.Amount,
+			Status:              entity.TransferBatchItemStatus(record.Status),
+			Error:               record.Error,
+			TransferID:          record.TransferID,
+		})
+	}
+
+	batch.Mode = entity.TransferBatchMode(mode)
+	batch.Status = entity.TransferBatchStatus(status)
+	batch.CreatedAt = created_at
+	batch.FinishedAt = finished_at
+
+	return batch, nil
+}
+
+func (r *TransferBatchRepository) Save(batch entity.TransferBatch) error {
+
+	items, err := encodeBatchItems(batch.Items)
+	if err != nil {
+		log.Info().Err(err).Str("BatchID", batch.ID).Msg("Failed to encode batch items")
+		return err
+	}
+
+	_, err = r.connection.Exec(`UPDATE "TransferBatch" SET status = $2, items = $3::jsonb, finished_at = $4 WHERE id = $1`,
+		batch.ID, string(batch.Status), items, batch.FinishedAt)
+	if err != nil {
+		log.Error().Err(err).Str("BatchID", batch.ID).Str("Status", string(batch.Status)).Msg("Failed to save transfer batch")
+		return err
+	}
+
+	return nil
+}
+
+func (r *TransferBatchRepository) ReadUnfinished() ([]entity.TransferBatch, error) {
+
+	rows, err := r.connection.Query(`SELECT id FROM "TransferBatch" WHERE status IN ($1, $2) ORDER BY created_at`, string(entity.BatchPending), string(entity.BatchProcessing))
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to query unfinished transfer batches")
+		return nil, err
+	}
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			log.Info().Err(err).Msg("Failed to scan transfer batch id")
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	batches := []entity.TransferBatch{}
+	for _, id := range ids {
+		batch, err := r.ReadByID(id)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+
+	return batches, nil
+}
+
+func (r *TransferBatchRepository) Reset() error {
+
+	_, err := r.connection.Exec(`DELETE FROM "TransferBatch"`)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to reset transfer batches")
+		return err
+	}
+
+	return nil
+}
+
+func encodeBatchItems(items []entity.TransferBatchItem) (string, error) {
+
+	records := []transferBatchItem{}
+	for _, item := range items {
+		records = append(records, transferBatchItem{
+			DestinationID:       item.DestinationID,
+			DestinationPublicID: item.DestinationPublicID,
+			Amount:              item.Amount,
+			Status:              string(item.Status),
+			Error:               item.Error,
+			TransferID:          item.TransferID,
+		})
+	}
+
+	encoded, err := json.Marshal(records)
+	return string(encoded), err
+}
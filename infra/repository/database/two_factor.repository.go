@@ -0,0 +1,261 @@
+package database
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/infra/encryption"
+	"github.com/jackc/pgx"
+	"github.com/rs/zerolog/log"
+)
+
+// TwoFactorRepository keeps the TOTP secrets encrypted, like the CPFs, anyone
+// reading them could generate the codes.
+type TwoFactorRepository struct {
+	connection *pgx.ConnPool
+	cipher     *encryption.Cipher
+}
+
+func NewTwoFactorRepository() *TwoFactorRepository {
+
+	pool, err := pgx.NewConnPool(pgx.ConnPoolConfig{
+		ConnConfig: loadDatabaseEnvs(),
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to connect to database")
+		panic("Couldn't connect to database")
+	}
+
+	return &TwoFactorRepository{connection: pool, cipher: encryption.LoadFromEnv()}
+}
+
+func (r *TwoFactorRepository) Read(accountId int) (entity.TwoFactor, error) {
+
+	rows, err := r.connection.Query(`SELECT account_id, secret, enabled, recovery_codes, last_used_step, created_at FROM "TwoFactor" WHERE account_id = $1`, accountId)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", accountId).Msg("Failed to query two-factor")
+		return entity.TwoFactor{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var accountId int
+		var secret string
+		var enabled bool
+		var recoveryCodes []string
+		var lastUsedStep int64
+		var created_at time.Time
+
+		err = rows.Scan(&accountId, &secret, &enabled, &recoveryCodes, &lastUsedStep, &created_at)
+		if err != nil {
+			log.Info().Err(err).Msg("Failed to scan two-factor")
+			return entity.TwoFactor{}, err
+		}
+
+		secret, err = r.cipher.Decrypt(secret)
+		if err != nil {
+			log.Info().Err(err).Int("AccountID", accountId).Msg("Failed to decrypt two-factor secret")
+			return entity.TwoFactor{}, err
+		}
+
+		bs, err := hex.DecodeString(secret)
+		if err != nil {
+			log.Info().Err(err).Msg("Failed to decode two-factor secret")
+			return entity.TwoFactor{}, err
+		}
+
+		return entity.TwoFactor{
+			AccountID:     accountId,
+			Secret:        bs,
+			Enabled:       enabled,
+			RecoveryCodes: recoveryCodes,
+			LastUsedStep:  lastUsedStep,
+			CreatedAt:     created_at,
+		}, nil
+	}
+
+	return entity.TwoFactor{}, fmt.Errorf("Couldn't find two-factor for the provided account")
+}
+
+func (r *TwoFactorRepository) Save(twoFactor entity.TwoFactor) error {
+
+	secret, err := r.cipher.Encrypt(hex.EncodeToString(twoFactor.Secret))
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", twoFactor.AccountID).Msg("Failed to encrypt two-factor secret")
+		return err
+	}
+
+	rows, err := r.connection.Query(`INSERT INTO "TwoFactor" (account_id, secret, enabled, recovery_codes, last_used_step, created_at) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (account_id) DO UPDATE SET secret = $2, enabled = $3, recovery_codes = $4, last_used_step = $5`, twoFactor.AccountID, secret, twoFactor.Enabled, twoFactor.RecoveryCodes, twoFactor.LastUsedStep, twoFactor.CreatedAt)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", twoFactor.AccountID).Msg("Failed to save two-factor")
+		return err
+	}
+	defer rows.Close()
+
+	return nil
+}
+
+// UseStep stores the TOTP step as used, failing when the same or a later step
+// was used in the meantime.
+func (r *TwoFactorRepository) UseStep(accountId int, step int64) error {
+
+	tag, err := r.connection.Exec(`UPDATE "TwoFactor" SET last_used_step = $2 WHERE account_id = $1 AND enabled AND last_used_step < $2`, accountId, step)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", accountId).Msg("Failed to use two-factor step")
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("Two-factor code was already used")
+	}
+
+	return nil
+}
+
+// ReplaceRecoveryCodes stores the remaining recovery codes, failing when the
+// stored ones are no longer those the codes were taken from.
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(accountId int, previous, remaining []string) error {
+
+	tag, err := r.connection.Exec(`UPDATE "TwoFactor" SET recovery_codes = $3 WHERE account_id = $1 AND enabled AND recovery_codes = $2`, accountId, previous, remaining)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", accountId).Msg("Failed to replace recovery codes")
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("Recovery codes changed in the meantime")
+	}
+
+	return nil
+}
+
+func (r *TwoFactorRepository) Delete(accountId int) error {
+
+	_, err := r.connection.Exec(`DELETE FROM "TwoFactor" WHERE account_id = $1`, accountId)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", accountId).Msg("Failed to delete two-factor")
+		return err
+	}
+
+	return nil
+}
+
+// Reencrypt moves every TOTP secret still in plaintext or sealed by an old
+// master key to the active key. Returns how many were updated.
+func (r *TwoFactorRepository) Reencrypt() (int, error) {
+
+	rows, err := r.connection.Query(`SELECT account_id, secret FROM "TwoFactor" ORDER BY account_id`)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to query two-factor secrets to re-encrypt")
+		return 0, err
+	}
+
+	pending := map[int]string{}
+	for rows.Next() {
+		var accountId int
+		var secret string
+
+		if err = rows.Scan(&accountId, &secret); err != nil {
+			rows.Close()
+			log.Info().Err(err).Msg("Failed to scan two-factor")
+			return 0, err
+		}
+
+		if r.cipher.NeedsReencryption(secret) {
+			pending[accountId] = secret
+		}
+	}
+	rows.Close()
+
+	updated := 0
+	for accountId, stored := range pending {
+		encrypted, err := r.cipher.Reencrypt(stored)
+		if err != nil {
+			log.Info().Err(err).Int("AccountID", accountId).Msg("Failed to re-encrypt two-factor secret")
+			return updated, err
+		}
+
+		// Only replaced when unchanged, a secret enrolled in the meantime is
+		// already sealed by the active key.
+		_, err = r.connection.Exec(`UPDATE "TwoFactor" SET secret = $1 WHERE account_id = $2 AND secret = $3`, encrypted, accountId, stored)
+		if err != nil {
+			log.Info().Err(err).Int("AccountID", accountId).Msg("Failed to re-encrypt two-factor secret")
+			return updated, err
+		}
+
+		updated++
+	}
+
+	return updated, nil
+}
+
+func (r *TwoFactorRepository) CreateChallenge(challenge entity.LoginChallenge) error {
+	return r.SaveChallenge(challenge)
+}
+
+func (r *TwoFactorRepository) ReadChallenge(id string) (entity.LoginChallenge, error) {
+
+	rows, err := r.connection.Query(`SELECT id, account_id, user_agent, ip, attempts, expires_at FROM "LoginChallenge" WHERE id = $1`, id)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to query login challenge")
+		return entity.LoginChallenge{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		challenge := entity.LoginChallenge{}
+
+		err = rows.Scan(&challenge.ID, &challenge.AccountID, &challenge.UserAgent, &challenge.IP, &challenge.Attempts, &challenge.ExpiresAt)
+		if err != nil {
+			log.Info().Err(err).Msg("Failed to scan login challenge")
+			return entity.LoginChallenge{}, err
+		}
+
+		return challenge, nil
+	}
+
+	return entity.LoginChallenge{}, fmt.Errorf("Couldn't find a login challenge with the provided ID")
+}
+
+func (r *TwoFactorRepository) SaveChallenge(challenge entity.LoginChallenge) error {
+
+	rows, err := r.connection.Query(`INSERT INTO "LoginChallenge" (id, account_id, user_agent, ip, attempts, expires_at) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (id) DO UPDATE SET attempts = $5`, challenge.ID, challenge.AccountID, challenge.UserAgent, challenge.IP, challenge.Attempts, challenge.ExpiresAt)
+	if err != nil {
+		log.Info().Err(err).Int("AccountID", challenge.AccountID).Msg("Failed to save login challenge")
+		return err
+	}
+	defer rows.Close()
+
+	return nil
+}
+
+func (r *TwoFactorRepository) DeleteChallenge(id string) error {
+
+	_, err := r.connection.Exec(`DELETE FROM "LoginChallenge" WHERE id = $1 OR expires_at < NOW()`, id)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to delete login challenge")
+		return err
+	}
+
+	return nil
+}
+
+func (r *TwoFactorRepository) Reset() error {
+
+	_, err := r.connection.Exec(`DELETE FROM "LoginChallenge"`)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to reset login challenges")
+		return err
+	}
+
+	_, err = r.connection.Exec(`DELETE FROM "TwoFactor"`)
+	if err != nil {
+		log.Info().Err(err).Msg("Failed to reset two-factor")
+		return err
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/jackc/pgx"
 	"github.com/joho/godotenv"
+	"github.com/rs/zerolog/log"
 )
 
 const (
@@ -30,7 +31,13 @@ func loadDatabaseEnvs() pgx.ConnConfig {
 		config = loadDatabaseEnvsUsingFile()
 	}
 
-	fmt.Printf("Using enviroment: %+v\n", config)
+	// Never print the whole config, it carries the database password.
+	log.Info().
+		Str("Host", config.Host).
+		Uint16("Port", config.Port).
+		Str("Database", config.Database).
+		Str("User", config.User).
+		Msg("Using database enviroment")
 
 	return config
 
@@ -67,7 +74,7 @@ func loadDatabaseEnvsUsingOS() pgx.ConnConfig {
 	config, err := pgx.ParseConnectionString(dbconnstring)
 
 	if err != nil {
-		panic(fmt.Sprintf("Couldn't get enviroment variables!", err))
+		panic(fmt.Sprintf("Couldn't get enviroment variables! err: %v", err))
 	}
 
 	return config
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/PPAKruNN/golearn/domain/entity"
+	"github.com/PPAKruNN/golearn/infra/encryption"
 )
 
 const (
@@ -17,9 +18,11 @@ const (
 )
 
 type accountJSONSchema struct {
-	ID        int
-	Name      string
+	ID   int
+	Name string
+	// Encrypted, found through CPFIndex.
 	CPF       string
+	CPFIndex  string
 	Secret    string
 	Balance   int
 	CreatedAt time.Time
@@ -29,6 +32,7 @@ type AccountRepository struct {
 	Accounts     []entity.Account
 	AccountsJson []accountJSONSchema
 	pathToFile   string
+	cipher       *encryption.Cipher
 }
 
 func NewAccountRepository(dir string) *AccountRepository {
@@ -38,6 +42,7 @@ func NewAccountRepository(dir string) *AccountRepository {
 	repo := &AccountRepository{
 		Accounts:   []entity.Account{{}},
 		pathToFile: path,
+		cipher:     encryption.LoadFromEnv(),
 	}
 
 	repo.loadIntoMemory()
@@ -75,20 +80,25 @@ func (r *AccountRepository) loadIntoMemory() {
 	// }
 
 	r.AccountsJson = accounts
-	r.Accounts = convertJSONtoEntity(accounts)
+	r.Accounts = convertJSONtoEntity(accounts, r.cipher)
 }
 
-func convertJSONtoEntity(json []accountJSONSchema) (output []entity.Account) {
+func convertJSONtoEntity(json []accountJSONSchema, cipher *encryption.Cipher) (output []entity.Account) {
 
 	for _, acc := range json {
 
 		hash := sha256.New()
 		hash.Write([]byte(acc.Secret))
 
+		cpf, err := cipher.Decrypt(acc.CPF)
+		if err != nil {
+			panic(fmt.Sprintf("Could not decrypt account %d cpf: %v", acc.ID, err))
+		}
+
 		output = append(output, entity.Account{
 			ID:        acc.ID,
 			Name:      acc.Name,
-			CPF:       acc.CPF,
+			CPF:       cpf,
 			Secret:    hash,
 			Balance:   acc.Balance,
 			CreatedAt: acc.CreatedAt,
@@ -98,17 +108,22 @@ func convertJSONtoEntity(json []accountJSONSchema) (output []entity.Account) {
 	return
 }
 
-func convertEntityToJSON(entities []entity.Account) (output []accountJSONSchema) {
+func convertEntityToJSON(entities []entity.Account, cipher *encryption.Cipher) (output []accountJSONSchema) {
 
 	for _, acc := range entities {
 
 		hash := hex.EncodeToString(acc.Secret.Sum(nil))
-		fmt.Println(hash)
+
+		cpf, err := cipher.Encrypt(acc.CPF)
+		if err != nil {
+			panic(fmt.Sprintf("Could not encrypt account %d cpf: %v", acc.ID, err))
+		}
 
 		output = append(output, accountJSONSchema{
 			ID:        acc.ID,
 			Name:      acc.Name,
-			CPF:       acc.CPF,
+			CPF:       cpf,
+			CPFIndex:  cipher.BlindIndex(acc.CPF),
 			Secret:    hash,
 			Balance:   acc.Balance,
 			CreatedAt: acc.CreatedAt,
@@ -146,8 +161,10 @@ func (r *AccountRepository) ReadHashByCPF(cpf string) (int, []byte) {
 	var accounts []accountJSONSchema
 	json.NewDecoder(handle).Decode(&accounts)
 
+	index := r.cipher.BlindIndex(cpf)
+
 	for _, acc := range accounts {
-		if acc.CPF == cpf {
+		if acc.CPFIndex == index || (acc.CPFIndex == "" && acc.CPF == cpf) {
 
 			bs, err := hex.DecodeString(acc.Secret)
 			if err != nil {
@@ -175,11 +192,17 @@ func (r *AccountRepository) Create(name string, cpf string, secret hash.Hash, ba
 		CreatedAt: time.Now().UTC(),
 	}
 
+	encryptedCPF, err := r.cipher.Encrypt(cpf)
+	if err != nil {
+		panic(fmt.Sprintf("Could not encrypt account cpf: %v", err))
+	}
+
 	r.Accounts = append(r.Accounts, newAccount)
 	r.AccountsJson = append(r.AccountsJson, accountJSONSchema{
 		ID:        newAccount.ID,
 		Name:      newAccount.Name,
-		CPF:       newAccount.CPF,
+		CPF:       encryptedCPF,
+		CPFIndex:  r.cipher.BlindIndex(cpf),
 		Secret:    hex.EncodeToString(newAccount.Secret.Sum(nil)),
 		Balance:   newAccount.Balance,
 		CreatedAt: time.Now().UTC(),
@@ -191,6 +214,37 @@ func (r *AccountRepository) Create(name string, cpf string, secret hash.Hash, ba
 
 }
 
+// Reencrypt moves every CPF to the active master key, filling the blind index.
+func (r *AccountRepository) Reencrypt() (int, error) {
+
+	r.loadIntoMemory()
+
+	updated := 0
+	for idx, acc := range r.AccountsJson {
+		if !r.cipher.NeedsReencryption(acc.CPF) && acc.CPFIndex != "" {
+			continue
+		}
+
+		plain, err := r.cipher.Decrypt(acc.CPF)
+		if err != nil {
+			return updated, err
+		}
+
+		encrypted, err := r.cipher.Encrypt(plain)
+		if err != nil {
+			return updated, err
+		}
+
+		r.AccountsJson[idx].CPF = encrypted
+		r.AccountsJson[idx].CPFIndex = r.cipher.BlindIndex(plain)
+		updated++
+	}
+
+	r.save()
+
+	return updated, nil
+}
+
 func (r *AccountRepository) UpdateBalance(id, balance int) *entity.Account {
 
 	r.loadIntoMemory()
@@ -2,8 +2,12 @@ package indisk
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
 )
 
 const (
@@ -11,13 +15,13 @@ const (
 )
 
 type AuthRepository struct {
-	Auths      map[string]int
+	Sessions   map[string]entity.Session
 	pathToFile string
 }
 
 func NewAuthRepository(dir string) *AuthRepository {
 	repo := &AuthRepository{
-		Auths:      map[string]int{},
+		Sessions:   map[string]entity.Session{},
 		pathToFile: path.Join(dir, AUTH_DATA_FILENAME),
 	}
 
@@ -26,21 +30,78 @@ func NewAuthRepository(dir string) *AuthRepository {
 	return repo
 }
 
-func (r *AuthRepository) RegisterToken(token string, accountId int) {
+func (r *AuthRepository) CreateSession(session entity.Session) error {
+
+	r.loadIntoMemory()
+
+	r.Sessions[session.TokenHash] = session
 
+	r.save()
+	return nil
+}
+
+func (r *AuthRepository) DecodeToken(tokenHash string) (entity.Session, error) {
 	r.loadIntoMemory()
 
-	r.Auths[token] = accountId
+	session, ok := r.Sessions[tokenHash]
+	if !ok || session.IsExpired(time.Now()) {
+		return entity.Session{}, fmt.Errorf("Failed to get decode/get session from token!")
+	}
 
+	session.LastUsedAt = time.Now().UTC()
+	r.Sessions[tokenHash] = session
 	r.save()
+
+	return session, nil
+}
+
+func (r *AuthRepository) ReadSessionsByAccountID(accountId int) ([]entity.Session, error) {
+	r.loadIntoMemory()
+
+	sessions := []entity.Session{}
+	for _, session := range r.Sessions {
+		if session.AccountID == accountId {
+			sessions = append(sessions, session)
+		}
+	}
+
+	return sessions, nil
 }
 
-func (r *AuthRepository) DecodeToken(token string) (int, error) {
+func (r *AuthRepository) RevokeSession(accountId int, sessionId string) error {
 	r.loadIntoMemory()
 
-	accountId := r.Auths[token]
+	for tokenHash, session := range r.Sessions {
+		if session.ID == sessionId && session.AccountID == accountId {
+			delete(r.Sessions, tokenHash)
+			r.save()
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Couldn't find a session with the provided ID")
+}
+
+func (r *AuthRepository) RevokeToken(tokenHash string) error {
+	r.loadIntoMemory()
+
+	delete(r.Sessions, tokenHash)
+	r.save()
+
+	return nil
+}
+
+func (r *AuthRepository) RevokeAllSessions(accountId int) error {
+	r.loadIntoMemory()
+
+	for tokenHash, session := range r.Sessions {
+		if session.AccountID == accountId {
+			delete(r.Sessions, tokenHash)
+		}
+	}
+	r.save()
 
-	return accountId, nil
+	return nil
 }
 
 func (r *AuthRepository) openHandle() *os.File {
@@ -54,17 +115,17 @@ func (r *AuthRepository) Reset() error {
 }
 
 func (r *AuthRepository) save() {
-	marshal, _ := json.MarshalIndent(r.Auths, "", "  ")
+	marshal, _ := json.MarshalIndent(r.Sessions, "", "  ")
 	saveInFile(r.pathToFile, marshal)
 }
 
 func (r *AuthRepository) loadIntoMemory() {
-	// Load accounts from disk
+	// Load sessions from disk
 	handle := r.openHandle()
 	defer handle.Close()
 
-	var auths map[string]int = map[string]int{}
-	json.NewDecoder(handle).Decode(&auths)
+	var sessions map[string]entity.Session = map[string]entity.Session{}
+	json.NewDecoder(handle).Decode(&sessions)
 
-	r.Auths = auths
+	r.Sessions = sessions
 }
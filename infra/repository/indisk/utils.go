@@ -35,7 +35,13 @@ func resetFile(pathToFile string) error {
 
 func saveInFile(pathToFile string, data []byte) {
 
-	handle, err := os.OpenFile(pathToFile, os.O_RDWR|os.O_CREATE, 0755)
+	// Truncating, otherwise a smaller content leaves the tail of the old one.
+	handle, err := os.OpenFile(pathToFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		panic("Failed opening file to save" + " " + err.Error())
+	}
+	defer handle.Close()
+
 	_, err = handle.Write(data)
 
 	if err != nil {
@@ -1,26 +1,101 @@
 package inmemory
 
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/PPAKruNN/golearn/domain/entity"
+)
+
 type AuthRepository struct {
-	Auths map[string]int
+	Sessions map[string]entity.Session
+	mu       *sync.Mutex
 }
 
 func NewAuthRepository() *AuthRepository {
 	return &AuthRepository{
-		Auths: map[string]int{},
+		Sessions: map[string]entity.Session{},
+		mu:       &sync.Mutex{},
+	}
+}
+
+func (r AuthRepository) CreateSession(session entity.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Sessions[session.TokenHash] = session
+	return nil
+}
+
+func (r AuthRepository) DecodeToken(tokenHash string) (entity.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.Sessions[tokenHash]
+	if !ok || session.IsExpired(time.Now()) {
+		return entity.Session{}, fmt.Errorf("Failed to get decode/get session from token!")
+	}
+
+	session.LastUsedAt = time.Now()
+	r.Sessions[tokenHash] = session
+
+	return session, nil
+}
+
+func (r AuthRepository) ReadSessionsByAccountID(accountId int) ([]entity.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sessions := []entity.Session{}
+	for _, session := range r.Sessions {
+		if session.AccountID == accountId {
+			sessions = append(sessions, session)
+		}
+	}
+
+	return sessions, nil
+}
+
+func (r AuthRepository) RevokeSession(accountId int, sessionId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for tokenHash, session := range r.Sessions {
+		if session.ID == sessionId && session.AccountID == accountId {
+			delete(r.Sessions, tokenHash)
+			return nil
+		}
 	}
+
+	return fmt.Errorf("Couldn't find a session with the provided ID")
 }
 
-func (r AuthRepository) RegisterToken(token string, accountId int) {
-	r.Auths[token] = accountId
+func (r AuthRepository) RevokeToken(tokenHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.Sessions, tokenHash)
+	return nil
 }
 
-func (r AuthRepository) DecodeToken(token string) (int, error) {
-	accountId := r.Auths[token]
+func (r AuthRepository) RevokeAllSessions(accountId int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	return accountId, nil
+	for tokenHash, session := range r.Sessions {
+		if session.AccountID == accountId {
+			delete(r.Sessions, tokenHash)
+		}
+	}
+
+	return nil
 }
 
 func (r AuthRepository) Reset() error {
-	r.Auths = map[string]int{}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clear(r.Sessions)
 	return nil
 }